@@ -16,7 +16,10 @@
 package backend
 
 import (
+	"context"
 	"crypto/sha256"
+	"errors"
+	"fmt"
 	"hash"
 
 	"github.com/consensys/gnark/constraint/solver"
@@ -60,6 +63,10 @@ type ProverConfig struct {
 	ChallengeHash  hash.Hash
 	KZGFoldingHash hash.Hash
 	Accelerator    string
+	Context        context.Context
+	// ProgressCallback is called when a phase of the prover is done, see
+	// [WithProverProgress].
+	ProgressCallback func(ProverEvent)
 }
 
 // NewProverConfig returns a default ProverConfig with given prover options opts
@@ -70,6 +77,7 @@ func NewProverConfig(opts ...ProverOption) (ProverConfig, error) {
 		// separation tags for PLONK and Groth16
 		ChallengeHash:  sha256.New(),
 		KZGFoldingHash: sha256.New(),
+		Context:        context.Background(),
 	}
 	for _, option := range opts {
 		if err := option(&opt); err != nil {
@@ -133,6 +141,48 @@ func WithIcicleAcceleration() ProverOption {
 	}
 }
 
+// WithProverContext sets the context of the prover. When the context is done,
+// the prover stops at the next check and returns a [*CancelledError]. The
+// context is checked by the constraint solver and between the phases of the
+// prover (FFTs, multi-exponentiations), so the prover may not return
+// immediately. The Groth16 prover also splits its multi-exponentiations in
+// chunks checked in turn when the context can be cancelled, and returns once
+// all its tasks are done.
+func WithProverContext(ctx context.Context) ProverOption {
+	return func(pc *ProverConfig) error {
+		if ctx == nil {
+			return errors.New("nil context")
+		}
+		pc.Context = ctx
+		return nil
+	}
+}
+
+// CancelledError is returned by the provers when the context set with
+// [WithProverContext] is done before the proof is computed. It wraps the
+// error of the context, so that [errors.Is] can be used to distinguish between
+// [context.Canceled] and [context.DeadlineExceeded].
+type CancelledError struct {
+	Err error
+}
+
+func (e *CancelledError) Error() string {
+	return fmt.Sprintf("prover cancelled: %v", e.Err)
+}
+
+func (e *CancelledError) Unwrap() error {
+	return e.Err
+}
+
+// Cancelled returns a [*CancelledError] if the context of the prover is done
+// and nil otherwise.
+func (cfg *ProverConfig) Cancelled() error {
+	if err := cfg.Context.Err(); err != nil {
+		return &CancelledError{Err: err}
+	}
+	return nil
+}
+
 // VerifierOption defines option for altering the behavior of the verifier. See
 // the descriptions of functions returning instances of this type for
 // implemented options.
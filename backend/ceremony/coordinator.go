@@ -0,0 +1,567 @@
+// Package ceremony implements a file-backed coordinator for the MPC setup
+// ceremonies of the mpcsetup packages.
+//
+// A ceremony lives in a directory holding its metadata (ceremony.json), the
+// state after each contribution (0000.bin being the initial state) and a
+// transcript (transcript.json). Each contribution is verified against the
+// previous one before it is accepted, and the transcript chains the hashes of
+// all the accepted states so that any later modification of the directory is
+// detected by [Coordinator.Verify]. A ceremony is closed with a last
+// contribution derived from a public random beacon, which anyone can
+// reproduce.
+//
+// The transcript is the commit point of a contribution: a state file written
+// before a crash but not recorded in the transcript is discarded when the
+// ceremony is re-opened, and the contributor must submit again. Likewise,
+// ceremony.json is written last when a ceremony is created, so that a
+// directory left by a crash during [Init] is not a ceremony.
+//
+// The initial state of a Groth16 phase 2 depends on the circuit and on the
+// final state of the phase 1. Such a ceremony is created with
+// [InitGroth16Phase2], which records the hashes of both in ceremony.json, and
+// verified with [Coordinator.VerifyGroth16Phase2], which computes the initial
+// state again and compares it with 0000.bin.
+package ceremony
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/internal/utils"
+)
+
+const (
+	metadataFile   = "ceremony.json"
+	transcriptFile = "transcript.json"
+	tmpSuffix      = ".tmp"
+)
+
+var (
+	// ErrSealed is returned when contributing to a sealed ceremony.
+	ErrSealed = errors.New("ceremony is sealed")
+	// ErrCorrupted is returned when the content of the ceremony directory
+	// doesn't match its transcript.
+	ErrCorrupted = errors.New("ceremony directory doesn't match the transcript")
+)
+
+// Entry is an entry of the transcript of a ceremony.
+type Entry struct {
+	Index int    `json:"index"`
+	File  string `json:"file"`
+	// Hash is the hex encoded sha256 hash of the file.
+	Hash string `json:"hash"`
+	// Chain is the hex encoded sha256 hash of the Chain of the previous
+	// entry concatenated with the Hash of this entry.
+	Chain       string `json:"chain"`
+	Contributor string `json:"contributor,omitempty"`
+	// Beacon is the hex encoded beacon of a sealing contribution.
+	Beacon string    `json:"beacon,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+type metadata struct {
+	Phase string `json:"phase"`
+	Curve string `json:"curve"`
+	// R1CS and Phase1 are the hex encoded sha256 hashes of the encoded
+	// circuit and final state of the phase 1 of a Groth16 phase 2.
+	R1CS   string `json:"r1cs,omitempty"`
+	Phase1 string `json:"phase1,omitempty"`
+}
+
+// Coordinator manages the ceremony stored in a directory. It is safe for
+// concurrent use; contributions are processed one at a time.
+type Coordinator struct {
+	dir        string
+	phase      Phase
+	md         metadata
+	transcript []Entry
+	latest     Contribution
+	lock       sync.Mutex
+}
+
+// Init creates a ceremony of the given phase in dir, starting from initial.
+// dir is created if needed and must not already hold a ceremony. A Groth16
+// phase 2 is created with [InitGroth16Phase2].
+func Init(dir string, phase Phase, initial Contribution) (*Coordinator, error) {
+	if phase.Name == Groth16Phase2 {
+		return nil, errors.New("a Groth16 phase 2 must be created with InitGroth16Phase2")
+	}
+	return initCeremony(dir, phase, metadata{}, initial)
+}
+
+// InitGroth16Phase2 creates a Groth16 phase 2 for the circuit ccs in dir,
+// starting from initial. initial must be the state returned by
+// [NewGroth16Phase2] for ccs and phase1, the final state of the phase 1: it is
+// computed again and compared. The hashes of ccs and phase1 are recorded so
+// that [Coordinator.VerifyGroth16Phase2] can check the same inputs.
+func InitGroth16Phase2(dir string, ccs constraint.ConstraintSystem, phase1, initial Contribution) (*Coordinator, error) {
+	phase, err := NewPhase(Groth16Phase2, utils.FieldToCurve(ccs.Field()))
+	if err != nil {
+		return nil, err
+	}
+	md, expected, err := groth16Phase2Initial(ccs, phase1)
+	if err != nil {
+		return nil, err
+	}
+	b, err := encode(initial)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(b, expected) {
+		return nil, errors.New("initial state doesn't match the circuit and the phase 1")
+	}
+	return initCeremony(dir, phase, md, initial)
+}
+
+func initCeremony(dir string, phase Phase, md metadata, initial Contribution) (*Coordinator, error) {
+	if _, err := os.Stat(filepath.Join(dir, metadataFile)); err == nil {
+		return nil, fmt.Errorf("%s already holds a ceremony", dir)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	md.Phase, md.Curve = phase.Name, phase.Curve.String()
+	c := &Coordinator{dir: dir, phase: phase, md: md}
+	b, err := encode(initial)
+	if err != nil {
+		return nil, err
+	}
+	// decode the initial state to make sure it matches the phase
+	c.latest = phase.NewContribution()
+	if _, err := c.latest.ReadFrom(bytes.NewReader(b)); err != nil {
+		return nil, fmt.Errorf("initial state: %w", err)
+	}
+	if err := phase.VerifyInitial(c.latest); err != nil {
+		return nil, fmt.Errorf("initial state: %w", err)
+	}
+	if _, err := c.append(b, "", nil); err != nil {
+		return nil, err
+	}
+
+	// the ceremony exists once its metadata is written
+	b, err = json.Marshal(md)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, metadataFile), b); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Open resumes the ceremony stored in dir.
+func Open(dir string) (*Coordinator, error) {
+	b, err := os.ReadFile(filepath.Join(dir, metadataFile))
+	if err != nil {
+		return nil, err
+	}
+	var md metadata
+	if err := json.Unmarshal(b, &md); err != nil {
+		return nil, err
+	}
+	curve, err := ecc.IDFromString(md.Curve)
+	if err != nil {
+		return nil, err
+	}
+	phase, err := NewPhase(md.Phase, curve)
+	if err != nil {
+		return nil, err
+	}
+
+	if phase.Name == Groth16Phase2 && (md.R1CS == "" || md.Phase1 == "") {
+		return nil, fmt.Errorf("%s: missing the hashes of the circuit and of the phase 1", metadataFile)
+	}
+
+	c := &Coordinator{dir: dir, phase: phase, md: md}
+	if b, err = os.ReadFile(filepath.Join(dir, transcriptFile)); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &c.transcript); err != nil {
+		return nil, err
+	}
+	if len(c.transcript) == 0 {
+		return nil, ErrCorrupted
+	}
+	if err := c.cleanup(); err != nil {
+		return nil, err
+	}
+
+	// load the latest state
+	last := c.transcript[len(c.transcript)-1]
+	b, err = c.readState(last)
+	if err != nil {
+		return nil, err
+	}
+	c.latest = phase.NewContribution()
+	if _, err := c.latest.ReadFrom(bytes.NewReader(b)); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Phase returns the phase of the ceremony.
+func (c *Coordinator) Phase() Phase {
+	return c.phase
+}
+
+// Transcript returns a copy of the transcript of the ceremony.
+func (c *Coordinator) Transcript() []Entry {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]Entry(nil), c.transcript...)
+}
+
+// Sealed returns true if the ceremony has been sealed with a random beacon.
+func (c *Coordinator) Sealed() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.sealed()
+}
+
+// WriteLatest writes the latest state of the ceremony to w. This is the state
+// the next participant contributes to, or the final state once sealed.
+func (c *Coordinator) WriteLatest(w io.Writer) (int64, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.latest.WriteTo(w)
+}
+
+// Contribute reads a contribution from r, verifies it against the latest
+// state and records it in the transcript.
+func (c *Coordinator) Contribute(r io.Reader, contributor string) (Entry, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.sealed() {
+		return Entry{}, ErrSealed
+	}
+	next := c.phase.NewContribution()
+	if _, err := next.ReadFrom(r); err != nil {
+		return Entry{}, fmt.Errorf("decode contribution: %w", err)
+	}
+	if err := c.phase.Verify(c.latest, next); err != nil {
+		return Entry{}, fmt.Errorf("verify contribution: %w", err)
+	}
+	var buf bytes.Buffer
+	if _, err := next.WriteTo(&buf); err != nil {
+		return Entry{}, err
+	}
+	entry, err := c.append(buf.Bytes(), contributor, nil)
+	if err != nil {
+		return Entry{}, err
+	}
+	c.latest = next
+	return entry, nil
+}
+
+// Seal closes the ceremony with a last contribution derived from the random
+// beacon. No contribution is accepted afterwards.
+func (c *Coordinator) Seal(beacon []byte) (Entry, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.sealed() {
+		return Entry{}, ErrSealed
+	}
+	if len(beacon) == 0 {
+		return Entry{}, errors.New("empty beacon")
+	}
+	next, b, err := c.seal(c.latest, beacon)
+	if err != nil {
+		return Entry{}, err
+	}
+	if err := c.phase.Verify(c.latest, next); err != nil {
+		return Entry{}, fmt.Errorf("verify sealed state: %w", err)
+	}
+	entry, err := c.append(b, "", beacon)
+	if err != nil {
+		return Entry{}, err
+	}
+	c.latest = next
+	return entry, nil
+}
+
+// Verify re-verifies the whole ceremony from the files of the directory: the
+// hash chain of the transcript, the initial state, every contribution against
+// the previous one, and the sealing contribution against the beacon. A Groth16
+// phase 2 is verified with [Coordinator.VerifyGroth16Phase2].
+func (c *Coordinator) Verify() error {
+	if c.phase.Name == Groth16Phase2 {
+		return errors.New("a Groth16 phase 2 must be verified with VerifyGroth16Phase2")
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.verify(nil)
+}
+
+// VerifyGroth16Phase2 re-verifies a Groth16 phase 2 as [Coordinator.Verify]
+// does. In addition, it checks that ccs and phase1 are the circuit and the
+// phase 1 the ceremony was created with, and that the initial state is the
+// one computed from them.
+func (c *Coordinator) VerifyGroth16Phase2(ccs constraint.ConstraintSystem, phase1 Contribution) error {
+	if c.phase.Name != Groth16Phase2 {
+		return fmt.Errorf("not a Groth16 phase 2 but a %s", c.phase.Name)
+	}
+	md, initial, err := groth16Phase2Initial(ccs, phase1)
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if md.R1CS != c.md.R1CS {
+		return errors.New("circuit doesn't match the ceremony")
+	}
+	if md.Phase1 != c.md.Phase1 {
+		return errors.New("phase 1 doesn't match the ceremony")
+	}
+	return c.verify(initial)
+}
+
+// verify checks the ceremony, and that its initial state is initial if not nil.
+func (c *Coordinator) verify(initial []byte) error {
+	var prev Contribution
+	var prevChain []byte
+	for i, entry := range c.transcript {
+		if entry.Index != i || entry.File != stateFile(i) {
+			return ErrCorrupted
+		}
+		if entry.Beacon != "" && i != len(c.transcript)-1 {
+			return fmt.Errorf("entry %d: contribution after the beacon", i)
+		}
+		b, err := c.readState(entry)
+		if err != nil {
+			return err
+		}
+		h := sha256.Sum256(b)
+		chain := chainHash(prevChain, h[:])
+		if hex.EncodeToString(chain) != entry.Chain {
+			return fmt.Errorf("entry %d: %w", i, ErrCorrupted)
+		}
+		prevChain = chain
+
+		next := c.phase.NewContribution()
+		if _, err := next.ReadFrom(bytes.NewReader(b)); err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+		if i == 0 && initial != nil && !bytes.Equal(b, initial) {
+			return fmt.Errorf("entry 0: initial state doesn't match the circuit and the phase 1")
+		}
+		if prev != nil {
+			if err := c.phase.Verify(prev, next); err != nil {
+				return fmt.Errorf("entry %d: %w", i, err)
+			}
+		} else if err := c.phase.VerifyInitial(next); err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+		if entry.Beacon != "" {
+			if prev == nil {
+				return fmt.Errorf("entry %d: beacon without previous state", i)
+			}
+			beacon, err := hex.DecodeString(entry.Beacon)
+			if err != nil {
+				return fmt.Errorf("entry %d: %w", i, err)
+			}
+			_, expected, err := c.seal(prev, beacon)
+			if err != nil {
+				return fmt.Errorf("entry %d: %w", i, err)
+			}
+			if !bytes.Equal(expected, b) {
+				return fmt.Errorf("entry %d: sealed state doesn't match the beacon", i)
+			}
+		}
+		prev = next
+	}
+	return nil
+}
+
+func (c *Coordinator) sealed() bool {
+	return c.transcript[len(c.transcript)-1].Beacon != ""
+}
+
+// groth16Phase2Initial returns the metadata of a Groth16 phase 2 for ccs and
+// phase1, and the encoding of its initial state.
+func groth16Phase2Initial(ccs constraint.ConstraintSystem, phase1 Contribution) (metadata, []byte, error) {
+	r1cs, err := encode(ccs)
+	if err != nil {
+		return metadata{}, nil, err
+	}
+	srs1, err := encode(phase1)
+	if err != nil {
+		return metadata{}, nil, err
+	}
+	initial, _, err := NewGroth16Phase2(ccs, phase1)
+	if err != nil {
+		return metadata{}, nil, err
+	}
+	b, err := encode(initial)
+	if err != nil {
+		return metadata{}, nil, err
+	}
+	hr, h1 := sha256.Sum256(r1cs), sha256.Sum256(srs1)
+	return metadata{R1CS: hex.EncodeToString(hr[:]), Phase1: hex.EncodeToString(h1[:])}, b, nil
+}
+
+func encode(v io.WriterTo) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := v.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// seal returns a copy of state sealed with the beacon, and its encoding.
+func (c *Coordinator) seal(state Contribution, beacon []byte) (Contribution, []byte, error) {
+	var buf bytes.Buffer
+	if _, err := state.WriteTo(&buf); err != nil {
+		return nil, nil, err
+	}
+	next := c.phase.NewContribution()
+	if _, err := next.ReadFrom(&buf); err != nil {
+		return nil, nil, err
+	}
+	if err := next.Seal(beacon); err != nil {
+		return nil, nil, err
+	}
+	buf.Reset()
+	if _, err := next.WriteTo(&buf); err != nil {
+		return nil, nil, err
+	}
+	return next, buf.Bytes(), nil
+}
+
+// append persists the encoded state and records it in the transcript.
+func (c *Coordinator) append(state []byte, contributor string, beacon []byte) (Entry, error) {
+	index := len(c.transcript)
+	h := sha256.Sum256(state)
+	var prevChain []byte
+	if index > 0 {
+		var err error
+		if prevChain, err = hex.DecodeString(c.transcript[index-1].Chain); err != nil {
+			return Entry{}, err
+		}
+	}
+	entry := Entry{
+		Index:       index,
+		File:        stateFile(index),
+		Hash:        hex.EncodeToString(h[:]),
+		Chain:       hex.EncodeToString(chainHash(prevChain, h[:])),
+		Contributor: contributor,
+		Beacon:      hex.EncodeToString(beacon),
+		Time:        time.Now().UTC(),
+	}
+	if err := writeFileAtomic(filepath.Join(c.dir, entry.File), state); err != nil {
+		return Entry{}, err
+	}
+	transcript := append(c.transcript[:index:index], entry)
+	b, err := json.MarshalIndent(transcript, "", "  ")
+	if err != nil {
+		return Entry{}, err
+	}
+	if err := writeFileAtomic(filepath.Join(c.dir, transcriptFile), b); err != nil {
+		return Entry{}, err
+	}
+	c.transcript = transcript
+	return entry, nil
+}
+
+// readState reads the file of the entry and checks its hash.
+func (c *Coordinator) readState(entry Entry) ([]byte, error) {
+	b, err := os.ReadFile(filepath.Join(c.dir, entry.File))
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(b)
+	if hex.EncodeToString(h[:]) != entry.Hash {
+		return nil, fmt.Errorf("%s: %w", entry.File, ErrCorrupted)
+	}
+	return b, nil
+}
+
+// cleanup removes the temporary files and the states which were not recorded
+// in the transcript before a crash.
+func (c *Coordinator) cleanup() error {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		name := f.Name()
+		var index int
+		orphan := strings.HasSuffix(name, tmpSuffix)
+		if n, err := fmt.Sscanf(name, "%04d.bin", &index); err == nil && n == 1 && name == stateFile(index) {
+			orphan = index >= len(c.transcript)
+		}
+		if orphan {
+			if err := os.Remove(filepath.Join(c.dir, name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func stateFile(index int) string {
+	return fmt.Sprintf("%04d.bin", index)
+}
+
+func chainHash(prev, hash []byte) []byte {
+	h := sha256.New()
+	h.Write(prev)
+	h.Write(hash)
+	return h.Sum(nil)
+}
+
+// writeFileAtomic writes data to a temporary file synced to disk, then renames
+// it to name and syncs the directory.
+func writeFileAtomic(name string, data []byte) error {
+	tmp := name + tmpSuffix
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(name))
+}
+
+// syncDir syncs the directory dir to disk, so that the files created or renamed
+// in it survive a crash.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		// directories can't be opened for syncing on Windows
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		d.Close()
+		return err
+	}
+	return d.Close()
+}
@@ -0,0 +1,265 @@
+package ceremony
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/stretchr/testify/require"
+)
+
+// contribute plays the role of a participant: it downloads the latest state,
+// contributes to it and returns the encoded result.
+func contribute(t *testing.T, c *Coordinator) []byte {
+	var buf bytes.Buffer
+	_, err := c.WriteLatest(&buf)
+	require.NoError(t, err)
+	state := c.Phase().NewContribution()
+	_, err = state.ReadFrom(&buf)
+	require.NoError(t, err)
+	state.Contribute()
+	buf.Reset()
+	_, err = state.WriteTo(&buf)
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestCoordinator(t *testing.T) {
+	assert := require.New(t)
+	dir := t.TempDir()
+
+	phase, err := NewPhase(Groth16Phase1, ecc.BN254)
+	assert.NoError(err)
+	initial, err := NewGroth16Phase1(ecc.BN254, 3)
+	assert.NoError(err)
+	c, err := Init(dir, phase, initial)
+	assert.NoError(err)
+	_, err = Init(dir, phase, initial)
+	assert.Error(err, "a directory holds a single ceremony")
+
+	_, err = c.Contribute(bytes.NewReader(contribute(t, c)), "alice")
+	assert.NoError(err)
+
+	// replaying the previous state is not a contribution
+	var latest bytes.Buffer
+	_, err = c.WriteLatest(&latest)
+	assert.NoError(err)
+	_, err = c.Contribute(&latest, "mallory")
+	assert.Error(err)
+	_, err = c.Contribute(bytes.NewReader([]byte{1, 2, 3}), "mallory")
+	assert.Error(err)
+
+	// a contribution on top of an older state is rejected
+	stale := contribute(t, c)
+	_, err = c.Contribute(bytes.NewReader(contribute(t, c)), "bob")
+	assert.NoError(err)
+	_, err = c.Contribute(bytes.NewReader(stale), "mallory")
+	assert.Error(err)
+
+	// simulate a crash after writing the state of a contribution
+	assert.NoError(os.WriteFile(filepath.Join(dir, stateFile(3)), stale, 0o644))
+	assert.NoError(os.WriteFile(filepath.Join(dir, transcriptFile+tmpSuffix), []byte("{"), 0o644))
+
+	c, err = Open(dir)
+	assert.NoError(err)
+	transcript := c.Transcript()
+	assert.Len(transcript, 3)
+	assert.Equal("bob", transcript[2].Contributor)
+	assert.NoFileExists(filepath.Join(dir, stateFile(3)))
+	assert.NoFileExists(filepath.Join(dir, transcriptFile+tmpSuffix))
+
+	entry, err := c.Contribute(bytes.NewReader(contribute(t, c)), "carol")
+	assert.NoError(err)
+	assert.Equal(3, entry.Index)
+
+	entry, err = c.Seal([]byte("block hash"))
+	assert.NoError(err)
+	assert.True(c.Sealed())
+	_, err = c.Contribute(bytes.NewReader(contribute(t, c)), "dave")
+	assert.ErrorIs(err, ErrSealed)
+	_, err = c.Seal([]byte("block hash"))
+	assert.ErrorIs(err, ErrSealed)
+
+	assert.NoError(c.Verify())
+	c, err = Open(dir)
+	assert.NoError(err)
+	assert.True(c.Sealed())
+	assert.NoError(c.Verify())
+
+	// the sealed state must match the beacon
+	transcript = c.Transcript()
+	transcript[len(transcript)-1].Beacon = "00"
+	writeTranscript(t, dir, transcript)
+	c, err = Open(dir)
+	assert.NoError(err)
+	assert.Error(c.Verify())
+	transcript[len(transcript)-1].Beacon = entry.Beacon
+	writeTranscript(t, dir, transcript)
+
+	// modified states are detected
+	assert.NoError(os.WriteFile(filepath.Join(dir, stateFile(1)), stale, 0o644))
+	c, err = Open(dir)
+	assert.NoError(err)
+	assert.ErrorIs(c.Verify(), ErrCorrupted)
+}
+
+func writeTranscript(t *testing.T, dir string, transcript []Entry) {
+	b, err := json.Marshal(transcript)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, transcriptFile), b, 0o644))
+}
+
+type circuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (c *circuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(c.X, c.Y), c.Z)
+	return nil
+}
+
+func TestCoordinatorGroth16Phase2(t *testing.T) {
+	assert := require.New(t)
+	dir := t.TempDir()
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuit{})
+	assert.NoError(err)
+	phase1, err := NewGroth16Phase1(ecc.BN254, 1)
+	assert.NoError(err)
+	phase1.Contribute()
+	assert.NoError(phase1.Seal([]byte("beacon")))
+	other, err := NewGroth16Phase1(ecc.BN254, 1)
+	assert.NoError(err)
+	other.Contribute()
+	assert.NoError(other.Seal([]byte("beacon")))
+
+	initial, evals, err := NewGroth16Phase2(ccs, phase1)
+	assert.NoError(err)
+	assert.NotNil(evals)
+	phase, err := NewPhase(Groth16Phase2, ecc.BN254)
+	assert.NoError(err)
+	_, err = Init(dir, phase, initial)
+	assert.Error(err, "the circuit and the phase 1 are needed")
+	otherInitial, _, err := NewGroth16Phase2(ccs, other)
+	assert.NoError(err)
+	_, err = InitGroth16Phase2(dir, ccs, phase1, otherInitial)
+	assert.Error(err, "initial state of another phase 1")
+
+	c, err := InitGroth16Phase2(dir, ccs, phase1, initial)
+	assert.NoError(err)
+	_, err = c.Contribute(bytes.NewReader(contribute(t, c)), "alice")
+	assert.NoError(err)
+	_, err = c.Seal([]byte("beacon"))
+	assert.NoError(err)
+	assert.Error(c.Verify(), "the circuit and the phase 1 are needed")
+	assert.NoError(c.VerifyGroth16Phase2(ccs, phase1))
+
+	// the inputs are decoded again by a verifier
+	var buf bytes.Buffer
+	_, err = ccs.WriteTo(&buf)
+	assert.NoError(err)
+	decoded := groth16.NewCS(ecc.BN254)
+	_, err = decoded.ReadFrom(&buf)
+	assert.NoError(err)
+	c, err = Open(dir)
+	assert.NoError(err)
+	assert.NoError(c.VerifyGroth16Phase2(decoded, phase1))
+	assert.Error(c.VerifyGroth16Phase2(ccs, other), "another phase 1")
+	otherCcs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &otherCircuit{})
+	assert.NoError(err)
+	assert.Error(c.VerifyGroth16Phase2(otherCcs, phase1), "another circuit")
+
+	// replace the initial state with the one of another phase 1, with a
+	// consistent transcript
+	b, err := encode(otherInitial)
+	assert.NoError(err)
+	assert.NoError(os.WriteFile(filepath.Join(dir, stateFile(0)), b, 0o644))
+	h := sha256.Sum256(b)
+	transcript := c.Transcript()
+	transcript[0].Hash = hex.EncodeToString(h[:])
+	transcript[0].Chain = hex.EncodeToString(chainHash(nil, h[:]))
+	writeTranscript(t, dir, transcript)
+	c, err = Open(dir)
+	assert.NoError(err)
+	assert.Error(c.VerifyGroth16Phase2(ccs, phase1))
+
+	// the hashes of the inputs are required
+	assert.NoError(os.WriteFile(filepath.Join(dir, metadataFile), []byte(`{"phase":"groth16-phase2","curve":"bn254"}`), 0o644))
+	_, err = Open(dir)
+	assert.Error(err)
+}
+
+type otherCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (c *otherCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Add(c.X, c.Y), c.Z)
+	return nil
+}
+
+func TestCoordinatorPlonkPhase1(t *testing.T) {
+	assert := require.New(t)
+
+	phase, err := NewPhase(PlonkPhase1, ecc.BLS12_381)
+	assert.NoError(err)
+	initial, err := NewPlonkPhase1(ecc.BLS12_381, 8)
+	assert.NoError(err)
+	c, err := Init(t.TempDir(), phase, initial)
+	assert.NoError(err)
+	_, err = c.Contribute(bytes.NewReader(contribute(t, c)), "alice")
+	assert.NoError(err)
+	_, err = c.Seal([]byte("beacon"))
+	assert.NoError(err)
+	assert.NoError(c.Verify())
+
+	_, err = NewPhase("unknown", ecc.BN254)
+	assert.Error(err)
+	_, err = NewPhase(PlonkPhase1, ecc.SECP256K1)
+	assert.Error(err)
+}
+
+func TestCoordinatorInitialState(t *testing.T) {
+	assert := require.New(t)
+	dir := t.TempDir()
+
+	phase, err := NewPhase(Groth16Phase1, ecc.BN254)
+	assert.NoError(err)
+	contributed, err := NewGroth16Phase1(ecc.BN254, 3)
+	assert.NoError(err)
+	contributed.Contribute()
+	_, err = Init(dir, phase, contributed)
+	assert.Error(err, "a contribution is not an initial state")
+	_, err = Open(dir)
+	assert.Error(err, "the ceremony is created once its metadata is written")
+
+	initial, err := NewGroth16Phase1(ecc.BN254, 3)
+	assert.NoError(err)
+	c, err := Init(dir, phase, initial)
+	assert.NoError(err)
+	assert.NoError(c.Verify())
+
+	// replace the initial state, with a consistent transcript
+	var buf bytes.Buffer
+	_, err = contributed.WriteTo(&buf)
+	assert.NoError(err)
+	assert.NoError(os.WriteFile(filepath.Join(dir, stateFile(0)), buf.Bytes(), 0o644))
+	h := sha256.Sum256(buf.Bytes())
+	transcript := c.Transcript()
+	transcript[0].Hash = hex.EncodeToString(h[:])
+	transcript[0].Chain = hex.EncodeToString(chainHash(nil, h[:]))
+	writeTranscript(t, dir, transcript)
+	c, err = Open(dir)
+	assert.NoError(err)
+	assert.Error(c.Verify())
+}
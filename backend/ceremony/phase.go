@@ -0,0 +1,291 @@
+package ceremony
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/constraint"
+	cs_bls12377 "github.com/consensys/gnark/constraint/bls12-377"
+	cs_bls12381 "github.com/consensys/gnark/constraint/bls12-381"
+	cs_bls24315 "github.com/consensys/gnark/constraint/bls24-315"
+	cs_bls24317 "github.com/consensys/gnark/constraint/bls24-317"
+	cs_bn254 "github.com/consensys/gnark/constraint/bn254"
+	cs_bw6633 "github.com/consensys/gnark/constraint/bw6-633"
+	cs_bw6761 "github.com/consensys/gnark/constraint/bw6-761"
+
+	groth16_bls12377 "github.com/consensys/gnark/backend/groth16/bls12-377/mpcsetup"
+	groth16_bls12381 "github.com/consensys/gnark/backend/groth16/bls12-381/mpcsetup"
+	groth16_bls24315 "github.com/consensys/gnark/backend/groth16/bls24-315/mpcsetup"
+	groth16_bls24317 "github.com/consensys/gnark/backend/groth16/bls24-317/mpcsetup"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254/mpcsetup"
+	groth16_bw6633 "github.com/consensys/gnark/backend/groth16/bw6-633/mpcsetup"
+	groth16_bw6761 "github.com/consensys/gnark/backend/groth16/bw6-761/mpcsetup"
+
+	plonk_bls12377 "github.com/consensys/gnark/backend/plonk/bls12-377/mpcsetup"
+	plonk_bls12381 "github.com/consensys/gnark/backend/plonk/bls12-381/mpcsetup"
+	plonk_bls24315 "github.com/consensys/gnark/backend/plonk/bls24-315/mpcsetup"
+	plonk_bls24317 "github.com/consensys/gnark/backend/plonk/bls24-317/mpcsetup"
+	plonk_bn254 "github.com/consensys/gnark/backend/plonk/bn254/mpcsetup"
+	plonk_bw6633 "github.com/consensys/gnark/backend/plonk/bw6-633/mpcsetup"
+	plonk_bw6761 "github.com/consensys/gnark/backend/plonk/bw6-761/mpcsetup"
+)
+
+// Names of the supported phases.
+const (
+	Groth16Phase1 = "groth16-phase1"
+	Groth16Phase2 = "groth16-phase2"
+	PlonkPhase1   = "plonk-phase1"
+)
+
+// Contribution is the state of a ceremony after a contribution, as
+// implemented by the Phase1 and Phase2 types of the mpcsetup packages.
+type Contribution interface {
+	io.WriterTo
+	io.ReaderFrom
+
+	// Contribute adds fresh randomness to the state.
+	Contribute()
+
+	// Seal adds a last contribution derived from a public random beacon.
+	Seal(beacon []byte) error
+}
+
+// Phase describes the contributions of a phase of a ceremony on a curve.
+type Phase struct {
+	Name  string
+	Curve ecc.ID
+
+	new           func() Contribution
+	verify        func(prev, next Contribution) error
+	verifyInitial func(initial Contribution) error
+}
+
+// NewContribution returns an empty contribution of the phase, to be decoded
+// with ReadFrom.
+func (p Phase) NewContribution() Contribution {
+	return p.new()
+}
+
+// Verify checks that next is a valid contribution on top of prev.
+func (p Phase) Verify(prev, next Contribution) error {
+	return p.verify(prev, next)
+}
+
+// VerifyInitial checks that initial is a valid initial state of the phase.
+func (p Phase) VerifyInitial(initial Contribution) error {
+	return p.verifyInitial(initial)
+}
+
+func newPhase[T any, PT interface {
+	*T
+	Contribution
+}](name string, curve ecc.ID, verify func(c0, c1 PT, c ...PT) error, verifyInitial func(c0 PT) error) Phase {
+	return Phase{
+		Name:  name,
+		Curve: curve,
+		new: func() Contribution {
+			return PT(new(T))
+		},
+		verify: func(prev, next Contribution) error {
+			p, ok := prev.(PT)
+			if !ok {
+				return errInvalidContributionType
+			}
+			n, ok := next.(PT)
+			if !ok {
+				return errInvalidContributionType
+			}
+			return verify(p, n)
+		},
+		verifyInitial: func(initial Contribution) error {
+			c0, ok := initial.(PT)
+			if !ok {
+				return errInvalidContributionType
+			}
+			return verifyInitial(c0)
+		},
+	}
+}
+
+var errInvalidContributionType = errors.New("invalid contribution type")
+
+// NewPhase returns the phase with the given name on the given curve.
+func NewPhase(name string, curve ecc.ID) (Phase, error) {
+	switch name {
+	case Groth16Phase1:
+		switch curve {
+		case ecc.BLS12_377:
+			return newPhase[groth16_bls12377.Phase1](name, curve, groth16_bls12377.VerifyPhase1, groth16_bls12377.VerifyInitialPhase1), nil
+		case ecc.BLS12_381:
+			return newPhase[groth16_bls12381.Phase1](name, curve, groth16_bls12381.VerifyPhase1, groth16_bls12381.VerifyInitialPhase1), nil
+		case ecc.BLS24_315:
+			return newPhase[groth16_bls24315.Phase1](name, curve, groth16_bls24315.VerifyPhase1, groth16_bls24315.VerifyInitialPhase1), nil
+		case ecc.BLS24_317:
+			return newPhase[groth16_bls24317.Phase1](name, curve, groth16_bls24317.VerifyPhase1, groth16_bls24317.VerifyInitialPhase1), nil
+		case ecc.BN254:
+			return newPhase[groth16_bn254.Phase1](name, curve, groth16_bn254.VerifyPhase1, groth16_bn254.VerifyInitialPhase1), nil
+		case ecc.BW6_633:
+			return newPhase[groth16_bw6633.Phase1](name, curve, groth16_bw6633.VerifyPhase1, groth16_bw6633.VerifyInitialPhase1), nil
+		case ecc.BW6_761:
+			return newPhase[groth16_bw6761.Phase1](name, curve, groth16_bw6761.VerifyPhase1, groth16_bw6761.VerifyInitialPhase1), nil
+		}
+	case Groth16Phase2:
+		switch curve {
+		case ecc.BLS12_377:
+			return newPhase[groth16_bls12377.Phase2](name, curve, groth16_bls12377.VerifyPhase2, groth16_bls12377.VerifyInitialPhase2), nil
+		case ecc.BLS12_381:
+			return newPhase[groth16_bls12381.Phase2](name, curve, groth16_bls12381.VerifyPhase2, groth16_bls12381.VerifyInitialPhase2), nil
+		case ecc.BLS24_315:
+			return newPhase[groth16_bls24315.Phase2](name, curve, groth16_bls24315.VerifyPhase2, groth16_bls24315.VerifyInitialPhase2), nil
+		case ecc.BLS24_317:
+			return newPhase[groth16_bls24317.Phase2](name, curve, groth16_bls24317.VerifyPhase2, groth16_bls24317.VerifyInitialPhase2), nil
+		case ecc.BN254:
+			return newPhase[groth16_bn254.Phase2](name, curve, groth16_bn254.VerifyPhase2, groth16_bn254.VerifyInitialPhase2), nil
+		case ecc.BW6_633:
+			return newPhase[groth16_bw6633.Phase2](name, curve, groth16_bw6633.VerifyPhase2, groth16_bw6633.VerifyInitialPhase2), nil
+		case ecc.BW6_761:
+			return newPhase[groth16_bw6761.Phase2](name, curve, groth16_bw6761.VerifyPhase2, groth16_bw6761.VerifyInitialPhase2), nil
+		}
+	case PlonkPhase1:
+		switch curve {
+		case ecc.BLS12_377:
+			return newPhase[plonk_bls12377.Phase1](name, curve, plonk_bls12377.VerifyPhase1, plonk_bls12377.VerifyInitialPhase1), nil
+		case ecc.BLS12_381:
+			return newPhase[plonk_bls12381.Phase1](name, curve, plonk_bls12381.VerifyPhase1, plonk_bls12381.VerifyInitialPhase1), nil
+		case ecc.BLS24_315:
+			return newPhase[plonk_bls24315.Phase1](name, curve, plonk_bls24315.VerifyPhase1, plonk_bls24315.VerifyInitialPhase1), nil
+		case ecc.BLS24_317:
+			return newPhase[plonk_bls24317.Phase1](name, curve, plonk_bls24317.VerifyPhase1, plonk_bls24317.VerifyInitialPhase1), nil
+		case ecc.BN254:
+			return newPhase[plonk_bn254.Phase1](name, curve, plonk_bn254.VerifyPhase1, plonk_bn254.VerifyInitialPhase1), nil
+		case ecc.BW6_633:
+			return newPhase[plonk_bw6633.Phase1](name, curve, plonk_bw6633.VerifyPhase1, plonk_bw6633.VerifyInitialPhase1), nil
+		case ecc.BW6_761:
+			return newPhase[plonk_bw6761.Phase1](name, curve, plonk_bw6761.VerifyPhase1, plonk_bw6761.VerifyInitialPhase1), nil
+		}
+	default:
+		return Phase{}, fmt.Errorf("unknown phase %q", name)
+	}
+	return Phase{}, fmt.Errorf("phase %s: unsupported curve %s", name, curve)
+}
+
+// NewGroth16Phase1 returns the initial state of a Groth16 phase 1 for
+// circuits of up to 2ᵖᵒʷᵉʳ constraints.
+func NewGroth16Phase1(curve ecc.ID, power int) (Contribution, error) {
+	switch curve {
+	case ecc.BLS12_377:
+		phase1 := groth16_bls12377.InitPhase1(power)
+		return &phase1, nil
+	case ecc.BLS12_381:
+		phase1 := groth16_bls12381.InitPhase1(power)
+		return &phase1, nil
+	case ecc.BLS24_315:
+		phase1 := groth16_bls24315.InitPhase1(power)
+		return &phase1, nil
+	case ecc.BLS24_317:
+		phase1 := groth16_bls24317.InitPhase1(power)
+		return &phase1, nil
+	case ecc.BN254:
+		phase1 := groth16_bn254.InitPhase1(power)
+		return &phase1, nil
+	case ecc.BW6_633:
+		phase1 := groth16_bw6633.InitPhase1(power)
+		return &phase1, nil
+	case ecc.BW6_761:
+		phase1 := groth16_bw6761.InitPhase1(power)
+		return &phase1, nil
+	default:
+		return nil, fmt.Errorf("unsupported curve %s", curve)
+	}
+}
+
+// NewPlonkPhase1 returns the initial state of a PLONK powers of tau ceremony
+// for circuits of up to size constraints.
+func NewPlonkPhase1(curve ecc.ID, size uint64) (Contribution, error) {
+	switch curve {
+	case ecc.BLS12_377:
+		phase1, err := plonk_bls12377.InitPhase1(size)
+		return &phase1, err
+	case ecc.BLS12_381:
+		phase1, err := plonk_bls12381.InitPhase1(size)
+		return &phase1, err
+	case ecc.BLS24_315:
+		phase1, err := plonk_bls24315.InitPhase1(size)
+		return &phase1, err
+	case ecc.BLS24_317:
+		phase1, err := plonk_bls24317.InitPhase1(size)
+		return &phase1, err
+	case ecc.BN254:
+		phase1, err := plonk_bn254.InitPhase1(size)
+		return &phase1, err
+	case ecc.BW6_633:
+		phase1, err := plonk_bw6633.InitPhase1(size)
+		return &phase1, err
+	case ecc.BW6_761:
+		phase1, err := plonk_bw6761.InitPhase1(size)
+		return &phase1, err
+	default:
+		return nil, fmt.Errorf("unsupported curve %s", curve)
+	}
+}
+
+// NewGroth16Phase2 returns the initial state of a Groth16 phase 2 for the
+// circuit ccs, built on top of the final state of a phase 1. The returned
+// evaluations are needed with both phases to extract the keys.
+func NewGroth16Phase2(ccs constraint.ConstraintSystem, phase1 Contribution) (phase2 Contribution, evals io.WriterTo, err error) {
+	switch r1cs := ccs.(type) {
+	case *cs_bls12377.R1CS:
+		srs1, ok := phase1.(*groth16_bls12377.Phase1)
+		if !ok {
+			return nil, nil, errInvalidContributionType
+		}
+		srs2, e := groth16_bls12377.InitPhase2(r1cs, srs1)
+		return &srs2, &e, nil
+	case *cs_bls12381.R1CS:
+		srs1, ok := phase1.(*groth16_bls12381.Phase1)
+		if !ok {
+			return nil, nil, errInvalidContributionType
+		}
+		srs2, e := groth16_bls12381.InitPhase2(r1cs, srs1)
+		return &srs2, &e, nil
+	case *cs_bls24315.R1CS:
+		srs1, ok := phase1.(*groth16_bls24315.Phase1)
+		if !ok {
+			return nil, nil, errInvalidContributionType
+		}
+		srs2, e := groth16_bls24315.InitPhase2(r1cs, srs1)
+		return &srs2, &e, nil
+	case *cs_bls24317.R1CS:
+		srs1, ok := phase1.(*groth16_bls24317.Phase1)
+		if !ok {
+			return nil, nil, errInvalidContributionType
+		}
+		srs2, e := groth16_bls24317.InitPhase2(r1cs, srs1)
+		return &srs2, &e, nil
+	case *cs_bn254.R1CS:
+		srs1, ok := phase1.(*groth16_bn254.Phase1)
+		if !ok {
+			return nil, nil, errInvalidContributionType
+		}
+		srs2, e := groth16_bn254.InitPhase2(r1cs, srs1)
+		return &srs2, &e, nil
+	case *cs_bw6633.R1CS:
+		srs1, ok := phase1.(*groth16_bw6633.Phase1)
+		if !ok {
+			return nil, nil, errInvalidContributionType
+		}
+		srs2, e := groth16_bw6633.InitPhase2(r1cs, srs1)
+		return &srs2, &e, nil
+	case *cs_bw6761.R1CS:
+		srs1, ok := phase1.(*groth16_bw6761.Phase1)
+		if !ok {
+			return nil, nil, errInvalidContributionType
+		}
+		srs2, e := groth16_bw6761.InitPhase2(r1cs, srs1)
+		return &srs2, &e, nil
+	default:
+		return nil, nil, errors.New("unsupported constraint system")
+	}
+}
@@ -0,0 +1,97 @@
+// Package export defines the targets and options of the verifier exporters of
+// the backends (see the ExportVerifier methods of the verifying keys).
+//
+// The verifiers of all the targets share the encodings of the verifying key
+// and of the proof, which are implemented once per backend and curve; a new
+// target only adds a template.
+package export
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/consensys/gnark/backend/solidity"
+)
+
+// Target identifies the runtime of an exported verifier.
+type Target uint8
+
+const (
+	// Solidity is a Solidity contract for the EVM, see ExportSolidity.
+	Solidity Target = iota
+	// Rust is a Rust module built on the arkworks crates ark-bn254, ark-ec,
+	// ark-ff and ark-groth16 (0.4).
+	Rust
+	// Move is a Sui Move module using the native Groth16 verifier
+	// sui::groth16. Unlike the other targets, it is not run by the tests of
+	// the CI: it must be checked with sui move test before use.
+	Move
+	// C is a standalone C99 source file for embedded devices, which includes
+	// the arithmetic of the curve and the pairing.
+	C
+)
+
+// String returns the name of the target.
+func (t Target) String() string {
+	switch t {
+	case Solidity:
+		return "solidity"
+	case Rust:
+		return "rust"
+	case Move:
+		return "move"
+	case C:
+		return "c"
+	default:
+		return fmt.Sprintf("Target(%d)", t)
+	}
+}
+
+// Option defines option for altering the behavior of the verifier exporters.
+// See the descriptions of functions returning instances of this type for
+// implemented options.
+type Option func(*Config) error
+
+// Config is the configuration of the verifier exporters with the options
+// applied.
+type Config struct {
+	// Name is the name of the verifier, "gnark" by default.
+	Name string
+	// SolidityOptions are the options of the Solidity target.
+	SolidityOptions []solidity.ExportOption
+}
+
+// NewConfig returns the configuration with the options applied.
+func NewConfig(opts ...Option) (Config, error) {
+	cfg := Config{Name: "gnark"}
+	for _, option := range opts {
+		if err := option(&cfg); err != nil {
+			return Config{}, err
+		}
+	}
+	return cfg, nil
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// WithName sets the name of the verifier. It is the prefix of the functions
+// of the C verifier and the named address of the Move module. The Rust
+// verifier is named after the file it is written to, and the name of the
+// Solidity contract is set with solidity.WithContractName.
+func WithName(name string) Option {
+	return func(cfg *Config) error {
+		if !identifier.MatchString(name) {
+			return fmt.Errorf("invalid verifier name %q", name)
+		}
+		cfg.Name = name
+		return nil
+	}
+}
+
+// WithSolidityOptions sets the options of the Solidity target.
+func WithSolidityOptions(opts ...solidity.ExportOption) Option {
+	return func(cfg *Config) error {
+		cfg.SolidityOptions = append(cfg.SolidityOptions, opts...)
+		return nil
+	}
+}
@@ -19,7 +19,9 @@ package groth16
 import (
 	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"math/big"
 	"time"
 
 	"github.com/consensys/gnark-crypto/ecc"
@@ -29,6 +31,7 @@ import (
 	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr/pedersen"
 	"github.com/consensys/gnark-crypto/utils"
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/solidity"
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/logger"
 )
@@ -72,31 +75,7 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 		close(chDone)
 	}()
 
-	maxNbPublicCommitted := 0
-	for _, s := range vk.PublicAndCommitmentCommitted { // iterate over commitments
-		maxNbPublicCommitted = utils.Max(maxNbPublicCommitted, len(s))
-	}
-	commitmentsSerialized := make([]byte, len(vk.PublicAndCommitmentCommitted)*fr.Bytes)
-	commitmentPrehashSerialized := make([]byte, curve.SizeOfG1AffineUncompressed+maxNbPublicCommitted*fr.Bytes)
-	for i := range vk.PublicAndCommitmentCommitted { // solveCommitmentWire
-		copy(commitmentPrehashSerialized, proof.Commitments[i].Marshal())
-		offset := curve.SizeOfG1AffineUncompressed
-		for j := range vk.PublicAndCommitmentCommitted[i] {
-			copy(commitmentPrehashSerialized[offset:], publicWitness[vk.PublicAndCommitmentCommitted[i][j]-1].Marshal())
-			offset += fr.Bytes
-		}
-		opt.HashToFieldFn.Write(commitmentPrehashSerialized[:offset])
-		hashBts := opt.HashToFieldFn.Sum(nil)
-		opt.HashToFieldFn.Reset()
-		nbBuf := fr.Bytes
-		if opt.HashToFieldFn.Size() < fr.Bytes {
-			nbBuf = opt.HashToFieldFn.Size()
-		}
-		var res fr.Element
-		res.SetBytes(hashBts[:nbBuf])
-		publicWitness = append(publicWitness, res)
-		copy(commitmentsSerialized[i*fr.Bytes:], res.Marshal())
-	}
+	publicWitness, commitmentsSerialized := solveCommitmentWires(proof, vk, publicWitness, opt.HashToFieldFn)
 
 	if folded, err := pedersen.FoldCommitments(proof.Commitments, commitmentsSerialized); err != nil {
 		return err
@@ -139,7 +118,188 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 	return nil
 }
 
+// BatchVerify verifies the proofs of the same circuit with given VerifyingKey
+// and public witnesses.
+//
+// The pairing equations of the proofs and of the proofs of knowledge of the
+// Pedersen commitments are combined with random coefficients, so that all the
+// proofs are checked with a single multi-Miller loop and final
+// exponentiation. If the check fails, it does not tell which proof is invalid.
+func BatchVerify(proofs []*Proof, vk *VerifyingKey, publicWitnesses []fr.Vector, opts ...backend.VerifierOption) error {
+	opt, err := backend.NewVerifierConfig(opts...)
+	if err != nil {
+		return fmt.Errorf("new verifier config: %w", err)
+	}
+	if opt.HashToFieldFn == nil {
+		opt.HashToFieldFn = hash_to_field.New([]byte(constraint.CommitmentDst))
+	}
+	if len(proofs) != len(publicWitnesses) {
+		return fmt.Errorf("got %d proofs but %d public witnesses", len(proofs), len(publicWitnesses))
+	}
+	if len(proofs) == 0 {
+		return errors.New("no proof to verify")
+	}
+
+	nbPublicVars := len(vk.G1.K) - len(vk.PublicAndCommitmentCommitted)
+	for i := range publicWitnesses {
+		if len(publicWitnesses[i]) != nbPublicVars-1 {
+			return fmt.Errorf("invalid witness size for proof %d, got %d, expected %d (public - ONE_WIRE)", i, len(publicWitnesses[i]), nbPublicVars-1)
+		}
+	}
+	log := logger.Logger().With().Str("curve", vk.CurveID().String()).Str("backend", "groth16").Int("nbProofs", len(proofs)).Logger()
+	start := time.Now()
+
+	// check that the points in the proofs are in the correct subgroup
+	for i := range proofs {
+		if !proofs[i].isValid() {
+			return errCorrectSubgroupCheckFailed
+		}
+	}
+
+	// random coefficients of the pairing equations (r) and of the proofs of
+	// knowledge (s)
+	r := make([]fr.Element, len(proofs))
+	s := make([]fr.Element, len(proofs))
+	for i := range proofs {
+		if _, err := r[i].SetRandom(); err != nil {
+			return err
+		}
+		if _, err := s[i].SetRandom(); err != nil {
+			return err
+		}
+	}
+
+	// Σrᵢ.[Arᵢ]₁, [Bsᵢ]₂ and the random linear combinations of the public
+	// inputs (with the commitment wires), the commitments, [Krsᵢ]₁, the folded
+	// commitments and their proofs of knowledge.
+	nbCommitments := len(vk.PublicAndCommitmentCommitted)
+	P := make([]curve.G1Affine, len(proofs), len(proofs)+4)
+	Q := make([]curve.G2Affine, len(proofs), len(proofs)+4)
+	publicInputs := make([]fr.Element, len(vk.G1.K)-1)
+	commitments := make([]curve.G1Affine, 0, len(proofs)*nbCommitments)
+	commitmentScalars := make([]fr.Element, 0, len(proofs)*nbCommitments)
+	krs := make([]curve.G1Affine, len(proofs))
+	folded := make([]curve.G1Affine, len(proofs))
+	poks := make([]curve.G1Affine, len(proofs))
+	var rSum, t fr.Element
+	var rBig big.Int
+	for i, proof := range proofs {
+		if len(proof.Commitments) != nbCommitments {
+			return fmt.Errorf("invalid number of commitments for proof %d, got %d, expected %d", i, len(proof.Commitments), nbCommitments)
+		}
+		publicWitness, commitmentsSerialized := solveCommitmentWires(proof, vk, publicWitnesses[i], opt.HashToFieldFn)
+		for j := range publicWitness {
+			t.Mul(&r[i], &publicWitness[j])
+			publicInputs[j].Add(&publicInputs[j], &t)
+		}
+		for j := range proof.Commitments {
+			commitments = append(commitments, proof.Commitments[j])
+			commitmentScalars = append(commitmentScalars, r[i])
+		}
+		rSum.Add(&rSum, &r[i])
+
+		r[i].BigInt(&rBig)
+		P[i].ScalarMultiplication(&proof.Ar, &rBig)
+		Q[i] = proof.Bs
+		krs[i] = proof.Krs
+
+		if nbCommitments > 0 {
+			if folded[i], err = pedersen.FoldCommitments(proof.Commitments, commitmentsSerialized); err != nil {
+				return err
+			}
+			if !folded[i].IsInSubGroup() || !proof.CommitmentPok.IsInSubGroup() {
+				return errCorrectSubgroupCheckFailed
+			}
+			poks[i] = proof.CommitmentPok
+		}
+	}
+
+	// compute Σrᵢ.(Σx.[Kvk(t)]1) and Σrᵢ.[Krsᵢ]₁
+	var kSum, tmp curve.G1Jac
+	if _, err := kSum.MultiExp(vk.G1.K[1:], publicInputs, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	rSum.BigInt(&rBig)
+	tmp.FromAffine(&vk.G1.K[0])
+	tmp.ScalarMultiplication(&tmp, &rBig)
+	kSum.AddAssign(&tmp)
+	if nbCommitments > 0 {
+		if _, err := tmp.MultiExp(commitments, commitmentScalars, ecc.MultiExpConfig{}); err != nil {
+			return err
+		}
+		kSum.AddAssign(&tmp)
+	}
+	var kSumAff, krsSum curve.G1Affine
+	kSumAff.FromJacobian(&kSum)
+	if _, err := krsSum.MultiExp(krs, r, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	P = append(P, kSumAff, krsSum)
+	Q = append(Q, vk.G2.gammaNeg, vk.G2.deltaNeg)
+
+	// the proofs of knowledge check e(C, G)e(π, G^{-1/σ}) = 1
+	if nbCommitments > 0 {
+		var foldedSum, pokSum curve.G1Affine
+		if _, err := foldedSum.MultiExp(folded, s, ecc.MultiExpConfig{}); err != nil {
+			return err
+		}
+		if _, err := pokSum.MultiExp(poks, s, ecc.MultiExpConfig{}); err != nil {
+			return err
+		}
+		P = append(P, foldedSum, pokSum)
+		Q = append(Q, vk.CommitmentKey.G, vk.CommitmentKey.GRootSigmaNeg)
+	}
+
+	ml, err := curve.MillerLoop(P, Q)
+	if err != nil {
+		return err
+	}
+	ml = curve.FinalExponentiation(&ml)
+
+	// e(α, β)^Σrᵢ
+	var expected curve.GT
+	expected.Exp(vk.e, &rBig)
+	if !expected.Equal(&ml) {
+		return errPairingCheckFailed
+	}
+
+	log.Debug().Dur("took", time.Since(start)).Msg("verifier done")
+	return nil
+}
+
+// solveCommitmentWires returns the public witness appended with the values of
+// the commitment wires and the serialized values of the commitment wires.
+func solveCommitmentWires(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, hashToField hash.Hash) (fr.Vector, []byte) {
+	maxNbPublicCommitted := 0
+	for _, s := range vk.PublicAndCommitmentCommitted { // iterate over commitments
+		maxNbPublicCommitted = utils.Max(maxNbPublicCommitted, len(s))
+	}
+	publicWitness = publicWitness[:len(publicWitness):len(publicWitness)]
+	commitmentsSerialized := make([]byte, len(vk.PublicAndCommitmentCommitted)*fr.Bytes)
+	commitmentPrehashSerialized := make([]byte, curve.SizeOfG1AffineUncompressed+maxNbPublicCommitted*fr.Bytes)
+	for i := range vk.PublicAndCommitmentCommitted { // solveCommitmentWire
+		copy(commitmentPrehashSerialized, proof.Commitments[i].Marshal())
+		offset := curve.SizeOfG1AffineUncompressed
+		for j := range vk.PublicAndCommitmentCommitted[i] {
+			copy(commitmentPrehashSerialized[offset:], publicWitness[vk.PublicAndCommitmentCommitted[i][j]-1].Marshal())
+			offset += fr.Bytes
+		}
+		hashToField.Write(commitmentPrehashSerialized[:offset])
+		hashBts := hashToField.Sum(nil)
+		hashToField.Reset()
+		nbBuf := fr.Bytes
+		if hashToField.Size() < fr.Bytes {
+			nbBuf = hashToField.Size()
+		}
+		var res fr.Element
+		res.SetBytes(hashBts[:nbBuf])
+		publicWitness = append(publicWitness, res)
+		copy(commitmentsSerialized[i*fr.Bytes:], res.Marshal())
+	}
+	return publicWitness, commitmentsSerialized
+}
+
 // ExportSolidity not implemented for BLS12-377
-func (vk *VerifyingKey) ExportSolidity(w io.Writer) error {
+func (vk *VerifyingKey) ExportSolidity(w io.Writer, exportOpts ...solidity.ExportOption) error {
 	return errors.New("not implemented")
 }
@@ -0,0 +1,198 @@
+package groth16
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// The arkworks encodings are the CanonicalSerialize implementations of
+// ark_groth16::Proof<ark_bls12_381::Bls12_381> and ark_groth16::VerifyingKey
+// (0.4).
+//
+// On BLS12-381, arkworks encodes the points as zkcrypto/bls12_381, which is
+// also the encoding of gnark-crypto: the coordinates in big endian (x.c1 |
+// x.c0 in G2), x only if compressed, and the flags on the 3 most significant
+// bits of the first byte.
+
+// mCompressed is the flag of the compressed points.
+const mCompressed byte = 1 << 7
+
+var errArkworksCommitments = errors.New("commitments are not supported by arkworks")
+
+// WriteArkworksTo writes the proof as serialized by arkworks, compressed:
+// A | B | C. The commitments of api.Commit have no equivalent in arkworks, and
+// proofs using them are rejected.
+func (proof *Proof) WriteArkworksTo(w io.Writer) (int64, error) {
+	return proof.writeArkworksTo(w, true)
+}
+
+// WriteArkworksRawTo writes the proof as serialized by arkworks, uncompressed.
+func (proof *Proof) WriteArkworksRawTo(w io.Writer) (int64, error) {
+	return proof.writeArkworksTo(w, false)
+}
+
+func (proof *Proof) writeArkworksTo(w io.Writer, compressed bool) (int64, error) {
+	if len(proof.Commitments) > 0 {
+		return 0, errArkworksCommitments
+	}
+	var res []byte
+	res = appendArkworksG1(res, &proof.Ar, compressed)
+	res = appendArkworksG2(res, &proof.Bs, compressed)
+	res = appendArkworksG1(res, &proof.Krs, compressed)
+	n, err := w.Write(res)
+	return int64(n), err
+}
+
+// ReadArkworksFrom reads a proof serialized by arkworks, compressed, checking
+// that the points are in their subgroups.
+func (proof *Proof) ReadArkworksFrom(r io.Reader) (int64, error) {
+	return proof.readArkworksFrom(r, true)
+}
+
+// ReadArkworksRawFrom reads a proof serialized by arkworks, uncompressed,
+// checking that the points are in their subgroups.
+func (proof *Proof) ReadArkworksRawFrom(r io.Reader) (int64, error) {
+	return proof.readArkworksFrom(r, false)
+}
+
+func (proof *Proof) readArkworksFrom(r io.Reader, compressed bool) (int64, error) {
+	dec := arkworksDecoder{r: r, compressed: compressed}
+	dec.point(&proof.Ar, curve.SizeOfG1AffineCompressed)
+	dec.point(&proof.Bs, curve.SizeOfG2AffineCompressed)
+	dec.point(&proof.Krs, curve.SizeOfG1AffineCompressed)
+	proof.Commitments = nil
+	proof.CommitmentPok = curve.G1Affine{}
+	return dec.n, dec.err
+}
+
+// WriteArkworksTo writes the verifying key as serialized by arkworks,
+// compressed: α, β, γ, δ, then the number of points of K on 8 bytes in little
+// endian and the points of K. The commitments of api.Commit have no
+// equivalent in arkworks, and keys using them are rejected.
+func (vk *VerifyingKey) WriteArkworksTo(w io.Writer) (int64, error) {
+	return vk.writeArkworksTo(w, true)
+}
+
+// WriteArkworksRawTo writes the verifying key as serialized by arkworks,
+// uncompressed.
+func (vk *VerifyingKey) WriteArkworksRawTo(w io.Writer) (int64, error) {
+	return vk.writeArkworksTo(w, false)
+}
+
+func (vk *VerifyingKey) writeArkworksTo(w io.Writer, compressed bool) (int64, error) {
+	if len(vk.PublicAndCommitmentCommitted) > 0 {
+		return 0, errArkworksCommitments
+	}
+	var res []byte
+	res = appendArkworksG1(res, &vk.G1.Alpha, compressed)
+	res = appendArkworksG2(res, &vk.G2.Beta, compressed)
+	res = appendArkworksG2(res, &vk.G2.Gamma, compressed)
+	res = appendArkworksG2(res, &vk.G2.Delta, compressed)
+	res = binary.LittleEndian.AppendUint64(res, uint64(len(vk.G1.K)))
+	for i := range vk.G1.K {
+		res = appendArkworksG1(res, &vk.G1.K[i], compressed)
+	}
+	n, err := w.Write(res)
+	return int64(n), err
+}
+
+// ReadArkworksFrom reads a verifying key serialized by arkworks, compressed,
+// checking that the points are in their subgroups.
+func (vk *VerifyingKey) ReadArkworksFrom(r io.Reader) (int64, error) {
+	return vk.readArkworksFrom(r, true)
+}
+
+// ReadArkworksRawFrom reads a verifying key serialized by arkworks,
+// uncompressed, checking that the points are in their subgroups.
+func (vk *VerifyingKey) ReadArkworksRawFrom(r io.Reader) (int64, error) {
+	return vk.readArkworksFrom(r, false)
+}
+
+func (vk *VerifyingKey) readArkworksFrom(r io.Reader, compressed bool) (int64, error) {
+	*vk = VerifyingKey{}
+	dec := arkworksDecoder{r: r, compressed: compressed}
+	dec.point(&vk.G1.Alpha, curve.SizeOfG1AffineCompressed)
+	dec.point(&vk.G2.Beta, curve.SizeOfG2AffineCompressed)
+	dec.point(&vk.G2.Gamma, curve.SizeOfG2AffineCompressed)
+	dec.point(&vk.G2.Delta, curve.SizeOfG2AffineCompressed)
+	nbK := dec.uint64()
+	// the points are appended as they are read, so that a corrupted length
+	// doesn't allocate more than the data
+	for i := uint64(0); i < nbK && dec.err == nil; i++ {
+		var p curve.G1Affine
+		dec.point(&p, curve.SizeOfG1AffineCompressed)
+		vk.G1.K = append(vk.G1.K, p)
+	}
+	if dec.err != nil {
+		return dec.n, dec.err
+	}
+	if len(vk.G1.K) == 0 {
+		return dec.n, errors.New("invalid verifying key: K is empty")
+	}
+	return dec.n, vk.Precompute()
+}
+
+func appendArkworksG1(dst []byte, p *curve.G1Affine, compressed bool) []byte {
+	if compressed {
+		b := p.Bytes()
+		return append(dst, b[:]...)
+	}
+	b := p.RawBytes()
+	return append(dst, b[:]...)
+}
+
+func appendArkworksG2(dst []byte, p *curve.G2Affine, compressed bool) []byte {
+	if compressed {
+		b := p.Bytes()
+		return append(dst, b[:]...)
+	}
+	b := p.RawBytes()
+	return append(dst, b[:]...)
+}
+
+// arkworksDecoder reads points serialized by arkworks. It counts the bytes read
+// and stops at the first error.
+type arkworksDecoder struct {
+	r          io.Reader
+	compressed bool
+	n          int64
+	err        error
+}
+
+func (dec *arkworksDecoder) uint64() uint64 {
+	var buf [8]byte
+	if dec.err != nil {
+		return 0
+	}
+	var n int
+	n, dec.err = io.ReadFull(dec.r, buf[:])
+	dec.n += int64(n)
+	return binary.LittleEndian.Uint64(buf[:])
+}
+
+// point reads a point of the given compressed size, twice as large if
+// uncompressed, and decodes it with gnark-crypto, which checks the subgroup.
+func (dec *arkworksDecoder) point(p interface{ SetBytes([]byte) (int, error) }, compressedSize int) {
+	if dec.err != nil {
+		return
+	}
+	buf := make([]byte, compressedSize)
+	if !dec.compressed {
+		buf = make([]byte, 2*compressedSize)
+	}
+	var n int
+	n, dec.err = io.ReadFull(dec.r, buf)
+	dec.n += int64(n)
+	if dec.err != nil {
+		return
+	}
+	// gnark-crypto decodes both encodings, the size must match the flag
+	if (buf[0]&mCompressed != 0) != dec.compressed {
+		dec.err = errors.New("invalid arkworks point flags")
+		return
+	}
+	_, dec.err = p.SetBytes(buf)
+}
@@ -0,0 +1,101 @@
+package groth16_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bls12381 "github.com/consensys/gnark/backend/groth16/bls12-381"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/stretchr/testify/require"
+)
+
+type mulCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (c *mulCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(c.X, c.Y), c.Z)
+	return nil
+}
+
+func TestArkworksRoundTrip(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BLS12_381.ScalarField(), r1cs.NewBuilder, &mulCircuit{})
+	assert.NoError(err)
+	pk, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+	w, err := frontend.NewWitness(&mulCircuit{X: 3, Y: 5, Z: 15}, ecc.BLS12_381.ScalarField())
+	assert.NoError(err)
+	proof, err := groth16.Prove(ccs, pk, w)
+	assert.NoError(err)
+	publicWitness, err := w.Public()
+	assert.NoError(err)
+	_proof, _vk := proof.(*groth16_bls12381.Proof), vk.(*groth16_bls12381.VerifyingKey)
+
+	// compressed
+	var buf bytes.Buffer
+	n, err := _proof.WriteArkworksTo(&buf)
+	assert.NoError(err)
+	assert.EqualValues(2*48+96, n)
+	var proof2 groth16_bls12381.Proof
+	_, err = proof2.ReadArkworksFrom(&buf)
+	assert.NoError(err)
+	n, err = _vk.WriteArkworksTo(&buf)
+	assert.NoError(err)
+	assert.EqualValues(48+3*96+8+2*48, n)
+	var vk2 groth16_bls12381.VerifyingKey
+	_, err = vk2.ReadArkworksFrom(&buf)
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(&proof2, &vk2, publicWitness))
+
+	// uncompressed
+	n, err = _proof.WriteArkworksRawTo(&buf)
+	assert.NoError(err)
+	assert.EqualValues(2*(2*48+96), n)
+	proof2 = groth16_bls12381.Proof{}
+	_, err = proof2.ReadArkworksRawFrom(&buf)
+	assert.NoError(err)
+	_, err = _vk.WriteArkworksRawTo(&buf)
+	assert.NoError(err)
+	vk2 = groth16_bls12381.VerifyingKey{}
+	_, err = vk2.ReadArkworksRawFrom(&buf)
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(&proof2, &vk2, publicWitness))
+
+	// the encodings can't be mixed up
+	_, err = _proof.WriteArkworksTo(&buf)
+	assert.NoError(err)
+	_, err = proof2.ReadArkworksRawFrom(&buf)
+	assert.Error(err)
+}
+
+func TestArkworksEncoding(t *testing.T) {
+	assert := require.New(t)
+
+	// the encodings of the generators by zkcrypto/bls12_381 and arkworks
+	const (
+		g1Hex = "97f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb"
+		g2Hex = "93e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+			"024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8"
+	)
+	_, _, g1, g2 := curve.Generators()
+	var proof groth16_bls12381.Proof
+	proof.Ar, proof.Bs = g1, g2
+
+	var buf bytes.Buffer
+	_, err := proof.WriteArkworksTo(&buf)
+	assert.NoError(err)
+	assert.Equal(g1Hex+g2Hex+"c0"+hex.EncodeToString(make([]byte, 47)), hex.EncodeToString(buf.Bytes()))
+
+	var decoded groth16_bls12381.Proof
+	_, err = decoded.ReadArkworksFrom(&buf)
+	assert.NoError(err)
+	assert.Equal(proof, decoded)
+}
@@ -0,0 +1,439 @@
+package groth16
+
+import (
+	curve "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fp"
+)
+
+// solidityTemplate
+// this is an experimental feature and gnark solidity generator as not been thoroughly tested
+const solidityTemplate = `
+{{- $numPublic := sub (len .G1.K) 1 }}
+{{- $numCommitments := len .PublicAndCommitmentCommitted }}
+{{- $numWitness := sub $numPublic $numCommitments }}
+{{- $PublicAndCommitmentCommitted := .PublicAndCommitmentCommitted }}
+// SPDX-License-Identifier: MIT
+
+pragma solidity {{ pragma }};
+{{- range imports }}
+import "{{ . }}";
+{{- end }}
+
+/// @title Groth16 verifier template for BLS12-381.
+/// @notice Supports verifying Groth16 proofs on BLS12-381, using the precompiles
+/// of EIP-2537 (available since the Prague hard fork).
+/// @notice Elements of the base field Fp are encoded as in EIP-2537, on 64 bytes,
+/// that is two uint256 words (hi, lo): hi holds 16 bytes of zero padding followed
+/// by the 16 most significant bytes of the element, lo holds the 32 least significant
+/// bytes. G1 points (x, y) are encoded on 4 words, G2 points (x₀ + x₁⋅u, y₀ + y₁⋅u)
+/// on 8 words (x₀, x₁, y₀, y₁). The point at infinity is encoded with zeros.
+contract {{ contractName }}{{ with interfaces }} is {{ join . ", " }}{{ end }} {
+
+    /// Some of the provided public input values are larger than the field modulus.
+    /// @dev Public input elements are not automatically reduced, as this is can be
+    /// a dangerous source of bugs.
+    error PublicInputNotInField();
+
+    /// The proof is invalid.
+    /// @dev This can mean that provided Groth16 proof points are not on their
+    /// curves or subgroups, that pairing equation fails, or that the proof is not
+    /// for the provided public input.
+    error ProofInvalid();
+
+    {{- if gt $numCommitments 0 }}
+    /// The commitment is invalid
+    /// @dev This can mean that provided commitment points and/or proof of knowledge are not on their
+    /// curves or subgroups, that pairing equation fails, or that the commitment and/or proof of knowledge
+    /// is not for the commitment key.
+    error CommitmentInvalid();
+    {{- end }}
+
+    // Addresses of precompiles
+    uint256 constant PRECOMPILE_G1MSM = 0x0c;
+    uint256 constant PRECOMPILE_PAIRING = 0x0f;
+
+    // BLS12-381 scalar field Fr order R.
+    uint256 constant R = 0x73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001;
+
+    // Groth16 alpha point in G1
+    uint256 constant ALPHA_X_HI = {{ fpHi .G1.Alpha.X }};
+    uint256 constant ALPHA_X_LO = {{ fpLo .G1.Alpha.X }};
+    uint256 constant ALPHA_Y_HI = {{ fpHi .G1.Alpha.Y }};
+    uint256 constant ALPHA_Y_LO = {{ fpLo .G1.Alpha.Y }};
+
+    // Groth16 beta point in G2, negated
+    uint256 constant BETA_NEG_X_0_HI = {{ fpHi .G2.Beta.X.A0 }};
+    uint256 constant BETA_NEG_X_0_LO = {{ fpLo .G2.Beta.X.A0 }};
+    uint256 constant BETA_NEG_X_1_HI = {{ fpHi .G2.Beta.X.A1 }};
+    uint256 constant BETA_NEG_X_1_LO = {{ fpLo .G2.Beta.X.A1 }};
+    uint256 constant BETA_NEG_Y_0_HI = {{ fpHi .G2.Beta.Y.A0 }};
+    uint256 constant BETA_NEG_Y_0_LO = {{ fpLo .G2.Beta.Y.A0 }};
+    uint256 constant BETA_NEG_Y_1_HI = {{ fpHi .G2.Beta.Y.A1 }};
+    uint256 constant BETA_NEG_Y_1_LO = {{ fpLo .G2.Beta.Y.A1 }};
+
+    // Groth16 gamma point in G2, negated
+    uint256 constant GAMMA_NEG_X_0_HI = {{ fpHi .G2.Gamma.X.A0 }};
+    uint256 constant GAMMA_NEG_X_0_LO = {{ fpLo .G2.Gamma.X.A0 }};
+    uint256 constant GAMMA_NEG_X_1_HI = {{ fpHi .G2.Gamma.X.A1 }};
+    uint256 constant GAMMA_NEG_X_1_LO = {{ fpLo .G2.Gamma.X.A1 }};
+    uint256 constant GAMMA_NEG_Y_0_HI = {{ fpHi .G2.Gamma.Y.A0 }};
+    uint256 constant GAMMA_NEG_Y_0_LO = {{ fpLo .G2.Gamma.Y.A0 }};
+    uint256 constant GAMMA_NEG_Y_1_HI = {{ fpHi .G2.Gamma.Y.A1 }};
+    uint256 constant GAMMA_NEG_Y_1_LO = {{ fpLo .G2.Gamma.Y.A1 }};
+
+    // Groth16 delta point in G2, negated
+    uint256 constant DELTA_NEG_X_0_HI = {{ fpHi .G2.Delta.X.A0 }};
+    uint256 constant DELTA_NEG_X_0_LO = {{ fpLo .G2.Delta.X.A0 }};
+    uint256 constant DELTA_NEG_X_1_HI = {{ fpHi .G2.Delta.X.A1 }};
+    uint256 constant DELTA_NEG_X_1_LO = {{ fpLo .G2.Delta.X.A1 }};
+    uint256 constant DELTA_NEG_Y_0_HI = {{ fpHi .G2.Delta.Y.A0 }};
+    uint256 constant DELTA_NEG_Y_0_LO = {{ fpLo .G2.Delta.Y.A0 }};
+    uint256 constant DELTA_NEG_Y_1_HI = {{ fpHi .G2.Delta.Y.A1 }};
+    uint256 constant DELTA_NEG_Y_1_LO = {{ fpLo .G2.Delta.Y.A1 }};
+
+    {{- if gt $numCommitments 0 }}
+    // Pedersen G point in G2
+    uint256 constant PEDERSEN_G_X_0_HI = {{ fpHi .CommitmentKey.G.X.A0 }};
+    uint256 constant PEDERSEN_G_X_0_LO = {{ fpLo .CommitmentKey.G.X.A0 }};
+    uint256 constant PEDERSEN_G_X_1_HI = {{ fpHi .CommitmentKey.G.X.A1 }};
+    uint256 constant PEDERSEN_G_X_1_LO = {{ fpLo .CommitmentKey.G.X.A1 }};
+    uint256 constant PEDERSEN_G_Y_0_HI = {{ fpHi .CommitmentKey.G.Y.A0 }};
+    uint256 constant PEDERSEN_G_Y_0_LO = {{ fpLo .CommitmentKey.G.Y.A0 }};
+    uint256 constant PEDERSEN_G_Y_1_HI = {{ fpHi .CommitmentKey.G.Y.A1 }};
+    uint256 constant PEDERSEN_G_Y_1_LO = {{ fpLo .CommitmentKey.G.Y.A1 }};
+
+    // Pedersen GRootSigmaNeg point in G2
+    uint256 constant PEDERSEN_GROOTSIGMANEG_X_0_HI = {{ fpHi .CommitmentKey.GRootSigmaNeg.X.A0 }};
+    uint256 constant PEDERSEN_GROOTSIGMANEG_X_0_LO = {{ fpLo .CommitmentKey.GRootSigmaNeg.X.A0 }};
+    uint256 constant PEDERSEN_GROOTSIGMANEG_X_1_HI = {{ fpHi .CommitmentKey.GRootSigmaNeg.X.A1 }};
+    uint256 constant PEDERSEN_GROOTSIGMANEG_X_1_LO = {{ fpLo .CommitmentKey.GRootSigmaNeg.X.A1 }};
+    uint256 constant PEDERSEN_GROOTSIGMANEG_Y_0_HI = {{ fpHi .CommitmentKey.GRootSigmaNeg.Y.A0 }};
+    uint256 constant PEDERSEN_GROOTSIGMANEG_Y_0_LO = {{ fpLo .CommitmentKey.GRootSigmaNeg.Y.A0 }};
+    uint256 constant PEDERSEN_GROOTSIGMANEG_Y_1_HI = {{ fpHi .CommitmentKey.GRootSigmaNeg.Y.A1 }};
+    uint256 constant PEDERSEN_GROOTSIGMANEG_Y_1_LO = {{ fpLo .CommitmentKey.GRootSigmaNeg.Y.A1 }};
+    {{- end }}
+
+    // Constant and public input points
+    {{- $k0 := index .G1.K 0}}
+    uint256 constant CONSTANT_X_HI = {{ fpHi $k0.X }};
+    uint256 constant CONSTANT_X_LO = {{ fpLo $k0.X }};
+    uint256 constant CONSTANT_Y_HI = {{ fpHi $k0.Y }};
+    uint256 constant CONSTANT_Y_LO = {{ fpLo $k0.Y }};
+    {{- range $i, $ki := .G1.K }}
+        {{- if gt $i 0 }}
+    uint256 constant PUB_{{sub $i 1}}_X_HI = {{ fpHi $ki.X }};
+    uint256 constant PUB_{{sub $i 1}}_X_LO = {{ fpLo $ki.X }};
+    uint256 constant PUB_{{sub $i 1}}_Y_HI = {{ fpHi $ki.Y }};
+    uint256 constant PUB_{{sub $i 1}}_Y_LO = {{ fpLo $ki.Y }};
+        {{- end }}
+    {{- end }}
+
+    /// Compute the public input linear combination.
+    /// @notice Reverts with PublicInputNotInField if the input is not in the field.
+    /// @notice Computes the multi-scalar-multiplication of the public input
+    /// elements and the verification key including the constant term.
+    /// @param input The public inputs. These are elements of the scalar field Fr.
+    {{- if gt $numCommitments 0 }}
+    /// @param publicCommitments public inputs generated from pedersen commitments.
+    /// @param commitments The Pedersen commitments from the proof.
+    {{- end }}
+    /// @return l The resulting G1 point.
+    {{- if eq $numCommitments 0 }}
+    function publicInputMSM(uint256[{{$numWitness}}] calldata input)
+    {{- else }}
+    function publicInputMSM(
+        uint256[{{$numWitness}}] calldata input,
+        uint256[{{$numCommitments}}] memory publicCommitments,
+        uint256[{{mul 4 $numCommitments}}] calldata commitments
+    )
+    {{- end }}
+    internal view returns (uint256[4] memory l) {
+        // Note: The G1MSM precompile does not reject unreduced scalars, so we check this.
+        // G1MSM has input (x, y, scalar)* and output (x', y'), each entry being 0xa0 bytes long.
+        // The constant term and the commitments (if any) are added with the scalar 1.
+        bool success = true;
+        assembly ("memory-safe") {
+            let f := mload(0x40)
+            let g := f
+            let s
+            mstore(g, CONSTANT_X_HI)
+            mstore(add(g, 0x20), CONSTANT_X_LO)
+            mstore(add(g, 0x40), CONSTANT_Y_HI)
+            mstore(add(g, 0x60), CONSTANT_Y_LO)
+            mstore(add(g, 0x80), 1)
+            g := add(g, 0xa0)
+            {{- range $i := intRange $numPublic }}
+            mstore(g, PUB_{{$i}}_X_HI)
+            mstore(add(g, 0x20), PUB_{{$i}}_X_LO)
+            mstore(add(g, 0x40), PUB_{{$i}}_Y_HI)
+            mstore(add(g, 0x60), PUB_{{$i}}_Y_LO)
+            {{- if eq $i 0 }}
+            s := calldataload(input)
+            {{- else if lt $i $numWitness }}
+            s := calldataload(add(input, {{mul $i 0x20}}))
+            {{- else if eq $i $numWitness }}
+            s := mload(publicCommitments)
+            {{- else}}
+            s := mload(add(publicCommitments, {{mul 0x20 (sub $i $numWitness)}}))
+            {{- end }}
+            mstore(add(g, 0x80), s)
+            success := and(success, lt(s, R))
+            g := add(g, 0xa0)
+            {{- end }}
+            {{- range $i := intRange $numCommitments }}
+            calldatacopy(g, add(commitments, {{mul $i 0x80}}), 0x80)
+            mstore(add(g, 0x80), 1)
+            g := add(g, 0xa0)
+            {{- end }}
+            success := and(success, staticcall(gas(), PRECOMPILE_G1MSM, f, sub(g, f), l, 0x80))
+        }
+        if (!success) {
+            // Either Public input not in field, or verification key invalid.
+            // We assume the contract is correctly generated, so the verification key is valid.
+            revert PublicInputNotInField();
+        }
+    }
+
+    /// Verify an uncompressed Groth16 proof.
+    /// @notice Reverts with InvalidProof if the proof is invalid or
+    /// with PublicInputNotInField the public input is not reduced.
+    /// @notice There is no return value. If the function does not revert, the
+    /// proof was successfully verified.
+    /// @param proof the points (A, B, C) in EIP-2537 format, on 16 words.
+    {{- if gt $numCommitments 0 }}
+    /// @param commitments the Pedersen commitments from the proof.
+    /// @param commitmentPok the proof of knowledge for the Pedersen commitments.
+    {{- end }}
+    /// @param input the public input field elements in the scalar field Fr.
+    /// Elements must be reduced.
+    function verifyProof(
+        uint256[16] calldata proof,
+        {{- if gt $numCommitments 0}}
+        uint256[{{mul 4 $numCommitments}}] calldata commitments,
+        uint256[4] calldata commitmentPok,
+        {{- end }}
+        uint256[{{$numWitness}}] calldata input
+    ) public view {
+        {{- if eq $numCommitments 0 }}
+        uint256[4] memory l = publicInputMSM(input);
+        {{- else }}
+        // HashToField
+        uint256[{{$numCommitments}}] memory publicCommitments;
+        uint256[] memory publicAndCommitmentCommitted;
+        {{- range $i := intRange $numCommitments }}
+        {{- $pcIndex := index $PublicAndCommitmentCommitted $i }}
+        {{- if gt (len $pcIndex) 0 }}
+        publicAndCommitmentCommitted = new uint256[]({{(len $pcIndex)}});
+        assembly ("memory-safe") {
+            let publicAndCommitmentCommittedOffset := add(publicAndCommitmentCommitted, 0x20)
+            {{- $segment_start := index $pcIndex 0 }}
+            {{- $segment_end := index $pcIndex 0 }}
+            {{- $l := 0 }}
+            {{- range $k := intRange (sub (len $pcIndex) 1) }}
+                {{- $next := index $pcIndex (sum $k 1) }}
+                {{- if ne $next (sum $segment_end 1) }}
+            calldatacopy(add(publicAndCommitmentCommittedOffset, {{mul $l 0x20}}), add(input, {{mul 0x20 (sub $segment_start 1)}}), {{mul 0x20 (sum 1 (sub $segment_end $segment_start))}})
+                    {{- $segment_start = $next }}
+                    {{- $l = (sum $k 1) }}
+                {{- end }}
+                {{- $segment_end = $next }}
+            {{- end }}
+            calldatacopy(add(publicAndCommitmentCommittedOffset, {{mul $l 0x20}}), add(input, {{mul 0x20 (sub $segment_start 1)}}), {{mul 0x20 (sum 1 (sub $segment_end $segment_start))}})
+        }
+        {{- end }}
+
+        // The commitment is hashed as in gnark, with its coordinates on 48 bytes.
+        publicCommitments[{{$i}}] = uint256(
+            {{ hashToField }}(
+                abi.encodePacked(
+                    uint128(commitments[{{mul $i 4}}]),
+                    commitments[{{sum (mul $i 4) 1}}],
+                    uint128(commitments[{{sum (mul $i 4) 2}}]),
+                    commitments[{{sum (mul $i 4) 3}}],
+                    publicAndCommitmentCommitted
+                )
+            )
+        ) % R;
+        {{- end }}
+
+        // Verify pedersen commitments
+        bool success;
+        assembly ("memory-safe") {
+            let f := mload(0x40)
+
+            calldatacopy(f, commitments, 0x80) // Copy Commitments
+            mstore(add(f, 0x80), PEDERSEN_G_X_0_HI)
+            mstore(add(f, 0xa0), PEDERSEN_G_X_0_LO)
+            mstore(add(f, 0xc0), PEDERSEN_G_X_1_HI)
+            mstore(add(f, 0xe0), PEDERSEN_G_X_1_LO)
+            mstore(add(f, 0x100), PEDERSEN_G_Y_0_HI)
+            mstore(add(f, 0x120), PEDERSEN_G_Y_0_LO)
+            mstore(add(f, 0x140), PEDERSEN_G_Y_1_HI)
+            mstore(add(f, 0x160), PEDERSEN_G_Y_1_LO)
+            calldatacopy(add(f, 0x180), commitmentPok, 0x80)
+            mstore(add(f, 0x200), PEDERSEN_GROOTSIGMANEG_X_0_HI)
+            mstore(add(f, 0x220), PEDERSEN_GROOTSIGMANEG_X_0_LO)
+            mstore(add(f, 0x240), PEDERSEN_GROOTSIGMANEG_X_1_HI)
+            mstore(add(f, 0x260), PEDERSEN_GROOTSIGMANEG_X_1_LO)
+            mstore(add(f, 0x280), PEDERSEN_GROOTSIGMANEG_Y_0_HI)
+            mstore(add(f, 0x2a0), PEDERSEN_GROOTSIGMANEG_Y_0_LO)
+            mstore(add(f, 0x2c0), PEDERSEN_GROOTSIGMANEG_Y_1_HI)
+            mstore(add(f, 0x2e0), PEDERSEN_GROOTSIGMANEG_Y_1_LO)
+
+            success := staticcall(gas(), PRECOMPILE_PAIRING, f, 0x300, f, 0x20)
+            success := and(success, mload(f))
+        }
+        if (!success) {
+            revert CommitmentInvalid();
+        }
+
+        uint256[4] memory l = publicInputMSM(
+            input,
+            publicCommitments,
+            commitments
+        );
+        {{- end }}
+
+        // Note: The pairing precompile rejects points which are not in their subgroup,
+        // and coordinates which are not reduced, so we won't check that here.
+
+        {{- if eq $numCommitments 0 }}
+        bool success;
+        {{- end }}
+        assembly ("memory-safe") {
+            let f := mload(0x40) // Free memory pointer.
+
+            // Copy points (A, B) to memory. They are already in correct encoding.
+            // This is pairing e(A, B).
+            calldatacopy(f, proof, 0x180)
+
+            // Write e(C, -δ), e(α, -β), e(L_pub, -γ) to memory.
+            calldatacopy(add(f, 0x180), add(proof, 0x180), 0x80)
+            mstore(add(f, 0x200), DELTA_NEG_X_0_HI)
+            mstore(add(f, 0x220), DELTA_NEG_X_0_LO)
+            mstore(add(f, 0x240), DELTA_NEG_X_1_HI)
+            mstore(add(f, 0x260), DELTA_NEG_X_1_LO)
+            mstore(add(f, 0x280), DELTA_NEG_Y_0_HI)
+            mstore(add(f, 0x2a0), DELTA_NEG_Y_0_LO)
+            mstore(add(f, 0x2c0), DELTA_NEG_Y_1_HI)
+            mstore(add(f, 0x2e0), DELTA_NEG_Y_1_LO)
+            mstore(add(f, 0x300), ALPHA_X_HI)
+            mstore(add(f, 0x320), ALPHA_X_LO)
+            mstore(add(f, 0x340), ALPHA_Y_HI)
+            mstore(add(f, 0x360), ALPHA_Y_LO)
+            mstore(add(f, 0x380), BETA_NEG_X_0_HI)
+            mstore(add(f, 0x3a0), BETA_NEG_X_0_LO)
+            mstore(add(f, 0x3c0), BETA_NEG_X_1_HI)
+            mstore(add(f, 0x3e0), BETA_NEG_X_1_LO)
+            mstore(add(f, 0x400), BETA_NEG_Y_0_HI)
+            mstore(add(f, 0x420), BETA_NEG_Y_0_LO)
+            mstore(add(f, 0x440), BETA_NEG_Y_1_HI)
+            mstore(add(f, 0x460), BETA_NEG_Y_1_LO)
+            mstore(add(f, 0x480), mload(l))
+            mstore(add(f, 0x4a0), mload(add(l, 0x20)))
+            mstore(add(f, 0x4c0), mload(add(l, 0x40)))
+            mstore(add(f, 0x4e0), mload(add(l, 0x60)))
+            mstore(add(f, 0x500), GAMMA_NEG_X_0_HI)
+            mstore(add(f, 0x520), GAMMA_NEG_X_0_LO)
+            mstore(add(f, 0x540), GAMMA_NEG_X_1_HI)
+            mstore(add(f, 0x560), GAMMA_NEG_X_1_LO)
+            mstore(add(f, 0x580), GAMMA_NEG_Y_0_HI)
+            mstore(add(f, 0x5a0), GAMMA_NEG_Y_0_LO)
+            mstore(add(f, 0x5c0), GAMMA_NEG_Y_1_HI)
+            mstore(add(f, 0x5e0), GAMMA_NEG_Y_1_LO)
+
+            // Check pairing equation.
+            success := staticcall(gas(), PRECOMPILE_PAIRING, f, 0x600, f, 0x20)
+            // Also check returned value (both are either 1 or 0).
+            success := and(success, mload(f))
+        }
+        if (!success) {
+            // Either proof or verification key invalid.
+            // We assume the contract is correctly generated, so the verification key is valid.
+            revert ProofInvalid();
+        }
+    }
+
+    {{- if boolVerifier }}
+    {{- $commitmentsOffset := 0x200 }}
+    {{- $pokOffset := sum $commitmentsOffset (mul 0x80 $numCommitments) }}
+
+    /// Verify a Groth16 proof.
+    /// @notice Returns false instead of reverting if the proof is invalid or
+    /// the public input is not reduced.
+    /// @param proof the proof encoded with gnark's MarshalSolidity.
+    /// @param input the public input field elements in the scalar field Fr.
+    /// @return true if the proof is valid.
+    function Verify(bytes calldata proof, uint256[] calldata input) public view returns (bool) {
+        {{- if eq $numCommitments 0 }}
+        if (proof.length != 0x200 || input.length != {{$numWitness}}) {
+        {{- else }}
+        if (proof.length != {{ sum $pokOffset 0x80 }} || input.length != {{$numWitness}}) {
+        {{- end }}
+            return false;
+        }
+        uint256[16] memory points;
+        {{- if gt $numCommitments 0 }}
+        uint256[{{mul 4 $numCommitments}}] memory commitments;
+        uint256[4] memory commitmentPok;
+        {{- end }}
+        uint256[{{$numWitness}}] memory publicInput;
+        assembly ("memory-safe") {
+            calldatacopy(points, proof.offset, 0x200)
+            {{- if gt $numCommitments 0 }}
+            calldatacopy(commitments, add(proof.offset, {{$commitmentsOffset}}), {{mul 0x80 $numCommitments}})
+            calldatacopy(commitmentPok, add(proof.offset, {{$pokOffset}}), 0x80)
+            {{- end }}
+            calldatacopy(publicInput, input.offset, {{mul 0x20 $numWitness}})
+        }
+        {{- if eq $numCommitments 0 }}
+        try this.verifyProof(points, publicInput) {
+        {{- else }}
+        try this.verifyProof(points, commitments, commitmentPok, publicInput) {
+        {{- end }}
+            return true;
+        } catch {
+            return false;
+        }
+    }
+    {{- end }}
+}
+`
+
+// MarshalSolidity converts a proof to a byte array that can be used in a
+// Solidity contract.
+//
+// The points are encoded as in EIP-2537: each coordinate is written in big
+// endian on 64 bytes, with 16 bytes of zero padding. The proof is Ar | Bs | Krs,
+// followed by the commitments and their proof of knowledge if any.
+func (proof *Proof) MarshalSolidity() []byte {
+	res := make([]byte, 0, 512+128*(len(proof.Commitments)+1))
+	res = appendG1Solidity(res, &proof.Ar)
+	res = appendG2Solidity(res, &proof.Bs)
+	res = appendG1Solidity(res, &proof.Krs)
+	if len(proof.Commitments) > 0 {
+		for i := range proof.Commitments {
+			res = appendG1Solidity(res, &proof.Commitments[i])
+		}
+		res = appendG1Solidity(res, &proof.CommitmentPok)
+	}
+	return res
+}
+
+func appendFpSolidity(dst []byte, x *fp.Element) []byte {
+	var padding [64 - fp.Bytes]byte
+	b := x.Bytes()
+	return append(append(dst, padding[:]...), b[:]...)
+}
+
+func appendG1Solidity(dst []byte, p *curve.G1Affine) []byte {
+	dst = appendFpSolidity(dst, &p.X)
+	return appendFpSolidity(dst, &p.Y)
+}
+
+func appendG2Solidity(dst []byte, p *curve.G2Affine) []byte {
+	dst = appendFpSolidity(dst, &p.X.A0)
+	dst = appendFpSolidity(dst, &p.X.A1)
+	dst = appendFpSolidity(dst, &p.Y.A0)
+	return appendFpSolidity(dst, &p.Y.A1)
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend/groth16"
+	groth16_bls24_315 "github.com/consensys/gnark/backend/groth16/bls24-315"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
@@ -148,3 +149,20 @@ func TestOneSecretOnePublicCommitted(t *testing.T) {
 		Two: 2,
 	})
 }
+
+func TestBatchVerifyCommitmentPok(t *testing.T) {
+	_r1cs, pk, vk := setup(t, &oneSecretOnePublicCommittedCircuit{})
+	assignment := &oneSecretOnePublicCommittedCircuit{One: 1, Two: 2}
+	publics := make([]witness.Witness, 3)
+	proofs := make([]groth16.Proof, 3)
+	for i := range proofs {
+		publics[i], proofs[i] = prove(t, assignment, _r1cs, pk)
+	}
+	assert.NoError(t, groth16.BatchVerify(proofs, vk, publics))
+
+	// invalid proof of knowledge of the committed values
+	invalid := proofs[1].(*groth16_bls24_315.Proof)
+	invalid.CommitmentPok = invalid.Commitments[0]
+	assert.Error(t, groth16.Verify(invalid, vk, publics[1]))
+	assert.Error(t, groth16.BatchVerify(proofs, vk, publics))
+}
@@ -17,8 +17,13 @@
 package mpcsetup
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/bits"
 	"testing"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	curve "github.com/consensys/gnark-crypto/ecc/bls24-315"
 	cs "github.com/consensys/gnark/constraint/bls24-315"
 	"github.com/consensys/gnark/frontend"
@@ -51,3 +56,91 @@ func TestContributionSerialization(t *testing.T) {
 
 	assert.NoError(gnarkio.RoundTripCheck(&srs2, func() interface{} { return new(Phase2) }))
 }
+
+// writeLegacy writes a Phase2 without commitments in the legacy format, and
+// sets its hash as the legacy format does.
+func writeLegacy(t *testing.T, c *Phase2) []byte {
+	var buf bytes.Buffer
+	enc := curve.NewEncoder(&buf)
+	for _, v := range []interface{}{
+		&c.PublicKey.SG,
+		&c.PublicKey.SXG,
+		&c.PublicKey.XR,
+		&c.Parameters.G1.Delta,
+		c.Parameters.G1.L,
+		c.Parameters.G1.Z,
+		&c.Parameters.G2.Delta,
+	} {
+		if err := enc.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := sha256.Sum256(buf.Bytes())
+	return append(buf.Bytes(), h[:]...)
+}
+
+func TestPhase2LegacyFormat(t *testing.T) {
+	assert := require.New(t)
+
+	var myCircuit Circuit
+	ccs, err := frontend.Compile(curve.ID.ScalarField(), r1cs.NewBuilder, &myCircuit)
+	assert.NoError(err)
+	srs1 := InitPhase1(bits.Len64(ecc.NextPowerOfTwo(uint64(ccs.GetNbConstraints()))) - 1)
+	srs1.Contribute()
+	srs2, _ := InitPhase2(ccs.(*cs.R1CS), &srs1)
+
+	legacy := writeLegacy(t, &srs2)
+	var prev, next Phase2
+	n, err := prev.ReadFrom(bytes.NewReader(legacy))
+	assert.NoError(err)
+	assert.Equal(int64(len(legacy)), n)
+	_, err = next.ReadFrom(bytes.NewReader(legacy))
+	assert.NoError(err)
+
+	// a legacy Phase2 is contributed to, hashed and written in the legacy
+	// format
+	next.Contribute()
+	assert.NoError(VerifyPhase2(&prev, &next))
+	var buf bytes.Buffer
+	_, err = next.WriteTo(&buf)
+	assert.NoError(err)
+	assert.Equal(writeLegacy(t, &next), buf.Bytes())
+
+	// formats can't be mixed
+	assert.Error(VerifyPhase2(&srs2, &next))
+}
+
+func TestPhase2InvalidFormat(t *testing.T) {
+	assert := require.New(t)
+
+	var myCircuit Circuit
+	ccs, err := frontend.Compile(curve.ID.ScalarField(), r1cs.NewBuilder, &myCircuit)
+	assert.NoError(err)
+	srs1 := InitPhase1(bits.Len64(ecc.NextPowerOfTwo(uint64(ccs.GetNbConstraints()))) - 1)
+	srs2, evals := InitPhase2(ccs.(*cs.R1CS), &srs1)
+
+	var buf bytes.Buffer
+	_, err = srs2.WriteTo(&buf)
+	assert.NoError(err)
+	b := buf.Bytes()
+
+	// unknown version
+	unknown := append([]byte{}, b...)
+	binary.BigEndian.PutUint32(unknown[4:], formatVersion+1)
+	_, err = new(Phase2).ReadFrom(bytes.NewReader(unknown))
+	assert.Error(err)
+
+	// the number of commitments, before the hash, is not trusted
+	large := append([]byte{}, b[:len(b)-32]...)
+	binary.BigEndian.PutUint32(large[len(large)-4:], 0xffffffff)
+	_, err = new(Phase2).ReadFrom(bytes.NewReader(large))
+	assert.Error(err)
+
+	buf.Reset()
+	_, err = evals.WriteTo(&buf)
+	assert.NoError(err)
+	large = buf.Bytes()
+	binary.BigEndian.PutUint32(large[len(large)-4:], 0xffffffff)
+	_, err = new(Phase2Evaluations).ReadFrom(bytes.NewReader(large))
+	assert.Error(err)
+}
@@ -17,6 +17,7 @@
 package mpcsetup
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"errors"
 	curve "github.com/consensys/gnark-crypto/ecc/bls24-315"
@@ -87,16 +88,37 @@ func InitPhase1(power int) (phase1 Phase1) {
 
 // Contribute contributes randomness to the phase1 object. This mutates phase1.
 func (phase1 *Phase1) Contribute() {
-	N := len(phase1.Parameters.G2.Tau)
-
-	// Generate key pairs
 	var tau, alpha, beta fr.Element
+	var s [3]fr.Element
 	tau.SetRandom()
 	alpha.SetRandom()
 	beta.SetRandom()
-	phase1.PublicKeys.Tau = newPublicKey(tau, phase1.Hash[:], 1)
-	phase1.PublicKeys.Alpha = newPublicKey(alpha, phase1.Hash[:], 2)
-	phase1.PublicKeys.Beta = newPublicKey(beta, phase1.Hash[:], 3)
+	for i := range s {
+		s[i].SetRandom()
+	}
+	phase1.contribute(tau, alpha, beta, s)
+}
+
+// Seal finalizes phase 1 with a last contribution whose secrets are derived
+// from a public random beacon and the hash of the current state, so that
+// anyone can reproduce it. The beacon must be unpredictable at the time the
+// last regular contribution was made, e.g. a future block hash.
+func (phase1 *Phase1) Seal(beacon []byte) error {
+	secrets, err := beaconSecrets(phase1.Hash, beacon, 6)
+	if err != nil {
+		return err
+	}
+	phase1.contribute(secrets[0], secrets[1], secrets[2], [3]fr.Element(secrets[3:]))
+	return nil
+}
+
+func (phase1 *Phase1) contribute(tau, alpha, beta fr.Element, s [3]fr.Element) {
+	N := len(phase1.Parameters.G2.Tau)
+
+	// Generate key pairs
+	phase1.PublicKeys.Tau = newPublicKeyFromSecret(tau, s[0], phase1.Hash[:], 1)
+	phase1.PublicKeys.Alpha = newPublicKeyFromSecret(alpha, s[1], phase1.Hash[:], 2)
+	phase1.PublicKeys.Beta = newPublicKeyFromSecret(beta, s[2], phase1.Hash[:], 3)
 
 	// Compute powers of τ, ατ, and βτ
 	taus := powers(tau, 2*N-1)
@@ -131,6 +153,47 @@ func VerifyPhase1(c0, c1 *Phase1, c ...*Phase1) error {
 	return nil
 }
 
+// VerifyInitialPhase1 checks that c0 is a valid initial state for a ceremony,
+// as returned by InitPhase1 or imported from an existing ceremony: its
+// parameters are powers of τ starting from the generators, its public keys
+// are the ones of the secret 1 rather than of a contribution, and its hash
+// matches them.
+func VerifyInitialPhase1(c0 *Phase1) error {
+	if err := c0.verifyInitial(); err != nil {
+		return err
+	}
+	for _, pk := range []*PublicKey{&c0.PublicKeys.Tau, &c0.PublicKeys.Alpha, &c0.PublicKeys.Beta} {
+		if pk.SG.IsInfinity() || !pk.SG.Equal(&pk.SXG) {
+			return errors.New("couldn't verify that the public keys are the initial ones")
+		}
+	}
+	if !bytes.Equal(c0.hash(), c0.Hash) {
+		return errors.New("couldn't verify hash of the initial state")
+	}
+	return nil
+}
+
+// verifyInitial checks that the parameters of an initial state, created by
+// InitPhase1 or imported from an existing ceremony, are well formed.
+func (phase1 *Phase1) verifyInitial() error {
+	p := &phase1.Parameters
+	_, _, g1, g2 := curve.Generators()
+	if len(p.G1.Tau) < 3 || len(p.G2.Tau) != (len(p.G1.Tau)+1)/2 ||
+		len(p.G1.AlphaTau) != len(p.G2.Tau) || len(p.G1.BetaTau) != len(p.G2.Tau) {
+		return errors.New("invalid sizes of the parameters")
+	}
+	if !p.G1.Tau[0].Equal(&g1) || !p.G2.Tau[0].Equal(&g2) {
+		return errors.New("couldn't verify that [τ⁰] are the generators")
+	}
+	if p.G1.Tau[1].IsInfinity() || p.G1.AlphaTau[0].IsInfinity() || p.G1.BetaTau[0].IsInfinity() {
+		return errors.New("parameters contain the point at infinity")
+	}
+	if !sameRatio(p.G1.BetaTau[0], g1, g2, p.G2.Beta) {
+		return errors.New("couldn't verify that [β]₁ and [β]₂ match")
+	}
+	return phase1.verifyPowers()
+}
+
 // verifyPhase1 checks that a contribution is based on a known previous Phase1 state.
 func verifyPhase1(current, contribution *Phase1) error {
 	// Compute R for τ, α, β
@@ -167,22 +230,8 @@ func verifyPhase1(current, contribution *Phase1) error {
 	}
 
 	// Check for valid updates using powers of τ
-	_, _, g1, g2 := curve.Generators()
-	tauL1, tauL2 := linearCombinationG1(contribution.Parameters.G1.Tau)
-	if !sameRatio(tauL1, tauL2, contribution.Parameters.G2.Tau[1], g2) {
-		return errors.New("couldn't verify valid powers of τ in G₁")
-	}
-	alphaL1, alphaL2 := linearCombinationG1(contribution.Parameters.G1.AlphaTau)
-	if !sameRatio(alphaL1, alphaL2, contribution.Parameters.G2.Tau[1], g2) {
-		return errors.New("couldn't verify valid powers of α(τ) in G₁")
-	}
-	betaL1, betaL2 := linearCombinationG1(contribution.Parameters.G1.BetaTau)
-	if !sameRatio(betaL1, betaL2, contribution.Parameters.G2.Tau[1], g2) {
-		return errors.New("couldn't verify valid powers of α(τ) in G₁")
-	}
-	tau2L1, tau2L2 := linearCombinationG2(contribution.Parameters.G2.Tau)
-	if !sameRatio(contribution.Parameters.G1.Tau[1], g1, tau2L1, tau2L2) {
-		return errors.New("couldn't verify valid powers of τ in G₂")
+	if err := contribution.verifyPowers(); err != nil {
+		return err
 	}
 
 	// Check hash of the contribution
@@ -196,6 +245,29 @@ func verifyPhase1(current, contribution *Phase1) error {
 	return nil
 }
 
+// verifyPowers checks that the parameters are successive powers of the same τ,
+// scaled by α and β for AlphaTau and BetaTau.
+func (phase1 *Phase1) verifyPowers() error {
+	_, _, g1, g2 := curve.Generators()
+	tauL1, tauL2 := linearCombinationG1(phase1.Parameters.G1.Tau)
+	if !sameRatio(tauL1, tauL2, phase1.Parameters.G2.Tau[1], g2) {
+		return errors.New("couldn't verify valid powers of τ in G₁")
+	}
+	alphaL1, alphaL2 := linearCombinationG1(phase1.Parameters.G1.AlphaTau)
+	if !sameRatio(alphaL1, alphaL2, phase1.Parameters.G2.Tau[1], g2) {
+		return errors.New("couldn't verify valid powers of α(τ) in G₁")
+	}
+	betaL1, betaL2 := linearCombinationG1(phase1.Parameters.G1.BetaTau)
+	if !sameRatio(betaL1, betaL2, phase1.Parameters.G2.Tau[1], g2) {
+		return errors.New("couldn't verify valid powers of α(τ) in G₁")
+	}
+	tau2L1, tau2L2 := linearCombinationG2(phase1.Parameters.G2.Tau)
+	if !sameRatio(phase1.Parameters.G1.Tau[1], g1, tau2L1, tau2L2) {
+		return errors.New("couldn't verify valid powers of τ in G₂")
+	}
+	return nil
+}
+
 func (phase1 *Phase1) hash() []byte {
 	sha := sha256.New()
 	phase1.writeTo(sha)
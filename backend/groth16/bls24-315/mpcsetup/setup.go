@@ -19,6 +19,7 @@ package mpcsetup
 import (
 	curve "github.com/consensys/gnark-crypto/ecc/bls24-315"
 	"github.com/consensys/gnark-crypto/ecc/bls24-315/fr/fft"
+	"github.com/consensys/gnark-crypto/ecc/bls24-315/fr/pedersen"
 	groth16 "github.com/consensys/gnark/backend/groth16/bls24-315"
 )
 
@@ -88,6 +89,20 @@ func ExtractKeys(srs1 *Phase1, srs2 *Phase2, evals *Phase2Evaluations, nConstrai
 	vk.G2.Gamma.Set(&g2)
 	vk.G1.K = evals.G1.VKK
 
+	// Commitment keys: the proof of knowledge σ[C]₁ of a commitment [C]₁ is
+	// checked with e([C]₁, [σ]₂) e(σ[C]₁, -[1]₂) = 1
+	if len(evals.G1.CKK) != len(srs2.Parameters.G1.SigmaCKK) {
+		panic("number of commitment keys doesn't match the evaluations")
+	}
+	pk.CommitmentKeys = make([]pedersen.ProvingKey, len(evals.G1.CKK))
+	for i := range pk.CommitmentKeys {
+		pk.CommitmentKeys[i].Basis = evals.G1.CKK[i]
+		pk.CommitmentKeys[i].BasisExpSigma = srs2.Parameters.G1.SigmaCKK[i]
+	}
+	vk.CommitmentKey.G.Set(&srs2.Parameters.G2.Sigma)
+	vk.CommitmentKey.GRootSigmaNeg.Neg(&g2)
+	vk.PublicAndCommitmentCommitted = evals.PublicAndCommitmentCommitted
+
 	// sets e, -[δ]2, -[γ]2
 	if err := vk.Precompute(); err != nil {
 		panic(err)
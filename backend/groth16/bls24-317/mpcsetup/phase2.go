@@ -17,12 +17,14 @@
 package mpcsetup
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"errors"
 	"math/big"
 
 	curve "github.com/consensys/gnark-crypto/ecc/bls24-317"
 	"github.com/consensys/gnark-crypto/ecc/bls24-317/fr"
+	"github.com/consensys/gnark/backend/groth16/internal"
 	"github.com/consensys/gnark/constraint"
 	cs "github.com/consensys/gnark/constraint/bls24-317"
 )
@@ -30,26 +32,35 @@ import (
 type Phase2Evaluations struct {
 	G1 struct {
 		A, B, VKK []curve.G1Affine
+		CKK       [][]curve.G1Affine // commitment keys, evaluations of the private committed wires
 	}
 	G2 struct {
 		B []curve.G2Affine
 	}
+	PublicAndCommitmentCommitted [][]int // indexes of public/commitment committed variables
 }
 
 type Phase2 struct {
 	Parameters struct {
 		G1 struct {
-			Delta curve.G1Affine
-			L, Z  []curve.G1Affine
+			Delta    curve.G1Affine
+			L, Z     []curve.G1Affine
+			SigmaCKK [][]curve.G1Affine // σ times the commitment keys
 		}
 		G2 struct {
 			Delta curve.G2Affine
+			Sigma curve.G2Affine // [σ]₂
 		}
 	}
-	PublicKey PublicKey
-	Hash      []byte
+	PublicKey      PublicKey // proof of knowledge of δ
+	SigmaPublicKey PublicKey // proof of knowledge of σ
+	Hash           []byte
+
+	legacy bool // read from the legacy format, without σ and the commitment keys
 }
 
+// InitPhase2 returns the initial state of a Phase2 for the circuit r1cs, and the
+// evaluations needed to extract the keys. It is deterministic.
 func InitPhase2(r1cs *cs.R1CS, srs1 *Phase1) (Phase2, Phase2Evaluations) {
 	srs := srs1.Parameters
 	size := len(srs.G1.AlphaTau)
@@ -105,8 +116,8 @@ func InitPhase2(r1cs *cs.R1CS, srs1 *Phase1) (Phase2, Phase2Evaluations) {
 	coeffAlphaTau1 := lagrangeCoeffsG1(srs.G1.AlphaTau, size)
 	coeffBetaTau1 := lagrangeCoeffsG1(srs.G1.BetaTau, size)
 
-	internal, secret, public := r1cs.GetNbVariables()
-	nWires := internal + secret + public
+	nbInternal, nbSecret, nbPublic := r1cs.GetNbVariables()
+	nWires := nbInternal + nbSecret + nbPublic
 	var evals Phase2Evaluations
 	evals.G1.A = make([]curve.G1Affine, nWires)
 	evals.G1.B = make([]curve.G1Affine, nWires)
@@ -153,25 +164,63 @@ func InitPhase2(r1cs *cs.R1CS, srs1 *Phase1) (Phase2, Phase2Evaluations) {
 	bitReverse(c2.Parameters.G1.Z)
 	c2.Parameters.G1.Z = c2.Parameters.G1.Z[:n-1]
 
-	// Evaluate L
-	nPrivate := internal + secret
-	c2.Parameters.G1.L = make([]curve.G1Affine, nPrivate)
-	evals.G1.VKK = make([]curve.G1Affine, public)
-	offset := public
+	// Evaluate L, and split out the evaluations of the public and commitment
+	// wires (VKK) and of the private committed wires (CKK) as groth16.Setup does
+	commitmentInfo := r1cs.CommitmentInfo.(constraint.Groth16Commitments)
+	commitmentWires := commitmentInfo.CommitmentIndexes()
+	privateCommitted := commitmentInfo.GetPrivateCommitted()
+	nPrivate := nbInternal + nbSecret - internal.NbElements(privateCommitted) - len(commitmentInfo)
+	c2.Parameters.G1.L = make([]curve.G1Affine, 0, nPrivate)
+	evals.G1.VKK = make([]curve.G1Affine, 0, nbPublic+len(commitmentInfo))
+	evals.G1.CKK = make([][]curve.G1Affine, len(commitmentInfo))
+	for i := range evals.G1.CKK {
+		evals.G1.CKK[i] = make([]curve.G1Affine, 0, len(privateCommitted[i]))
+	}
+	nbCommitmentsSeen := 0
 	for i := 0; i < nWires; i++ {
 		var tmp curve.G1Affine
 		tmp.Add(&bA[i], &aB[i])
 		tmp.Add(&tmp, &C[i])
-		if i < public {
-			evals.G1.VKK[i].Set(&tmp)
-		} else {
-			c2.Parameters.G1.L[i-offset].Set(&tmp)
+
+		commitment := -1 // index of the commitment that commits to this variable as a private value
+		isCommitment := false
+		if i >= nbPublic {
+			if nbCommitmentsSeen < len(commitmentWires) && commitmentWires[nbCommitmentsSeen] == i {
+				isCommitment = true
+				nbCommitmentsSeen++
+			}
+			for j := range privateCommitted {
+				if k := len(evals.G1.CKK[j]); k < len(privateCommitted[j]) && privateCommitted[j][k] == i {
+					commitment = j
+					break
+				}
+			}
+		}
+
+		switch {
+		case i < nbPublic || isCommitment:
+			evals.G1.VKK = append(evals.G1.VKK, tmp)
+		case commitment != -1:
+			evals.G1.CKK[commitment] = append(evals.G1.CKK[commitment], tmp)
+		default:
+			c2.Parameters.G1.L = append(c2.Parameters.G1.L, tmp)
 		}
 	}
-	// Set δ public key
-	var delta fr.Element
-	delta.SetOne()
-	c2.PublicKey = newPublicKey(delta, nil, 1)
+	evals.PublicAndCommitmentCommitted = commitmentInfo.GetPublicAndCommitmentCommitted(commitmentWires, nbPublic)
+
+	// Commitment keys are scaled by σ, [σ]₂ being in the verifying key
+	c2.Parameters.G1.SigmaCKK = make([][]curve.G1Affine, len(evals.G1.CKK))
+	for i := range evals.G1.CKK {
+		c2.Parameters.G1.SigmaCKK[i] = append([]curve.G1Affine{}, evals.G1.CKK[i]...)
+	}
+	c2.Parameters.G2.Sigma = g2
+
+	// Set δ and σ public keys. δ = σ = 1 are not secret, the keys use a fixed
+	// randomness so that the initial state can be computed again by a verifier
+	var one fr.Element
+	one.SetOne()
+	c2.PublicKey = newPublicKeyFromSecret(one, one, nil, 1)
+	c2.SigmaPublicKey = newPublicKeyFromSecret(one, one, nil, 2)
 
 	// Hash initial contribution
 	c2.Hash = c2.hash()
@@ -179,17 +228,38 @@ func InitPhase2(r1cs *cs.R1CS, srs1 *Phase1) (Phase2, Phase2Evaluations) {
 }
 
 func (c *Phase2) Contribute() {
-	// Sample toxic δ
-	var delta, deltaInv fr.Element
-	var deltaBI, deltaInvBI big.Int
+	// Sample toxic δ and σ
+	var delta, sigma fr.Element
+	var s [2]fr.Element
 	delta.SetRandom()
+	sigma.SetRandom()
+	s[0].SetRandom()
+	s[1].SetRandom()
+	c.contribute(delta, sigma, s)
+}
+
+// Seal finalizes phase 2 with a last contribution whose secrets are derived
+// from a public random beacon and the hash of the current state, so that
+// anyone can reproduce it.
+func (c *Phase2) Seal(beacon []byte) error {
+	secrets, err := beaconSecrets(c.Hash, beacon, 4)
+	if err != nil {
+		return err
+	}
+	c.contribute(secrets[0], secrets[1], [2]fr.Element(secrets[2:]))
+	return nil
+}
+
+func (c *Phase2) contribute(delta, sigma fr.Element, s [2]fr.Element) {
+	var deltaInv fr.Element
+	var deltaBI, deltaInvBI big.Int
 	deltaInv.Inverse(&delta)
 
 	delta.BigInt(&deltaBI)
 	deltaInv.BigInt(&deltaInvBI)
 
 	// Set δ public key
-	c.PublicKey = newPublicKey(delta, c.Hash, 1)
+	c.PublicKey = newPublicKeyFromSecret(delta, s[0], c.Hash, 1)
 
 	// Update δ
 	c.Parameters.G1.Delta.ScalarMultiplication(&c.Parameters.G1.Delta, &deltaBI)
@@ -205,7 +275,21 @@ func (c *Phase2) Contribute() {
 		c.Parameters.G1.L[i].ScalarMultiplication(&c.Parameters.G1.L[i], &deltaInvBI)
 	}
 
-	// 4. Hash contribution
+	// Update the commitment keys using σ, unless the Phase2 is in the legacy
+	// format which has none
+	if !c.legacy {
+		var sigmaBI big.Int
+		sigma.BigInt(&sigmaBI)
+		c.SigmaPublicKey = newPublicKeyFromSecret(sigma, s[1], c.Hash, 2)
+		for i := range c.Parameters.G1.SigmaCKK {
+			for j := range c.Parameters.G1.SigmaCKK[i] {
+				c.Parameters.G1.SigmaCKK[i][j].ScalarMultiplication(&c.Parameters.G1.SigmaCKK[i][j], &sigmaBI)
+			}
+		}
+		c.Parameters.G2.Sigma.ScalarMultiplication(&c.Parameters.G2.Sigma, &sigmaBI)
+	}
+
+	// Hash contribution
 	c.Hash = c.hash()
 }
 
@@ -219,6 +303,24 @@ func VerifyPhase2(c0, c1 *Phase2, c ...*Phase2) error {
 	return nil
 }
 
+// VerifyInitialPhase2 checks that c0 is a valid initial state for a Phase2, as
+// returned by InitPhase2: [δ] and [σ]₂ are the generators, and its hash
+// matches its parameters. L, Z and the commitment keys depend on the circuit
+// and on the Phase1, they are checked by computing them again with InitPhase2.
+func VerifyInitialPhase2(c0 *Phase2) error {
+	_, _, g1, g2 := curve.Generators()
+	if !c0.Parameters.G1.Delta.Equal(&g1) || !c0.Parameters.G2.Delta.Equal(&g2) {
+		return errors.New("couldn't verify that [δ] are the generators")
+	}
+	if !c0.legacy && !c0.Parameters.G2.Sigma.Equal(&g2) {
+		return errors.New("couldn't verify that [σ]₂ is the generator")
+	}
+	if !bytes.Equal(c0.hash(), c0.Hash) {
+		return errors.New("couldn't verify hash of the initial state")
+	}
+	return nil
+}
+
 func verifyPhase2(current, contribution *Phase2) error {
 	// Compute R for δ
 	deltaR := genR(contribution.PublicKey.SG, contribution.PublicKey.SXG, current.Hash[:], 1)
@@ -246,6 +348,16 @@ func verifyPhase2(current, contribution *Phase2) error {
 		return errors.New("couldn't verify valid updates of L using δ⁻¹")
 	}
 
+	// Check for knowledge of σ and valid updates of the commitment keys
+	if current.legacy != contribution.legacy {
+		return errors.New("format of the contribution doesn't match the previous contribution")
+	}
+	if !contribution.legacy {
+		if err := verifySigma(current, contribution); err != nil {
+			return err
+		}
+	}
+
 	// Check hash of the contribution
 	h := contribution.hash()
 	for i := 0; i < len(h); i++ {
@@ -257,6 +369,36 @@ func verifyPhase2(current, contribution *Phase2) error {
 	return nil
 }
 
+func verifySigma(current, contribution *Phase2) error {
+	sigmaR := genR(contribution.SigmaPublicKey.SG, contribution.SigmaPublicKey.SXG, current.Hash[:], 2)
+	if !sameRatio(contribution.SigmaPublicKey.SG, contribution.SigmaPublicKey.SXG, contribution.SigmaPublicKey.XR, sigmaR) {
+		return errors.New("couldn't verify knowledge of σ")
+	}
+	if !sameRatio(contribution.SigmaPublicKey.SG, contribution.SigmaPublicKey.SXG, contribution.Parameters.G2.Sigma, current.Parameters.G2.Sigma) {
+		return errors.New("couldn't verify that [σ]₂ is based on previous contribution")
+	}
+
+	if len(contribution.Parameters.G1.SigmaCKK) != len(current.Parameters.G1.SigmaCKK) {
+		return errors.New("number of commitment keys doesn't match the previous contribution")
+	}
+	var sigmaCKK, prevSigmaCKK []curve.G1Affine
+	for i := range current.Parameters.G1.SigmaCKK {
+		if len(contribution.Parameters.G1.SigmaCKK[i]) != len(current.Parameters.G1.SigmaCKK[i]) {
+			return errors.New("size of the commitment keys doesn't match the previous contribution")
+		}
+		sigmaCKK = append(sigmaCKK, contribution.Parameters.G1.SigmaCKK[i]...)
+		prevSigmaCKK = append(prevSigmaCKK, current.Parameters.G1.SigmaCKK[i]...)
+	}
+	if len(sigmaCKK) == 0 {
+		return nil
+	}
+	ckk, prevCKK := merge(sigmaCKK, prevSigmaCKK)
+	if !sameRatio(ckk, prevCKK, current.Parameters.G2.Sigma, contribution.Parameters.G2.Sigma) {
+		return errors.New("couldn't verify valid updates of the commitment keys using σ")
+	}
+	return nil
+}
+
 func (c *Phase2) hash() []byte {
 	sha := sha256.New()
 	c.writeTo(sha)
@@ -17,6 +17,8 @@
 package groth16
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/consensys/gnark-crypto/ecc"
 	curve "github.com/consensys/gnark-crypto/ecc/bls24-317"
@@ -34,6 +36,7 @@ import (
 	"github.com/consensys/gnark/logger"
 	"math/big"
 	"runtime"
+	"sync"
 	"time"
 
 	fcs "github.com/consensys/gnark/frontend/cs"
@@ -76,6 +79,7 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 	proof := &Proof{Commitments: make([]curve.G1Affine, len(commitmentInfo))}
 
 	solverOpts := opt.SolverOpts[:len(opt.SolverOpts):len(opt.SolverOpts)]
+	solverOpts = append(solverOpts, solver.WithContext(opt.Context))
 
 	privateCommittedValues := make([][]fr.Element, len(commitmentInfo))
 
@@ -109,13 +113,19 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 		return nil
 	}))
 
+	progress := opt.NewProgress(backend.GROTH16, curve.ID)
+
 	_solution, err := r1cs.Solve(fullWitness, solverOpts...)
 	if err != nil {
+		if cErr := opt.Cancelled(); cErr != nil {
+			return nil, cErr
+		}
 		return nil, err
 	}
 
 	solution := _solution.(*cs.R1CSSolution)
 	wireValues := []fr.Element(solution.W)
+	progress.Done(backend.PhaseSolve, progress.Start())
 
 	start := time.Now()
 
@@ -130,13 +140,18 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 
 	// H (witness reduction / FFT part)
 	var h []fr.Element
-	chHDone := make(chan struct{}, 1)
+	chHDone := make(chan error, 1)
 	go func() {
-		h = computeH(solution.A, solution.B, solution.C, &pk.Domain)
+		var err error
+		hStart := time.Now()
+		h, err = computeH(opt.Context, solution.A, solution.B, solution.C, &pk.Domain)
 		solution.A = nil
 		solution.B = nil
 		solution.C = nil
-		chHDone <- struct{}{}
+		if err == nil {
+			progress.Done(backend.PhaseQuotient, hStart)
+		}
+		chHDone <- err
 	}()
 
 	// we need to copy and filter the wireValues for each multi exp
@@ -187,11 +202,12 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 	var bs1, ar curve.G1Jac
 
 	n := runtime.NumCPU()
+	ctx := opt.Context
 
 	chBs1Done := make(chan error, 1)
 	computeBS1 := func() {
 		<-chWireValuesB
-		if _, err := bs1.MultiExp(pk.G1.B, wireValuesB, ecc.MultiExpConfig{NbTasks: n / 2}); err != nil {
+		if err := multiExpG1(ctx, &bs1, pk.G1.B, wireValuesB, ecc.MultiExpConfig{NbTasks: n / 2}); err != nil {
 			chBs1Done <- err
 			close(chBs1Done)
 			return
@@ -204,7 +220,7 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 	chArDone := make(chan error, 1)
 	computeAR1 := func() {
 		<-chWireValuesA
-		if _, err := ar.MultiExp(pk.G1.A, wireValuesA, ecc.MultiExpConfig{NbTasks: n / 2}); err != nil {
+		if err := multiExpG1(ctx, &ar, pk.G1.A, wireValuesA, ecc.MultiExpConfig{NbTasks: n / 2}); err != nil {
 			chArDone <- err
 			close(chArDone)
 			return
@@ -224,8 +240,7 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 		chKrs2Done := make(chan error, 1)
 		sizeH := int(pk.Domain.Cardinality - 1) // comes from the fact the deg(H)=(n-1)+(n-1)-n=n-2
 		go func() {
-			_, err := krs2.MultiExp(pk.G1.Z, h[:sizeH], ecc.MultiExpConfig{NbTasks: n / 2})
-			chKrs2Done <- err
+			chKrs2Done <- multiExpG1(ctx, &krs2, pk.G1.Z, h[:sizeH], ecc.MultiExpConfig{NbTasks: n / 2})
 		}()
 
 		// filter the wire values if needed
@@ -234,20 +249,20 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 		toRemove = append(toRemove, commitmentInfo.CommitmentIndexes())
 		_wireValues := filterHeap(wireValues[r1cs.GetNbPublicVariables():], r1cs.GetNbPublicVariables(), internal.ConcatAll(toRemove...))
 
-		if _, err := krs.MultiExp(pk.G1.K, _wireValues, ecc.MultiExpConfig{NbTasks: n / 2}); err != nil {
+		err := multiExpG1(ctx, &krs, pk.G1.K, _wireValues, ecc.MultiExpConfig{NbTasks: n / 2})
+		// krs2 is waited for even on error, so that no task outlives the prover
+		if err2 := <-chKrs2Done; err == nil {
+			err = err2
+		}
+		if err != nil {
 			chKrsDone <- err
 			return
 		}
 		krs.AddMixed(&deltas[2])
-		n := 3
+		krs.AddAssign(&krs2)
+		n := 2
 		for n != 0 {
 			select {
-			case err := <-chKrs2Done:
-				if err != nil {
-					chKrsDone <- err
-					return
-				}
-				krs.AddAssign(&krs2)
 			case err := <-chArDone:
 				if err != nil {
 					chKrsDone <- err
@@ -280,7 +295,7 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 			nbTasks *= 2
 		}
 		<-chWireValuesB
-		if _, err := Bs.MultiExp(pk.G2.B, wireValuesB, ecc.MultiExpConfig{NbTasks: nbTasks}); err != nil {
+		if err := multiExpG2(ctx, &Bs, pk.G2.B, wireValuesB, ecc.MultiExpConfig{NbTasks: nbTasks}); err != nil {
 			return err
 		}
 
@@ -294,20 +309,47 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 	}
 
 	// wait for FFT to end, as it uses all our CPUs
-	<-chHDone
+	if err := <-chHDone; err != nil {
+		<-chWireValuesA
+		<-chWireValuesB
+		return nil, &backend.CancelledError{Err: err}
+	}
+	msmStart := time.Now()
+
+	// schedule our proof part computations. The multi-exponentiations check
+	// the context between chunks, and all the tasks are waited for before
+	// returning, also on cancellation.
+	var wg sync.WaitGroup
+	var errBs2 error
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		computeKRS()
+	}()
+	go func() {
+		defer wg.Done()
+		computeAR1()
+	}()
+	go func() {
+		defer wg.Done()
+		computeBS1()
+	}()
+	go func() {
+		defer wg.Done()
+		errBs2 = computeBS2()
+	}()
+	wg.Wait()
 
-	// schedule our proof part computations
-	go computeKRS()
-	go computeAR1()
-	go computeBS1()
-	if err := computeBS2(); err != nil {
+	if err := opt.Cancelled(); err != nil {
 		return nil, err
 	}
-
-	// wait for all parts of the proof to be computed.
 	if err := <-chKrsDone; err != nil {
 		return nil, err
 	}
+	if errBs2 != nil {
+		return nil, errBs2
+	}
+	progress.Done(backend.PhaseMSM, msmStart)
 
 	log.Debug().Dur("took", time.Since(start)).Msg("prover done")
 
@@ -343,7 +385,70 @@ func filterHeap(slice []fr.Element, sliceFirstIndex int, toRemove []int) (r []fr
 	return
 }
 
-func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
+// msmChunkSize is the number of points of the chunks in which the
+// multi-exponentiations are split when the proving can be cancelled. It is a
+// variable for testing.
+var msmChunkSize = 1 << 18
+
+// multiExpG1 sets p to the multi-exponentiation of points and scalars. If ctx
+// can be cancelled, the multi-exponentiation is computed by chunks of
+// msmChunkSize points and the error of ctx is returned if it is done between
+// two chunks.
+func multiExpG1(ctx context.Context, p *curve.G1Jac, points []curve.G1Affine, scalars []fr.Element, config ecc.MultiExpConfig) error {
+	if ctx.Done() == nil {
+		_, err := p.MultiExp(points, scalars, config)
+		return err
+	}
+	if len(points) != len(scalars) {
+		return errors.New("len(points) != len(scalars)")
+	}
+	var res, chunk curve.G1Jac
+	res.X.SetOne()
+	res.Y.SetOne()
+	res.Z.SetZero()
+	for start := 0; start < len(points) || start == 0; start += msmChunkSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := min(start+msmChunkSize, len(points))
+		if _, err := chunk.MultiExp(points[start:end], scalars[start:end], config); err != nil {
+			return err
+		}
+		res.AddAssign(&chunk)
+	}
+	p.Set(&res)
+	return nil
+}
+
+// multiExpG2 is the same as multiExpG1 in G2.
+func multiExpG2(ctx context.Context, p *curve.G2Jac, points []curve.G2Affine, scalars []fr.Element, config ecc.MultiExpConfig) error {
+	if ctx.Done() == nil {
+		_, err := p.MultiExp(points, scalars, config)
+		return err
+	}
+	if len(points) != len(scalars) {
+		return errors.New("len(points) != len(scalars)")
+	}
+	var res, chunk curve.G2Jac
+	res.X.SetOne()
+	res.Y.SetOne()
+	res.Z.SetZero()
+	for start := 0; start < len(points) || start == 0; start += msmChunkSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := min(start+msmChunkSize, len(points))
+		if _, err := chunk.MultiExp(points[start:end], scalars[start:end], config); err != nil {
+			return err
+		}
+		res.AddAssign(&chunk)
+	}
+	p.Set(&res)
+	return nil
+}
+
+// computeH returns the error of the context if it is done between the FFTs.
+func computeH(ctx context.Context, a, b, c []fr.Element, domain *fft.Domain) ([]fr.Element, error) {
 	// H part of Krs
 	// Compute H (hz=ab-c, where z=-2 on ker X^n+1 (z(x)=x^n-1))
 	// 	1 - _a = ifft(a), _b = ifft(b), _c = ifft(c)
@@ -362,10 +467,16 @@ func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
 	domain.FFTInverse(a, fft.DIF)
 	domain.FFTInverse(b, fft.DIF)
 	domain.FFTInverse(c, fft.DIF)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	domain.FFT(a, fft.DIT, fft.OnCoset())
 	domain.FFT(b, fft.DIT, fft.OnCoset())
 	domain.FFT(c, fft.DIT, fft.OnCoset())
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	var den, one fr.Element
 	one.SetOne()
@@ -385,5 +496,5 @@ func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
 	// ifft_coset
 	domain.FFTInverse(a, fft.DIF, fft.OnCoset())
 
-	return a
+	return a, nil
 }
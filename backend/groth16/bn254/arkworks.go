@@ -0,0 +1,304 @@
+package groth16
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+)
+
+// The arkworks encodings are the CanonicalSerialize implementations of
+// ark_groth16::Proof<ark_bn254::Bn254> and ark_groth16::VerifyingKey (0.4).
+//
+// arkworks writes the coordinates of the points in little endian (x.c0 | x.c1
+// in G2), x only if compressed, and the flags on the most significant bits of
+// the last byte: whether the point is at infinity, its coordinates being then
+// zero, or whether y is lexicographically larger than -y. The order of Fp2 is
+// the one of gnark-crypto, comparing the A1 coordinates first.
+
+const (
+	arkworksMask        byte = 0b11 << 6
+	arkworksYIsNegative byte = 1 << 7
+	arkworksInfinity    byte = 1 << 6
+
+	// flags of the compressed encoding of gnark-crypto, on the most
+	// significant bits of the first byte.
+	mCompressedSmallest byte = 0b10 << 6
+	mCompressedLargest  byte = 0b11 << 6
+	mCompressedInfinity byte = 0b01 << 6
+)
+
+var errArkworksCommitments = errors.New("commitments are not supported by arkworks")
+
+// WriteArkworksTo writes the proof as serialized by arkworks, compressed:
+// A | B | C. The commitments of api.Commit have no equivalent in arkworks, and
+// proofs using them are rejected.
+func (proof *Proof) WriteArkworksTo(w io.Writer) (int64, error) {
+	return proof.writeArkworksTo(w, true)
+}
+
+// WriteArkworksRawTo writes the proof as serialized by arkworks, uncompressed.
+func (proof *Proof) WriteArkworksRawTo(w io.Writer) (int64, error) {
+	return proof.writeArkworksTo(w, false)
+}
+
+func (proof *Proof) writeArkworksTo(w io.Writer, compressed bool) (int64, error) {
+	if len(proof.Commitments) > 0 {
+		return 0, errArkworksCommitments
+	}
+	n, err := w.Write(proof.appendArkworks(nil, compressed))
+	return int64(n), err
+}
+
+func (proof *Proof) appendArkworks(dst []byte, compressed bool) []byte {
+	dst = appendArkworksG1(dst, &proof.Ar, compressed)
+	dst = appendArkworksG2(dst, &proof.Bs, compressed)
+	return appendArkworksG1(dst, &proof.Krs, compressed)
+}
+
+// ReadArkworksFrom reads a proof serialized by arkworks, compressed, checking
+// that the points are in their subgroups.
+func (proof *Proof) ReadArkworksFrom(r io.Reader) (int64, error) {
+	return proof.readArkworksFrom(r, true)
+}
+
+// ReadArkworksRawFrom reads a proof serialized by arkworks, uncompressed,
+// checking that the points are in their subgroups.
+func (proof *Proof) ReadArkworksRawFrom(r io.Reader) (int64, error) {
+	return proof.readArkworksFrom(r, false)
+}
+
+func (proof *Proof) readArkworksFrom(r io.Reader, compressed bool) (int64, error) {
+	dec := arkworksDecoder{r: r, compressed: compressed}
+	dec.g1(&proof.Ar)
+	dec.g2(&proof.Bs)
+	dec.g1(&proof.Krs)
+	proof.Commitments = nil
+	proof.CommitmentPok = curve.G1Affine{}
+	return dec.n, dec.err
+}
+
+// WriteArkworksTo writes the verifying key as serialized by arkworks,
+// compressed: α, β, γ, δ, then the number of points of K on 8 bytes in little
+// endian and the points of K. The commitments of api.Commit have no
+// equivalent in arkworks, and keys using them are rejected.
+func (vk *VerifyingKey) WriteArkworksTo(w io.Writer) (int64, error) {
+	return vk.writeArkworksTo(w, true)
+}
+
+// WriteArkworksRawTo writes the verifying key as serialized by arkworks,
+// uncompressed.
+func (vk *VerifyingKey) WriteArkworksRawTo(w io.Writer) (int64, error) {
+	return vk.writeArkworksTo(w, false)
+}
+
+func (vk *VerifyingKey) writeArkworksTo(w io.Writer, compressed bool) (int64, error) {
+	if len(vk.PublicAndCommitmentCommitted) > 0 {
+		return 0, errArkworksCommitments
+	}
+	n, err := w.Write(vk.appendArkworks(nil, compressed))
+	return int64(n), err
+}
+
+func (vk *VerifyingKey) appendArkworks(dst []byte, compressed bool) []byte {
+	dst = appendArkworksG1(dst, &vk.G1.Alpha, compressed)
+	dst = appendArkworksG2(dst, &vk.G2.Beta, compressed)
+	dst = appendArkworksG2(dst, &vk.G2.Gamma, compressed)
+	dst = appendArkworksG2(dst, &vk.G2.Delta, compressed)
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(len(vk.G1.K)))
+	for i := range vk.G1.K {
+		dst = appendArkworksG1(dst, &vk.G1.K[i], compressed)
+	}
+	return dst
+}
+
+// ReadArkworksFrom reads a verifying key serialized by arkworks, compressed,
+// checking that the points are in their subgroups.
+func (vk *VerifyingKey) ReadArkworksFrom(r io.Reader) (int64, error) {
+	return vk.readArkworksFrom(r, true)
+}
+
+// ReadArkworksRawFrom reads a verifying key serialized by arkworks,
+// uncompressed, checking that the points are in their subgroups.
+func (vk *VerifyingKey) ReadArkworksRawFrom(r io.Reader) (int64, error) {
+	return vk.readArkworksFrom(r, false)
+}
+
+func (vk *VerifyingKey) readArkworksFrom(r io.Reader, compressed bool) (int64, error) {
+	*vk = VerifyingKey{}
+	dec := arkworksDecoder{r: r, compressed: compressed}
+	dec.g1(&vk.G1.Alpha)
+	dec.g2(&vk.G2.Beta)
+	dec.g2(&vk.G2.Gamma)
+	dec.g2(&vk.G2.Delta)
+	nbK := dec.uint64()
+	// the points are appended as they are read, so that a corrupted length
+	// doesn't allocate more than the data
+	for i := uint64(0); i < nbK && dec.err == nil; i++ {
+		var p curve.G1Affine
+		dec.g1(&p)
+		vk.G1.K = append(vk.G1.K, p)
+	}
+	if dec.err != nil {
+		return dec.n, dec.err
+	}
+	if len(vk.G1.K) == 0 {
+		return dec.n, errors.New("invalid verifying key: K is empty")
+	}
+	return dec.n, vk.Precompute()
+}
+
+func appendArkworksG1(dst []byte, p *curve.G1Affine, compressed bool) []byte {
+	dst = appendArkworksFp(dst, &p.X)
+	if !compressed {
+		dst = appendArkworksFp(dst, &p.Y)
+	}
+	dst[len(dst)-1] |= arkworksFlags(p.IsInfinity(), p.Y.LexicographicallyLargest())
+	return dst
+}
+
+func appendArkworksG2(dst []byte, p *curve.G2Affine, compressed bool) []byte {
+	dst = appendArkworksFp(dst, &p.X.A0)
+	dst = appendArkworksFp(dst, &p.X.A1)
+	if !compressed {
+		dst = appendArkworksFp(dst, &p.Y.A0)
+		dst = appendArkworksFp(dst, &p.Y.A1)
+	}
+	dst[len(dst)-1] |= arkworksFlags(p.IsInfinity(), p.Y.LexicographicallyLargest())
+	return dst
+}
+
+func appendArkworksFp(dst []byte, x *fp.Element) []byte {
+	var b [fp.Bytes]byte
+	fp.LittleEndian.PutElement(&b, *x)
+	return append(dst, b[:]...)
+}
+
+func arkworksFlags(infinity, yIsLargest bool) byte {
+	switch {
+	case infinity:
+		return arkworksInfinity
+	case yIsLargest:
+		return arkworksYIsNegative
+	default:
+		return 0
+	}
+}
+
+// arkworksDecoder reads points serialized by arkworks. It counts the bytes read
+// and stops at the first error.
+type arkworksDecoder struct {
+	r          io.Reader
+	compressed bool
+	n          int64
+	err        error
+}
+
+// read reads the encoding of a point in buf, and clears and returns its flags.
+func (dec *arkworksDecoder) read(buf []byte) byte {
+	if dec.err != nil {
+		return 0
+	}
+	var n int
+	n, dec.err = io.ReadFull(dec.r, buf)
+	dec.n += int64(n)
+	if dec.err != nil {
+		return 0
+	}
+	flags := buf[len(buf)-1] & arkworksMask
+	buf[len(buf)-1] &^= arkworksMask
+	if flags == arkworksMask {
+		dec.err = errors.New("invalid arkworks point flags")
+	}
+	return flags
+}
+
+func (dec *arkworksDecoder) uint64() uint64 {
+	var buf [8]byte
+	if dec.err != nil {
+		return 0
+	}
+	var n int
+	n, dec.err = io.ReadFull(dec.r, buf[:])
+	dec.n += int64(n)
+	return binary.LittleEndian.Uint64(buf[:])
+}
+
+func (dec *arkworksDecoder) g1(p *curve.G1Affine) {
+	if dec.compressed {
+		var buf [fp.Bytes]byte
+		dec.setCompressed(p, buf[:], dec.read(buf[:]))
+		return
+	}
+	var buf [2 * fp.Bytes]byte
+	flags := dec.read(buf[:])
+	coordinates := []*fp.Element{&p.X, &p.Y}
+	if dec.setCoordinates(coordinates, buf[:], flags) && (p.IsInfinity() || !p.IsOnCurve() || !p.IsInSubGroup()) {
+		dec.err = errors.New("invalid point: subgroup check failed")
+	}
+}
+
+func (dec *arkworksDecoder) g2(p *curve.G2Affine) {
+	if dec.compressed {
+		var buf [2 * fp.Bytes]byte
+		dec.setCompressed(p, buf[:], dec.read(buf[:]))
+		return
+	}
+	var buf [4 * fp.Bytes]byte
+	flags := dec.read(buf[:])
+	coordinates := []*fp.Element{&p.X.A0, &p.X.A1, &p.Y.A0, &p.Y.A1}
+	if dec.setCoordinates(coordinates, buf[:], flags) && (p.IsInfinity() || !p.IsOnCurve() || !p.IsInSubGroup()) {
+		dec.err = errors.New("invalid point: subgroup check failed")
+	}
+}
+
+// setCompressed decodes a compressed point with gnark-crypto, which computes
+// y and checks the subgroup: the bytes of x are reversed to big endian, which
+// also swaps the coordinates of Fp2, and the flags are converted.
+func (dec *arkworksDecoder) setCompressed(p interface{ SetBytes([]byte) (int, error) }, buf []byte, flags byte) {
+	if dec.err != nil {
+		return
+	}
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	switch flags {
+	case arkworksInfinity:
+		buf[0] |= mCompressedInfinity
+	case arkworksYIsNegative:
+		buf[0] |= mCompressedLargest
+	default:
+		buf[0] |= mCompressedSmallest
+	}
+	_, dec.err = p.SetBytes(buf)
+}
+
+// setCoordinates decodes the coordinates of an uncompressed point. It returns
+// true if the point is not at infinity, in which case it must be checked to be
+// on the curve and in the subgroup.
+func (dec *arkworksDecoder) setCoordinates(coordinates []*fp.Element, buf []byte, flags byte) bool {
+	if dec.err != nil {
+		return false
+	}
+	if flags == arkworksInfinity {
+		for _, b := range buf {
+			if b != 0 {
+				dec.err = errors.New("invalid encoding of the point at infinity")
+				return false
+			}
+		}
+		for _, c := range coordinates {
+			c.SetZero()
+		}
+		return false
+	}
+	for i, c := range coordinates {
+		*c, dec.err = fp.LittleEndian.Element((*[fp.Bytes]byte)(buf[i*fp.Bytes : (i+1)*fp.Bytes]))
+		if dec.err != nil {
+			return false
+		}
+	}
+	return true
+}
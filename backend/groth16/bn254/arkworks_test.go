@@ -0,0 +1,154 @@
+package groth16_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArkworksRoundTrip(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &mulCircuit{})
+	assert.NoError(err)
+	pk, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+	w, err := frontend.NewWitness(&mulCircuit{X: 3, Y: 5, Z: 15}, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	proof, err := groth16.Prove(ccs, pk, w)
+	assert.NoError(err)
+	publicWitness, err := w.Public()
+	assert.NoError(err)
+
+	for _, compressed := range []bool{true, false} {
+		var buf bytes.Buffer
+		var (
+			proofSize = 128
+			vkSize    = 32 + 3*64 + 8 + 2*32
+		)
+		if !compressed {
+			proofSize, vkSize = 2*proofSize, 2*(vkSize-8)+8
+		}
+
+		n, err := writeArkworks(proof.(*groth16_bn254.Proof), &buf, compressed)
+		assert.NoError(err)
+		assert.EqualValues(proofSize, n)
+		var proof2 groth16_bn254.Proof
+		n, err = readArkworks(&proof2, &buf, compressed)
+		assert.NoError(err)
+		assert.EqualValues(proofSize, n)
+
+		n, err = writeArkworks(vk.(*groth16_bn254.VerifyingKey), &buf, compressed)
+		assert.NoError(err)
+		assert.EqualValues(vkSize, n)
+		var vk2 groth16_bn254.VerifyingKey
+		n, err = readArkworks(&vk2, &buf, compressed)
+		assert.NoError(err)
+		assert.EqualValues(vkSize, n)
+
+		assert.NoError(groth16.Verify(&proof2, &vk2, publicWitness))
+	}
+}
+
+func TestArkworksEncoding(t *testing.T) {
+	assert := require.New(t)
+
+	_, _, g1, g2 := curve.Generators()
+	var proof groth16_bn254.Proof
+	proof.Ar, proof.Bs = g1, g2
+	proof.Krs.Neg(&g1)
+
+	var compressed, raw bytes.Buffer
+	_, err := proof.WriteArkworksTo(&compressed)
+	assert.NoError(err)
+	_, err = proof.WriteArkworksRawTo(&raw)
+	assert.NoError(err)
+
+	// the generator (1, 2) of G1 has the smallest y, and its negation the
+	// largest
+	zeros := strings.Repeat("00", 31)
+	c, r := compressed.Bytes(), raw.Bytes()
+	assert.Equal("01"+zeros, hex.EncodeToString(c[:32]))
+	assert.Equal("01"+zeros+"02"+zeros, hex.EncodeToString(r[:64]))
+	assert.Equal("01"+zeros[:60]+"80", hex.EncodeToString(c[96:]))
+	assert.Equal(c[96:127], r[192:223])
+	assert.Equal(byte(0x80), r[255]&0xc0)
+
+	// the uncompressed encoding holds x as the compressed one, and the flag on y
+	assert.Equal(c[32:95], r[64:127])
+	assert.Equal(c[95]&0xc0, r[191]&0xc0)
+	assert.Equal(c[95]&0x3f, r[127])
+
+	// the point at infinity only has the infinity flag
+	proof.Krs = curve.G1Affine{}
+	compressed.Reset()
+	raw.Reset()
+	_, err = proof.WriteArkworksTo(&compressed)
+	assert.NoError(err)
+	_, err = proof.WriteArkworksRawTo(&raw)
+	assert.NoError(err)
+	assert.Equal(zeros+"40", hex.EncodeToString(compressed.Bytes()[96:]))
+	assert.Equal(zeros+"00"+zeros+"40", hex.EncodeToString(raw.Bytes()[192:]))
+
+	var decoded groth16_bn254.Proof
+	_, err = decoded.ReadArkworksFrom(bytes.NewReader(compressed.Bytes()))
+	assert.NoError(err)
+	assert.Equal(proof, decoded)
+	_, err = decoded.ReadArkworksRawFrom(bytes.NewReader(raw.Bytes()))
+	assert.NoError(err)
+	assert.Equal(proof, decoded)
+
+	// invalid flags
+	b := bytes.Clone(compressed.Bytes())
+	b[31] |= 0xc0
+	_, err = decoded.ReadArkworksFrom(bytes.NewReader(b))
+	assert.Error(err)
+
+	// (1, 3) is not on the curve
+	b = bytes.Clone(raw.Bytes())
+	b[32] = 3
+	_, err = decoded.ReadArkworksRawFrom(bytes.NewReader(b))
+	assert.Error(err)
+
+	// (0, 0) is not the point at infinity without the flag
+	b = bytes.Clone(raw.Bytes())
+	b[255] = 0
+	_, err = decoded.ReadArkworksRawFrom(bytes.NewReader(b))
+	assert.Error(err)
+
+	// truncated
+	_, err = decoded.ReadArkworksFrom(bytes.NewReader(compressed.Bytes()[:100]))
+	assert.Error(err)
+}
+
+// writeArkworks writes v compressed or not as serialized by arkworks.
+func writeArkworks(v interface {
+	WriteArkworksTo(w io.Writer) (int64, error)
+	WriteArkworksRawTo(w io.Writer) (int64, error)
+}, w io.Writer, compressed bool) (int64, error) {
+	if compressed {
+		return v.WriteArkworksTo(w)
+	}
+	return v.WriteArkworksRawTo(w)
+}
+
+// readArkworks reads v compressed or not as serialized by arkworks.
+func readArkworks(v interface {
+	ReadArkworksFrom(r io.Reader) (int64, error)
+	ReadArkworksRawFrom(r io.Reader) (int64, error)
+}, r io.Reader, compressed bool) (int64, error) {
+	if compressed {
+		return v.ReadArkworksFrom(r)
+	}
+	return v.ReadArkworksRawFrom(r)
+}
@@ -0,0 +1,158 @@
+package groth16
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/export"
+)
+
+var errCommitmentsNotSupported = errors.New("commitments are not supported by the verifier")
+
+// maxMovePublicInputs is the maximum number of public inputs of sui::groth16.
+const maxMovePublicInputs = 8
+
+// verifierTemplates are the templates of the verifiers exported with
+// ExportVerifier, Solidity aside. They are executed on the VerifyingKey with
+// the functions of verifierFuncs.
+var verifierTemplates = map[export.Target]string{
+	export.Rust: rustTemplate,
+	export.Move: moveTemplate,
+	export.C:    cTemplate,
+}
+
+// ExportVerifier writes a verifier of the proofs of vk for the target runtime,
+// customized with the exportOpts.
+//
+// The proofs are encoded for the verifier with MarshalVerifier and the public
+// inputs are passed as unsigned 256 bits integers, which must be reduced.
+// Except for Solidity, the verifiers don't support the commitments of
+// api.Commit.
+//
+// Code has not been audited and is provided as-is, we make no guarantees or
+// warranties to its safety and reliability.
+func (vk *VerifyingKey) ExportVerifier(w io.Writer, target export.Target, exportOpts ...export.Option) error {
+	cfg, err := export.NewConfig(exportOpts...)
+	if err != nil {
+		return err
+	}
+	if target == export.Solidity {
+		return vk.ExportSolidity(w, cfg.SolidityOptions...)
+	}
+	tmplVerifier, ok := verifierTemplates[target]
+	if !ok {
+		return fmt.Errorf("verifier target %s not supported", target)
+	}
+	if len(vk.PublicAndCommitmentCommitted) > 0 {
+		return fmt.Errorf("%s: %w", target, errCommitmentsNotSupported)
+	}
+	if target == export.Move && vk.NbPublicWitness() > maxMovePublicInputs {
+		return fmt.Errorf("move: at most %d public inputs are supported", maxMovePublicInputs)
+	}
+
+	tmpl, err := template.New(target.String()).Funcs(verifierFuncs(cfg)).Parse(tmplVerifier)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, vk)
+}
+
+// MarshalVerifier encodes the proof for a verifier exported for the target:
+//   - Solidity: as MarshalSolidity,
+//   - Rust and C: the points Ar, Bs and Krs encoded as in EIP-197 (256 bytes),
+//   - Move: the points Ar, Bs and Krs compressed as in arkworks (128 bytes).
+func (proof *Proof) MarshalVerifier(target export.Target) ([]byte, error) {
+	if target == export.Solidity {
+		return proof.MarshalSolidity(), nil
+	}
+	if len(proof.Commitments) > 0 {
+		return nil, fmt.Errorf("%s: %w", target, errCommitmentsNotSupported)
+	}
+	switch target {
+	case export.Rust, export.C:
+		res := make([]byte, 0, 4*2*fp.Bytes)
+		res = appendG1EIP197(res, &proof.Ar)
+		res = appendG2EIP197(res, &proof.Bs)
+		return appendG1EIP197(res, &proof.Krs), nil
+	case export.Move:
+		return proof.appendArkworks(make([]byte, 0, 4*fp.Bytes), true), nil
+	default:
+		return nil, fmt.Errorf("verifier target %s not supported", target)
+	}
+}
+
+// verifierFuncs returns the functions shared by the verifier templates. They
+// implement the encodings of the points and field elements of the targets.
+func verifierFuncs(cfg export.Config) template.FuncMap {
+	return template.FuncMap{
+		"name": func() string {
+			return cfg.Name
+		},
+		"sub": func(a, b int) int {
+			return a - b
+		},
+		// decimal value of a base field element
+		"fpstr": func(x fp.Element) string {
+			return x.String()
+		},
+		// EIP-197 encodings of the points, and of the negation of the G2 points
+		"g1": func(p curve.G1Affine) []byte {
+			return appendG1EIP197(nil, &p)
+		},
+		"g2": func(p curve.G2Affine) []byte {
+			return appendG2EIP197(nil, &p)
+		},
+		"negG2": func(p curve.G2Affine) []byte {
+			p.Neg(&p)
+			return appendG2EIP197(nil, &p)
+		},
+		// the verifying key serialized as in arkworks, compressed
+		"arkworksVK": func(vk *VerifyingKey) []byte {
+			return vk.appendArkworks(nil, true)
+		},
+		"modulus": func() []byte {
+			b := fr.Modulus().Bytes()
+			return b[:]
+		},
+		"hex": hex.EncodeToString,
+		// constants of the arithmetic of the C verifier
+		"constants": newCConstants,
+		"climbs":    climbs,
+		// bytes as the elements of a C array, 16 per line
+		"cbytes": func(b []byte, indent string) string {
+			var sb strings.Builder
+			for i, v := range b {
+				if i > 0 {
+					if i%16 == 0 {
+						sb.WriteString(",\n" + indent)
+					} else {
+						sb.WriteString(", ")
+					}
+				}
+				fmt.Fprintf(&sb, "0x%02x", v)
+			}
+			return sb.String()
+		},
+	}
+}
+
+// appendG1EIP197 appends p to dst as encoded in EIP-197: x | y, in big endian,
+// the point at infinity being (0, 0).
+func appendG1EIP197(dst []byte, p *curve.G1Affine) []byte {
+	b := p.RawBytes()
+	return append(dst, b[:]...)
+}
+
+// appendG2EIP197 appends p to dst as encoded in EIP-197: x.A1 | x.A0 | y.A1 |
+// y.A0, in big endian, the point at infinity being (0, 0, 0, 0).
+func appendG2EIP197(dst []byte, p *curve.G2Affine) []byte {
+	b := p.RawBytes()
+	return append(dst, b[:]...)
+}
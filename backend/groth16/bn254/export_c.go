@@ -0,0 +1,833 @@
+package groth16
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// cConstants are the constants of the BN254 arithmetic of the C verifier. The
+// field elements are in Montgomery form, with R = 2²⁵⁶, except P.
+//
+// Fp12 is represented as Fp[w]/(w¹² - 18w⁶ + 82), where Fp2 = Fp[i]/(i² + 1) is
+// embedded with i = w⁶ - 9, and the points of the twist (x, y) are mapped to
+// (xw², yw³), as in the reference implementation of EIP-197.
+type cConstants struct {
+	P    *big.Int // modulus of the base field
+	PInv uint32   // -p⁻¹ mod 2³²
+	One  *big.Int
+	R2   *big.Int // R² mod p, to convert to Montgomery form
+
+	Nine, Eighteen, EightyTwo *big.Int
+	// B2 is the coefficient b of the twist y² = x³ + 3/(9+i)
+	B2 [2]*big.Int
+	// FrobX and FrobY are the coefficients of the Frobenius endomorphism on
+	// the twist: ξ⁽ᵖ⁻¹⁾ᐟ³ and ξ⁽ᵖ⁻¹⁾ᐟ², where ξ = 9+i
+	FrobX, FrobY [2]*big.Int
+	// FrobW are the coefficients (a - 9b, b) of the embeddings of
+	// ξⁱ⁽ᵖ⁻¹⁾ᐟ⁶ = a + bi, such that (wⁱ)ᵖ = wⁱ ξⁱ⁽ᵖ⁻¹⁾ᐟ⁶
+	FrobW [12][2]*big.Int
+
+	// AteLoop is 6u+2 - 2⁶⁴, the loop of the optimal ate pairing without its
+	// leading bit
+	AteLoop uint64
+	// PMinus2 is the exponent of the inversion in Fp, and HardExp the hard
+	// part (p⁴ - p² + 1)/r of the final exponentiation
+	PMinus2, HardExp []byte
+}
+
+func newCConstants() cConstants {
+	p := fp.Modulus()
+	mont := func(x *big.Int) *big.Int {
+		res := new(big.Int).Lsh(x, 256)
+		return res.Mod(res, p)
+	}
+	e2 := func(x curve.E2) [2]*big.Int {
+		var a0, a1 big.Int
+		x.A0.BigInt(&a0)
+		x.A1.BigInt(&a1)
+		return [2]*big.Int{mont(&a0), mont(&a1)}
+	}
+	var c cConstants
+	c.P = p
+	pInv := new(big.Int).ModInverse(p, new(big.Int).Lsh(big.NewInt(1), 32))
+	c.PInv = uint32(-pInv.Uint64())
+	c.One = mont(big.NewInt(1))
+	c.R2 = mont(mont(big.NewInt(1)))
+	c.Nine, c.Eighteen, c.EightyTwo = mont(big.NewInt(9)), mont(big.NewInt(18)), mont(big.NewInt(82))
+
+	var xi, b2 curve.E2
+	xi.A0.SetUint64(9)
+	xi.A1.SetOne()
+	b2.Inverse(&xi).MulByElement(&b2, new(fp.Element).SetUint64(3))
+	c.B2 = e2(b2)
+
+	pMinus1 := new(big.Int).Sub(p, big.NewInt(1))
+	exp := func(k int64) curve.E2 {
+		e := new(big.Int).Mul(pMinus1, big.NewInt(k))
+		var res curve.E2
+		res.Exp(xi, e.Div(e, big.NewInt(6)))
+		return res
+	}
+	c.FrobX, c.FrobY = e2(exp(2)), e2(exp(3))
+	for i := range c.FrobW {
+		g := exp(int64(i))
+		var nine, a fp.Element
+		nine.SetUint64(9)
+		a.Mul(&g.A1, &nine).Sub(&g.A0, &a)
+		c.FrobW[i] = e2(curve.E2{A0: a, A1: g.A1})
+	}
+
+	u := new(big.Int).SetUint64(4965661367192848881)
+	ate := new(big.Int).Mul(u, big.NewInt(6))
+	ate.Add(ate, big.NewInt(2))
+	if ate.BitLen() != 65 {
+		panic("unexpected loop of the optimal ate pairing")
+	}
+	c.AteLoop = new(big.Int).SetBit(ate, 64, 0).Uint64()
+
+	c.PMinus2 = new(big.Int).Sub(p, big.NewInt(2)).Bytes()
+	p2 := new(big.Int).Mul(p, p)
+	hard := new(big.Int).Mul(p2, p2)
+	hard.Sub(hard, p2).Add(hard, big.NewInt(1))
+	hard.Div(hard, fr.Modulus())
+	c.HardExp = hard.Bytes()
+	return c
+}
+
+// climbs formats x as the initializer of an fp of the C verifier: 8 limbs of 32
+// bits, least significant first.
+func climbs(x *big.Int) string {
+	words := make([]string, 8)
+	var w big.Int
+	mask := big.NewInt(0xffffffff)
+	for i := range words {
+		w.Rsh(x, uint(32*i)).And(&w, mask)
+		words[i] = fmt.Sprintf("0x%08x", w.Uint64())
+	}
+	return "{{" + strings.Join(words, ", ") + "}}"
+}
+
+// cTemplate is the template of the C verifier, see ExportVerifier.
+const cTemplate = `// Code generated by gnark DO NOT EDIT
+
+// Groth16 verifier on BN254, in C99 for embedded devices. It is standalone: the
+// arithmetic of the curve and the pairing are implemented below, with the
+// encodings of the points and the checks of the EVM precompiles of EIP-196
+// and EIP-197. It is written for simplicity rather than speed, and is not
+// constant time, which a verifier doesn't need.
+//
+// Code has not been audited and is provided as-is, we make no guarantees or
+// warranties to its safety and reliability.
+
+#include <stddef.h>
+#include <stdint.h>
+#include <string.h>
+
+// number of public inputs of the circuit
+#define {{ name }}_NB_PUBLIC_INPUTS {{ sub (len .G1.K) 1 }}
+
+// size of a proof encoded with gnark's MarshalVerifier: the points A, B and C
+// encoded as in EIP-197
+#define {{ name }}_PROOF_SIZE 256
+
+// order of the scalar field
+static const uint8_t R_MOD[32] = {
+	{{ cbytes modulus "\t" }}
+};
+
+static const uint8_t ALPHA[64] = {
+	{{ cbytes (g1 .G1.Alpha) "\t" }}
+};
+
+static const uint8_t BETA_NEG[128] = {
+	{{ cbytes (negG2 .G2.Beta) "\t" }}
+};
+
+static const uint8_t GAMMA_NEG[128] = {
+	{{ cbytes (negG2 .G2.Gamma) "\t" }}
+};
+
+static const uint8_t DELTA_NEG[128] = {
+	{{ cbytes (negG2 .G2.Delta) "\t" }}
+};
+
+// constant term and coefficients of the public inputs
+static const uint8_t K[{{ len .G1.K }}][64] = {
+{{- range .G1.K }}
+	{
+		{{ cbytes (g1 .) "\t\t" }}
+	},
+{{- end }}
+};
+{{ $c := constants }}
+// ---------------------------------------------------------------------------
+// Base field Fp, elements in Montgomery form on 8 limbs of 32 bits, least
+// significant first.
+
+typedef struct {
+	uint32_t l[8];
+} fp;
+
+static const fp P = {{ climbs $c.P }};
+static const uint32_t P_INV = 0x{{ printf "%08x" $c.PInv }}; // -p⁻¹ mod 2³²
+static const fp FP_ONE = {{ climbs $c.One }};
+static const fp FP_R2 = {{ climbs $c.R2 }};
+static const fp FP_9 = {{ climbs $c.Nine }};
+static const fp FP_18 = {{ climbs $c.Eighteen }};
+static const fp FP_82 = {{ climbs $c.EightyTwo }};
+static const uint8_t P_MINUS_2[{{ len $c.PMinus2 }}] = {
+	{{ cbytes $c.PMinus2 "\t" }}
+};
+
+// geq_p returns 1 if a >= p.
+static int geq_p(const uint32_t a[8]) {
+	int i;
+	for (i = 7; i >= 0; i--) {
+		if (a[i] != P.l[i]) {
+			return a[i] > P.l[i];
+		}
+	}
+	return 1;
+}
+
+// sub_p sets r = a - p and returns the borrow.
+static uint32_t sub_p(uint32_t r[8], const uint32_t a[8]) {
+	uint64_t borrow = 0;
+	int i;
+	for (i = 0; i < 8; i++) {
+		uint64_t d = (uint64_t)a[i] - P.l[i] - borrow;
+		r[i] = (uint32_t)d;
+		borrow = (d >> 32) & 1;
+	}
+	return (uint32_t)borrow;
+}
+
+static void fp_add(fp *r, const fp *a, const fp *b) {
+	uint64_t carry = 0;
+	int i;
+	for (i = 0; i < 8; i++) {
+		carry += (uint64_t)a->l[i] + b->l[i];
+		r->l[i] = (uint32_t)carry;
+		carry >>= 32;
+	}
+	// p < 2²⁵⁴, the sum doesn't overflow
+	if (geq_p(r->l)) {
+		sub_p(r->l, r->l);
+	}
+}
+
+static void fp_sub(fp *r, const fp *a, const fp *b) {
+	uint64_t borrow = 0, carry = 0;
+	int i;
+	for (i = 0; i < 8; i++) {
+		uint64_t d = (uint64_t)a->l[i] - b->l[i] - borrow;
+		r->l[i] = (uint32_t)d;
+		borrow = (d >> 32) & 1;
+	}
+	if (borrow) {
+		for (i = 0; i < 8; i++) {
+			carry += (uint64_t)r->l[i] + P.l[i];
+			r->l[i] = (uint32_t)carry;
+			carry >>= 32;
+		}
+	}
+}
+
+static void fp_neg(fp *r, const fp *a) {
+	fp zero;
+	memset(&zero, 0, sizeof(zero));
+	fp_sub(r, &zero, a);
+}
+
+// fp_mul sets r = a b R⁻¹ (CIOS Montgomery multiplication).
+static void fp_mul(fp *r, const fp *a, const fp *b) {
+	uint32_t t[10];
+	int i, j;
+	memset(t, 0, sizeof(t));
+	for (i = 0; i < 8; i++) {
+		uint64_t c = 0;
+		uint32_t m;
+		for (j = 0; j < 8; j++) {
+			c += (uint64_t)a->l[j] * b->l[i] + t[j];
+			t[j] = (uint32_t)c;
+			c >>= 32;
+		}
+		c += t[8];
+		t[8] = (uint32_t)c;
+		t[9] = (uint32_t)(c >> 32);
+
+		m = t[0] * P_INV;
+		c = ((uint64_t)m * P.l[0] + t[0]) >> 32;
+		for (j = 1; j < 8; j++) {
+			c += (uint64_t)m * P.l[j] + t[j];
+			t[j - 1] = (uint32_t)c;
+			c >>= 32;
+		}
+		c += t[8];
+		t[7] = (uint32_t)c;
+		t[8] = t[9] + (uint32_t)(c >> 32);
+	}
+	if (t[8] != 0 || geq_p(t)) {
+		sub_p(t, t);
+	}
+	memcpy(r->l, t, sizeof(r->l));
+}
+
+static int fp_is_zero(const fp *a) {
+	int i;
+	for (i = 0; i < 8; i++) {
+		if (a->l[i] != 0) {
+			return 0;
+		}
+	}
+	return 1;
+}
+
+static int fp_eq(const fp *a, const fp *b) {
+	return memcmp(a->l, b->l, sizeof(a->l)) == 0;
+}
+
+// fp_inv sets r = a⁻¹, or 0 if a = 0.
+static void fp_inv(fp *r, const fp *a) {
+	fp res = FP_ONE;
+	size_t i;
+	int j;
+	for (i = 0; i < sizeof(P_MINUS_2); i++) {
+		for (j = 7; j >= 0; j--) {
+			fp_mul(&res, &res, &res);
+			if ((P_MINUS_2[i] >> j) & 1) {
+				fp_mul(&res, &res, a);
+			}
+		}
+	}
+	*r = res;
+}
+
+// fp_from_bytes decodes a big endian integer, and returns 0 if it isn't
+// reduced modulo p.
+static int fp_from_bytes(fp *r, const uint8_t in[32]) {
+	int i;
+	for (i = 0; i < 8; i++) {
+		const uint8_t *b = in + 28 - 4 * i;
+		r->l[i] = ((uint32_t)b[0] << 24) | ((uint32_t)b[1] << 16) | ((uint32_t)b[2] << 8) | b[3];
+	}
+	if (geq_p(r->l)) {
+		return 0;
+	}
+	fp_mul(r, r, &FP_R2);
+	return 1;
+}
+
+// ---------------------------------------------------------------------------
+// Quadratic extension Fp2 = Fp[i]/(i² + 1), a = c0 + c1 i.
+
+typedef struct {
+	fp c0, c1;
+} fp2;
+
+static const fp2 B2 = { {{ climbs (index $c.B2 0) }}, {{ climbs (index $c.B2 1) }} };
+static const fp2 FROB_X = { {{ climbs (index $c.FrobX 0) }}, {{ climbs (index $c.FrobX 1) }} };
+static const fp2 FROB_Y = { {{ climbs (index $c.FrobY 0) }}, {{ climbs (index $c.FrobY 1) }} };
+
+static void fp2_add(fp2 *r, const fp2 *a, const fp2 *b) {
+	fp_add(&r->c0, &a->c0, &b->c0);
+	fp_add(&r->c1, &a->c1, &b->c1);
+}
+
+static void fp2_sub(fp2 *r, const fp2 *a, const fp2 *b) {
+	fp_sub(&r->c0, &a->c0, &b->c0);
+	fp_sub(&r->c1, &a->c1, &b->c1);
+}
+
+static void fp2_neg(fp2 *r, const fp2 *a) {
+	fp_neg(&r->c0, &a->c0);
+	fp_neg(&r->c1, &a->c1);
+}
+
+static void fp2_mul(fp2 *r, const fp2 *a, const fp2 *b) {
+	fp t0, t1, t2;
+	fp_mul(&t0, &a->c0, &b->c0);
+	fp_mul(&t1, &a->c1, &b->c1);
+	fp_mul(&t2, &a->c0, &b->c1);
+	fp_mul(&r->c1, &a->c1, &b->c0);
+	fp_add(&r->c1, &r->c1, &t2);
+	fp_sub(&r->c0, &t0, &t1);
+}
+
+static void fp2_mul_fp(fp2 *r, const fp2 *a, const fp *b) {
+	fp_mul(&r->c0, &a->c0, b);
+	fp_mul(&r->c1, &a->c1, b);
+}
+
+static void fp2_conj(fp2 *r, const fp2 *a) {
+	r->c0 = a->c0;
+	fp_neg(&r->c1, &a->c1);
+}
+
+// fp2_inv sets r = a⁻¹ = conj(a) / (c0² + c1²), or 0 if a = 0.
+static void fp2_inv(fp2 *r, const fp2 *a) {
+	fp t0, t1;
+	fp_mul(&t0, &a->c0, &a->c0);
+	fp_mul(&t1, &a->c1, &a->c1);
+	fp_add(&t0, &t0, &t1);
+	fp_inv(&t0, &t0);
+	fp2_conj(r, a);
+	fp2_mul_fp(r, r, &t0);
+}
+
+static int fp2_is_zero(const fp2 *a) {
+	return fp_is_zero(&a->c0) && fp_is_zero(&a->c1);
+}
+
+static int fp2_eq(const fp2 *a, const fp2 *b) {
+	return fp_eq(&a->c0, &b->c0) && fp_eq(&a->c1, &b->c1);
+}
+
+// ---------------------------------------------------------------------------
+// Points of G1 (y² = x³ + 3 over Fp) and G2 (y² = x³ + 3/(9+i) over Fp2) in
+// affine coordinates.
+
+typedef struct {
+	fp x, y;
+	int inf;
+} g1;
+
+typedef struct {
+	fp2 x, y;
+	int inf;
+} g2;
+
+// g1_decode decodes a point encoded as in EIP-196, and returns 0 if it is not
+// on the curve. G1 has a cofactor of 1.
+static int g1_decode(g1 *r, const uint8_t in[64]) {
+	fp t0, t1;
+	if (!fp_from_bytes(&r->x, in) || !fp_from_bytes(&r->y, in + 32)) {
+		return 0;
+	}
+	r->inf = fp_is_zero(&r->x) && fp_is_zero(&r->y);
+	if (r->inf) {
+		return 1;
+	}
+	fp_mul(&t0, &r->y, &r->y);
+	fp_mul(&t1, &r->x, &r->x);
+	fp_mul(&t1, &t1, &r->x);
+	fp_add(&t1, &t1, &FP_ONE);
+	fp_add(&t1, &t1, &FP_ONE);
+	fp_add(&t1, &t1, &FP_ONE);
+	return fp_eq(&t0, &t1);
+}
+
+static void g1_double(g1 *r, const g1 *a) {
+	fp l, t;
+	g1 res;
+	if (a->inf || fp_is_zero(&a->y)) {
+		r->inf = 1;
+		return;
+	}
+	// λ = 3x² / 2y
+	fp_mul(&t, &a->x, &a->x);
+	fp_add(&l, &t, &t);
+	fp_add(&l, &l, &t);
+	fp_add(&t, &a->y, &a->y);
+	fp_inv(&t, &t);
+	fp_mul(&l, &l, &t);
+	// x' = λ² - 2x, y' = λ(x - x') - y
+	fp_mul(&res.x, &l, &l);
+	fp_sub(&res.x, &res.x, &a->x);
+	fp_sub(&res.x, &res.x, &a->x);
+	fp_sub(&t, &a->x, &res.x);
+	fp_mul(&res.y, &l, &t);
+	fp_sub(&res.y, &res.y, &a->y);
+	res.inf = 0;
+	*r = res;
+}
+
+static void g1_add(g1 *r, const g1 *a, const g1 *b) {
+	fp l, t;
+	g1 res;
+	if (a->inf) {
+		*r = *b;
+		return;
+	}
+	if (b->inf) {
+		*r = *a;
+		return;
+	}
+	if (fp_eq(&a->x, &b->x)) {
+		if (fp_eq(&a->y, &b->y)) {
+			g1_double(r, a);
+		} else {
+			r->inf = 1;
+		}
+		return;
+	}
+	// λ = (y2 - y1) / (x2 - x1)
+	fp_sub(&t, &b->x, &a->x);
+	fp_inv(&t, &t);
+	fp_sub(&l, &b->y, &a->y);
+	fp_mul(&l, &l, &t);
+	// x3 = λ² - x1 - x2, y3 = λ(x1 - x3) - y1
+	fp_mul(&res.x, &l, &l);
+	fp_sub(&res.x, &res.x, &a->x);
+	fp_sub(&res.x, &res.x, &b->x);
+	fp_sub(&t, &a->x, &res.x);
+	fp_mul(&res.y, &l, &t);
+	fp_sub(&res.y, &res.y, &a->y);
+	res.inf = 0;
+	*r = res;
+}
+
+// g1_mul sets r = [s]a, s being a big endian integer.
+static void g1_mul(g1 *r, const g1 *a, const uint8_t s[32]) {
+	g1 res;
+	int i, j;
+	res.inf = 1;
+	for (i = 0; i < 32; i++) {
+		for (j = 7; j >= 0; j--) {
+			g1_double(&res, &res);
+			if ((s[i] >> j) & 1) {
+				g1_add(&res, &res, a);
+			}
+		}
+	}
+	*r = res;
+}
+
+static void g2_double(g2 *r, const g2 *a) {
+	fp2 l, t;
+	g2 res;
+	if (a->inf || fp2_is_zero(&a->y)) {
+		r->inf = 1;
+		return;
+	}
+	fp2_mul(&t, &a->x, &a->x);
+	fp2_add(&l, &t, &t);
+	fp2_add(&l, &l, &t);
+	fp2_add(&t, &a->y, &a->y);
+	fp2_inv(&t, &t);
+	fp2_mul(&l, &l, &t);
+	fp2_mul(&res.x, &l, &l);
+	fp2_sub(&res.x, &res.x, &a->x);
+	fp2_sub(&res.x, &res.x, &a->x);
+	fp2_sub(&t, &a->x, &res.x);
+	fp2_mul(&res.y, &l, &t);
+	fp2_sub(&res.y, &res.y, &a->y);
+	res.inf = 0;
+	*r = res;
+}
+
+static void g2_add(g2 *r, const g2 *a, const g2 *b) {
+	fp2 l, t;
+	g2 res;
+	if (a->inf) {
+		*r = *b;
+		return;
+	}
+	if (b->inf) {
+		*r = *a;
+		return;
+	}
+	if (fp2_eq(&a->x, &b->x)) {
+		if (fp2_eq(&a->y, &b->y)) {
+			g2_double(r, a);
+		} else {
+			r->inf = 1;
+		}
+		return;
+	}
+	fp2_sub(&t, &b->x, &a->x);
+	fp2_inv(&t, &t);
+	fp2_sub(&l, &b->y, &a->y);
+	fp2_mul(&l, &l, &t);
+	fp2_mul(&res.x, &l, &l);
+	fp2_sub(&res.x, &res.x, &a->x);
+	fp2_sub(&res.x, &res.x, &b->x);
+	fp2_sub(&t, &a->x, &res.x);
+	fp2_mul(&res.y, &l, &t);
+	fp2_sub(&res.y, &res.y, &a->y);
+	res.inf = 0;
+	*r = res;
+}
+
+// g2_decode decodes a point encoded as in EIP-197 (x.c1 | x.c0 | y.c1 | y.c0),
+// and returns 0 if it is not on the curve or not in the subgroup of order r.
+static int g2_decode(g2 *r, const uint8_t in[128]) {
+	fp2 t0, t1;
+	g2 q;
+	int i, j;
+	if (!fp_from_bytes(&r->x.c1, in) || !fp_from_bytes(&r->x.c0, in + 32) ||
+		!fp_from_bytes(&r->y.c1, in + 64) || !fp_from_bytes(&r->y.c0, in + 96)) {
+		return 0;
+	}
+	r->inf = fp2_is_zero(&r->x) && fp2_is_zero(&r->y);
+	if (r->inf) {
+		return 1;
+	}
+	fp2_mul(&t0, &r->y, &r->y);
+	fp2_mul(&t1, &r->x, &r->x);
+	fp2_mul(&t1, &t1, &r->x);
+	fp2_add(&t1, &t1, &B2);
+	if (!fp2_eq(&t0, &t1)) {
+		return 0;
+	}
+	// [r]q = 0
+	q.inf = 1;
+	for (i = 0; i < 32; i++) {
+		for (j = 7; j >= 0; j--) {
+			g2_double(&q, &q);
+			if ((R_MOD[i] >> j) & 1) {
+				g2_add(&q, &q, r);
+			}
+		}
+	}
+	return q.inf;
+}
+
+// g2_frobenius sets r to the image of a by the Frobenius endomorphism, on the
+// twist.
+static void g2_frobenius(g2 *r, const g2 *a) {
+	fp2_conj(&r->x, &a->x);
+	fp2_mul(&r->x, &r->x, &FROB_X);
+	fp2_conj(&r->y, &a->y);
+	fp2_mul(&r->y, &r->y, &FROB_Y);
+	r->inf = a->inf;
+}
+
+// ---------------------------------------------------------------------------
+// Fp12 = Fp[w]/(w¹² - 18w⁶ + 82), where i = w⁶ - 9 and the points (x, y) of
+// the twist are mapped to (xw², yw³).
+
+typedef struct {
+	fp c[12];
+} fp12;
+
+// coefficients (a - 9b, b) of the embeddings of ξⁱ⁽ᵖ⁻¹⁾ᐟ⁶ = a + bi, ξ = 9+i
+static const fp FROB_W[12][2] = {
+{{- range $c.FrobW }}
+	{ {{ climbs (index . 0) }}, {{ climbs (index . 1) }} },
+{{- end }}
+};
+
+// loop of the optimal ate pairing 6u+2, without its leading bit 2⁶⁴
+static const uint64_t ATE_LOOP = 0x{{ printf "%016x" $c.AteLoop }};
+
+// hard part (p⁴ - p² + 1)/r of the final exponentiation
+static const uint8_t HARD_EXP[{{ len $c.HardExp }}] = {
+	{{ cbytes $c.HardExp "\t" }}
+};
+
+static void fp12_one(fp12 *r) {
+	memset(r, 0, sizeof(*r));
+	r->c[0] = FP_ONE;
+}
+
+static void fp12_mul(fp12 *r, const fp12 *a, const fp12 *b) {
+	fp t[23], m;
+	int i, j;
+	memset(t, 0, sizeof(t));
+	for (i = 0; i < 12; i++) {
+		for (j = 0; j < 12; j++) {
+			fp_mul(&m, &a->c[i], &b->c[j]);
+			fp_add(&t[i + j], &t[i + j], &m);
+		}
+	}
+	// w¹² = 18w⁶ - 82
+	for (i = 22; i >= 12; i--) {
+		fp_mul(&m, &t[i], &FP_18);
+		fp_add(&t[i - 6], &t[i - 6], &m);
+		fp_mul(&m, &t[i], &FP_82);
+		fp_sub(&t[i - 12], &t[i - 12], &m);
+	}
+	memcpy(r->c, t, sizeof(r->c));
+}
+
+// fp12_frobenius sets r = aᵖ = Σ aᵢ wⁱ ξⁱ⁽ᵖ⁻¹⁾ᐟ⁶.
+static void fp12_frobenius(fp12 *r, const fp12 *a) {
+	fp12 res;
+	fp m, n;
+	int i;
+	memset(&res, 0, sizeof(res));
+	for (i = 0; i < 12; i++) {
+		fp_mul(&m, &a->c[i], &FROB_W[i][0]);
+		fp_add(&res.c[i], &res.c[i], &m);
+		fp_mul(&m, &a->c[i], &FROB_W[i][1]);
+		if (i < 6) {
+			fp_add(&res.c[i + 6], &res.c[i + 6], &m);
+		} else {
+			// w⁶⁺ⁱ = 18wⁱ - 82wⁱ⁻⁶
+			fp_mul(&n, &m, &FP_18);
+			fp_add(&res.c[i], &res.c[i], &n);
+			fp_mul(&n, &m, &FP_82);
+			fp_sub(&res.c[i - 6], &res.c[i - 6], &n);
+		}
+	}
+	*r = res;
+}
+
+// fp12_set_fp2 sets the coefficients of the embedding of a wᵏ, k < 6.
+static void fp12_set_fp2(fp12 *r, int k, const fp2 *a) {
+	fp m;
+	fp_mul(&m, &a->c1, &FP_9);
+	fp_sub(&r->c[k], &a->c0, &m);
+	r->c[k + 6] = a->c1;
+}
+
+// line multiplies f by the line through a and b (the tangent if a = b) on the
+// twist, evaluated at p.
+static void line(fp12 *f, const g2 *a, const g2 *b, const g1 *p) {
+	fp12 l;
+	fp2 m, t;
+	memset(&l, 0, sizeof(l));
+	if (!fp2_eq(&a->x, &b->x)) {
+		// m = (y2 - y1) / (x2 - x1)
+		fp2_sub(&t, &b->x, &a->x);
+		fp2_inv(&t, &t);
+		fp2_sub(&m, &b->y, &a->y);
+		fp2_mul(&m, &m, &t);
+	} else if (fp2_eq(&a->y, &b->y) && !fp2_is_zero(&a->y)) {
+		// m = 3x² / 2y
+		fp2_mul(&t, &a->x, &a->x);
+		fp2_add(&m, &t, &t);
+		fp2_add(&m, &m, &t);
+		fp2_add(&t, &a->y, &a->y);
+		fp2_inv(&t, &t);
+		fp2_mul(&m, &m, &t);
+	} else {
+		// vertical line xₚ - x w²
+		fp2_neg(&t, &a->x);
+		fp12_set_fp2(&l, 2, &t);
+		fp_add(&l.c[0], &l.c[0], &p->x);
+		fp12_mul(f, f, &l);
+		return;
+	}
+	// mw(xₚ - x w²) - (yₚ - y w³) = -yₚ + m xₚ w + (y - m x) w³
+	fp2_mul_fp(&t, &m, &p->x);
+	fp12_set_fp2(&l, 1, &t);
+	fp2_mul(&t, &m, &a->x);
+	fp2_sub(&t, &a->y, &t);
+	fp12_set_fp2(&l, 3, &t);
+	fp_neg(&l.c[0], &p->y);
+	fp12_mul(f, f, &l);
+}
+
+// pairing_check returns 1 if Π e(pᵢ, qᵢ) = 1. The pairs with a point at
+// infinity are skipped.
+static int pairing_check(const g1 *p, const g2 *q, size_t n) {
+	g2 r[4], q1, q2;
+	fp12 f, g;
+	size_t k;
+	int i;
+	fp12_one(&f);
+	for (k = 0; k < n; k++) {
+		r[k] = q[k];
+	}
+	// Miller loop
+	for (i = 63; i >= 0; i--) {
+		fp12_mul(&f, &f, &f);
+		for (k = 0; k < n; k++) {
+			if (p[k].inf || q[k].inf) {
+				continue;
+			}
+			line(&f, &r[k], &r[k], &p[k]);
+			g2_double(&r[k], &r[k]);
+			if ((ATE_LOOP >> i) & 1) {
+				line(&f, &r[k], &q[k], &p[k]);
+				g2_add(&r[k], &r[k], &q[k]);
+			}
+		}
+	}
+	for (k = 0; k < n; k++) {
+		if (p[k].inf || q[k].inf) {
+			continue;
+		}
+		// q1 = π(q), q2 = -π²(q)
+		g2_frobenius(&q1, &q[k]);
+		g2_frobenius(&q2, &q1);
+		fp2_neg(&q2.y, &q2.y);
+		line(&f, &r[k], &q1, &p[k]);
+		g2_add(&r[k], &r[k], &q1);
+		line(&f, &r[k], &q2, &p[k]);
+	}
+
+	for (i = 0; i < 12; i++) {
+		if (!fp_is_zero(&f.c[i])) {
+			break;
+		}
+	}
+	if (i == 12) {
+		return 0;
+	}
+	// f⁽ᵖ¹²⁻¹⁾ᐟʳ = 1 if and only if g = f⁽ᵖ²⁺¹⁾⁽ᵖ⁴⁻ᵖ²⁺¹⁾ᐟʳ satisfies
+	// g^(p⁶-1) = 1, that is, g is in Fp6: its odd coefficients are zero.
+	fp12_frobenius(&g, &f);
+	fp12_frobenius(&g, &g);
+	fp12_mul(&f, &g, &f);
+	fp12_one(&g);
+	for (k = 0; k < sizeof(HARD_EXP); k++) {
+		for (i = 7; i >= 0; i--) {
+			fp12_mul(&g, &g, &g);
+			if ((HARD_EXP[k] >> i) & 1) {
+				fp12_mul(&g, &g, &f);
+			}
+		}
+	}
+	for (i = 1; i < 12; i += 2) {
+		if (!fp_is_zero(&g.c[i])) {
+			return 0;
+		}
+	}
+	return 1;
+}
+
+// less_than_r returns 1 if x, in big endian, is reduced modulo R_MOD.
+static int less_than_r(const uint8_t x[32]) {
+	int i;
+	for (i = 0; i < 32; i++) {
+		if (x[i] != R_MOD[i]) {
+			return x[i] < R_MOD[i];
+		}
+	}
+	return 0;
+}
+
+// {{ name }}_verify verifies a proof encoded with gnark's MarshalVerifier. The
+// public inputs are encoded in big endian on 32 bytes, and must be reduced
+// modulo the order of the scalar field. Returns 1 if the proof is valid, and 0
+// if the proof or the public inputs are invalid.
+int {{ name }}_verify(const uint8_t proof[{{ name }}_PROOF_SIZE], const uint8_t (*public_inputs)[32], size_t nb_public_inputs) {
+	g1 ps[4], t;
+	g2 qs[4];
+	size_t i;
+
+	if (nb_public_inputs != {{ name }}_NB_PUBLIC_INPUTS) {
+		return 0;
+	}
+	if (!g1_decode(&ps[0], proof) || !g2_decode(&qs[0], proof + 64) || !g1_decode(&ps[1], proof + 192)) {
+		return 0;
+	}
+
+	// L = K[0] + Σ public_inputs[i] K[i+1]
+	if (!g1_decode(&ps[3], K[0])) {
+		return 0;
+	}
+	for (i = 0; i < nb_public_inputs; i++) {
+		if (!less_than_r(public_inputs[i]) || !g1_decode(&t, K[i + 1])) {
+			return 0;
+		}
+		g1_mul(&t, &t, public_inputs[i]);
+		g1_add(&ps[3], &ps[3], &t);
+	}
+
+	// e(A, B) e(C, -δ) e(α, -β) e(L, -γ) = 1
+	if (!g1_decode(&ps[2], ALPHA) || !g2_decode(&qs[1], DELTA_NEG) ||
+		!g2_decode(&qs[2], BETA_NEG) || !g2_decode(&qs[3], GAMMA_NEG)) {
+		return 0;
+	}
+	return pairing_check(ps, qs, 4);
+}
+`
@@ -0,0 +1,229 @@
+//go:build verifiercheck
+
+package groth16_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark/backend/export"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/stretchr/testify/require"
+)
+
+// The tests of this file run the exported verifiers with the toolchains of
+// their targets, which must be reachable in the PATH:
+//
+//	go test -tags verifiercheck -run TestVerifierCheck ./backend/groth16/bn254
+//
+// The C verifier is compiled with gcc, the Rust verifier is tested with cargo
+// (which fetches the arkworks crates) and the Move verifier with sui move test.
+
+// verifierCheck returns the verifier of the multiplication circuit exported
+// for target, a valid proof encoded for it, and its public input 15.
+func verifierCheck(t *testing.T, target export.Target) (verifier, proof []byte) {
+	assert := require.New(t)
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &mulCircuit{})
+	assert.NoError(err)
+	pk, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+	w, err := frontend.NewWitness(&mulCircuit{X: 3, Y: 5, Z: 15}, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	p, err := groth16.Prove(ccs, pk, w)
+	assert.NoError(err)
+
+	var buf bytes.Buffer
+	assert.NoError(groth16.ExportVerifier(&buf, vk, target, export.WithName("mul")))
+	proof, err = p.(*groth16_bn254.Proof).MarshalVerifier(target)
+	assert.NoError(err)
+	return buf.Bytes(), proof
+}
+
+// tamper returns a copy of proof with the point C changed.
+func tamper(proof []byte) []byte {
+	res := bytes.Clone(proof)
+	res[len(res)-1] ^= 1
+	return res
+}
+
+// shiftC returns a copy of proof with the point C, encoded as in EIP-197,
+// replaced by C + G, which is on the curve.
+func shiftC(t *testing.T, proof []byte) []byte {
+	var c curve.G1Affine
+	_, err := c.SetBytes(proof[192:])
+	require.NoError(t, err)
+	_, _, g1, _ := curve.Generators()
+	c.Add(&c, &g1)
+	b := c.RawBytes()
+	return append(bytes.Clone(proof[:192]), b[:]...)
+}
+
+// outsideSubgroupB returns a copy of proof with the point B, encoded as in
+// EIP-197, replaced by a point of the twist which is not in G2.
+func outsideSubgroupB(proof []byte) []byte {
+	var xi, b, x, y curve.E2
+	xi.A0.SetUint64(9)
+	xi.A1.SetOne()
+	b.Inverse(&xi).MulByElement(&b, new(fp.Element).SetUint64(3))
+	for x.A0.SetOne(); ; x.A0.Double(&x.A0) {
+		y.Square(&x).Mul(&y, &x).Add(&y, &b)
+		if y.Legendre() == 1 {
+			break
+		}
+	}
+	y.Sqrt(&y)
+	q := curve.G2Affine{X: x, Y: y}
+	if q.IsInSubGroup() || !q.IsOnCurve() {
+		panic("point of the twist expected outside of G2")
+	}
+	res := bytes.Clone(proof)
+	for i, e := range []*fp.Element{&x.A1, &x.A0, &y.A1, &y.A0} {
+		v := e.Bytes()
+		copy(res[64+32*i:], v[:])
+	}
+	return res
+}
+
+func run(t *testing.T, dir, name string, args ...string) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	t.Log("running ", cmd.String())
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+// literal formats b with format for each byte, separated by sep.
+func literal(b []byte, format, sep string) string {
+	s := make([]string, len(b))
+	for i := range b {
+		s[i] = fmt.Sprintf(format, b[i])
+	}
+	return strings.Join(s, sep)
+}
+
+// input is the public input 15 of the multiplication circuit on 32 bytes.
+var input = append(make([]byte, 31), 15)
+
+func TestVerifierCheckC(t *testing.T) {
+	verifier, proof := verifierCheck(t, export.C)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"verifier.c": string(verifier),
+		"main.c": `#include <stddef.h>
+#include <stdint.h>
+
+int mul_verify(const uint8_t *proof, const uint8_t (*public_inputs)[32], size_t nb_public_inputs);
+
+static const uint8_t proof[256] = {` + literal(proof, "0x%02x", ", ") + `};
+static const uint8_t tampered[256] = {` + literal(tamper(proof), "0x%02x", ", ") + `};
+static const uint8_t shifted[256] = {` + literal(shiftC(t, proof), "0x%02x", ", ") + `};
+static const uint8_t outside_subgroup[256] = {` + literal(outsideSubgroupB(proof), "0x%02x", ", ") + `};
+static const uint8_t input[1][32] = {{` + literal(input, "0x%02x", ", ") + `}};
+static const uint8_t wrong_input[1][32] = {{` + literal(append(make([]byte, 31), 16), "0x%02x", ", ") + `}};
+
+int main(void) {
+	if (!mul_verify(proof, input, 1)) {
+		return 1;
+	}
+	if (mul_verify(tampered, input, 1)) {
+		return 2;
+	}
+	if (mul_verify(proof, wrong_input, 1)) {
+		return 3;
+	}
+	if (mul_verify(shifted, input, 1)) {
+		return 4;
+	}
+	if (mul_verify(outside_subgroup, input, 1)) {
+		return 5;
+	}
+	return 0;
+}
+`,
+	})
+	run(t, dir, "gcc", "-std=c99", "-pedantic", "-Wall", "-Wextra", "-Werror", "-O2", "-o", "verify", "main.c", "verifier.c")
+	run(t, dir, filepath.Join(dir, "verify"))
+}
+
+func TestVerifierCheckRust(t *testing.T) {
+	verifier, proof := verifierCheck(t, export.Rust)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Cargo.toml": `[package]
+name = "mul_verifier"
+version = "0.1.0"
+edition = "2021"
+
+[dependencies]
+ark-bn254 = "0.4"
+ark-ec = "0.4"
+ark-ff = "0.4"
+ark-groth16 = "0.4"
+`,
+		"src/lib.rs":      "pub mod verifier;\n",
+		"src/verifier.rs": string(verifier),
+		"tests/verify.rs": `use mul_verifier::verifier::verify;
+
+const PROOF: [u8; 256] = [` + literal(proof, "%d", ", ") + `];
+const TAMPERED: [u8; 256] = [` + literal(tamper(proof), "%d", ", ") + `];
+
+#[test]
+fn verifies_the_proof() {
+    let mut input = [0u8; 32];
+    input[31] = 15;
+    assert!(verify(&PROOF, &[input]));
+    assert!(!verify(&TAMPERED, &[input]));
+    input[31] = 16;
+    assert!(!verify(&PROOF, &[input]));
+}
+`,
+	})
+	run(t, dir, "cargo", "test")
+}
+
+func TestVerifierCheckMove(t *testing.T) {
+	verifier, proof := verifierCheck(t, export.Move)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Move.toml": `[package]
+name = "mul"
+edition = "2024.beta"
+
+[addresses]
+mul = "0x0"
+`,
+		"sources/groth16_verifier.move": string(verifier),
+		"tests/groth16_verifier_tests.move": `#[test_only]
+module mul::groth16_verifier_tests {
+    use mul::groth16_verifier;
+
+    #[test]
+    fun verifies_the_proof() {
+        assert!(groth16_verifier::verify(x"` + literal(proof, "%02x", "") + `", vector[15u256]), 0);
+        assert!(!groth16_verifier::verify(x"` + literal(tamper(proof), "%02x", "") + `", vector[15u256]), 1);
+        assert!(!groth16_verifier::verify(x"` + literal(proof, "%02x", "") + `", vector[16u256]), 2);
+    }
+}
+`,
+	})
+	run(t, dir, "sui", "move", "test")
+}
@@ -0,0 +1,47 @@
+package groth16
+
+// moveTemplate is the template of the Sui Move verifier, see ExportVerifier.
+const moveTemplate = `// Code generated by gnark DO NOT EDIT
+
+/// Groth16 verifier on BN254, using the native verifier of Sui.
+///
+/// Code has not been audited and is provided as-is, we make no guarantees or
+/// warranties to its safety and reliability.
+module {{ name }}::groth16_verifier {
+    use sui::bcs;
+    use sui::groth16;
+
+    /// Number of public inputs of the circuit.
+    const NB_PUBLIC_INPUTS: u64 = {{ sub (len .G1.K) 1 }};
+
+    /// The verifying key, serialized as in arkworks (compressed).
+    const VERIFYING_KEY: vector<u8> = x"{{ hex (arkworksVK .) }}";
+
+    /// Verifies a proof encoded with gnark's MarshalVerifier, that is the points
+    /// A, B and C compressed as in arkworks.
+    /// The public inputs must be reduced modulo the order of the scalar field,
+    /// the verification aborts otherwise.
+    public fun verify(proof: vector<u8>, public_inputs: vector<u256>): bool {
+        if (public_inputs.length() != NB_PUBLIC_INPUTS) {
+            return false
+        };
+        let curve = groth16::bn254();
+        let pvk = groth16::prepare_verifying_key(&curve, &VERIFYING_KEY);
+
+        // the public inputs are encoded in little endian on 32 bytes, as u256 in BCS
+        let mut inputs = vector[];
+        let mut i = 0;
+        while (i < NB_PUBLIC_INPUTS) {
+            inputs.append(bcs::to_bytes(&public_inputs[i]));
+            i = i + 1;
+        };
+
+        groth16::verify_groth16_proof(
+            &curve,
+            &pvk,
+            &groth16::public_proof_inputs_from_bytes(inputs),
+            &groth16::proof_points_from_bytes(proof),
+        )
+    }
+}
+`
@@ -0,0 +1,119 @@
+package groth16
+
+// rustTemplate is the template of the Rust verifier, see ExportVerifier.
+const rustTemplate = `// Code generated by gnark DO NOT EDIT
+
+//! Groth16 verifier on BN254.
+//!
+//! It depends on the arkworks crates ark-bn254, ark-ec, ark-ff and ark-groth16
+//! (0.4). Code has not been audited and is provided as-is, we make no guarantees
+//! or warranties to its safety and reliability.
+
+use ark_bn254::{Bn254, Fq, Fq2, Fr, G1Affine, G2Affine};
+use ark_ec::AffineRepr;
+use ark_ff::{BigInteger256, MontFp, PrimeField};
+use ark_groth16::{prepare_verifying_key, Groth16, Proof, VerifyingKey};
+
+/// Number of public inputs of the circuit.
+pub const NB_PUBLIC_INPUTS: usize = {{ sub (len .G1.K) 1 }};
+
+/// Size of a proof encoded with gnark's MarshalVerifier: the points A, B and C
+/// encoded as in EIP-197.
+pub const PROOF_SIZE: usize = 256;
+
+/// Returns the verifying key.
+pub fn verifying_key() -> VerifyingKey<Bn254> {
+    VerifyingKey {
+        alpha_g1: {{ template "g1" .G1.Alpha }},
+        beta_g2: {{ template "g2" .G2.Beta }},
+        gamma_g2: {{ template "g2" .G2.Gamma }},
+        delta_g2: {{ template "g2" .G2.Delta }},
+        gamma_abc_g1: vec![
+        {{- range .G1.K }}
+            {{ template "g1" . }},
+        {{- end }}
+        ],
+    }
+}
+
+/// Verifies a proof encoded with gnark's MarshalVerifier.
+///
+/// The public inputs are encoded in big endian on 32 bytes, and must be
+/// reduced modulo the order of the scalar field. Returns false if the proof or
+/// the public inputs are invalid.
+pub fn verify(proof: &[u8], public_inputs: &[[u8; 32]]) -> bool {
+    if public_inputs.len() != NB_PUBLIC_INPUTS {
+        return false;
+    }
+    let proof = match decode_proof(proof) {
+        Some(proof) => proof,
+        None => return false,
+    };
+    let mut inputs = Vec::with_capacity(NB_PUBLIC_INPUTS);
+    for input in public_inputs {
+        match decode_field::<Fr>(input) {
+            Some(x) => inputs.push(x),
+            None => return false,
+        }
+    }
+    let pvk = prepare_verifying_key(&verifying_key());
+    Groth16::<Bn254>::verify_proof(&pvk, &proof, &inputs).unwrap_or(false)
+}
+
+/// Decodes a proof encoded with gnark's MarshalVerifier, checking that the
+/// points are in their subgroups.
+pub fn decode_proof(proof: &[u8]) -> Option<Proof<Bn254>> {
+    if proof.len() != PROOF_SIZE {
+        return None;
+    }
+    Some(Proof {
+        a: decode_g1(&proof[0..64])?,
+        b: decode_g2(&proof[64..192])?,
+        c: decode_g1(&proof[192..256])?,
+    })
+}
+
+/// Decodes an element of a prime field from 32 bytes in big endian, rejecting
+/// unreduced values.
+fn decode_field<F: PrimeField<BigInt = BigInteger256>>(b: &[u8]) -> Option<F> {
+    let mut limbs = [0u64; 4];
+    for (i, limb) in limbs.iter_mut().enumerate() {
+        let start = 24 - 8 * i;
+        *limb = u64::from_be_bytes(b[start..start + 8].try_into().ok()?);
+    }
+    F::from_bigint(BigInteger256::new(limbs))
+}
+
+/// Decodes a point of G1 encoded as in EIP-197: x | y.
+fn decode_g1(b: &[u8]) -> Option<G1Affine> {
+    let x: Fq = decode_field(&b[0..32])?;
+    let y: Fq = decode_field(&b[32..64])?;
+    if x == Fq::from(0u8) && y == Fq::from(0u8) {
+        return Some(G1Affine::zero());
+    }
+    let p = G1Affine::new_unchecked(x, y);
+    if !p.is_on_curve() || !p.is_in_correct_subgroup_assuming_on_curve() {
+        return None;
+    }
+    Some(p)
+}
+
+/// Decodes a point of G2 encoded as in EIP-197: x.c1 | x.c0 | y.c1 | y.c0.
+fn decode_g2(b: &[u8]) -> Option<G2Affine> {
+    let x = Fq2::new(decode_field(&b[32..64])?, decode_field(&b[0..32])?);
+    let y = Fq2::new(decode_field(&b[96..128])?, decode_field(&b[64..96])?);
+    if x == Fq2::from(0u8) && y == Fq2::from(0u8) {
+        return Some(G2Affine::zero());
+    }
+    let p = G2Affine::new_unchecked(x, y);
+    if !p.is_on_curve() || !p.is_in_correct_subgroup_assuming_on_curve() {
+        return None;
+    }
+    Some(p)
+}
+{{- define "g1" }}G1Affine::new_unchecked(MontFp!("{{ fpstr .X }}"), MontFp!("{{ fpstr .Y }}")){{ end }}
+{{- define "g2" }}G2Affine::new_unchecked(
+            Fq2::new(MontFp!("{{ fpstr .X.A0 }}"), MontFp!("{{ fpstr .X.A1 }}")),
+            Fq2::new(MontFp!("{{ fpstr .Y.A0 }}"), MontFp!("{{ fpstr .Y.A1 }}")),
+        ){{ end }}
+`
@@ -0,0 +1,120 @@
+package groth16_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark/backend/export"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/stretchr/testify/require"
+)
+
+type mulCircuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (c *mulCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(c.X, c.Y), c.Z)
+	return nil
+}
+
+func TestExportVerifier(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &mulCircuit{})
+	assert.NoError(err)
+	_, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+
+	var buf bytes.Buffer
+	assert.NoError(groth16.ExportVerifier(&buf, vk, export.Rust))
+	assert.Contains(buf.String(), "pub const NB_PUBLIC_INPUTS: usize = 1;")
+	assert.Contains(buf.String(), "gamma_abc_g1: vec![\n            G1Affine::new_unchecked(MontFp!(")
+
+	buf.Reset()
+	assert.NoError(groth16.ExportVerifier(&buf, vk, export.Move, export.WithName("verifiers")))
+	assert.Contains(buf.String(), "module verifiers::groth16_verifier {")
+	assert.Contains(buf.String(), `const VERIFYING_KEY: vector<u8> = x"`)
+
+	buf.Reset()
+	assert.NoError(groth16.ExportVerifier(&buf, vk, export.C, export.WithName("mul")))
+	assert.Contains(buf.String(), "int mul_verify(")
+	if _, err := exec.LookPath("gcc"); err == nil {
+		dir := t.TempDir()
+		src := filepath.Join(dir, "verifier.c")
+		assert.NoError(os.WriteFile(src, buf.Bytes(), 0o644))
+		out, err := exec.Command("gcc", "-std=c99", "-Wall", "-Wextra", "-Werror", "-c", "-o", filepath.Join(dir, "verifier.o"), src).CombinedOutput()
+		assert.NoError(err, string(out))
+	}
+
+	buf.Reset()
+	assert.NoError(groth16.ExportVerifier(&buf, vk, export.Solidity))
+	assert.Contains(buf.String(), "contract Verifier {")
+
+	assert.Error(groth16.ExportVerifier(&buf, vk, export.Target(42)))
+	assert.Error(groth16.ExportVerifier(&buf, vk, export.Rust, export.WithName("not a name")))
+}
+
+func TestExportVerifierCommitments(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &singleSecretCommittedCircuit{})
+	assert.NoError(err)
+	_, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+
+	var buf bytes.Buffer
+	for _, target := range []export.Target{export.Rust, export.Move, export.C} {
+		assert.Error(groth16.ExportVerifier(&buf, vk, target), target.String())
+	}
+	assert.NoError(groth16.ExportVerifier(&buf, vk, export.Solidity))
+}
+
+func TestMarshalVerifier(t *testing.T) {
+	assert := require.New(t)
+
+	_, _, g1, g2 := curve.Generators()
+	var proof groth16_bn254.Proof
+	proof.Ar, proof.Bs = g1, g2
+	proof.Krs.Neg(&g1)
+
+	b, err := proof.MarshalVerifier(export.C)
+	assert.NoError(err)
+	assert.Len(b, 256)
+	assert.Equal(b, proof.MarshalSolidity())
+
+	// compressed as in arkworks, the generator (1, 2) of G1 has the smallest y
+	// and its negation the largest
+	b, err = proof.MarshalVerifier(export.Move)
+	assert.NoError(err)
+	assert.Len(b, 128)
+	one := "01" + strings.Repeat("00", 31)
+	assert.Equal(one, hex.EncodeToString(b[:32]))
+	assert.Equal("01"+strings.Repeat("00", 30)+"80", hex.EncodeToString(b[96:]))
+
+	// the compressed encoding of gnark-crypto holds the same data, in reverse
+	// order and with other flags
+	gnarkBs := proof.Bs.Bytes()
+	for i := 0; i < len(gnarkBs)-1; i++ {
+		assert.Equal(gnarkBs[len(gnarkBs)-1-i], b[32+i], "byte %d", i)
+	}
+	assert.Equal(gnarkBs[0]&0b111111, b[95]&0b111111)
+
+	// the point at infinity only has the infinity flag
+	proof.Krs.X.SetZero()
+	proof.Krs.Y.SetZero()
+	b, err = proof.MarshalVerifier(export.Move)
+	assert.NoError(err)
+	assert.Equal(strings.Repeat("00", 31)+"40", hex.EncodeToString(b[96:]))
+}
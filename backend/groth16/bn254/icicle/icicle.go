@@ -154,6 +154,7 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 	proof := &groth16_bn254.Proof{Commitments: make([]curve.G1Affine, len(commitmentInfo))}
 
 	solverOpts := opt.SolverOpts[:len(opt.SolverOpts):len(opt.SolverOpts)]
+	solverOpts = append(solverOpts, solver.WithContext(opt.Context))
 
 	privateCommittedValues := make([][]fr.Element, len(commitmentInfo))
 	for i := range commitmentInfo {
@@ -193,13 +194,19 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 			solver.OverrideHint(r1cs.GkrInfo.ProveHintID, cs.GkrProveHint(r1cs.GkrInfo.HashName, &gkrData)))
 	}
 
+	progress := opt.NewProgress(backend.GROTH16, curve.ID)
+
 	_solution, err := r1cs.Solve(fullWitness, solverOpts...)
 	if err != nil {
+		if cErr := opt.Cancelled(); cErr != nil {
+			return nil, cErr
+		}
 		return nil, err
 	}
 
 	solution := _solution.(*cs.R1CSSolution)
 	wireValues := []fr.Element(solution.W)
+	progress.Done(backend.PhaseSolve, progress.Start())
 
 	start := time.Now()
 
@@ -216,10 +223,12 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 	var h unsafe.Pointer
 	chHDone := make(chan struct{}, 1)
 	go func() {
+		hStart := time.Now()
 		h = computeH(solution.A, solution.B, solution.C, pk)
 		solution.A = nil
 		solution.B = nil
 		solution.C = nil
+		progress.Done(backend.PhaseQuotient, hStart)
 		chHDone <- struct{}{}
 	}()
 
@@ -392,31 +401,42 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...b
 		return nil
 	}
 
+	// free device/GPU memory that is not needed for future proofs (scalars/hpoly)
+	freeDevice := func() {
+		go func() {
+			iciclegnark.FreeDevicePointer(wireValuesADevice.P)
+			iciclegnark.FreeDevicePointer(wireValuesBDevice.P)
+			iciclegnark.FreeDevicePointer(h)
+		}()
+	}
+
 	// wait for FFT to end
 	<-chHDone
 
-	// schedule our proof part computations
-	if err := computeAR1(); err != nil {
-		return nil, err
-	}
-	if err := computeBS1(); err != nil {
-		return nil, err
-	}
-	if err := computeKRS(); err != nil {
+	if err := opt.Cancelled(); err != nil {
+		<-chWireValuesA
+		<-chWireValuesB
+		freeDevice()
 		return nil, err
 	}
-	if err := computeBS2(); err != nil {
-		return nil, err
+	msmStart := time.Now()
+
+	// schedule our proof part computations. The multi-exponentiations on the
+	// device cannot be interrupted, so the context is checked between them.
+	for _, compute := range []func() error{computeAR1, computeBS1, computeKRS, computeBS2} {
+		if err := opt.Cancelled(); err != nil {
+			freeDevice()
+			return nil, err
+		}
+		if err := compute(); err != nil {
+			return nil, err
+		}
 	}
+	progress.Done(backend.PhaseMSM, msmStart)
 
 	log.Debug().Dur("took", time.Since(start)).Msg("prover done")
 
-	// free device/GPU memory that is not needed for future proofs (scalars/hpoly)
-	go func() {
-		iciclegnark.FreeDevicePointer(wireValuesADevice.P)
-		iciclegnark.FreeDevicePointer(wireValuesBDevice.P)
-		iciclegnark.FreeDevicePointer(h)
-	}()
+	freeDevice()
 
 	return proof, nil
 }
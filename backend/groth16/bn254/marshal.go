@@ -19,10 +19,14 @@ package groth16
 import (
 	curve "github.com/consensys/gnark-crypto/ecc/bn254"
 
+	"bytes"
+	"errors"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/pedersen"
 	"github.com/consensys/gnark-crypto/utils/unsafe"
+	"github.com/consensys/gnark/backend/groth16/internal"
 	"github.com/consensys/gnark/internal/utils"
 	"io"
+	"os"
 )
 
 // WriteTo writes binary encoding of the Proof elements to writer
@@ -535,3 +539,167 @@ func (pk *ProvingKey) ReadDump(r io.Reader) error {
 	return nil
 
 }
+
+// WriteMappable writes the ProvingKey to w in a format where the tables of points are
+// stored like in WriteDump, but at aligned offsets, so that they can be memory-mapped
+// with Map. The same caveats as WriteDump apply.
+func (pk *ProvingKey) WriteMappable(w io.Writer) error {
+	var header bytes.Buffer
+	if err := unsafe.WriteMarker(&header); err != nil {
+		return err
+	}
+	if _, err := pk.Domain.WriteTo(&header); err != nil {
+		return err
+	}
+
+	// sizes of the tables, in the order they are written
+	sizes := []uint64{
+		uint64(len(pk.InfinityA)),
+		uint64(len(pk.InfinityB)),
+		uint64(len(pk.G1.A)),
+		uint64(len(pk.G1.B)),
+		uint64(len(pk.G1.Z)),
+		uint64(len(pk.G1.K)),
+		uint64(len(pk.G2.B)),
+	}
+	for i := range pk.CommitmentKeys {
+		sizes = append(sizes, uint64(len(pk.CommitmentKeys[i].Basis)), uint64(len(pk.CommitmentKeys[i].BasisExpSigma)))
+	}
+
+	enc := curve.NewEncoder(&header, curve.RawEncoding())
+	toEncode := []interface{}{
+		&pk.G1.Alpha,
+		&pk.G1.Beta,
+		&pk.G1.Delta,
+		&pk.G2.Beta,
+		&pk.G2.Delta,
+		pk.NbInfinityA,
+		pk.NbInfinityB,
+		uint32(len(sizes)),
+	}
+	for i := range sizes {
+		toEncode = append(toEncode, sizes[i])
+	}
+	for _, v := range toEncode {
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+
+	tw := internal.NewTableWriter(w)
+	if err := tw.WriteHeader(header.Bytes()); err != nil {
+		return err
+	}
+	if err := internal.WriteTable(tw, pk.InfinityA); err != nil {
+		return err
+	}
+	if err := internal.WriteTable(tw, pk.InfinityB); err != nil {
+		return err
+	}
+	for _, t := range [][]curve.G1Affine{pk.G1.A, pk.G1.B, pk.G1.Z, pk.G1.K} {
+		if err := internal.WriteTable(tw, t); err != nil {
+			return err
+		}
+	}
+	if err := internal.WriteTable(tw, pk.G2.B); err != nil {
+		return err
+	}
+	for i := range pk.CommitmentKeys {
+		if err := internal.WriteTable(tw, pk.CommitmentKeys[i].Basis); err != nil {
+			return err
+		}
+		if err := internal.WriteTable(tw, pk.CommitmentKeys[i].BasisExpSigma); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Map reads a ProvingKey written by WriteMappable from f. The tables of points are
+// memory-mapped instead of being read, so that the prover can run with less memory
+// than the size of the key: the points are loaded from the file when a multi-exponentiation
+// needs them, and the system can reclaim them afterwards. When memory is sufficient, the
+// tables stay in the page cache and the prover runs as fast as with a key read in memory.
+//
+// The returned Closer releases the mapping, the ProvingKey must not be used afterwards.
+// The tables are mapped read-only and shared with f: writing to them, e.g. by modifying
+// the ProvingKey in place, faults with SIGSEGV instead of returning an error, and f must
+// not be modified or truncated while it is mapped.
+func (pk *ProvingKey) Map(f *os.File) (io.Closer, error) {
+	tr, err := internal.NewTableReader(f)
+	if err != nil {
+		return nil, err
+	}
+	if err := pk.readMapped(tr); err != nil {
+		tr.Close()
+		return nil, err
+	}
+	return tr, nil
+}
+
+func (pk *ProvingKey) readMapped(tr *internal.TableReader) error {
+	header, err := tr.ReadHeader()
+	if err != nil {
+		return err
+	}
+	r := bytes.NewReader(header)
+	if err := unsafe.ReadMarker(r); err != nil {
+		return err
+	}
+	if _, err := pk.Domain.ReadFrom(r); err != nil {
+		return err
+	}
+
+	dec := curve.NewDecoder(r, curve.NoSubgroupChecks())
+	var nbSizes uint32
+	toDecode := []interface{}{
+		&pk.G1.Alpha,
+		&pk.G1.Beta,
+		&pk.G1.Delta,
+		&pk.G2.Beta,
+		&pk.G2.Delta,
+		&pk.NbInfinityA,
+		&pk.NbInfinityB,
+		&nbSizes,
+	}
+	for _, v := range toDecode {
+		if err := dec.Decode(v); err != nil {
+			return err
+		}
+	}
+	if nbSizes < 7 || nbSizes%2 != 1 || uint64(nbSizes) > uint64(len(header)) {
+		return errors.New("invalid number of tables")
+	}
+	sizes := make([]uint64, nbSizes)
+	for i := range sizes {
+		if err := dec.Decode(&sizes[i]); err != nil {
+			return err
+		}
+	}
+
+	if pk.InfinityA, err = internal.ReadTable[bool](tr, sizes[0]); err != nil {
+		return err
+	}
+	if pk.InfinityB, err = internal.ReadTable[bool](tr, sizes[1]); err != nil {
+		return err
+	}
+	for i, t := range []*[]curve.G1Affine{&pk.G1.A, &pk.G1.B, &pk.G1.Z, &pk.G1.K} {
+		if *t, err = internal.ReadTable[curve.G1Affine](tr, sizes[2+i]); err != nil {
+			return err
+		}
+	}
+	if pk.G2.B, err = internal.ReadTable[curve.G2Affine](tr, sizes[6]); err != nil {
+		return err
+	}
+	sizes = sizes[7:]
+	pk.CommitmentKeys = make([]pedersen.ProvingKey, len(sizes)/2)
+	for i := range pk.CommitmentKeys {
+		if pk.CommitmentKeys[i].Basis, err = internal.ReadTable[curve.G1Affine](tr, sizes[2*i]); err != nil {
+			return err
+		}
+		if pk.CommitmentKeys[i].BasisExpSigma, err = internal.ReadTable[curve.G1Affine](tr, sizes[2*i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
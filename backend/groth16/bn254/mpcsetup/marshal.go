@@ -17,8 +17,13 @@
 package mpcsetup
 
 import (
-	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"bytes"
+	"encoding/binary"
+	"fmt"
 	"io"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark/internal/utils"
 )
 
 // WriteTo implements io.WriterTo
@@ -88,6 +93,35 @@ func (phase1 *Phase1) ReadFrom(reader io.Reader) (int64, error) {
 	return dec.BytesRead() + int64(nBytes), err
 }
 
+// formatMarker starts the versioned serialization of Phase2 and
+// Phase2Evaluations, followed by formatVersion. It can't be mistaken for the
+// legacy format (gnark ≤ v0.10, without the commitment keys), which starts
+// with a compressed point or the length of a slice of points.
+const (
+	formatMarker  = 0xffffffff
+	formatVersion = 1
+)
+
+// readFormat reads the header of the versioned format. If the data is in the
+// legacy format, the header bytes are put back in front of the returned
+// reader.
+func readFormat(reader io.Reader) (r io.Reader, legacy bool, n int64, err error) {
+	var buf [4]byte
+	if _, err = io.ReadFull(reader, buf[:]); err != nil {
+		return reader, false, 0, err
+	}
+	if binary.BigEndian.Uint32(buf[:]) != formatMarker {
+		return io.MultiReader(bytes.NewReader(buf[:]), reader), true, 0, nil
+	}
+	if _, err = io.ReadFull(reader, buf[:]); err != nil {
+		return reader, false, 4, err
+	}
+	if version := binary.BigEndian.Uint32(buf[:]); version != formatVersion {
+		return reader, false, 8, fmt.Errorf("unsupported format version %d", version)
+	}
+	return reader, false, 8, nil
+}
+
 // WriteTo implements io.WriterTo
 func (phase2 *Phase2) WriteTo(writer io.Writer) (int64, error) {
 	n, err := phase2.writeTo(writer)
@@ -100,14 +134,33 @@ func (phase2 *Phase2) WriteTo(writer io.Writer) (int64, error) {
 
 func (c *Phase2) writeTo(writer io.Writer) (int64, error) {
 	enc := curve.NewEncoder(writer)
-	toEncode := []interface{}{
+	var toEncode []interface{}
+	if !c.legacy {
+		toEncode = append(toEncode, uint32(formatMarker), uint32(formatVersion))
+	}
+	toEncode = append(toEncode,
 		&c.PublicKey.SG,
 		&c.PublicKey.SXG,
 		&c.PublicKey.XR,
+	)
+	if !c.legacy {
+		toEncode = append(toEncode,
+			&c.SigmaPublicKey.SG,
+			&c.SigmaPublicKey.SXG,
+			&c.SigmaPublicKey.XR,
+		)
+	}
+	toEncode = append(toEncode,
 		&c.Parameters.G1.Delta,
 		c.Parameters.G1.L,
 		c.Parameters.G1.Z,
 		&c.Parameters.G2.Delta,
+	)
+	if !c.legacy {
+		toEncode = append(toEncode, &c.Parameters.G2.Sigma, uint32(len(c.Parameters.G1.SigmaCKK)))
+		for i := range c.Parameters.G1.SigmaCKK {
+			toEncode = append(toEncode, c.Parameters.G1.SigmaCKK[i])
+		}
 	}
 
 	for _, v := range toEncode {
@@ -119,28 +172,65 @@ func (c *Phase2) writeTo(writer io.Writer) (int64, error) {
 	return enc.BytesWritten(), nil
 }
 
-// ReadFrom implements io.ReaderFrom
+// ReadFrom implements io.ReaderFrom. It also reads the legacy format, in which
+// case the Phase2 has no commitment keys and keeps being written and hashed
+// in that format.
 func (c *Phase2) ReadFrom(reader io.Reader) (int64, error) {
+	reader, legacy, n, err := readFormat(reader)
+	if err != nil {
+		return n, err
+	}
+	c.legacy = legacy
+	if legacy {
+		c.SigmaPublicKey = PublicKey{}
+		c.Parameters.G2.Sigma = curve.G2Affine{}
+	}
 	dec := curve.NewDecoder(reader)
 	toEncode := []interface{}{
 		&c.PublicKey.SG,
 		&c.PublicKey.SXG,
 		&c.PublicKey.XR,
+	}
+	if !legacy {
+		toEncode = append(toEncode,
+			&c.SigmaPublicKey.SG,
+			&c.SigmaPublicKey.SXG,
+			&c.SigmaPublicKey.XR,
+		)
+	}
+	toEncode = append(toEncode,
 		&c.Parameters.G1.Delta,
 		&c.Parameters.G1.L,
 		&c.Parameters.G1.Z,
 		&c.Parameters.G2.Delta,
+	)
+	var nbCommitments uint32
+	if !legacy {
+		toEncode = append(toEncode, &c.Parameters.G2.Sigma, &nbCommitments)
 	}
 
 	for _, v := range toEncode {
 		if err := dec.Decode(v); err != nil {
-			return dec.BytesRead(), err
+			return n + dec.BytesRead(), err
 		}
 	}
+	// the number of commitments is untrusted, the keys are appended as they
+	// are read
+	c.Parameters.G1.SigmaCKK = nil
+	if !legacy {
+		c.Parameters.G1.SigmaCKK = [][]curve.G1Affine{}
+	}
+	for i := uint32(0); i < nbCommitments; i++ {
+		var sigmaCKK []curve.G1Affine
+		if err := dec.Decode(&sigmaCKK); err != nil {
+			return n + dec.BytesRead(), err
+		}
+		c.Parameters.G1.SigmaCKK = append(c.Parameters.G1.SigmaCKK, sigmaCKK)
+	}
 
 	c.Hash = make([]byte, 32)
-	n, err := reader.Read(c.Hash)
-	return int64(n) + dec.BytesRead(), err
+	nBytes, err := io.ReadFull(reader, c.Hash)
+	return n + int64(nBytes) + dec.BytesRead(), err
 
 }
 
@@ -148,9 +238,17 @@ func (c *Phase2) ReadFrom(reader io.Reader) (int64, error) {
 func (c *Phase2Evaluations) WriteTo(writer io.Writer) (int64, error) {
 	enc := curve.NewEncoder(writer)
 	toEncode := []interface{}{
+		uint32(formatMarker),
+		uint32(formatVersion),
 		c.G1.A,
 		c.G1.B,
 		c.G2.B,
+		c.G1.VKK,
+		utils.IntSliceSliceToUint64SliceSlice(c.PublicAndCommitmentCommitted),
+		uint32(len(c.G1.CKK)),
+	}
+	for i := range c.G1.CKK {
+		toEncode = append(toEncode, c.G1.CKK[i])
 	}
 
 	for _, v := range toEncode {
@@ -162,20 +260,44 @@ func (c *Phase2Evaluations) WriteTo(writer io.Writer) (int64, error) {
 	return enc.BytesWritten(), nil
 }
 
-// ReadFrom implements io.ReaderFrom
+// ReadFrom implements io.ReaderFrom. It also reads the legacy format, which
+// only contains A, B and G2.B: the evaluations must then be computed again
+// with InitPhase2 to extract the keys.
 func (c *Phase2Evaluations) ReadFrom(reader io.Reader) (int64, error) {
+	reader, legacy, n, err := readFormat(reader)
+	if err != nil {
+		return n, err
+	}
 	dec := curve.NewDecoder(reader)
+	var publicAndCommitmentCommitted [][]uint64
+	var nbCommitments uint32
 	toEncode := []interface{}{
 		&c.G1.A,
 		&c.G1.B,
 		&c.G2.B,
 	}
+	if !legacy {
+		toEncode = append(toEncode, &c.G1.VKK, &publicAndCommitmentCommitted, &nbCommitments)
+	} else {
+		c.G1.VKK = nil
+	}
 
 	for _, v := range toEncode {
 		if err := dec.Decode(v); err != nil {
-			return dec.BytesRead(), err
+			return n + dec.BytesRead(), err
+		}
+	}
+	c.PublicAndCommitmentCommitted = utils.Uint64SliceSliceToIntSliceSlice(publicAndCommitmentCommitted)
+	// the number of commitments is untrusted, the keys are appended as they
+	// are read
+	c.G1.CKK = nil
+	for i := uint32(0); i < nbCommitments; i++ {
+		var ckk []curve.G1Affine
+		if err := dec.Decode(&ckk); err != nil {
+			return n + dec.BytesRead(), err
 		}
+		c.G1.CKK = append(c.G1.CKK, ckk)
 	}
 
-	return dec.BytesRead(), nil
+	return n + dec.BytesRead(), nil
 }
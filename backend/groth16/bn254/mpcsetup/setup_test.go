@@ -17,10 +17,13 @@
 package mpcsetup
 
 import (
+	"math/bits"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
 	curve "github.com/consensys/gnark-crypto/ecc/bn254"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	cs "github.com/consensys/gnark/constraint/bn254"
-	"testing"
 
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/frontend"
@@ -98,6 +101,79 @@ func TestSetupCircuit(t *testing.T) {
 	assert.NoError(err)
 }
 
+func TestSetupCircuitWithCommitment(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(curve.ID.ScalarField(), r1cs.NewBuilder, &commitmentCircuit{})
+	assert.NoError(err)
+
+	// the domain of the ceremony must match the one of the circuit
+	power := bits.Len64(ecc.NextPowerOfTwo(uint64(ccs.GetNbConstraints()))) - 1
+	srs1 := InitPhase1(power)
+	srs1.Contribute()
+
+	srs2, evals := InitPhase2(ccs.(*cs.R1CS), &srs1)
+	assert.Len(evals.G1.CKK, 1)
+	for i := 0; i < 2; i++ {
+		prev := srs2.clone()
+		srs2.Contribute()
+		assert.NoError(VerifyPhase2(&prev, &srs2))
+	}
+
+	// contribution with a commitment key not updated with σ
+	tampered := srs2.clone()
+	tampered.Contribute()
+	tampered.Parameters.G1.SigmaCKK[0][0] = srs2.Parameters.G1.SigmaCKK[0][0]
+	tampered.Hash = tampered.hash()
+	assert.Error(VerifyPhase2(&srs2, &tampered))
+
+	pk, vk := ExtractKeys(&srs1, &srs2, &evals, ccs.GetNbConstraints())
+
+	witness, err := frontend.NewWitness(&commitmentCircuit{X: [3]frontend.Variable{1, 2, 3}, Public: 4}, curve.ID.ScalarField())
+	assert.NoError(err)
+	pubWitness, err := witness.Public()
+	assert.NoError(err)
+
+	proof, err := groth16.Prove(ccs, &pk, witness)
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(proof, &vk, pubWitness))
+}
+
+func TestSeal(t *testing.T) {
+	assert := require.New(t)
+	beacon := []byte("beacon")
+
+	srs1 := InitPhase1(4)
+	srs1.Contribute()
+	sealed1 := srs1.clone()
+	assert.NoError(sealed1.Seal(beacon))
+	assert.NoError(VerifyPhase1(&srs1, &sealed1))
+
+	// sealing is deterministic
+	again := srs1.clone()
+	assert.NoError(again.Seal(beacon))
+	assert.Equal(sealed1.Hash, again.Hash)
+	again = srs1.clone()
+	assert.NoError(again.Seal([]byte("other beacon")))
+	assert.NotEqual(sealed1.Hash, again.Hash)
+
+	var myCircuit Circuit
+	ccs, err := frontend.Compile(curve.ID.ScalarField(), r1cs.NewBuilder, &myCircuit)
+	assert.NoError(err)
+	power := bits.Len64(ecc.NextPowerOfTwo(uint64(ccs.GetNbConstraints()))) - 1
+	srs1 = InitPhase1(power)
+	srs1.Contribute()
+
+	srs2, _ := InitPhase2(ccs.(*cs.R1CS), &srs1)
+	srs2.Contribute()
+	sealed2 := srs2.clone()
+	assert.NoError(sealed2.Seal(beacon))
+	assert.NoError(VerifyPhase2(&srs2, &sealed2))
+	again2 := srs2.clone()
+	assert.NoError(again2.Seal(beacon))
+	assert.Equal(sealed2.Hash, again2.Hash)
+}
+
 func BenchmarkPhase1(b *testing.B) {
 	const power = 14
 
@@ -168,6 +244,22 @@ func (circuit *Circuit) Define(api frontend.API) error {
 	return nil
 }
 
+// commitmentCircuit commits to private and public variables
+type commitmentCircuit struct {
+	X      [3]frontend.Variable
+	Public frontend.Variable `gnark:",public"`
+}
+
+func (circuit *commitmentCircuit) Define(api frontend.API) error {
+	cmt, err := api.(frontend.Committer).Commit(circuit.X[0], circuit.X[1], circuit.Public)
+	if err != nil {
+		return err
+	}
+	api.AssertIsDifferent(cmt, api.Mul(circuit.X[2], circuit.Public))
+	api.AssertIsEqual(api.Add(circuit.X[0], circuit.X[1], circuit.X[2]), 6)
+	return nil
+}
+
 func (phase1 *Phase1) clone() Phase1 {
 	r := Phase1{}
 	r.Parameters.G1.Tau = append(r.Parameters.G1.Tau, phase1.Parameters.G1.Tau...)
@@ -188,8 +280,14 @@ func (phase2 *Phase2) clone() Phase2 {
 	r.Parameters.G1.Delta = phase2.Parameters.G1.Delta
 	r.Parameters.G1.L = append(r.Parameters.G1.L, phase2.Parameters.G1.L...)
 	r.Parameters.G1.Z = append(r.Parameters.G1.Z, phase2.Parameters.G1.Z...)
+	r.Parameters.G1.SigmaCKK = make([][]curve.G1Affine, len(phase2.Parameters.G1.SigmaCKK))
+	for i := range phase2.Parameters.G1.SigmaCKK {
+		r.Parameters.G1.SigmaCKK[i] = append(r.Parameters.G1.SigmaCKK[i], phase2.Parameters.G1.SigmaCKK[i]...)
+	}
 	r.Parameters.G2.Delta = phase2.Parameters.G2.Delta
+	r.Parameters.G2.Sigma = phase2.Parameters.G2.Sigma
 	r.PublicKey = phase2.PublicKey
+	r.SigmaPublicKey = phase2.SigmaPublicKey
 	r.Hash = append(r.Hash, phase2.Hash...)
 
 	return r
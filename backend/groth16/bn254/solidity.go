@@ -2,7 +2,11 @@ package groth16
 
 import (
 	"bytes"
+	"errors"
+	"math/big"
 
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 )
 
@@ -15,7 +19,10 @@ const solidityTemplate = `
 {{- $PublicAndCommitmentCommitted := .PublicAndCommitmentCommitted }}
 // SPDX-License-Identifier: MIT
 
-pragma solidity ^0.8.0;
+pragma solidity {{ pragma }};
+{{- range imports }}
+import "{{ . }}";
+{{- end }}
 
 /// @title Groth16 verifier template.
 /// @author Remco Bloemen
@@ -23,7 +30,7 @@ pragma solidity ^0.8.0;
 /// (256 bytes) and compressed (128 bytes) format. A view function is provided
 /// to compress proofs.
 /// @notice See <https://2π.com/23/bn254-compression> for further explanation.
-contract Verifier {
+contract {{ contractName }}{{ with interfaces }} is {{ join . ", " }}{{ end }} {
 
     /// Some of the provided public input values are larger than the field modulus.
     /// @dev Public input elements are not automatically reduced, as this is can be
@@ -566,7 +573,7 @@ contract Verifier {
             {{- end }}
 
             publicCommitments[{{$i}}] = uint256(
-                sha256(
+                {{ hashToField }}(
                     abi.encodePacked(
                         commitments[{{mul $i 2}}],
                         commitments[{{sum (mul $i 2) 1}}],
@@ -713,7 +720,7 @@ contract Verifier {
         {{- end }}
 
             publicCommitments[{{$i}}] = uint256(
-                sha256(
+                {{ hashToField }}(
                     abi.encodePacked(
                         commitments[{{mul $i 2}}],
                         commitments[{{sum (mul $i 2) 1}}],
@@ -798,6 +805,95 @@ contract Verifier {
             revert ProofInvalid();
         }
     }
+
+    {{- if boolVerifier }}
+    {{- $commitmentsOffset := 0x104 }}
+    {{- $pokOffset := sum $commitmentsOffset (mul 0x40 $numCommitments) }}
+
+    /// Verify a Groth16 proof.
+    /// @notice Returns false instead of reverting if the proof is invalid or
+    /// the public input is not reduced.
+    /// @param proof the proof encoded with gnark's MarshalSolidity.
+    /// @param input the public input field elements in the scalar field Fr.
+    /// @return true if the proof is valid.
+    function Verify(bytes calldata proof, uint256[] calldata input) public view returns (bool) {
+        {{- if eq $numCommitments 0 }}
+        if (proof.length != 0x100 || input.length != {{$numWitness}}) {
+        {{- else }}
+        if (proof.length != {{ sum $pokOffset 0x40 }} || input.length != {{$numWitness}}) {
+        {{- end }}
+            return false;
+        }
+        uint256[8] memory points;
+        {{- if gt $numCommitments 0 }}
+        uint256[{{mul 2 $numCommitments}}] memory commitments;
+        uint256[2] memory commitmentPok;
+        {{- end }}
+        uint256[{{$numWitness}}] memory publicInput;
+        assembly ("memory-safe") {
+            for { let i := 0 } lt(i, 8) { i := add(i, 1) } {
+                mstore(add(points, mul(i, 0x20)), calldataload(add(proof.offset, mul(i, 0x20))))
+            }
+            {{- if gt $numCommitments 0 }}
+            // the commitments follow their number, encoded on 4 bytes
+            for { let i := 0 } lt(i, {{mul 2 $numCommitments}}) { i := add(i, 1) } {
+                mstore(add(commitments, mul(i, 0x20)), calldataload(add(proof.offset, add({{$commitmentsOffset}}, mul(i, 0x20)))))
+            }
+            mstore(commitmentPok, calldataload(add(proof.offset, {{$pokOffset}})))
+            mstore(add(commitmentPok, 0x20), calldataload(add(proof.offset, {{sum $pokOffset 0x20}})))
+            {{- end }}
+            calldatacopy(publicInput, input.offset, {{mul 0x20 $numWitness}})
+        }
+        {{- if eq $numCommitments 0 }}
+        try this.verifyProof(points, publicInput) {
+        {{- else }}
+        try this.verifyProof(points, commitments, commitmentPok, publicInput) {
+        {{- end }}
+            return true;
+        } catch {
+            return false;
+        }
+    }
+
+    /// Verify a Groth16 proof with compressed points.
+    /// @notice Returns false instead of reverting if the proof is invalid or
+    /// the public input is not reduced.
+    /// @param proof the proof encoded with gnark's MarshalSolidityCompressed.
+    /// @param input the public input field elements in the scalar field Fr.
+    /// @return true if the proof is valid.
+    function VerifyCompressed(bytes calldata proof, uint256[] calldata input) public view returns (bool) {
+        {{- if eq $numCommitments 0 }}
+        if (proof.length != 0x80 || input.length != {{$numWitness}}) {
+        {{- else }}
+        if (proof.length != {{ sum 0xa0 (mul 0x20 $numCommitments) }} || input.length != {{$numWitness}}) {
+        {{- end }}
+            return false;
+        }
+        uint256[4] memory compressedProof;
+        {{- if gt $numCommitments 0 }}
+        uint256[{{$numCommitments}}] memory compressedCommitments;
+        uint256 compressedCommitmentPok;
+        {{- end }}
+        uint256[{{$numWitness}}] memory publicInput;
+        assembly ("memory-safe") {
+            calldatacopy(compressedProof, proof.offset, 0x80)
+            {{- if gt $numCommitments 0 }}
+            calldatacopy(compressedCommitments, add(proof.offset, 0x80), {{mul 0x20 $numCommitments}})
+            compressedCommitmentPok := calldataload(add(proof.offset, {{ sum 0x80 (mul 0x20 $numCommitments) }}))
+            {{- end }}
+            calldatacopy(publicInput, input.offset, {{mul 0x20 $numWitness}})
+        }
+        {{- if eq $numCommitments 0 }}
+        try this.verifyCompressedProof(compressedProof, publicInput) {
+        {{- else }}
+        try this.verifyCompressedProof(compressedProof, compressedCommitments, compressedCommitmentPok, publicInput) {
+        {{- end }}
+            return true;
+        } catch {
+            return false;
+        }
+    }
+    {{- end }}
 }
 `
 
@@ -817,3 +913,175 @@ func (proof *Proof) MarshalSolidity() []byte {
 		return buf.Bytes()[:8*fr.Bytes]
 	}
 }
+
+// MarshalSolidityCompressed converts a proof to a byte array that can be used
+// with the VerifyCompressed function of the Solidity contract (see
+// solidity.WithBoolVerifier), or split into the arguments of
+// verifyCompressedProof.
+//
+// The points are compressed as by the compressProof function of the contract:
+// 128 bytes for Ar | Bs | Krs, followed, if the proof has commitments, by 32
+// bytes for each commitment and 32 bytes for the proof of knowledge. Unlike
+// MarshalSolidity, the number of commitments is not written. This halves the
+// size of the calldata, at the cost of decompressing the points on-chain.
+//
+// It returns an error if a point of the proof is not on the curve.
+func (proof *Proof) MarshalSolidityCompressed() ([]byte, error) {
+	res := make([]byte, 0, 4*fp.Bytes+(len(proof.Commitments)+1)*fp.Bytes)
+	res, err := appendCompressedG1Solidity(res, &proof.Ar)
+	if err != nil {
+		return nil, err
+	}
+	if res, err = appendCompressedG2Solidity(res, &proof.Bs); err != nil {
+		return nil, err
+	}
+	if res, err = appendCompressedG1Solidity(res, &proof.Krs); err != nil {
+		return nil, err
+	}
+	if len(proof.Commitments) > 0 {
+		for i := range proof.Commitments {
+			if res, err = appendCompressedG1Solidity(res, &proof.Commitments[i]); err != nil {
+				return nil, err
+			}
+		}
+		if res, err = appendCompressedG1Solidity(res, &proof.CommitmentPok); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+var (
+	// expSqrtFp is (p+1)/4, the exponent of the square roots in the contract.
+	expSqrtFp = new(big.Int).Rsh(new(big.Int).Add(fp.Modulus(), big.NewInt(1)), 2)
+
+	errNotCompressible = errors.New("point can not be compressed")
+)
+
+// curveCoefficients returns the coefficients b of the equations y² = x³ + b
+// of G1 and of the twist G2, 3 and 3/(9+i).
+func curveCoefficients() (b fp.Element, bTwist curve.E2) {
+	b.SetUint64(3)
+	bTwist.A0.SetUint64(9)
+	bTwist.A1.SetOne()
+	bTwist.Inverse(&bTwist)
+	bTwist.MulByElement(&bTwist, &b)
+	return
+}
+
+// normFp2 returns a0² + a1², the norm of a in Fp[i]/(i² + 1).
+func normFp2(a *curve.E2) *fp.Element {
+	var n, t fp.Element
+	n.Square(&a.A0)
+	t.Square(&a.A1)
+	return n.Add(&n, &t)
+}
+
+// sqrtFp returns a^((p+1)/4), which is the square root of a picked by the
+// contract if a is a square.
+func sqrtFp(a *fp.Element) (x fp.Element, ok bool) {
+	var check fp.Element
+	x.Exp(*a, expSqrtFp)
+	check.Square(&x)
+	return x, check.Equal(a)
+}
+
+// sqrtFp2 returns the square root of a picked by sqrt_Fp2 in the contract.
+func sqrtFp2(a *curve.E2, hint bool) (x curve.E2, ok bool) {
+	var t fp.Element
+	d, ok := sqrtFp(normFp2(a))
+	if !ok {
+		return x, false
+	}
+	if hint {
+		d.Neg(&d)
+	}
+	t.Add(&a.A0, &d).Halve()
+	if x.A0, ok = sqrtFp(&t); !ok || x.A0.IsZero() {
+		return x, false
+	}
+	t.Double(&x.A0).Inverse(&t)
+	x.A1.Mul(&a.A1, &t)
+	var check curve.E2
+	check.Square(&x)
+	return x, check.Equal(a)
+}
+
+// appendCompressedG1Solidity appends the point p to dst as compressed by
+// compress_g1: x << 1 | s, where s is set if y is the negation of the square
+// root picked by the contract. The point at infinity is compressed to 0. It
+// returns an error if p is not on the curve.
+func appendCompressedG1Solidity(dst []byte, p *curve.G1Affine) ([]byte, error) {
+	var c big.Int
+	if !p.IsInfinity() {
+		b, _ := curveCoefficients()
+		var y2 fp.Element
+		y2.Square(&p.X).Mul(&y2, &p.X).Add(&y2, &b)
+		y, ok := sqrtFp(&y2)
+		if !ok {
+			return nil, errNotCompressible
+		}
+		p.X.BigInt(&c)
+		c.Lsh(&c, 1)
+		if !y.Equal(&p.Y) {
+			var negY fp.Element
+			if !negY.Neg(&y).Equal(&p.Y) {
+				return nil, errNotCompressible
+			}
+			c.SetBit(&c, 0, 1)
+		}
+	}
+	return appendUint256(dst, &c), nil
+}
+
+// appendCompressedG2Solidity appends the point p to dst as compressed by
+// compress_g2: x1 followed by x0 << 2 | h << 1 | s, where h selects the square
+// root of the norm and s is set if y is the negation of the square root
+// picked by the contract. The point at infinity is compressed to (0, 0). It
+// returns an error if p is not on the twist.
+func appendCompressedG2Solidity(dst []byte, p *curve.G2Affine) ([]byte, error) {
+	var c0, c1 big.Int
+	if !p.IsInfinity() {
+		// y² = x³ + b'
+		_, bTwist := curveCoefficients()
+		var y2 curve.E2
+		y2.Square(&p.X).Mul(&y2, &p.X).Add(&y2, &bTwist)
+
+		// the hint is set if the square root of the norm doesn't lead to a square
+		d, ok := sqrtFp(normFp2(&y2))
+		if !ok {
+			return nil, errNotCompressible
+		}
+		var t fp.Element
+		t.Add(&y2.A0, &d).Halve()
+		_, isSquare := sqrtFp(&t)
+		hint := !isSquare
+
+		y, ok := sqrtFp2(&y2, hint)
+		if !ok {
+			return nil, errNotCompressible
+		}
+		p.X.A0.BigInt(&c0)
+		p.X.A1.BigInt(&c1)
+		c0.Lsh(&c0, 2)
+		if hint {
+			c0.SetBit(&c0, 1, 1)
+		}
+		if !y.Equal(&p.Y) {
+			var negY curve.E2
+			if !negY.Neg(&y).Equal(&p.Y) {
+				return nil, errNotCompressible
+			}
+			c0.SetBit(&c0, 0, 1)
+		}
+	}
+	dst = appendUint256(dst, &c1)
+	return appendUint256(dst, &c0), nil
+}
+
+// appendUint256 appends the big endian encoding of x on 32 bytes to dst.
+func appendUint256(dst []byte, x *big.Int) []byte {
+	var b [32]byte
+	x.FillBytes(b[:])
+	return append(dst, b[:]...)
+}
@@ -0,0 +1,132 @@
+package groth16
+
+import (
+	"math/big"
+	"testing"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/stretchr/testify/require"
+)
+
+// decompressG1 decompresses c as decompress_g1 in the Solidity contract.
+func decompressG1(c []byte) (p curve.G1Affine, ok bool) {
+	v := new(big.Int).SetBytes(c)
+	if v.Sign() == 0 {
+		return p, true
+	}
+	negate := v.Bit(0) == 1
+	v.Rsh(v, 1)
+	if v.Cmp(fp.Modulus()) >= 0 {
+		return p, false
+	}
+	p.X.SetBigInt(v)
+	b, _ := curveCoefficients()
+	var y2 fp.Element
+	y2.Square(&p.X).Mul(&y2, &p.X).Add(&y2, &b)
+	if p.Y, ok = sqrtFp(&y2); !ok {
+		return p, false
+	}
+	if negate {
+		p.Y.Neg(&p.Y)
+	}
+	return p, true
+}
+
+// decompressG2 decompresses (c0, c1) as decompress_g2 in the Solidity contract.
+func decompressG2(c1, c0 []byte) (p curve.G2Affine, ok bool) {
+	v0, v1 := new(big.Int).SetBytes(c0), new(big.Int).SetBytes(c1)
+	if v0.Sign() == 0 && v1.Sign() == 0 {
+		return p, true
+	}
+	negate, hint := v0.Bit(0) == 1, v0.Bit(1) == 1
+	v0.Rsh(v0, 2)
+	if v0.Cmp(fp.Modulus()) >= 0 || v1.Cmp(fp.Modulus()) >= 0 {
+		return p, false
+	}
+	p.X.A0.SetBigInt(v0)
+	p.X.A1.SetBigInt(v1)
+	_, bTwist := curveCoefficients()
+	var y2 curve.E2
+	y2.Square(&p.X).Mul(&y2, &p.X).Add(&y2, &bTwist)
+	if p.Y, ok = sqrtFp2(&y2, hint); !ok {
+		return p, false
+	}
+	if negate {
+		p.Y.Neg(&p.Y)
+	}
+	return p, true
+}
+
+func TestMarshalSolidityCompressed(t *testing.T) {
+	assert := require.New(t)
+
+	// the coefficients match the constants of the contract
+	_, bTwist := curveCoefficients()
+	assert.Equal("0x2b149d40ceb8aaae81be18991be06ac3b5b4c5e559dbefa33267e6dc24a138e5", "0x"+bTwist.A0.Text(16))
+	var f fp.Element
+	f.Neg(&bTwist.A1)
+	assert.Equal("0x2fcd3ac2a640a154eb23960892a85a68f031ca0c8344b23a577dcf1052b9e775", "0x"+f.Text(16))
+
+	_, _, g1, g2 := curve.Generators()
+	var proof Proof
+	proof.Commitments = make([]curve.G1Affine, 2)
+	for i := 0; i < 10; i++ {
+		var s fr.Element
+		var b big.Int
+		s.SetRandom()
+		proof.Ar.ScalarMultiplication(&g1, s.BigInt(&b))
+		s.SetRandom()
+		proof.Bs.ScalarMultiplication(&g2, s.BigInt(&b))
+		s.SetRandom()
+		proof.Krs.ScalarMultiplication(&g1, s.BigInt(&b))
+		s.SetRandom()
+		proof.Commitments[0].ScalarMultiplication(&g1, s.BigInt(&b))
+		s.SetRandom()
+		proof.CommitmentPok.ScalarMultiplication(&g1, s.BigInt(&b))
+		if i == 0 {
+			// the point at infinity is compressed to zero
+			proof.Commitments[1].X.SetZero()
+			proof.Commitments[1].Y.SetZero()
+		} else {
+			proof.Commitments[1].Neg(&proof.Commitments[0])
+		}
+
+		res, err := proof.MarshalSolidityCompressed()
+		assert.NoError(err)
+		assert.Len(res, 0x80+0x20*len(proof.Commitments)+0x20)
+
+		g1Points := []*curve.G1Affine{&proof.Ar, &proof.Krs, &proof.Commitments[0], &proof.Commitments[1], &proof.CommitmentPok}
+		offsets := []int{0, 0x60, 0x80, 0xa0, 0xc0}
+		for j, p := range g1Points {
+			q, ok := decompressG1(res[offsets[j] : offsets[j]+0x20])
+			assert.True(ok)
+			assert.True(q.Equal(p), "G1 point %d", j)
+		}
+		q, ok := decompressG2(res[0x20:0x40], res[0x40:0x60])
+		assert.True(ok)
+		assert.True(q.Equal(&proof.Bs))
+	}
+
+	// without commitments, only the points A, B and C are encoded
+	proof.Commitments = nil
+	res, err := proof.MarshalSolidityCompressed()
+	assert.NoError(err)
+	assert.Len(res, 0x80)
+
+	// points which are not on the curve can't be compressed
+	invalid := proof
+	invalid.Krs.Y.Double(&invalid.Krs.Y)
+	_, err = invalid.MarshalSolidityCompressed()
+	assert.Error(err)
+	invalid = proof
+	invalid.Bs.Y.A1.Double(&invalid.Bs.Y.A1)
+	_, err = invalid.MarshalSolidityCompressed()
+	assert.Error(err)
+	invalid = proof
+	invalid.Ar.X.SetOne()
+	invalid.Ar.Y.SetOne()
+	_, err = invalid.MarshalSolidityCompressed()
+	assert.Error(err)
+}
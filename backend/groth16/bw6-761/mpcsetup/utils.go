@@ -18,6 +18,7 @@ package mpcsetup
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"math/big"
 	"math/bits"
 	"runtime"
@@ -35,12 +36,18 @@ type PublicKey struct {
 }
 
 func newPublicKey(x fr.Element, challenge []byte, dst byte) PublicKey {
+	var s fr.Element
+	s.SetRandom()
+	return newPublicKeyFromSecret(x, s, challenge, dst)
+}
+
+// newPublicKeyFromSecret returns the public key of x, using s as the
+// randomness of the proof of knowledge.
+func newPublicKeyFromSecret(x, s fr.Element, challenge []byte, dst byte) PublicKey {
 	var pk PublicKey
 	_, _, g1, _ := curve.Generators()
 
-	var s fr.Element
 	var sBi big.Int
-	s.SetRandom()
 	s.BigInt(&sBi)
 	pk.SG.ScalarMultiplication(&g1, &sBi)
 
@@ -57,6 +64,26 @@ func newPublicKey(x fr.Element, challenge []byte, dst byte) PublicKey {
 	return pk
 }
 
+// beaconIterationsExp is the log₂ of the number of iterations of SHA-256
+// applied to the random beacon, so that the secrets of the last contribution
+// can't be computed quickly for many candidate beacons, as in snarkjs.
+const beaconIterationsExp = 20
+
+// beaconSecrets derives n secrets for the last contribution of a phase from
+// the hash of the state it applies to and a public random beacon.
+func beaconSecrets(hash, beacon []byte, n int) ([]fr.Element, error) {
+	h := sha256.New()
+	h.Write(hash)
+	h.Write(beacon)
+	digest := h.Sum(nil)
+	for i := 0; i < 1<<beaconIterationsExp; i++ {
+		h.Reset()
+		h.Write(digest)
+		digest = h.Sum(digest[:0])
+	}
+	return fr.Hash(digest, []byte("gnark-groth16-mpcsetup-beacon"), n)
+}
+
 func bitReverse[T any](a []T) {
 	n := uint64(len(a))
 	nn := uint64(64 - bits.TrailingZeros64(n))
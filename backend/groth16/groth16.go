@@ -20,10 +20,16 @@
 package groth16
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 
+	"github.com/blang/semver/v4"
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/export"
+	"github.com/consensys/gnark/backend/solidity"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
 	cs_bls12377 "github.com/consensys/gnark/constraint/bls12-377"
@@ -42,6 +48,7 @@ import (
 	fr_bw6633 "github.com/consensys/gnark-crypto/ecc/bw6-633/fr"
 	fr_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr"
 
+	"github.com/consensys/gnark/internal/utils"
 	gnarkio "github.com/consensys/gnark/io"
 
 	groth16_bls12377 "github.com/consensys/gnark/backend/groth16/bls12-377"
@@ -66,11 +73,16 @@ type groth16Object interface {
 // it's underlying implementation is curve specific (see gnark/internal/backend)
 type Proof interface {
 	groth16Object
+	json.Marshaler
+	json.Unmarshaler
 }
 
 // ProvingKey represents a Groth16 ProvingKey
 //
 // it's underlying implementation is strongly typed with the curve (see gnark/internal/backend)
+//
+// The implementations also implement gnarkio.Mapper, to memory-map the tables of
+// keys larger than the available memory (see the curve specific Map method).
 type ProvingKey interface {
 	groth16Object
 	gnarkio.UnsafeReaderFrom
@@ -93,6 +105,8 @@ type ProvingKey interface {
 type VerifyingKey interface {
 	groth16Object
 	gnarkio.UnsafeReaderFrom
+	json.Marshaler
+	json.Unmarshaler
 
 	// NbPublicWitness returns number of elements expected in the public witness
 	NbPublicWitness() int
@@ -103,9 +117,10 @@ type VerifyingKey interface {
 	// NbG2 returns the number of G2 elements in the VerifyingKey
 	NbG2() int
 
-	// ExportSolidity writes a solidity Verifier contract from the VerifyingKey
+	// ExportSolidity writes a solidity Verifier contract from the VerifyingKey,
+	// customized with the exportOpts (see package backend/solidity)
 	// this will return an error if not supported on the CurveID()
-	ExportSolidity(w io.Writer) error
+	ExportSolidity(w io.Writer, exportOpts ...solidity.ExportOption) error
 
 	IsDifferent(interface{}) bool
 }
@@ -161,6 +176,80 @@ func Verify(proof Proof, vk VerifyingKey, publicWitness witness.Witness, opts ..
 	}
 }
 
+// BatchVerify runs the groth16.BatchVerify algorithm on provided proofs of the
+// same circuit with given witnesses. It is faster than verifying the proofs
+// one by one, but does not tell which proof is invalid.
+func BatchVerify(proofs []Proof, vk VerifyingKey, publicWitnesses []witness.Witness, opts ...backend.VerifierOption) error {
+	switch _vk := vk.(type) {
+	case *groth16_bls12377.VerifyingKey:
+		p, w, err := batchVerifyInputs[*groth16_bls12377.Proof, fr_bls12377.Vector](proofs, publicWitnesses)
+		if err != nil {
+			return err
+		}
+		return groth16_bls12377.BatchVerify(p, _vk, w, opts...)
+	case *groth16_bls12381.VerifyingKey:
+		p, w, err := batchVerifyInputs[*groth16_bls12381.Proof, fr_bls12381.Vector](proofs, publicWitnesses)
+		if err != nil {
+			return err
+		}
+		return groth16_bls12381.BatchVerify(p, _vk, w, opts...)
+	case *groth16_bn254.VerifyingKey:
+		p, w, err := batchVerifyInputs[*groth16_bn254.Proof, fr_bn254.Vector](proofs, publicWitnesses)
+		if err != nil {
+			return err
+		}
+		return groth16_bn254.BatchVerify(p, _vk, w, opts...)
+	case *groth16_bw6761.VerifyingKey:
+		p, w, err := batchVerifyInputs[*groth16_bw6761.Proof, fr_bw6761.Vector](proofs, publicWitnesses)
+		if err != nil {
+			return err
+		}
+		return groth16_bw6761.BatchVerify(p, _vk, w, opts...)
+	case *groth16_bls24317.VerifyingKey:
+		p, w, err := batchVerifyInputs[*groth16_bls24317.Proof, fr_bls24317.Vector](proofs, publicWitnesses)
+		if err != nil {
+			return err
+		}
+		return groth16_bls24317.BatchVerify(p, _vk, w, opts...)
+	case *groth16_bls24315.VerifyingKey:
+		p, w, err := batchVerifyInputs[*groth16_bls24315.Proof, fr_bls24315.Vector](proofs, publicWitnesses)
+		if err != nil {
+			return err
+		}
+		return groth16_bls24315.BatchVerify(p, _vk, w, opts...)
+	case *groth16_bw6633.VerifyingKey:
+		p, w, err := batchVerifyInputs[*groth16_bw6633.Proof, fr_bw6633.Vector](proofs, publicWitnesses)
+		if err != nil {
+			return err
+		}
+		return groth16_bw6633.BatchVerify(p, _vk, w, opts...)
+	default:
+		panic("unrecognized R1CS curve type")
+	}
+}
+
+// batchVerifyInputs returns the proofs and the public witness vectors with
+// the concrete types of the curve.
+func batchVerifyInputs[P Proof, V any](proofs []Proof, publicWitnesses []witness.Witness) ([]P, []V, error) {
+	_proofs := make([]P, len(proofs))
+	for i := range proofs {
+		p, ok := proofs[i].(P)
+		if !ok {
+			return nil, nil, fmt.Errorf("proof %d has unexpected type %T", i, proofs[i])
+		}
+		_proofs[i] = p
+	}
+	vectors := make([]V, len(publicWitnesses))
+	for i := range publicWitnesses {
+		v, ok := publicWitnesses[i].Vector().(V)
+		if !ok {
+			return nil, nil, witness.ErrInvalidWitness
+		}
+		vectors[i] = v
+	}
+	return _proofs, vectors, nil
+}
+
 // Prove runs the groth16.Prove algorithm.
 //
 // if the force flag is set:
@@ -409,6 +498,60 @@ func NewProof(curveID ecc.ID) Proof {
 	return proof
 }
 
+// UnmarshalProofJSON decodes a Proof encoded in JSON with json.Marshal. The
+// curve is read from the header of the JSON object (see gnarkio.JSONHeader).
+func UnmarshalProofJSON(data []byte) (Proof, error) {
+	curveID, err := readJSONHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	proof := NewProof(curveID)
+	if err := json.Unmarshal(data, proof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// UnmarshalVerifyingKeyJSON decodes a VerifyingKey encoded in JSON with
+// json.Marshal. The curve is read from the header of the JSON object (see
+// gnarkio.JSONHeader).
+func UnmarshalVerifyingKeyJSON(data []byte) (VerifyingKey, error) {
+	curveID, err := readJSONHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	vk := NewVerifyingKey(curveID)
+	if err := json.Unmarshal(data, vk); err != nil {
+		return nil, err
+	}
+	return vk, nil
+}
+
+// readJSONHeader returns the curve of a Groth16 object encoded in JSON.
+func readJSONHeader(data []byte) (ecc.ID, error) {
+	curveID, backendID, err := gnarkio.ReadJSONHeader(data)
+	if err != nil {
+		return ecc.UNKNOWN, err
+	}
+	if backendID != backend.GROTH16.String() {
+		return ecc.UNKNOWN, fmt.Errorf("JSON object of backend %q, expected %s", backendID, backend.GROTH16)
+	}
+	if err = checkCurve(curveID); err != nil {
+		return ecc.UNKNOWN, err
+	}
+	return curveID, nil
+}
+
+// checkCurve returns an error if Groth16 is not implemented on the curve.
+func checkCurve(curveID ecc.ID) error {
+	switch curveID {
+	case ecc.BN254, ecc.BLS12_377, ecc.BLS12_381, ecc.BW6_761, ecc.BLS24_317, ecc.BLS24_315, ecc.BW6_633:
+		return nil
+	default:
+		return fmt.Errorf("curve %s not supported", curveID)
+	}
+}
+
 // NewCS instantiate a concrete curved-typed R1CS and return a R1CS interface
 // This method exists for (de)serialization purposes
 func NewCS(curveID ecc.ID) constraint.ConstraintSystem {
@@ -433,3 +576,179 @@ func NewCS(curveID ecc.ID) constraint.ConstraintSystem {
 	}
 	return r1cs
 }
+
+// WriteCS writes the R1CS ccs to w with its WriteTo method, in an envelope
+// which identifies its curve (see gnarkio.EnvelopeHeader). It is read back
+// with ReadCS.
+func WriteCS(w io.Writer, ccs constraint.ConstraintSystem) (int64, error) {
+	if _, ok := ccs.(constraint.R1CS); !ok {
+		return 0, errors.New("groth16 constraint system must be an R1CS")
+	}
+	curveID := utils.FieldToCurve(ccs.Field())
+	if err := checkCurve(curveID); err != nil {
+		return 0, err
+	}
+	return gnarkio.WriteEnvelope(w, gnarkio.NewEnvelopeHeader(curveID, backend.GROTH16, gnarkio.KindConstraintSystem), ccs)
+}
+
+// ReadCS reads an R1CS written by WriteCS, in the concrete type of its curve.
+func ReadCS(r io.Reader) (constraint.ConstraintSystem, error) {
+	curveID, err := readEnvelopeHeader(r, gnarkio.KindConstraintSystem)
+	if err != nil {
+		return nil, err
+	}
+	ccs := NewCS(curveID)
+	if _, err = gnarkio.ReadEnvelopePayload(r, ccs); err != nil {
+		return nil, err
+	}
+	return ccs, nil
+}
+
+// WriteProvingKey writes pk to w with its WriteTo method, in an envelope which
+// identifies its curve (see gnarkio.EnvelopeHeader). It is read back with
+// ReadProvingKey.
+func WriteProvingKey(w io.Writer, pk ProvingKey) (int64, error) {
+	return gnarkio.WriteEnvelope(w, gnarkio.NewEnvelopeHeader(pk.CurveID(), backend.GROTH16, gnarkio.KindProvingKey), pk)
+}
+
+// ReadProvingKey reads a ProvingKey written by WriteProvingKey, in the
+// concrete type of its curve.
+func ReadProvingKey(r io.Reader) (ProvingKey, error) {
+	curveID, err := readEnvelopeHeader(r, gnarkio.KindProvingKey)
+	if err != nil {
+		return nil, err
+	}
+	pk := NewProvingKey(curveID)
+	if _, err = gnarkio.ReadEnvelopePayload(r, pk); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+// ReadLegacyProvingKey reads a ProvingKey of the curve written by WriteTo in
+// the given version of gnark, from v0.8 on, and converts it to the current
+// layout. The constraint systems of these versions are migrated by their
+// ReadFrom method.
+func ReadLegacyProvingKey(r io.Reader, curveID ecc.ID, version semver.Version) (ProvingKey, error) {
+	if err := checkCurve(curveID); err != nil {
+		return nil, err
+	}
+	pk := NewProvingKey(curveID)
+	legacy, ok := pk.(interface {
+		ReadLegacyFrom(io.Reader, semver.Version) (int64, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("no migration of proving keys on curve %s", curveID)
+	}
+	if _, err := legacy.ReadLegacyFrom(r, version); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+// ReadLegacyVerifyingKey reads a VerifyingKey of the curve written by WriteTo
+// in the given version of gnark, from v0.8 on, and converts it to the current
+// layout.
+func ReadLegacyVerifyingKey(r io.Reader, curveID ecc.ID, version semver.Version) (VerifyingKey, error) {
+	if err := checkCurve(curveID); err != nil {
+		return nil, err
+	}
+	vk := NewVerifyingKey(curveID)
+	legacy, ok := vk.(interface {
+		ReadLegacyFrom(io.Reader, semver.Version) (int64, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("no migration of verifying keys on curve %s", curveID)
+	}
+	if _, err := legacy.ReadLegacyFrom(r, version); err != nil {
+		return nil, err
+	}
+	return vk, nil
+}
+
+// WriteVerifyingKey writes vk to w with its WriteTo method, in an envelope
+// which identifies its curve (see gnarkio.EnvelopeHeader). It is read back
+// with ReadVerifyingKey.
+func WriteVerifyingKey(w io.Writer, vk VerifyingKey) (int64, error) {
+	return gnarkio.WriteEnvelope(w, gnarkio.NewEnvelopeHeader(vk.CurveID(), backend.GROTH16, gnarkio.KindVerifyingKey), vk)
+}
+
+// ReadVerifyingKey reads a VerifyingKey written by WriteVerifyingKey, in the
+// concrete type of its curve.
+func ReadVerifyingKey(r io.Reader) (VerifyingKey, error) {
+	curveID, err := readEnvelopeHeader(r, gnarkio.KindVerifyingKey)
+	if err != nil {
+		return nil, err
+	}
+	vk := NewVerifyingKey(curveID)
+	if _, err = gnarkio.ReadEnvelopePayload(r, vk); err != nil {
+		return nil, err
+	}
+	return vk, nil
+}
+
+// WriteProof writes proof to w with its WriteTo method, in an envelope which
+// identifies its curve (see gnarkio.EnvelopeHeader). It is read back with
+// ReadProof.
+func WriteProof(w io.Writer, proof Proof) (int64, error) {
+	return gnarkio.WriteEnvelope(w, gnarkio.NewEnvelopeHeader(proof.CurveID(), backend.GROTH16, gnarkio.KindProof), proof)
+}
+
+// ReadProof reads a Proof written by WriteProof, in the concrete type of its
+// curve.
+func ReadProof(r io.Reader) (Proof, error) {
+	curveID, err := readEnvelopeHeader(r, gnarkio.KindProof)
+	if err != nil {
+		return nil, err
+	}
+	proof := NewProof(curveID)
+	if _, err = gnarkio.ReadEnvelopePayload(r, proof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// readEnvelopeHeader reads the header of an envelope holding a Groth16 object
+// of the kind, and returns its curve.
+func readEnvelopeHeader(r io.Reader, kind gnarkio.Kind) (ecc.ID, error) {
+	h, err := gnarkio.ReadEnvelopeHeader(r)
+	if err != nil {
+		return ecc.UNKNOWN, err
+	}
+	if err = h.Check(backend.GROTH16, kind); err != nil {
+		return ecc.UNKNOWN, err
+	}
+	if err = checkCurve(h.Curve); err != nil {
+		return ecc.UNKNOWN, err
+	}
+	return h.Curve, nil
+}
+
+// ExportVerifier writes a verifier of the proofs of vk for the target runtime,
+// customized with the exportOpts (see package backend/export). The targets
+// other than Solidity are implemented for BN254.
+func ExportVerifier(w io.Writer, vk VerifyingKey, target export.Target, exportOpts ...export.Option) error {
+	if _vk, ok := vk.(*groth16_bn254.VerifyingKey); ok {
+		return _vk.ExportVerifier(w, target, exportOpts...)
+	}
+	if target != export.Solidity {
+		return fmt.Errorf("verifier target %s not supported on %s", target, vk.CurveID())
+	}
+	cfg, err := export.NewConfig(exportOpts...)
+	if err != nil {
+		return err
+	}
+	return vk.ExportSolidity(w, cfg.SolidityOptions...)
+}
+
+// MarshalVerifier encodes the proof for a verifier exported for the target
+// with ExportVerifier.
+func MarshalVerifier(proof Proof, target export.Target) ([]byte, error) {
+	if _proof, ok := proof.(*groth16_bn254.Proof); ok {
+		return _proof.MarshalVerifier(target)
+	}
+	if _proof, ok := proof.(interface{ MarshalSolidity() []byte }); ok && target == export.Solidity {
+		return _proof.MarshalSolidity(), nil
+	}
+	return nil, fmt.Errorf("verifier target %s not supported on %s", target, proof.CurveID())
+}
@@ -1,17 +1,28 @@
 package groth16_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/consensys/gnark"
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/constraint/solver"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
+	gnarkio "github.com/consensys/gnark/io"
 	"github.com/consensys/gnark/test"
 )
 
@@ -51,10 +62,285 @@ func TestCustomHashToField(t *testing.T) {
 	}
 }
 
+func TestProverContext(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, &cancelCircuit{})
+			assert.NoError(err)
+			pk, vk, err := groth16.Setup(ccs)
+			assert.NoError(err)
+			witness, err := frontend.NewWitness(&cancelCircuit{X: 3}, curve.ScalarField())
+			assert.NoError(err)
+			assert.Run(func(assert *test.Assert) {
+				var ctx context.Context
+				ctx, cancelProving = context.WithCancel(context.Background())
+				defer cancelProving()
+				proof, err := groth16.Prove(ccs, pk, witness, backend.WithProverContext(ctx), backend.WithSolverOptions(solver.WithHints(cancelHint)))
+				assert.Error(err)
+				var cErr *backend.CancelledError
+				assert.True(errors.As(err, &cErr))
+				assert.True(errors.Is(err, context.Canceled))
+				assert.Nil(proof)
+			}, "cancelled_in_solver")
+			assert.Run(func(assert *test.Assert) {
+				ctx, cancel := context.WithTimeout(context.Background(), 0)
+				defer cancel()
+				_, err := groth16.Prove(ccs, pk, witness, backend.WithProverContext(ctx), backend.WithSolverOptions(solver.WithHints(cancelHint)))
+				assert.True(errors.Is(err, context.DeadlineExceeded))
+			}, "deadline")
+			assert.Run(func(assert *test.Assert) {
+				cancelProving = func() {}
+				proof, err := groth16.Prove(ccs, pk, witness, backend.WithProverContext(context.Background()), backend.WithSolverOptions(solver.WithHints(cancelHint)))
+				assert.NoError(err)
+				pubWitness, err := witness.Public()
+				assert.NoError(err)
+				assert.NoError(groth16.Verify(proof, vk, pubWitness))
+			}, "not_cancelled")
+		}, curve.String())
+	}
+}
+
+func TestProverContextMSM(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		assert.Run(func(assert *test.Assert) {
+			ccs, assignment := referenceCircuit(curve)
+			pk, _, err := groth16.Setup(ccs)
+			assert.NoError(err)
+			witness, err := frontend.NewWitness(assignment, curve.ScalarField())
+			assert.NoError(err)
+
+			// cancel once the quotient is computed, right before the
+			// multi-exponentiations.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var phases []backend.ProverPhase
+			onProgress := func(e backend.ProverEvent) {
+				phases = append(phases, e.Phase)
+				if e.Phase == backend.PhaseQuotient {
+					cancel()
+				}
+			}
+			before := runtime.NumGoroutine()
+			proof, err := groth16.Prove(ccs, pk, witness, backend.WithProverContext(ctx), backend.WithProverProgress(onProgress))
+			var cErr *backend.CancelledError
+			assert.True(errors.As(err, &cErr))
+			assert.True(errors.Is(err, context.Canceled))
+			assert.Nil(proof)
+			assert.NotContains(phases, backend.PhaseMSM)
+			// no task of the prover outlives it.
+			for i := 0; i < 20 && runtime.NumGoroutine() > before; i++ {
+				time.Sleep(time.Millisecond)
+			}
+			assert.LessOrEqual(runtime.NumGoroutine(), before)
+		}, curve.String())
+	}
+}
+
+func TestBatchVerify(t *testing.T) {
+	assert := test.NewAssert(t)
+	const nbProofs = 4
+	for _, curve := range getCurves() {
+		for _, circuit := range []frontend.Circuit{&batchCircuit{}, &refCircuit{nbConstraints: 1}} {
+			assert.Run(func(assert *test.Assert) {
+				ccs, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, circuit)
+				assert.NoError(err)
+				pk, vk, err := groth16.Setup(ccs)
+				assert.NoError(err)
+				proofs := make([]groth16.Proof, nbProofs)
+				pubWitnesses := make([]witness.Witness, nbProofs)
+				for i := range proofs {
+					x := i + 2
+					var assignment frontend.Circuit = &batchCircuit{X: x, Y: x * x}
+					if _, ok := circuit.(*refCircuit); ok {
+						assignment = &refCircuit{X: x, Y: x * x}
+					}
+					w, err := frontend.NewWitness(assignment, curve.ScalarField())
+					assert.NoError(err)
+					proofs[i], err = groth16.Prove(ccs, pk, w)
+					assert.NoError(err)
+					pubWitnesses[i], err = w.Public()
+					assert.NoError(err)
+				}
+				assert.NoError(groth16.BatchVerify(proofs, vk, pubWitnesses))
+				assert.NoError(groth16.BatchVerify(proofs[:1], vk, pubWitnesses[:1]))
+
+				// wrong public witness
+				swapped := []witness.Witness{pubWitnesses[1], pubWitnesses[0], pubWitnesses[2], pubWitnesses[3]}
+				assert.Error(groth16.BatchVerify(proofs, vk, swapped))
+				// wrong number of public witnesses
+				assert.Error(groth16.BatchVerify(proofs, vk, pubWitnesses[1:]))
+				assert.Error(groth16.BatchVerify(nil, vk, nil))
+			}, curve.String(), fmt.Sprintf("%T", circuit))
+		}
+	}
+}
+
+func TestProverProgress(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, &refCircuit{nbConstraints: 4})
+			assert.NoError(err)
+			pk, vk, err := groth16.Setup(ccs)
+			assert.NoError(err)
+			witness, err := frontend.NewWitness(&refCircuit{X: 2, Y: 65536}, curve.ScalarField())
+			assert.NoError(err)
+			var events []backend.ProverEvent
+			proof, err := groth16.Prove(ccs, pk, witness, backend.WithProverProgress(func(e backend.ProverEvent) {
+				events = append(events, e)
+			}))
+			assert.NoError(err)
+			pubWitness, err := witness.Public()
+			assert.NoError(err)
+			assert.NoError(groth16.Verify(proof, vk, pubWitness))
+
+			assert.Equal(3, len(events))
+			for i, phase := range []backend.ProverPhase{backend.PhaseSolve, backend.PhaseQuotient, backend.PhaseMSM} {
+				assert.Equal(phase, events[i].Phase)
+				assert.Equal(backend.GROTH16, events[i].Backend)
+				assert.Equal(curve, events[i].Curve)
+				assert.True(events[i].Duration <= events[i].Elapsed)
+				assert.NotEqual(uint64(0), events[i].HeapAlloc)
+			}
+		}, curve.String())
+	}
+}
+
 //--------------------//
 //     benches		  //
 //--------------------//
 
+func TestMappedProvingKey(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, &batchCircuit{})
+			assert.NoError(err)
+			pk, vk, err := groth16.Setup(ccs)
+			assert.NoError(err)
+
+			f, err := os.Create(filepath.Join(t.TempDir(), "pk.bin"))
+			assert.NoError(err)
+			defer f.Close()
+			assert.NoError(pk.(gnarkio.Mapper).WriteMappable(f))
+			mapped := groth16.NewProvingKey(curve)
+			closer, err := mapped.(gnarkio.Mapper).Map(f)
+			assert.NoError(err)
+			defer closer.Close()
+
+			witness, err := frontend.NewWitness(&batchCircuit{X: 3, Y: 9}, curve.ScalarField())
+			assert.NoError(err)
+			pubWitness, err := witness.Public()
+			assert.NoError(err)
+			proof, err := groth16.Prove(ccs, mapped, witness)
+			assert.NoError(err)
+			assert.NoError(groth16.Verify(proof, vk, pubWitness))
+		}, curve.String())
+	}
+}
+
+func TestJSON(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, &batchCircuit{})
+			assert.NoError(err)
+			pk, vk, err := groth16.Setup(ccs)
+			assert.NoError(err)
+			witness, err := frontend.NewWitness(&batchCircuit{X: 3, Y: 9}, curve.ScalarField())
+			assert.NoError(err)
+			pubWitness, err := witness.Public()
+			assert.NoError(err)
+			proof, err := groth16.Prove(ccs, pk, witness)
+			assert.NoError(err)
+
+			proofJSON, err := json.Marshal(proof)
+			assert.NoError(err)
+			vkJSON, err := json.Marshal(vk)
+			assert.NoError(err)
+			decodedProof, err := groth16.UnmarshalProofJSON(proofJSON)
+			assert.NoError(err)
+			decodedVK, err := groth16.UnmarshalVerifyingKeyJSON(vkJSON)
+			assert.NoError(err)
+			assert.NoError(groth16.Verify(decodedProof, decodedVK, pubWitness))
+
+			// the header is checked
+			_, err = groth16.UnmarshalProofJSON(bytes.Replace(proofJSON, []byte(`"groth16"`), []byte(`"plonk"`), 1))
+			assert.Error(err)
+			otherCurve := ecc.BN254
+			if curve == ecc.BN254 {
+				otherCurve = ecc.BLS12_381
+			}
+			assert.Error(groth16.NewProof(otherCurve).UnmarshalJSON(proofJSON))
+		}, curve.String())
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, &batchCircuit{})
+			assert.NoError(err)
+			pk, vk, err := groth16.Setup(ccs)
+			assert.NoError(err)
+
+			var buf bytes.Buffer
+			_, err = groth16.WriteCS(&buf, ccs)
+			assert.NoError(err)
+			_, err = groth16.WriteProvingKey(&buf, pk)
+			assert.NoError(err)
+			_, err = groth16.WriteVerifyingKey(&buf, vk)
+			assert.NoError(err)
+			encoded := bytes.Clone(buf.Bytes())
+
+			decodedCCS, err := groth16.ReadCS(&buf)
+			assert.NoError(err)
+			decodedPK, err := groth16.ReadProvingKey(&buf)
+			assert.NoError(err)
+			decodedVK, err := groth16.ReadVerifyingKey(&buf)
+			assert.NoError(err)
+			assert.Equal(0, buf.Len())
+
+			witness, err := frontend.NewWitness(&batchCircuit{X: 3, Y: 9}, curve.ScalarField())
+			assert.NoError(err)
+			pubWitness, err := witness.Public()
+			assert.NoError(err)
+			proof, err := groth16.Prove(decodedCCS, decodedPK, witness)
+			assert.NoError(err)
+			_, err = groth16.WriteProof(&buf, proof)
+			assert.NoError(err)
+			decodedProof, err := groth16.ReadProof(&buf)
+			assert.NoError(err)
+			assert.NoError(groth16.Verify(decodedProof, decodedVK, pubWitness))
+
+			// the kind is checked
+			_, err = groth16.ReadVerifyingKey(bytes.NewReader(encoded))
+			assert.Error(err)
+
+			// the hash is checked
+			corrupted := bytes.Clone(encoded)
+			corrupted[len(corrupted)-1] ^= 1
+			r := bytes.NewReader(corrupted)
+			_, err = groth16.ReadCS(r)
+			assert.NoError(err)
+			_, err = groth16.ReadProvingKey(r)
+			assert.NoError(err)
+			_, err = groth16.ReadVerifyingKey(r)
+			assert.Error(err)
+
+			// data written by WriteTo has no envelope
+			buf.Reset()
+			_, err = vk.WriteTo(&buf)
+			assert.NoError(err)
+			_, err = groth16.ReadVerifyingKey(&buf)
+			assert.ErrorIs(err, gnarkio.ErrNotEnveloped)
+		}, curve.String())
+	}
+}
+
 func BenchmarkSetup(b *testing.B) {
 	for _, curve := range getCurves() {
 		b.Run(curve.String(), func(b *testing.B) {
@@ -168,6 +454,51 @@ func (c *commitmentCircuit) Define(api frontend.API) error {
 	return nil
 }
 
+// cancelProving is called by cancelHint to cancel the proving while solving.
+var cancelProving context.CancelFunc
+
+func cancelHint(_ *big.Int, inputs, outputs []*big.Int) error {
+	cancelProving()
+	outputs[0].Set(inputs[0])
+	return nil
+}
+
+// batchCircuit has public inputs and a commitment to both public and private
+// inputs.
+type batchCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *batchCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(c.X, c.X), c.Y)
+	cmt, err := api.(frontend.Committer).Commit(c.X, c.Y)
+	if err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	api.AssertIsDifferent(cmt, 0)
+	return nil
+}
+
+type cancelCircuit struct {
+	X frontend.Variable
+}
+
+func (c *cancelCircuit) Define(api frontend.API) error {
+	// the second hint depends on the output of the first, so the solver checks
+	// the context after the first hint cancelled it.
+	res, err := api.Compiler().NewHint(cancelHint, 1, c.X)
+	if err != nil {
+		return err
+	}
+	res, err = api.Compiler().NewHint(cancelHint, 1, res[0])
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(res[0], c.X)
+	return nil
+}
+
 type constantHash struct{}
 
 func (h constantHash) Write(p []byte) (n int, err error) { return len(p), nil }
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"unsafe"
+)
+
+// TableAlignment is the alignment of the tables in a file written with a
+// TableWriter, relative to the start of the file.
+const TableAlignment = 4096
+
+var errInvalidTable = errors.New("invalid mapped table")
+
+// TableWriter writes tables of fixed size elements in their in-memory
+// representation, at offsets aligned to TableAlignment, so that they can be
+// memory-mapped back with a TableReader.
+type TableWriter struct {
+	w       io.Writer
+	written int64
+}
+
+// NewTableWriter returns a TableWriter writing to w. The position of the
+// tables is relative to the start of w.
+func NewTableWriter(w io.Writer) *TableWriter {
+	return &TableWriter{w: w}
+}
+
+// WriteHeader writes the length prefixed header, before any table.
+func (tw *TableWriter) WriteHeader(header []byte) error {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(len(header)))
+	if err := tw.write(buf[:]); err != nil {
+		return err
+	}
+	return tw.write(header)
+}
+
+func (tw *TableWriter) write(b []byte) error {
+	n, err := tw.w.Write(b)
+	tw.written += int64(n)
+	return err
+}
+
+// WriteTable writes the elements of s at the next aligned offset.
+func WriteTable[T any](tw *TableWriter, s []T) error {
+	if pad := -tw.written & (TableAlignment - 1); pad != 0 {
+		if err := tw.write(make([]byte, pad)); err != nil {
+			return err
+		}
+	}
+	if len(s) == 0 {
+		return nil
+	}
+	var e T
+	return tw.write(unsafe.Slice((*byte)(unsafe.Pointer(&s[0])), len(s)*int(unsafe.Sizeof(e))))
+}
+
+// TableReader reads the tables written by a TableWriter from a memory mapped
+// file. The returned slices point to the mapping: they must not be used after
+// Close, and they are read-only, writing to them faults with SIGSEGV on the
+// platforms with memory mapping.
+type TableReader struct {
+	data   []byte
+	offset int64
+	unmap  func() error
+}
+
+// NewTableReader maps f in memory. The mapping remains valid after f is
+// closed.
+func NewTableReader(f *os.File) (*TableReader, error) {
+	data, unmap, err := mapFile(f)
+	if err != nil {
+		return nil, err
+	}
+	return &TableReader{data: data, unmap: unmap}, nil
+}
+
+// ReadHeader returns the length prefixed header.
+func (tr *TableReader) ReadHeader() ([]byte, error) {
+	if len(tr.data) < 8 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	n := binary.LittleEndian.Uint64(tr.data)
+	if n > uint64(len(tr.data)-8) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	tr.offset = 8 + int64(n)
+	return tr.data[8:tr.offset], nil
+}
+
+// ReadTable returns the n elements of the table at the next aligned offset.
+func ReadTable[T any](tr *TableReader, n uint64) ([]T, error) {
+	tr.offset += -tr.offset & (TableAlignment - 1)
+	if n == 0 {
+		return make([]T, 0), nil
+	}
+	var e T
+	size := uint64(unsafe.Sizeof(e))
+	if tr.offset > int64(len(tr.data)) || n > (uint64(len(tr.data))-uint64(tr.offset))/size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	p := unsafe.Pointer(&tr.data[tr.offset])
+	if uintptr(p)%unsafe.Alignof(e) != 0 {
+		return nil, errInvalidTable
+	}
+	tr.offset += int64(n * size)
+	return unsafe.Slice((*T)(p), n), nil
+}
+
+// Close releases the mapping.
+func (tr *TableReader) Close() error {
+	tr.data = nil
+	return tr.unmap()
+}
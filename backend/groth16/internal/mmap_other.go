@@ -0,0 +1,27 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package internal
+
+import (
+	"io"
+	"os"
+	"unsafe"
+)
+
+// mapFile reads f in memory on the platforms without memory mapping.
+func mapFile(f *os.File) ([]byte, func() error, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	// allocate words so that the tables are aligned
+	words := make([]uint64, (stat.Size()+7)/8)
+	if len(words) == 0 {
+		return nil, func() error { return nil }, nil
+	}
+	data := unsafe.Slice((*byte)(unsafe.Pointer(&words[0])), stat.Size())
+	if _, err := io.ReadFull(io.NewSectionReader(f, 0, stat.Size()), data); err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
@@ -0,0 +1,26 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package internal
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps f in memory, read-only. The pages are loaded from the file on
+// demand and can be reclaimed by the system under memory pressure. The mapping
+// is shared: a write to it faults, and changes to the file are visible in it.
+func mapFile(f *os.File) ([]byte, func() error, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if stat.Size() == 0 {
+		return nil, func() error { return nil }, nil
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(stat.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
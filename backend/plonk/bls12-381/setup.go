@@ -19,6 +19,7 @@ package plonk
 import (
 	"fmt"
 	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bls12-381"
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/fft"
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/iop"
@@ -146,6 +147,16 @@ func (pk *ProvingKey) VerifyingKey() interface{} {
 	return pk.Vk
 }
 
+// CurveID returns the curveID
+func (pk *ProvingKey) CurveID() ecc.ID {
+	return curve.ID
+}
+
+// CurveID returns the curveID
+func (vk *VerifyingKey) CurveID() ecc.ID {
+	return curve.ID
+}
+
 // NewTrace returns a new Trace object from the constraint system.
 // It fills the constant columns ql, qr, qm, qo, qk, and qcp with the
 // coefficients of the constraints.
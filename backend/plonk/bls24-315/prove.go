@@ -108,6 +108,11 @@ type Proof struct {
 	ZShiftedOpening kzg.OpeningProof
 }
 
+// CurveID returns the curveID
+func (proof *Proof) CurveID() ecc.ID {
+	return curve.ID
+}
+
 func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...backend.ProverOption) (*Proof, error) {
 
 	log := logger.Logger().With().
@@ -124,7 +129,7 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness witness.Witness, opts
 	start := time.Now()
 
 	// init instance
-	g, ctx := errgroup.WithContext(context.Background())
+	g, ctx := errgroup.WithContext(opt.Context)
 	instance, err := newInstance(ctx, spr, pk, fullWitness, &opt)
 	if err != nil {
 		return nil, fmt.Errorf("new instance: %w", err)
@@ -158,6 +163,9 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness witness.Witness, opts
 	g.Go(instance.batchOpening)
 
 	if err := g.Wait(); err != nil {
+		if cErr := opt.Cancelled(); cErr != nil {
+			return nil, cErr
+		}
 		return nil, err
 	}
 
@@ -174,6 +182,8 @@ type instance struct {
 	spr   *cs.SparseR1CS
 	opt   *backend.ProverConfig
 
+	progress *backend.ProverProgress
+
 	fs             *fiatshamir.Transcript
 	kzgFoldingHash hash.Hash // for KZG folding
 	htfFunc        hash.Hash // hash to field function
@@ -223,6 +233,7 @@ func newInstance(ctx context.Context, spr *cs.SparseR1CS, pk *ProvingKey, fullWi
 		proof:                  &Proof{},
 		spr:                    spr,
 		opt:                    opts,
+		progress:               opts.NewProgress(backend.PLONK, curve.ID),
 		fullWitness:            fullWitness,
 		bp:                     make([]*iop.Polynomial, nb_blinding_polynomials),
 		fs:                     fiatshamir.NewTranscript(opts.ChallengeHash, "gamma", "beta", "alpha", "zeta"),
@@ -239,6 +250,8 @@ func newInstance(ctx context.Context, spr *cs.SparseR1CS, pk *ProvingKey, fullWi
 		chRestoreLRO:           make(chan struct{}, 1),
 	}
 	s.initBSB22Commitments()
+	// the solver is stopped when the prover is cancelled or another task fails
+	s.opt.SolverOpts = append(s.opt.SolverOpts, solver.WithContext(ctx))
 	s.x = make([]*iop.Polynomial, id_Qci+2*len(s.commitmentInfo))
 
 	// init fft domains
@@ -327,6 +340,7 @@ func (s *instance) solveConstraints() error {
 		return err
 	}
 	solution := _solution.(*cs.SparseR1CSSolution)
+	s.progress.Done(backend.PhaseSolve, s.progress.Start())
 	evaluationLDomainSmall := []fr.Element(solution.L)
 	evaluationRDomainSmall := []fr.Element(solution.R)
 	evaluationODomainSmall := []fr.Element(solution.O)
@@ -346,9 +360,11 @@ func (s *instance) solveConstraints() error {
 	wg.Wait()
 
 	// commit to l, r, o and add blinding factors
+	lroStart := time.Now()
 	if err := s.commitToLRO(); err != nil {
 		return err
 	}
+	s.progress.Done(backend.PhaseCommitLRO, lroStart)
 	close(s.chLRO)
 	return nil
 }
@@ -510,6 +526,7 @@ func (s *instance) computeQuotient() (err error) {
 		return errContextDone
 	case <-s.chZ:
 	}
+	quotientStart := time.Now()
 
 	// derive alpha
 	if err = s.deriveAlpha(); err != nil {
@@ -549,6 +566,7 @@ func (s *instance) computeQuotient() (err error) {
 		return errContextDone
 	case <-s.chRestoreLRO:
 	}
+	s.progress.Done(backend.PhaseQuotient, quotientStart)
 
 	close(s.chH)
 
@@ -562,6 +580,7 @@ func (s *instance) buildRatioCopyConstraint() (err error) {
 		return errContextDone
 	case <-s.chGammaBeta:
 	}
+	zStart := time.Now()
 
 	// TODO @gbotrel having iop.BuildRatioCopyConstraint return something
 	// with capacity = len() + 4 would avoid extra alloc / copy during openZ
@@ -583,6 +602,9 @@ func (s *instance) buildRatioCopyConstraint() (err error) {
 
 	// commit to the blinded version of z
 	s.proof.Z, err = s.commitToPolyAndBlinding(s.x[id_Z], s.bp[id_Bz])
+	if err == nil {
+		s.progress.Done(backend.PhaseCommitZ, zStart)
+	}
 
 	close(s.chZ)
 
@@ -632,6 +654,7 @@ func (s *instance) computeLinearizedPolynomial() error {
 		return errContextDone
 	case <-s.chH:
 	}
+	linearizeStart := time.Now()
 
 	qcpzeta := make([]fr.Element, len(s.commitmentInfo))
 	var blzeta, brzeta, bozeta fr.Element
@@ -690,6 +713,7 @@ func (s *instance) computeLinearizedPolynomial() error {
 	if err != nil {
 		return err
 	}
+	s.progress.Done(backend.PhaseLinearize, linearizeStart)
 	close(s.chLinearizedPolynomial)
 	return nil
 }
@@ -709,6 +733,7 @@ func (s *instance) batchOpening() error {
 		return errContextDone
 	case <-s.chLinearizedPolynomial:
 	}
+	openingStart := time.Now()
 
 	polysQcp := coefficients(s.trace.Qcp)
 	polysToOpen := make([][]fr.Element, 6+len(polysQcp))
@@ -740,8 +765,12 @@ func (s *instance) batchOpening() error {
 		s.pk.Kzg,
 		s.proof.ZShiftedOpening.ClaimedValue.Marshal(),
 	)
+	if err != nil {
+		return err
+	}
+	s.progress.Done(backend.PhaseOpening, openingStart)
 
-	return err
+	return nil
 }
 
 // evaluate the full set of constraints, all polynomials in x are back in
@@ -892,6 +921,13 @@ func (s *instance) computeNumerator() (*iop.Polynomial, error) {
 
 	for i := 0; i < rho; i++ {
 
+		// the FFTs on every coset are expensive, stop early if the context is done
+		select {
+		case <-s.ctx.Done():
+			return nil, errContextDone
+		default:
+		}
+
 		coset.Mul(&coset, &shifters[i])
 		tmp.Exp(coset, bn).Sub(&tmp, &one)
 
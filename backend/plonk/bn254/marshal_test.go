@@ -82,6 +82,8 @@ func (vk *VerifyingKey) randomize() {
 	vk.Kzg.G1 = randomG1Point()
 	vk.Kzg.G2[0] = randomG2Point()
 	vk.Kzg.G2[1] = randomG2Point()
+	vk.Kzg.Lines[0] = curve.PrecomputeLines(vk.Kzg.G2[0])
+	vk.Kzg.Lines[1] = curve.PrecomputeLines(vk.Kzg.G2[1])
 
 	vk.Ql = randomG1Point()
 	vk.Qr = randomG1Point()
@@ -18,9 +18,12 @@ const tmplSolidityVerifier = `// SPDX-License-Identifier: Apache-2.0
 
 // Code generated by gnark DO NOT EDIT
 
-pragma solidity ^0.8.19;
+pragma solidity {{ pragma }};
+{{- range imports }}
+import "{{ . }}";
+{{- end }}
 
-contract PlonkVerifier {
+contract {{ contractName }}{{ with interfaces }} is {{ join . ", " }}{{ end }} {
 
   uint256 private constant R_MOD = 21888242871839275222246405745257275088548364400416034343698204186575808495617;
   uint256 private constant R_MOD_MINUS_ONE = 21888242871839275222246405745257275088548364400416034343698204186575808495616;
@@ -167,7 +170,11 @@ contract PlonkVerifier {
   /// @param proof serialised plonk proof (using gnark's MarshalSolidity)
   /// @param public_inputs (must be reduced)
   /// @return success true if the proof passes false otherwise
+  {{- if boolVerifier }}
+  function verifyProof(bytes calldata proof, uint256[] calldata public_inputs) 
+  {{- else }}
   function Verify(bytes calldata proof, uint256[] calldata public_inputs) 
+  {{- end }}
   public view returns(bool success) {
 
     assembly {
@@ -1331,6 +1338,22 @@ contract PlonkVerifier {
       }
     }
   }
+  {{- if boolVerifier }}
+
+  /// Verify a Plonk proof.
+  /// Returns false instead of reverting if the proof or the public inputs are malformed.
+  /// @param proof serialised plonk proof (using gnark's MarshalSolidity)
+  /// @param public_inputs (must be reduced)
+  /// @return true if the proof passes false otherwise
+  function Verify(bytes calldata proof, uint256[] calldata public_inputs)
+  public view returns(bool) {
+    try this.verifyProof(proof, public_inputs) returns (bool success) {
+      return success;
+    } catch {
+      return false;
+    }
+  }
+  {{- end }}
 }
 `
 
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"math/big"
+	"strings"
 	"text/template"
 	"time"
 
@@ -35,6 +36,7 @@ import (
 	"github.com/consensys/gnark-crypto/ecc/bn254/kzg"
 	fiatshamir "github.com/consensys/gnark-crypto/fiat-shamir"
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/solidity"
 	"github.com/consensys/gnark/logger"
 )
 
@@ -52,12 +54,73 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 		return fmt.Errorf("create backend config: %w", err)
 	}
 
+	digests, openings, points, err := openingClaims(proof, vk, publicWitness, &cfg)
+	if err != nil {
+		return err
+	}
+
+	// Batch verify
+	err = kzg.BatchVerifyMultiPoints(digests, openings, points, vk.Kzg)
+
+	log.Debug().Dur("took", time.Since(start)).Msg("verifier done")
+
+	return err
+}
+
+// BatchVerify verifies the proofs of the same circuit with given VerifyingKey
+// and public witnesses.
+//
+// The algebraic relations are checked for each proof, then the KZG opening
+// proofs of all the proofs are checked together with a single pairing check.
+// If the pairing check fails, it does not tell which proof is invalid.
+func BatchVerify(proofs []*Proof, vk *VerifyingKey, publicWitnesses []fr.Vector, opts ...backend.VerifierOption) error {
+
+	log := logger.Logger().With().Str("curve", "bn254").Str("backend", "plonk").Int("nbProofs", len(proofs)).Logger()
+	start := time.Now()
+	cfg, err := backend.NewVerifierConfig(opts...)
+	if err != nil {
+		return fmt.Errorf("create backend config: %w", err)
+	}
+
+	if len(proofs) != len(publicWitnesses) {
+		return fmt.Errorf("got %d proofs but %d public witnesses", len(proofs), len(publicWitnesses))
+	}
+	if len(proofs) == 0 {
+		return errors.New("no proof to verify")
+	}
+
+	digests := make([]kzg.Digest, 0, 2*len(proofs))
+	openings := make([]kzg.OpeningProof, 0, 2*len(proofs))
+	points := make([]fr.Element, 0, 2*len(proofs))
+	for i := range proofs {
+		d, o, p, err := openingClaims(proofs[i], vk, publicWitnesses[i], &cfg)
+		if err != nil {
+			return fmt.Errorf("proof %d: %w", i, err)
+		}
+		digests = append(digests, d...)
+		openings = append(openings, o...)
+		points = append(points, p...)
+	}
+
+	// Batch verify
+	err = kzg.BatchVerifyMultiPoints(digests, openings, points, vk.Kzg)
+
+	log.Debug().Dur("took", time.Since(start)).Msg("verifier done")
+
+	return err
+}
+
+// openingClaims checks the algebraic relation of the proof and returns the
+// KZG opening claims which remain to be verified: the folded batch opening at
+// ζ and the opening of Z at ωζ.
+func openingClaims(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, cfg *backend.VerifierConfig) ([]kzg.Digest, []kzg.OpeningProof, []fr.Element, error) {
+
 	if len(proof.Bsb22Commitments) != len(vk.Qcp) {
-		return errors.New("BSB22 Commitment number mismatch")
+		return nil, nil, nil, errors.New("BSB22 Commitment number mismatch")
 	}
 
 	if len(publicWitness) != int(vk.NbPublicVariables) {
-		return errInvalidWitness
+		return nil, nil, nil, errInvalidWitness
 	}
 
 	// transcript to derive the challenge
@@ -67,17 +130,17 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 	// the coefficients of the circuit, and the public inputs.
 	// derive gamma from the Comm(blinded cl), Comm(blinded cr), Comm(blinded co)
 	if err := bindPublicData(fs, "gamma", vk, publicWitness); err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 	gamma, err := deriveRandomness(fs, "gamma", &proof.LRO[0], &proof.LRO[1], &proof.LRO[2])
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
 	// derive beta from Comm(l), Comm(r), Comm(o)
 	beta, err := deriveRandomness(fs, "beta")
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
 	// derive alpha from Com(Z), Bsb22Commitments
@@ -88,13 +151,13 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 	alphaDeps[len(alphaDeps)-1] = &proof.Z
 	alpha, err := deriveRandomness(fs, "alpha", alphaDeps...)
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
 	// derive zeta, the point of evaluation
 	zeta, err := deriveRandomness(fs, "zeta", &proof.H[0], &proof.H[1], &proof.H[2])
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
 	// evaluation of zhZeta=ζⁿ-1
@@ -195,7 +258,7 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 	// check that the opening of the linearised polynomial is equal to -constLin
 	openingLinPol := proof.BatchedProof.ClaimedValues[0]
 	if !constLin.Equal(&openingLinPol) {
-		return errAlgebraicRelation
+		return nil, nil, nil, errAlgebraicRelation
 	}
 
 	// computing the linearised polynomial digest
@@ -252,7 +315,7 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 		zh, zetaNPlusTwoZh, zetaNPlusTwoSquareZh,
 	)
 	if _, err := linearizedPolynomialDigest.MultiExp(points, scalars, ecc.MultiExpConfig{}); err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
 	// Fold the first proof
@@ -272,16 +335,16 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 		zu.Marshal(),
 	)
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
-	// Batch verify
 	var shiftedZeta fr.Element
 	shiftedZeta.Mul(&zeta, &vk.Generator)
-	err = kzg.BatchVerifyMultiPoints([]kzg.Digest{
-		foldedDigest,
-		proof.Z,
-	},
+
+	return []kzg.Digest{
+			foldedDigest,
+			proof.Z,
+		},
 		[]kzg.OpeningProof{
 			foldedProof,
 			proof.ZShiftedOpening,
@@ -290,12 +353,7 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness fr.Vector, opts ...bac
 			zeta,
 			shiftedZeta,
 		},
-		vk.Kzg,
-	)
-
-	log.Debug().Dur("took", time.Since(start)).Msg("verifier done")
-
-	return err
+		nil
 }
 
 func bindPublicData(fs *fiatshamir.Transcript, challenge string, vk *VerifyingKey, publicInputs []fr.Element) error {
@@ -366,10 +424,28 @@ func deriveRandomness(fs *fiatshamir.Transcript, challenge string, points ...*cu
 
 // ExportSolidity exports the verifying key to a solidity smart contract.
 //
+// The contract can be customized with the exportOpts, see package backend/solidity.
+// The commitments are hashed to the field with the RFC 9380 hash_to_field, which is
+// the only supported construction.
+//
 // See https://github.com/ConsenSys/gnark-tests for example usage.
 //
 // Code has not been audited and is provided as-is, we make no guarantees or warranties to its safety and reliability.
-func (vk *VerifyingKey) ExportSolidity(w io.Writer) error {
+func (vk *VerifyingKey) ExportSolidity(w io.Writer, exportOpts ...solidity.ExportOption) error {
+	cfg, err := solidity.NewExportConfig(exportOpts...)
+	if err != nil {
+		return err
+	}
+	if cfg.PragmaVersion == "" {
+		cfg.PragmaVersion = "^0.8.19"
+	}
+	if cfg.ContractName == "" {
+		cfg.ContractName = "PlonkVerifier"
+	}
+	if cfg.HashToField != solidity.HashToFieldDefault && cfg.HashToField != solidity.HashToFieldRFC9380 && len(vk.CommitmentConstraintIndexes) > 0 {
+		return fmt.Errorf("hash to field %s not supported by the solidity verifier", cfg.HashToField)
+	}
+
 	funcMap := template.FuncMap{
 		"hex": func(i int) string {
 			return fmt.Sprintf("0x%x", i)
@@ -395,6 +471,22 @@ func (vk *VerifyingKey) ExportSolidity(w io.Writer) error {
 		"add": func(i, j int) int {
 			return i + j
 		},
+		"join": strings.Join,
+		"pragma": func() string {
+			return cfg.PragmaVersion
+		},
+		"contractName": func() string {
+			return cfg.ContractName
+		},
+		"imports": func() []string {
+			return cfg.Imports
+		},
+		"interfaces": func() []string {
+			return cfg.Interfaces
+		},
+		"boolVerifier": func() bool {
+			return cfg.BoolVerifier
+		},
 	}
 
 	t, err := template.New("t").Funcs(funcMap).Parse(tmplSolidityVerifier)
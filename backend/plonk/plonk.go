@@ -20,11 +20,15 @@
 package plonk
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/kzg"
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/solidity"
 	"github.com/consensys/gnark/constraint"
 
 	"github.com/consensys/gnark/backend/witness"
@@ -60,6 +64,7 @@ import (
 	kzg_bw6633 "github.com/consensys/gnark-crypto/ecc/bw6-633/kzg"
 	kzg_bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/kzg"
 
+	"github.com/consensys/gnark/internal/utils"
 	gnarkio "github.com/consensys/gnark/io"
 )
 
@@ -70,6 +75,9 @@ type Proof interface {
 	io.WriterTo
 	io.ReaderFrom
 	gnarkio.WriterRawTo
+	json.Marshaler
+	json.Unmarshaler
+	CurveID() ecc.ID
 }
 
 // ProvingKey represents a plonk ProvingKey
@@ -81,6 +89,7 @@ type ProvingKey interface {
 	gnarkio.WriterRawTo
 	gnarkio.UnsafeReaderFrom
 	VerifyingKey() interface{}
+	CurveID() ecc.ID
 }
 
 // VerifyingKey represents a plonk VerifyingKey
@@ -91,8 +100,11 @@ type VerifyingKey interface {
 	io.ReaderFrom
 	gnarkio.WriterRawTo
 	gnarkio.UnsafeReaderFrom
+	json.Marshaler
+	json.Unmarshaler
 	NbPublicWitness() int // number of elements expected in the public witness
-	ExportSolidity(w io.Writer) error
+	ExportSolidity(w io.Writer, exportOpts ...solidity.ExportOption) error
+	CurveID() ecc.ID
 }
 
 // Setup prepares the public data associated to a circuit + public inputs.
@@ -216,6 +228,89 @@ func Verify(proof Proof, vk VerifyingKey, publicWitness witness.Witness, opts ..
 	}
 }
 
+// BatchVerify verifies PLONK proofs of the same circuit with a single pairing
+// check. It is faster than verifying the proofs one by one, but does not tell
+// which proof is invalid.
+func BatchVerify(proofs []Proof, vk VerifyingKey, publicWitnesses []witness.Witness, opts ...backend.VerifierOption) error {
+
+	switch _vk := vk.(type) {
+
+	case *plonk_bn254.VerifyingKey:
+		p, w, err := batchVerifyInputs[*plonk_bn254.Proof, fr_bn254.Vector](proofs, publicWitnesses)
+		if err != nil {
+			return err
+		}
+		return plonk_bn254.BatchVerify(p, _vk, w, opts...)
+
+	case *plonk_bls12381.VerifyingKey:
+		p, w, err := batchVerifyInputs[*plonk_bls12381.Proof, fr_bls12381.Vector](proofs, publicWitnesses)
+		if err != nil {
+			return err
+		}
+		return plonk_bls12381.BatchVerify(p, _vk, w, opts...)
+
+	case *plonk_bls12377.VerifyingKey:
+		p, w, err := batchVerifyInputs[*plonk_bls12377.Proof, fr_bls12377.Vector](proofs, publicWitnesses)
+		if err != nil {
+			return err
+		}
+		return plonk_bls12377.BatchVerify(p, _vk, w, opts...)
+
+	case *plonk_bw6761.VerifyingKey:
+		p, w, err := batchVerifyInputs[*plonk_bw6761.Proof, fr_bw6761.Vector](proofs, publicWitnesses)
+		if err != nil {
+			return err
+		}
+		return plonk_bw6761.BatchVerify(p, _vk, w, opts...)
+
+	case *plonk_bw6633.VerifyingKey:
+		p, w, err := batchVerifyInputs[*plonk_bw6633.Proof, fr_bw6633.Vector](proofs, publicWitnesses)
+		if err != nil {
+			return err
+		}
+		return plonk_bw6633.BatchVerify(p, _vk, w, opts...)
+
+	case *plonk_bls24317.VerifyingKey:
+		p, w, err := batchVerifyInputs[*plonk_bls24317.Proof, fr_bls24317.Vector](proofs, publicWitnesses)
+		if err != nil {
+			return err
+		}
+		return plonk_bls24317.BatchVerify(p, _vk, w, opts...)
+
+	case *plonk_bls24315.VerifyingKey:
+		p, w, err := batchVerifyInputs[*plonk_bls24315.Proof, fr_bls24315.Vector](proofs, publicWitnesses)
+		if err != nil {
+			return err
+		}
+		return plonk_bls24315.BatchVerify(p, _vk, w, opts...)
+
+	default:
+		panic("unrecognized SparseR1CS curve type")
+	}
+}
+
+// batchVerifyInputs returns the proofs and the public witness vectors with
+// the concrete types of the curve.
+func batchVerifyInputs[P Proof, V any](proofs []Proof, publicWitnesses []witness.Witness) ([]P, []V, error) {
+	_proofs := make([]P, len(proofs))
+	for i := range proofs {
+		p, ok := proofs[i].(P)
+		if !ok {
+			return nil, nil, fmt.Errorf("proof %d has unexpected type %T", i, proofs[i])
+		}
+		_proofs[i] = p
+	}
+	vectors := make([]V, len(publicWitnesses))
+	for i := range publicWitnesses {
+		v, ok := publicWitnesses[i].Vector().(V)
+		if !ok {
+			return nil, nil, witness.ErrInvalidWitness
+		}
+		vectors[i] = v
+	}
+	return _proofs, vectors, nil
+}
+
 // NewCS instantiate a concrete curved-typed SparseR1CS and return a ConstraintSystem interface
 // This method exists for (de)serialization purposes
 func NewCS(curveID ecc.ID) constraint.ConstraintSystem {
@@ -319,6 +414,166 @@ func NewVerifyingKey(curveID ecc.ID) VerifyingKey {
 	return vk
 }
 
+// UnmarshalProofJSON decodes a Proof encoded in JSON with json.Marshal. The
+// curve is read from the header of the JSON object (see gnarkio.JSONHeader).
+func UnmarshalProofJSON(data []byte) (Proof, error) {
+	curveID, err := readJSONHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	proof := NewProof(curveID)
+	if err := json.Unmarshal(data, proof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// UnmarshalVerifyingKeyJSON decodes a VerifyingKey encoded in JSON with
+// json.Marshal. The curve is read from the header of the JSON object (see
+// gnarkio.JSONHeader).
+func UnmarshalVerifyingKeyJSON(data []byte) (VerifyingKey, error) {
+	curveID, err := readJSONHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	vk := NewVerifyingKey(curveID)
+	if err := json.Unmarshal(data, vk); err != nil {
+		return nil, err
+	}
+	return vk, nil
+}
+
+// readJSONHeader returns the curve of a PLONK object encoded in JSON.
+func readJSONHeader(data []byte) (ecc.ID, error) {
+	curveID, backendID, err := gnarkio.ReadJSONHeader(data)
+	if err != nil {
+		return ecc.UNKNOWN, err
+	}
+	if backendID != backend.PLONK.String() {
+		return ecc.UNKNOWN, fmt.Errorf("JSON object of backend %q, expected %s", backendID, backend.PLONK)
+	}
+	if err = checkCurve(curveID); err != nil {
+		return ecc.UNKNOWN, err
+	}
+	return curveID, nil
+}
+
+// checkCurve returns an error if PLONK is not implemented on the curve.
+func checkCurve(curveID ecc.ID) error {
+	switch curveID {
+	case ecc.BN254, ecc.BLS12_377, ecc.BLS12_381, ecc.BW6_761, ecc.BLS24_317, ecc.BLS24_315, ecc.BW6_633:
+		return nil
+	default:
+		return fmt.Errorf("curve %s not supported", curveID)
+	}
+}
+
+// WriteCS writes the SparseR1CS ccs to w with its WriteTo method, in an envelope
+// which identifies its curve (see gnarkio.EnvelopeHeader). It is read back
+// with ReadCS.
+func WriteCS(w io.Writer, ccs constraint.ConstraintSystem) (int64, error) {
+	if _, ok := ccs.(constraint.SparseR1CS); !ok {
+		return 0, errors.New("plonk constraint system must be a SparseR1CS")
+	}
+	curveID := utils.FieldToCurve(ccs.Field())
+	if err := checkCurve(curveID); err != nil {
+		return 0, err
+	}
+	return gnarkio.WriteEnvelope(w, gnarkio.NewEnvelopeHeader(curveID, backend.PLONK, gnarkio.KindConstraintSystem), ccs)
+}
+
+// ReadCS reads a SparseR1CS written by WriteCS, in the concrete type of its curve.
+func ReadCS(r io.Reader) (constraint.ConstraintSystem, error) {
+	curveID, err := readEnvelopeHeader(r, gnarkio.KindConstraintSystem)
+	if err != nil {
+		return nil, err
+	}
+	ccs := NewCS(curveID)
+	if _, err = gnarkio.ReadEnvelopePayload(r, ccs); err != nil {
+		return nil, err
+	}
+	return ccs, nil
+}
+
+// WriteProvingKey writes pk to w with its WriteTo method, in an envelope which
+// identifies its curve (see gnarkio.EnvelopeHeader). It is read back with
+// ReadProvingKey.
+func WriteProvingKey(w io.Writer, pk ProvingKey) (int64, error) {
+	return gnarkio.WriteEnvelope(w, gnarkio.NewEnvelopeHeader(pk.CurveID(), backend.PLONK, gnarkio.KindProvingKey), pk)
+}
+
+// ReadProvingKey reads a ProvingKey written by WriteProvingKey, in the
+// concrete type of its curve.
+func ReadProvingKey(r io.Reader) (ProvingKey, error) {
+	curveID, err := readEnvelopeHeader(r, gnarkio.KindProvingKey)
+	if err != nil {
+		return nil, err
+	}
+	pk := NewProvingKey(curveID)
+	if _, err = gnarkio.ReadEnvelopePayload(r, pk); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+// WriteVerifyingKey writes vk to w with its WriteTo method, in an envelope
+// which identifies its curve (see gnarkio.EnvelopeHeader). It is read back
+// with ReadVerifyingKey.
+func WriteVerifyingKey(w io.Writer, vk VerifyingKey) (int64, error) {
+	return gnarkio.WriteEnvelope(w, gnarkio.NewEnvelopeHeader(vk.CurveID(), backend.PLONK, gnarkio.KindVerifyingKey), vk)
+}
+
+// ReadVerifyingKey reads a VerifyingKey written by WriteVerifyingKey, in the
+// concrete type of its curve.
+func ReadVerifyingKey(r io.Reader) (VerifyingKey, error) {
+	curveID, err := readEnvelopeHeader(r, gnarkio.KindVerifyingKey)
+	if err != nil {
+		return nil, err
+	}
+	vk := NewVerifyingKey(curveID)
+	if _, err = gnarkio.ReadEnvelopePayload(r, vk); err != nil {
+		return nil, err
+	}
+	return vk, nil
+}
+
+// WriteProof writes proof to w with its WriteTo method, in an envelope which
+// identifies its curve (see gnarkio.EnvelopeHeader). It is read back with
+// ReadProof.
+func WriteProof(w io.Writer, proof Proof) (int64, error) {
+	return gnarkio.WriteEnvelope(w, gnarkio.NewEnvelopeHeader(proof.CurveID(), backend.PLONK, gnarkio.KindProof), proof)
+}
+
+// ReadProof reads a Proof written by WriteProof, in the concrete type of its
+// curve.
+func ReadProof(r io.Reader) (Proof, error) {
+	curveID, err := readEnvelopeHeader(r, gnarkio.KindProof)
+	if err != nil {
+		return nil, err
+	}
+	proof := NewProof(curveID)
+	if _, err = gnarkio.ReadEnvelopePayload(r, proof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// readEnvelopeHeader reads the header of an envelope holding a PLONK object
+// of the kind, and returns its curve.
+func readEnvelopeHeader(r io.Reader, kind gnarkio.Kind) (ecc.ID, error) {
+	h, err := gnarkio.ReadEnvelopeHeader(r)
+	if err != nil {
+		return ecc.UNKNOWN, err
+	}
+	if err = h.Check(backend.PLONK, kind); err != nil {
+		return ecc.UNKNOWN, err
+	}
+	if err = checkCurve(h.Curve); err != nil {
+		return ecc.UNKNOWN, err
+	}
+	return h.Curve, nil
+}
+
 // SRSSize returns the required size of the kzg SRS for a given constraint system
 // Note that the SRS size in Lagrange form is a power of 2,
 // and the SRS size in canonical form need few extra elements (3) to account for the blinding factors
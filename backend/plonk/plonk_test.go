@@ -2,6 +2,9 @@ package plonk_test
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"testing"
@@ -10,8 +13,12 @@ import (
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/kzg"
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/backend/plonk"
+	plonk_bn254 "github.com/consensys/gnark/backend/plonk/bn254"
+	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/constraint/solver"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/scs"
 	"github.com/consensys/gnark/test"
@@ -108,6 +115,237 @@ func TestCustomHashToField(t *testing.T) {
 	}
 }
 
+func TestProverContext(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		curve := curve
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, &cancelCircuit{})
+			assert.NoError(err)
+			srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+			assert.NoError(err)
+			pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+			assert.NoError(err)
+			witness, err := frontend.NewWitness(&cancelCircuit{X: 3}, curve.ScalarField())
+			assert.NoError(err)
+			assert.Run(func(assert *test.Assert) {
+				var ctx context.Context
+				ctx, cancelProving = context.WithCancel(context.Background())
+				defer cancelProving()
+				proof, err := plonk.Prove(ccs, pk, witness, backend.WithProverContext(ctx), backend.WithSolverOptions(solver.WithHints(cancelHint)))
+				assert.Error(err)
+				var cErr *backend.CancelledError
+				assert.True(errors.As(err, &cErr))
+				assert.True(errors.Is(err, context.Canceled))
+				assert.Nil(proof)
+			}, "cancelled_in_solver")
+			assert.Run(func(assert *test.Assert) {
+				ctx, cancel := context.WithTimeout(context.Background(), 0)
+				defer cancel()
+				_, err := plonk.Prove(ccs, pk, witness, backend.WithProverContext(ctx), backend.WithSolverOptions(solver.WithHints(cancelHint)))
+				assert.True(errors.Is(err, context.DeadlineExceeded))
+			}, "deadline")
+			assert.Run(func(assert *test.Assert) {
+				cancelProving = func() {}
+				proof, err := plonk.Prove(ccs, pk, witness, backend.WithProverContext(context.Background()), backend.WithSolverOptions(solver.WithHints(cancelHint)))
+				assert.NoError(err)
+				pubWitness, err := witness.Public()
+				assert.NoError(err)
+				assert.NoError(plonk.Verify(proof, vk, pubWitness))
+			}, "not_cancelled")
+		}, curve.String())
+	}
+}
+
+func TestBatchVerify(t *testing.T) {
+	assert := test.NewAssert(t)
+	const nbProofs = 4
+	for _, curve := range getCurves() {
+		curve := curve
+		for _, withCommitment := range []bool{false, true} {
+			withCommitment := withCommitment
+			assert.Run(func(assert *test.Assert) {
+				circuit := &batchCircuit{withCommitment: withCommitment}
+				ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, circuit)
+				assert.NoError(err)
+				srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+				assert.NoError(err)
+				pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+				assert.NoError(err)
+				proofs := make([]plonk.Proof, nbProofs)
+				pubWitnesses := make([]witness.Witness, nbProofs)
+				for i := range proofs {
+					x := i + 2
+					w, err := frontend.NewWitness(&batchCircuit{X: x, Y: x * x}, curve.ScalarField())
+					assert.NoError(err)
+					proofs[i], err = plonk.Prove(ccs, pk, w)
+					assert.NoError(err)
+					pubWitnesses[i], err = w.Public()
+					assert.NoError(err)
+				}
+				assert.NoError(plonk.BatchVerify(proofs, vk, pubWitnesses))
+				assert.NoError(plonk.BatchVerify(proofs[:1], vk, pubWitnesses[:1]))
+
+				// wrong public witness
+				swapped := []witness.Witness{pubWitnesses[1], pubWitnesses[0], pubWitnesses[2], pubWitnesses[3]}
+				assert.Error(plonk.BatchVerify(proofs, vk, swapped))
+				// wrong number of public witnesses
+				assert.Error(plonk.BatchVerify(proofs, vk, pubWitnesses[1:]))
+				assert.Error(plonk.BatchVerify(nil, vk, nil))
+
+				// invalid opening proof, which is only caught by the pairing check
+				if p, ok := proofs[2].(*plonk_bn254.Proof); ok {
+					p.ZShiftedOpening.H = p.BatchedProof.H
+					assert.Error(plonk.BatchVerify(proofs, vk, pubWitnesses))
+				}
+			}, curve.String(), fmt.Sprintf("commitment=%t", withCommitment))
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		curve := curve
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, &batchCircuit{withCommitment: true})
+			assert.NoError(err)
+			srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+			assert.NoError(err)
+			pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+			assert.NoError(err)
+			witness, err := frontend.NewWitness(&batchCircuit{X: 3, Y: 9}, curve.ScalarField())
+			assert.NoError(err)
+			pubWitness, err := witness.Public()
+			assert.NoError(err)
+			proof, err := plonk.Prove(ccs, pk, witness)
+			assert.NoError(err)
+
+			proofJSON, err := json.Marshal(proof)
+			assert.NoError(err)
+			vkJSON, err := json.Marshal(vk)
+			assert.NoError(err)
+			decodedProof, err := plonk.UnmarshalProofJSON(proofJSON)
+			assert.NoError(err)
+			decodedVK, err := plonk.UnmarshalVerifyingKeyJSON(vkJSON)
+			assert.NoError(err)
+			assert.NoError(plonk.Verify(decodedProof, decodedVK, pubWitness))
+
+			// the header is checked
+			_, err = plonk.UnmarshalProofJSON(bytes.Replace(proofJSON, []byte(`"plonk"`), []byte(`"groth16"`), 1))
+			assert.Error(err)
+			otherCurve := ecc.BN254
+			if curve == ecc.BN254 {
+				otherCurve = ecc.BLS12_381
+			}
+			assert.Error(plonk.NewProof(otherCurve).UnmarshalJSON(proofJSON))
+		}, curve.String())
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		curve := curve
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, &batchCircuit{withCommitment: true})
+			assert.NoError(err)
+			srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+			assert.NoError(err)
+			pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+			assert.NoError(err)
+
+			var buf bytes.Buffer
+			_, err = plonk.WriteCS(&buf, ccs)
+			assert.NoError(err)
+			_, err = plonk.WriteProvingKey(&buf, pk)
+			assert.NoError(err)
+			_, err = plonk.WriteVerifyingKey(&buf, vk)
+			assert.NoError(err)
+			encoded := bytes.Clone(buf.Bytes())
+
+			decodedCCS, err := plonk.ReadCS(&buf)
+			assert.NoError(err)
+			decodedPK, err := plonk.ReadProvingKey(&buf)
+			assert.NoError(err)
+			decodedVK, err := plonk.ReadVerifyingKey(&buf)
+			assert.NoError(err)
+			assert.Equal(0, buf.Len())
+
+			witness, err := frontend.NewWitness(&batchCircuit{X: 3, Y: 9}, curve.ScalarField())
+			assert.NoError(err)
+			pubWitness, err := witness.Public()
+			assert.NoError(err)
+			proof, err := plonk.Prove(decodedCCS, decodedPK, witness)
+			assert.NoError(err)
+			_, err = plonk.WriteProof(&buf, proof)
+			assert.NoError(err)
+			decodedProof, err := plonk.ReadProof(&buf)
+			assert.NoError(err)
+			assert.NoError(plonk.Verify(decodedProof, decodedVK, pubWitness))
+
+			// the kind and the backend are checked
+			_, err = plonk.ReadProvingKey(bytes.NewReader(encoded))
+			assert.Error(err)
+			_, err = groth16.ReadCS(bytes.NewReader(encoded))
+			assert.Error(err)
+
+			// the hash is checked
+			corrupted := bytes.Clone(encoded)
+			corrupted[len(corrupted)-1] ^= 1
+			r := bytes.NewReader(corrupted)
+			_, err = plonk.ReadCS(r)
+			assert.NoError(err)
+			_, err = plonk.ReadProvingKey(r)
+			assert.NoError(err)
+			_, err = plonk.ReadVerifyingKey(r)
+			assert.Error(err)
+		}, curve.String())
+	}
+}
+
+func TestProverProgress(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, curve := range getCurves() {
+		curve := curve
+		assert.Run(func(assert *test.Assert) {
+			ccs, err := frontend.Compile(curve.ScalarField(), scs.NewBuilder, &smallCircuit{})
+			assert.NoError(err)
+			srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+			assert.NoError(err)
+			pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+			assert.NoError(err)
+			witness, err := frontend.NewWitness(&smallCircuit{X: 1}, curve.ScalarField())
+			assert.NoError(err)
+			phases := make(map[backend.ProverPhase]backend.ProverEvent)
+			proof, err := plonk.Prove(ccs, pk, witness, backend.WithProverProgress(func(e backend.ProverEvent) {
+				_, ok := phases[e.Phase]
+				assert.False(ok, "phase %s reported twice", e.Phase)
+				phases[e.Phase] = e
+			}))
+			assert.NoError(err)
+			pubWitness, err := witness.Public()
+			assert.NoError(err)
+			assert.NoError(plonk.Verify(proof, vk, pubWitness))
+
+			expected := []backend.ProverPhase{backend.PhaseSolve, backend.PhaseCommitLRO, backend.PhaseCommitZ, backend.PhaseQuotient, backend.PhaseLinearize, backend.PhaseOpening}
+			assert.Equal(len(expected), len(phases))
+			for i, phase := range expected {
+				e, ok := phases[phase]
+				assert.True(ok, "phase %s not reported", phase)
+				assert.Equal(backend.PLONK, e.Backend)
+				assert.Equal(curve, e.Curve)
+				assert.True(e.Duration <= e.Elapsed)
+				assert.NotEqual(uint64(0), e.HeapAlloc)
+				if i > 0 {
+					// the phases depend on each other
+					assert.True(phases[expected[i-1]].Elapsed <= e.Elapsed)
+				}
+			}
+		}, curve.String())
+	}
+}
+
 func TestCustomChallengeHash(t *testing.T) {
 	assert := test.NewAssert(t)
 	assignment := &smallCircuit{X: 1}
@@ -297,6 +535,28 @@ func referenceCircuit(curve ecc.ID) (constraint.ConstraintSystem, frontend.Circu
 	return ccs, &good, srs, srsLagrange
 }
 
+// batchCircuit has a public input and optionally a commitment to both public
+// and private inputs.
+type batchCircuit struct {
+	withCommitment bool
+	X              frontend.Variable
+	Y              frontend.Variable `gnark:",public"`
+}
+
+func (c *batchCircuit) Define(api frontend.API) error {
+	x2 := api.Mul(c.X, c.X)
+	api.AssertIsEqual(x2, c.Y)
+	api.AssertIsDifferent(api.Add(x2, c.X), 0)
+	if c.withCommitment {
+		cmt, err := api.(frontend.Committer).Commit(c.X, c.Y)
+		if err != nil {
+			return fmt.Errorf("commit: %w", err)
+		}
+		api.AssertIsDifferent(cmt, 0)
+	}
+	return nil
+}
+
 type commitmentCircuit struct {
 	X frontend.Variable
 }
@@ -320,6 +580,35 @@ func (c *smallCircuit) Define(api frontend.API) error {
 	return nil
 }
 
+// cancelProving is called by cancelHint to cancel the proving while solving.
+var cancelProving context.CancelFunc
+
+func cancelHint(_ *big.Int, inputs, outputs []*big.Int) error {
+	cancelProving()
+	outputs[0].Set(inputs[0])
+	return nil
+}
+
+type cancelCircuit struct {
+	X frontend.Variable
+}
+
+func (c *cancelCircuit) Define(api frontend.API) error {
+	// the second hint depends on the output of the first, so the solver checks
+	// the context after the first hint cancelled it.
+	res, err := api.Compiler().NewHint(cancelHint, 1, c.X)
+	if err != nil {
+		return err
+	}
+	res, err = api.Compiler().NewHint(cancelHint, 1, res[0])
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(res[0], c.X)
+	api.AssertIsEqual(api.Mul(res[0], res[0]), api.Mul(c.X, c.X))
+	return nil
+}
+
 type constantHash struct{}
 
 func (h constantHash) Write(p []byte) (n int, err error) { return len(p), nil }
@@ -0,0 +1,131 @@
+package backend
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// ProverPhase identifies a phase of the prover in a [ProverEvent].
+type ProverPhase uint8
+
+const (
+	// PhaseSolve is the solving of the constraint system, including the
+	// computation of the commitments to the committed wires.
+	PhaseSolve ProverPhase = iota
+	// PhaseCommitLRO is the commitment to the wire polynomials (PLONK).
+	PhaseCommitLRO
+	// PhaseCommitZ is the computation and commitment of the permutation
+	// polynomial (PLONK).
+	PhaseCommitZ
+	// PhaseQuotient is the computation of the quotient polynomial. In PLONK it
+	// includes the commitment to the quotient.
+	PhaseQuotient
+	// PhaseLinearize is the computation of the linearized polynomial (PLONK).
+	PhaseLinearize
+	// PhaseOpening is the computation of the batch opening proof (PLONK).
+	PhaseOpening
+	// PhaseMSM is the computation of the proof elements with
+	// multi-exponentiations (Groth16).
+	PhaseMSM
+)
+
+// String returns the string representation of the phase.
+func (p ProverPhase) String() string {
+	switch p {
+	case PhaseSolve:
+		return "solve"
+	case PhaseCommitLRO:
+		return "commit_lro"
+	case PhaseCommitZ:
+		return "commit_z"
+	case PhaseQuotient:
+		return "quotient"
+	case PhaseLinearize:
+		return "linearize"
+	case PhaseOpening:
+		return "opening"
+	case PhaseMSM:
+		return "msm"
+	default:
+		return "unknown"
+	}
+}
+
+// ProverEvent is reported to the callback set with [WithProverProgress] when
+// a phase of the prover is done.
+type ProverEvent struct {
+	Backend ID
+	Curve   ecc.ID
+	Phase   ProverPhase
+	// Duration is the duration of the phase. As some phases run concurrently,
+	// the durations may add up to more than the total proving time.
+	Duration time.Duration
+	// Elapsed is the time since the start of the proving.
+	Elapsed time.Duration
+	// HeapAlloc is the number of bytes of allocated heap objects at the end of
+	// the phase, see [runtime.MemStats].
+	HeapAlloc uint64
+}
+
+// WithProverProgress sets the callback which is called when a phase of the
+// prover is done. The calls are serialized and the callback should return
+// quickly as it blocks the prover. To receive the events on a channel, send
+// them from the callback.
+//
+// Reading the memory statistics stops the world, so the option adds a small
+// overhead to the prover.
+func WithProverProgress(cb func(ProverEvent)) ProverOption {
+	return func(pc *ProverConfig) error {
+		if cb == nil {
+			return errors.New("nil progress callback")
+		}
+		pc.ProgressCallback = cb
+		return nil
+	}
+}
+
+// ProverProgress reports the progress of a prover to the callback set with
+// [WithProverProgress]. It is used by the provers and is safe for concurrent
+// use. All the methods are no-ops if no callback is set.
+type ProverProgress struct {
+	mu      sync.Mutex
+	cb      func(ProverEvent)
+	backend ID
+	curve   ecc.ID
+	start   time.Time
+}
+
+// NewProgress returns a [ProverProgress] for the proving with the given
+// backend and curve starting now.
+func (cfg *ProverConfig) NewProgress(backend ID, curve ecc.ID) *ProverProgress {
+	return &ProverProgress{cb: cfg.ProgressCallback, backend: backend, curve: curve, start: time.Now()}
+}
+
+// Done reports that the phase started at phaseStart is done.
+func (p *ProverProgress) Done(phase ProverPhase, phaseStart time.Time) {
+	if p.cb == nil {
+		return
+	}
+	now := time.Now()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cb(ProverEvent{
+		Backend:   p.backend,
+		Curve:     p.curve,
+		Phase:     phase,
+		Duration:  now.Sub(phaseStart),
+		Elapsed:   now.Sub(p.start),
+		HeapAlloc: ms.HeapAlloc,
+	})
+}
+
+// Start returns the start time of the proving.
+func (p *ProverProgress) Start() time.Time {
+	return p.start
+}
@@ -0,0 +1,245 @@
+package snarkjs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/backend/witness"
+)
+
+const protocolGroth16 = "groth16"
+
+// groth16VerifyingKey is the verification_key.json of snarkjs.
+type groth16VerifyingKey struct {
+	Protocol      string       `json:"protocol"`
+	Curve         string       `json:"curve"`
+	NPublic       int          `json:"nPublic"`
+	VkAlpha1      []string     `json:"vk_alpha_1"`
+	VkBeta2       [][]string   `json:"vk_beta_2"`
+	VkGamma2      [][]string   `json:"vk_gamma_2"`
+	VkDelta2      [][]string   `json:"vk_delta_2"`
+	VkAlphabeta12 [][][]string `json:"vk_alphabeta_12"`
+	IC            [][]string   `json:"IC"`
+}
+
+// groth16Proof is the proof.json of snarkjs.
+type groth16Proof struct {
+	PiA      []string   `json:"pi_a"`
+	PiB      [][]string `json:"pi_b"`
+	PiC      []string   `json:"pi_c"`
+	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve"`
+}
+
+// WriteGroth16VerifyingKey writes vk as the verification_key.json of snarkjs.
+// vk must be a BN254 verifying key without commitments.
+func WriteGroth16VerifyingKey(w io.Writer, vk groth16.VerifyingKey) error {
+	_vk, ok := vk.(*groth16_bn254.VerifyingKey)
+	if !ok {
+		return fmt.Errorf("snarkjs: verifying key of type %T not supported", vk)
+	}
+	if len(_vk.PublicAndCommitmentCommitted) > 0 {
+		return errCommitmentsNotSupported
+	}
+	if len(_vk.G1.K) == 0 {
+		return errors.New("snarkjs: invalid verifying key")
+	}
+
+	alphaBeta, err := curve.Pair([]curve.G1Affine{_vk.G1.Alpha}, []curve.G2Affine{_vk.G2.Beta})
+	if err != nil {
+		return err
+	}
+	res := groth16VerifyingKey{
+		Protocol:      protocolGroth16,
+		Curve:         curveName,
+		NPublic:       len(_vk.G1.K) - 1,
+		VkAlpha1:      g1JSON(&_vk.G1.Alpha),
+		VkBeta2:       g2JSON(&_vk.G2.Beta),
+		VkGamma2:      g2JSON(&_vk.G2.Gamma),
+		VkDelta2:      g2JSON(&_vk.G2.Delta),
+		VkAlphabeta12: gtJSON(&alphaBeta),
+		IC:            make([][]string, len(_vk.G1.K)),
+	}
+	for i := range _vk.G1.K {
+		res.IC[i] = g1JSON(&_vk.G1.K[i])
+	}
+	return writeJSON(w, &res)
+}
+
+// ReadGroth16VerifyingKey reads a verification_key.json of snarkjs. The
+// returned key is a *groth16_bn254.VerifyingKey, ready to verify proofs.
+func ReadGroth16VerifyingKey(r io.Reader) (groth16.VerifyingKey, error) {
+	var in groth16VerifyingKey
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, err
+	}
+	if err := checkHeader(in.Protocol, in.Curve); err != nil {
+		return nil, err
+	}
+	if in.NPublic < 0 || len(in.IC) != in.NPublic+1 {
+		return nil, fmt.Errorf("snarkjs: %d points in IC for %d public inputs", len(in.IC), in.NPublic)
+	}
+
+	var (
+		vk  groth16_bn254.VerifyingKey
+		err error
+	)
+	if vk.G1.Alpha, err = parseG1(in.VkAlpha1); err != nil {
+		return nil, err
+	}
+	if vk.G2.Beta, err = parseG2(in.VkBeta2); err != nil {
+		return nil, err
+	}
+	if vk.G2.Gamma, err = parseG2(in.VkGamma2); err != nil {
+		return nil, err
+	}
+	if vk.G2.Delta, err = parseG2(in.VkDelta2); err != nil {
+		return nil, err
+	}
+	vk.G1.K = make([]curve.G1Affine, len(in.IC))
+	for i := range in.IC {
+		if vk.G1.K[i], err = parseG1(in.IC[i]); err != nil {
+			return nil, err
+		}
+	}
+	if err = vk.Precompute(); err != nil {
+		return nil, err
+	}
+
+	// vk_alphabeta_12 is redundant, it is only checked for consistency
+	if in.VkAlphabeta12 != nil {
+		alphaBeta, err := curve.Pair([]curve.G1Affine{vk.G1.Alpha}, []curve.G2Affine{vk.G2.Beta})
+		if err != nil {
+			return nil, err
+		}
+		if !equalJSON(gtJSON(&alphaBeta), in.VkAlphabeta12) {
+			return nil, errors.New("snarkjs: vk_alphabeta_12 is not e(vk_alpha_1, vk_beta_2)")
+		}
+	}
+	return &vk, nil
+}
+
+// WriteGroth16Proof writes proof as the proof.json of snarkjs. proof must be a
+// BN254 proof without commitments.
+func WriteGroth16Proof(w io.Writer, proof groth16.Proof) error {
+	_proof, ok := proof.(*groth16_bn254.Proof)
+	if !ok {
+		return fmt.Errorf("snarkjs: proof of type %T not supported", proof)
+	}
+	if len(_proof.Commitments) > 0 {
+		return errCommitmentsNotSupported
+	}
+	return writeJSON(w, &groth16Proof{
+		PiA:      g1JSON(&_proof.Ar),
+		PiB:      g2JSON(&_proof.Bs),
+		PiC:      g1JSON(&_proof.Krs),
+		Protocol: protocolGroth16,
+		Curve:    curveName,
+	})
+}
+
+// ReadGroth16Proof reads a proof.json of snarkjs. The returned proof is a
+// *groth16_bn254.Proof.
+func ReadGroth16Proof(r io.Reader) (groth16.Proof, error) {
+	var in groth16Proof
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, err
+	}
+	if err := checkHeader(in.Protocol, in.Curve); err != nil {
+		return nil, err
+	}
+
+	var (
+		proof groth16_bn254.Proof
+		err   error
+	)
+	if proof.Ar, err = parseG1(in.PiA); err != nil {
+		return nil, err
+	}
+	if proof.Bs, err = parseG2(in.PiB); err != nil {
+		return nil, err
+	}
+	if proof.Krs, err = parseG1(in.PiC); err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
+
+// WritePublicSignals writes the public part of a BN254 witness as the
+// public.json of snarkjs, an array of the public inputs in decimal.
+func WritePublicSignals(w io.Writer, publicWitness witness.Witness) error {
+	publicWitness, err := publicWitness.Public()
+	if err != nil {
+		return err
+	}
+	v, ok := publicWitness.Vector().(fr.Vector)
+	if !ok {
+		return errors.New("snarkjs: the witness is not on the scalar field of BN254")
+	}
+	res := make([]string, len(v))
+	for i := range v {
+		res[i] = frString(&v[i])
+	}
+	return writeJSON(w, res)
+}
+
+// ReadPublicSignals reads a public.json of snarkjs into a public witness,
+// which can be passed to groth16.Verify.
+func ReadPublicSignals(r io.Reader) (witness.Witness, error) {
+	var in []string
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, err
+	}
+	values := make([]fr.Element, len(in))
+	for i := range in {
+		var err error
+		if values[i], err = parseFr(in[i]); err != nil {
+			return nil, err
+		}
+	}
+	return newWitness(len(values), 0, values)
+}
+
+// newWitness returns a witness of BN254 with the given public and secret
+// values.
+func newWitness(nbPublic, nbSecret int, values []fr.Element) (witness.Witness, error) {
+	w, err := witness.New(ecc.BN254.ScalarField())
+	if err != nil {
+		return nil, err
+	}
+	chValues := make(chan any, len(values))
+	for i := range values {
+		chValues <- values[i]
+	}
+	close(chValues)
+	if err := w.Fill(nbPublic, nbSecret, chValues); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func checkHeader(protocol, curveID string) error {
+	if protocol != protocolGroth16 {
+		return fmt.Errorf("snarkjs: protocol %q not supported", protocol)
+	}
+	if curveID != curveName {
+		return fmt.Errorf("snarkjs: curve %q not supported", curveID)
+	}
+	return nil
+}
+
+func equalJSON(a, b any) bool {
+	ja, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	jb, err := json.Marshal(b)
+	return err == nil && string(ja) == string(jb)
+}
@@ -0,0 +1,150 @@
+package snarkjs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/consensys/gnark/constraint"
+	cs "github.com/consensys/gnark/constraint/bn254"
+)
+
+// sections of the .r1cs files
+const (
+	r1csHeader                 = 1
+	r1csConstraints            = 2
+	r1csWire2Label             = 3
+	r1csCustomGatesList        = 4
+	r1csCustomGatesApplication = 5
+)
+
+// r1csFileHeader is the content of the header section of a .r1cs file,
+// following the field.
+type r1csFileHeader struct {
+	NbWires, NbPublicOutputs, NbPublicInputs, NbPrivateInputs uint32
+	NbLabels                                                  uint64
+	NbConstraints                                             uint32
+}
+
+// ReadR1CS reads a constraint system compiled by circom (.r1cs).
+//
+// The wires of circom are ordered as the variables of gnark: the constant one,
+// the public outputs and the public inputs are the public variables, in this
+// order, and all the other wires are secret variables. The witness computed by
+// circom for the circuit is read by ReadWitness, and the solver of gnark only
+// checks the constraints.
+//
+// The custom gates of circom are not supported.
+func ReadR1CS(r io.Reader) (*cs.R1CS, error) {
+	sections, err := readBinFile(r, "r1cs", 1)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := sections[r1csCustomGatesList]; ok {
+		return nil, errors.New("snarkjs: custom gates are not supported")
+	}
+	if _, ok := sections[r1csCustomGatesApplication]; ok {
+		return nil, errors.New("snarkjs: custom gates are not supported")
+	}
+	headerSection, ok := sections[r1csHeader]
+	if !ok {
+		return nil, errors.New("snarkjs: missing r1cs header")
+	}
+	constraintsSection, ok := sections[r1csConstraints]
+	if !ok {
+		return nil, errors.New("snarkjs: missing r1cs constraints")
+	}
+	labelsSection, ok := sections[r1csWire2Label]
+	if !ok {
+		return nil, errors.New("snarkjs: missing r1cs wire labels")
+	}
+
+	rh := bytes.NewReader(headerSection)
+	if err := readField(rh); err != nil {
+		return nil, err
+	}
+	var header r1csFileHeader
+	if err := binary.Read(rh, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	nbPublic := uint64(header.NbPublicOutputs) + uint64(header.NbPublicInputs)
+	if 1+nbPublic+uint64(header.NbPrivateInputs) > uint64(header.NbWires) {
+		return nil, errors.New("snarkjs: invalid r1cs header")
+	}
+	// the counts are bounded by the sizes of the sections before allocating:
+	// each wire has a label on 8 bytes, and each constraint is made of three
+	// linear expressions, each starting with its number of terms on 4 bytes
+	if uint64(len(labelsSection)) != 8*uint64(header.NbWires) {
+		return nil, errors.New("snarkjs: the number of wire labels doesn't match the header")
+	}
+	if 3*4*uint64(header.NbConstraints) > uint64(len(constraintsSection)) {
+		return nil, errors.New("snarkjs: the number of constraints doesn't match the header")
+	}
+
+	ccs := cs.NewR1CS(int(header.NbConstraints))
+	ccs.AddPublicVariable("1")
+	for i := uint32(1); i < header.NbWires; i++ {
+		if uint64(i) <= nbPublic {
+			ccs.AddPublicVariable(wireName(i))
+		} else {
+			ccs.AddSecretVariable(wireName(i))
+		}
+	}
+	genericGate := ccs.AddBlueprint(&constraint.BlueprintGenericR1C{})
+
+	rc := bytes.NewReader(constraintsSection)
+	readLinearExpression := func() (constraint.LinearExpression, error) {
+		var nbTerms uint32
+		if err := binary.Read(rc, binary.LittleEndian, &nbTerms); err != nil {
+			return nil, err
+		}
+		if uint64(nbTerms) > uint64(rc.Len()) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		l := make(constraint.LinearExpression, nbTerms)
+		for i := range l {
+			var wireID uint32
+			if err := binary.Read(rc, binary.LittleEndian, &wireID); err != nil {
+				return nil, err
+			}
+			if wireID >= header.NbWires {
+				return nil, fmt.Errorf("snarkjs: wire %d out of bounds", wireID)
+			}
+			c, err := readFr(rc)
+			if err != nil {
+				return nil, err
+			}
+			var coeff constraint.Element
+			copy(coeff[:], c[:])
+			l[i] = ccs.MakeTerm(coeff, int(wireID))
+		}
+		return l, nil
+	}
+	for i := uint32(0); i < header.NbConstraints; i++ {
+		var r1c constraint.R1C
+		if r1c.L, err = readLinearExpression(); err != nil {
+			return nil, err
+		}
+		if r1c.R, err = readLinearExpression(); err != nil {
+			return nil, err
+		}
+		if r1c.O, err = readLinearExpression(); err != nil {
+			return nil, err
+		}
+		ccs.AddR1C(r1c, genericGate)
+	}
+	if rc.Len() != 0 {
+		return nil, errors.New("snarkjs: unexpected data after the r1cs constraints")
+	}
+
+	return ccs, nil
+}
+
+// wireName returns the name of the variable of a wire. The names of the
+// signals are in the .sym files of circom, which are not read.
+func wireName(wireID uint32) string {
+	return "w" + strconv.FormatUint(uint64(wireID), 10)
+}
@@ -0,0 +1,253 @@
+// Package snarkjs reads and writes the file formats of circom and snarkjs, so
+// that gnark can prove circuits compiled by circom and produce Groth16 proofs
+// verifiable by snarkjs. Only Groth16 is supported, see below for PLONK.
+//
+// The package supports BN254 (bn128 in snarkjs) and:
+//   - the constraint systems of circom (.r1cs), see ReadR1CS,
+//   - the witnesses computed by circom or snarkjs (.wtns), see ReadWitness,
+//   - the Groth16 verifying keys (verification_key.json), proofs (proof.json)
+//     and public inputs (public.json) of snarkjs, see WriteGroth16VerifyingKey,
+//     WriteGroth16Proof, WritePublicSignals and the matching readers.
+//
+// A typical flow compiles the circuit and computes the witness with circom,
+// then proves with gnark and verifies with snarkjs:
+//
+//	ccs, _ := snarkjs.ReadR1CS(r1csFile)
+//	pk, vk, _ := groth16.Setup(ccs)
+//	w, _ := snarkjs.ReadWitness(wtnsFile, ccs)
+//	proof, _ := groth16.Prove(ccs, pk, w)
+//	_ = snarkjs.WriteGroth16VerifyingKey(vkFile, vk)
+//	_ = snarkjs.WriteGroth16Proof(proofFile, proof)
+//	publicWitness, _ := w.Public()
+//	_ = snarkjs.WritePublicSignals(publicFile, publicWitness)
+//
+// The Groth16 commitments of api.Commit have no equivalent in snarkjs, and the
+// keys and proofs using them are rejected.
+//
+// PLONK is not supported, neither for verifying keys nor for proofs, in either
+// direction: the PLONK of snarkjs and the one of gnark are different
+// protocols. They differ in the Fiat-Shamir transcript (Keccak-256 in
+// snarkjs, SHA2-256 in gnark), in the split of the quotient, in the
+// linearization and in the opening proofs, and snarkjs uses the coset shifts
+// k₁ = 2, k₂ = 3 while gnark requires k₂ = k₁². A verifying key or a proof of
+// one can't be converted to the other.
+package snarkjs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// curveName is the name of BN254 in snarkjs.
+const curveName = "bn128"
+
+var errCommitmentsNotSupported = errors.New("snarkjs: commitments are not supported")
+
+// writeJSON writes v to w indented as snarkjs does.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	return enc.Encode(v)
+}
+
+// fpString returns the decimal value of x, as snarkjs writes it. Unlike
+// x.String(), it never writes negative values.
+func fpString(x *fp.Element) string {
+	var b big.Int
+	return x.BigInt(&b).String()
+}
+
+func frString(x *fr.Element) string {
+	var b big.Int
+	return x.BigInt(&b).String()
+}
+
+// parseFp parses a base field element written in decimal, rejecting unreduced
+// values.
+func parseFp(s string) (fp.Element, error) {
+	var x fp.Element
+	b, ok := new(big.Int).SetString(s, 10)
+	if !ok || b.Sign() < 0 || b.Cmp(fp.Modulus()) >= 0 {
+		return x, fmt.Errorf("snarkjs: invalid base field element %q", s)
+	}
+	x.SetBigInt(b)
+	return x, nil
+}
+
+func parseFr(s string) (fr.Element, error) {
+	var x fr.Element
+	b, ok := new(big.Int).SetString(s, 10)
+	if !ok || b.Sign() < 0 || b.Cmp(fr.Modulus()) >= 0 {
+		return x, fmt.Errorf("snarkjs: invalid scalar field element %q", s)
+	}
+	x.SetBigInt(b)
+	return x, nil
+}
+
+// g1JSON encodes p in projective coordinates [x, y, z], the point at infinity
+// being [0, 1, 0].
+func g1JSON(p *curve.G1Affine) []string {
+	if p.IsInfinity() {
+		return []string{"0", "1", "0"}
+	}
+	return []string{fpString(&p.X), fpString(&p.Y), "1"}
+}
+
+// g2JSON encodes p in projective coordinates [[x.c0, x.c1], [y.c0, y.c1],
+// [z.c0, z.c1]], the point at infinity being [[0, 0], [1, 0], [0, 0]].
+func g2JSON(p *curve.G2Affine) [][]string {
+	if p.IsInfinity() {
+		return [][]string{{"0", "0"}, {"1", "0"}, {"0", "0"}}
+	}
+	return [][]string{
+		{fpString(&p.X.A0), fpString(&p.X.A1)},
+		{fpString(&p.Y.A0), fpString(&p.Y.A1)},
+		{"1", "0"},
+	}
+}
+
+// gtJSON encodes x as [[c0.b0, c0.b1, c0.b2], [c1.b0, c1.b1, c1.b2]], each
+// coefficient in Fp2 being written [a0, a1].
+func gtJSON(x *curve.GT) [][][]string {
+	e2 := func(a0, a1 *fp.Element) []string {
+		return []string{fpString(a0), fpString(a1)}
+	}
+	return [][][]string{
+		{e2(&x.C0.B0.A0, &x.C0.B0.A1), e2(&x.C0.B1.A0, &x.C0.B1.A1), e2(&x.C0.B2.A0, &x.C0.B2.A1)},
+		{e2(&x.C1.B0.A0, &x.C1.B0.A1), e2(&x.C1.B1.A0, &x.C1.B1.A1), e2(&x.C1.B2.A0, &x.C1.B2.A1)},
+	}
+}
+
+// parseG1 decodes a point encoded with g1JSON, checking that it is in G1. The
+// projective coordinates must be normalized, as snarkjs writes them.
+func parseG1(s []string) (curve.G1Affine, error) {
+	var p curve.G1Affine
+	if len(s) != 3 {
+		return p, errors.New("snarkjs: invalid G1 point")
+	}
+	switch s[2] {
+	case "0":
+		return p, nil
+	case "1":
+	default:
+		return p, errors.New("snarkjs: G1 point not normalized")
+	}
+	var err error
+	if p.X, err = parseFp(s[0]); err != nil {
+		return p, err
+	}
+	if p.Y, err = parseFp(s[1]); err != nil {
+		return p, err
+	}
+	if !p.IsOnCurve() || !p.IsInSubGroup() {
+		return p, errors.New("snarkjs: G1 point not in the subgroup")
+	}
+	return p, nil
+}
+
+// parseG2 decodes a point encoded with g2JSON, checking that it is in G2.
+func parseG2(s [][]string) (curve.G2Affine, error) {
+	var p curve.G2Affine
+	if len(s) != 3 || len(s[0]) != 2 || len(s[1]) != 2 || len(s[2]) != 2 {
+		return p, errors.New("snarkjs: invalid G2 point")
+	}
+	switch {
+	case s[2][0] == "0" && s[2][1] == "0":
+		return p, nil
+	case s[2][0] == "1" && s[2][1] == "0":
+	default:
+		return p, errors.New("snarkjs: G2 point not normalized")
+	}
+	var err error
+	for i, c := range []*fp.Element{&p.X.A0, &p.X.A1, &p.Y.A0, &p.Y.A1} {
+		if *c, err = parseFp(s[i/2][i%2]); err != nil {
+			return p, err
+		}
+	}
+	if !p.IsOnCurve() || !p.IsInSubGroup() {
+		return p, errors.New("snarkjs: G2 point not in the subgroup")
+	}
+	return p, nil
+}
+
+// readBinFile reads a binary file of circom or snarkjs, made of a magic, a
+// version and sections, each with a type and a size, all in little endian.
+// It returns the content of the sections by type; the sections may appear in
+// any order in the file.
+func readBinFile(r io.Reader, magic string, maxVersion uint32) (map[uint32][]byte, error) {
+	var header struct {
+		Magic               [4]byte
+		Version, NbSections uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	if string(header.Magic[:]) != magic {
+		return nil, fmt.Errorf("snarkjs: not a %s file", magic)
+	}
+	if header.Version == 0 || header.Version > maxVersion {
+		return nil, fmt.Errorf("snarkjs: unsupported %s version %d", magic, header.Version)
+	}
+
+	sections := make(map[uint32][]byte, header.NbSections)
+	for i := uint32(0); i < header.NbSections; i++ {
+		var sectionHeader struct {
+			Type uint32
+			Size uint64
+		}
+		if err := binary.Read(r, binary.LittleEndian, &sectionHeader); err != nil {
+			return nil, err
+		}
+		if _, ok := sections[sectionHeader.Type]; ok {
+			return nil, fmt.Errorf("snarkjs: duplicate %s section %d", magic, sectionHeader.Type)
+		}
+		var buf bytes.Buffer
+		if _, err := io.CopyN(&buf, r, int64(sectionHeader.Size)); err != nil {
+			return nil, err
+		}
+		sections[sectionHeader.Type] = buf.Bytes()
+	}
+	return sections, nil
+}
+
+// readField reads the size in bytes of the field elements and the modulus of
+// a file header, and checks that the modulus is the one of the scalar field of
+// BN254.
+func readField(r io.Reader) error {
+	var n8 uint32
+	if err := binary.Read(r, binary.LittleEndian, &n8); err != nil {
+		return err
+	}
+	if n8 != fr.Bytes {
+		return fmt.Errorf("snarkjs: field elements of %d bytes, expected %d", n8, fr.Bytes)
+	}
+	var buf [fr.Bytes]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return err
+	}
+	for i := 0; i < fr.Bytes/2; i++ {
+		buf[i], buf[fr.Bytes-1-i] = buf[fr.Bytes-1-i], buf[i]
+	}
+	if new(big.Int).SetBytes(buf[:]).Cmp(fr.Modulus()) != 0 {
+		return errors.New("snarkjs: the field is not the scalar field of BN254")
+	}
+	return nil
+}
+
+// readFr reads a field element in little endian, rejecting unreduced values.
+func readFr(r io.Reader) (fr.Element, error) {
+	var buf [fr.Bytes]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return fr.Element{}, err
+	}
+	return fr.LittleEndian.Element(&buf)
+}
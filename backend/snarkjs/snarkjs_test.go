@@ -0,0 +1,298 @@
+package snarkjs_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/snarkjs"
+	"github.com/stretchr/testify/require"
+)
+
+// term is a term of a linear combination of a .r1cs file.
+type term struct {
+	wire  uint32
+	coeff int64
+}
+
+// binFile encodes a binary file of circom with the given sections.
+func binFile(magic string, version uint32, sections map[uint32][]byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(magic)
+	_ = binary.Write(&buf, binary.LittleEndian, version)
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(sections)))
+	// the sections are written in decreasing order, which readers must accept
+	for typ := uint32(len(sections)); typ > 0; typ-- {
+		_ = binary.Write(&buf, binary.LittleEndian, typ)
+		_ = binary.Write(&buf, binary.LittleEndian, uint64(len(sections[typ])))
+		buf.Write(sections[typ])
+	}
+	return buf.Bytes()
+}
+
+func appendFr(dst []byte, v int64) []byte {
+	var x fr.Element
+	x.SetBigInt(big.NewInt(v))
+	var b [fr.Bytes]byte
+	fr.LittleEndian.PutElement(&b, x)
+	return append(dst, b[:]...)
+}
+
+func appendField(dst []byte) []byte {
+	dst = binary.LittleEndian.AppendUint32(dst, fr.Bytes)
+	var b [fr.Bytes]byte
+	fr.Modulus().FillBytes(b[:])
+	for i := len(b) - 1; i >= 0; i-- {
+		dst = append(dst, b[i])
+	}
+	return dst
+}
+
+// mulAddR1CS is the .r1cs file of the circom circuit
+//
+//	template MulAdd() {
+//	    signal input in;
+//	    signal input a;
+//	    signal input b;
+//	    signal output out;
+//	    signal t;
+//	    t <== a * b;
+//	    out <== t + 2 * in;
+//	    out - t === 2 * in;
+//	}
+//	component main {public [in]} = MulAdd();
+//
+// with the wires one, out, in, a, b, t.
+func mulAddR1CS() []byte {
+	constraints := [][3][]term{
+		{{{3, 1}}, {{4, 1}}, {{5, 1}}},
+		{{{5, 1}, {2, 2}}, {{0, 1}}, {{1, 1}}},
+		{{{1, 1}, {5, -1}}, {{0, 1}}, {{2, 2}}},
+	}
+
+	header := appendField(nil)
+	for _, v := range []uint32{6, 1, 1, 2} {
+		header = binary.LittleEndian.AppendUint32(header, v)
+	}
+	header = binary.LittleEndian.AppendUint64(header, 6)
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(constraints)))
+
+	var content []byte
+	for _, c := range constraints {
+		for _, l := range c {
+			content = binary.LittleEndian.AppendUint32(content, uint32(len(l)))
+			for _, t := range l {
+				content = binary.LittleEndian.AppendUint32(content, t.wire)
+				content = appendFr(content, t.coeff)
+			}
+		}
+	}
+
+	var labels []byte
+	for i := uint64(0); i < 6; i++ {
+		labels = binary.LittleEndian.AppendUint64(labels, i)
+	}
+
+	return binFile("r1cs", 1, map[uint32][]byte{1: header, 2: content, 3: labels})
+}
+
+// mulAddWtns is the .wtns file of the witness of the circuit of mulAddR1CS.
+func mulAddWtns(values ...int64) []byte {
+	header := binary.LittleEndian.AppendUint32(appendField(nil), uint32(len(values)))
+	var content []byte
+	for _, v := range values {
+		content = appendFr(content, v)
+	}
+	return binFile("wtns", 2, map[uint32][]byte{1: header, 2: content})
+}
+
+func TestGroth16(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := snarkjs.ReadR1CS(bytes.NewReader(mulAddR1CS()))
+	assert.NoError(err)
+	assert.Equal(3, ccs.GetNbConstraints())
+	assert.Equal(3, ccs.GetNbPublicVariables())
+	assert.Equal(3, ccs.GetNbSecretVariables())
+
+	pk, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+
+	// a = 3, b = 5, in = 7
+	w, err := snarkjs.ReadWitness(bytes.NewReader(mulAddWtns(1, 29, 7, 3, 5, 15)), ccs)
+	assert.NoError(err)
+	proof, err := groth16.Prove(ccs, pk, w)
+	assert.NoError(err)
+	publicWitness, err := w.Public()
+	assert.NoError(err)
+
+	// export to snarkjs and back
+	var vkJSON, proofJSON, publicJSON bytes.Buffer
+	assert.NoError(snarkjs.WriteGroth16VerifyingKey(&vkJSON, vk))
+	assert.NoError(snarkjs.WriteGroth16Proof(&proofJSON, proof))
+	assert.NoError(snarkjs.WritePublicSignals(&publicJSON, w))
+
+	var public []string
+	assert.NoError(json.Unmarshal(publicJSON.Bytes(), &public))
+	assert.Equal([]string{"29", "7"}, public)
+
+	var jsonProof map[string]any
+	assert.NoError(json.Unmarshal(proofJSON.Bytes(), &jsonProof))
+	assert.Equal("groth16", jsonProof["protocol"])
+	assert.Equal("bn128", jsonProof["curve"])
+	assert.Equal("1", jsonProof["pi_a"].([]any)[2])
+
+	var jsonVK map[string]any
+	assert.NoError(json.Unmarshal(vkJSON.Bytes(), &jsonVK))
+	assert.EqualValues(2, jsonVK["nPublic"])
+	assert.Len(jsonVK["IC"], 3)
+
+	vk2, err := snarkjs.ReadGroth16VerifyingKey(bytes.NewReader(vkJSON.Bytes()))
+	assert.NoError(err)
+	proof2, err := snarkjs.ReadGroth16Proof(bytes.NewReader(proofJSON.Bytes()))
+	assert.NoError(err)
+	publicWitness2, err := snarkjs.ReadPublicSignals(bytes.NewReader(publicJSON.Bytes()))
+	assert.NoError(err)
+
+	assert.NoError(groth16.Verify(proof, vk, publicWitness))
+	assert.NoError(groth16.Verify(proof2, vk2, publicWitness2))
+	assert.NoError(groth16.Verify(proof2, vk, publicWitness))
+
+	// the output is wrong
+	publicWitness2, err = snarkjs.ReadPublicSignals(bytes.NewReader([]byte(`["30", "7"]`)))
+	assert.NoError(err)
+	assert.Error(groth16.Verify(proof2, vk2, publicWitness2))
+
+	// an inconsistent vk_alphabeta_12 is rejected
+	jsonVK["vk_alphabeta_12"].([]any)[0].([]any)[0].([]any)[0] = "1"
+	b, err := json.Marshal(jsonVK)
+	assert.NoError(err)
+	_, err = snarkjs.ReadGroth16VerifyingKey(bytes.NewReader(b))
+	assert.Error(err)
+}
+
+func TestReadWitness(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := snarkjs.ReadR1CS(bytes.NewReader(mulAddR1CS()))
+	assert.NoError(err)
+
+	// the witness doesn't satisfy the constraints
+	w, err := snarkjs.ReadWitness(bytes.NewReader(mulAddWtns(1, 30, 7, 3, 5, 15)), ccs)
+	assert.NoError(err)
+	assert.Error(ccs.IsSolved(w))
+
+	// wrong number of values
+	_, err = snarkjs.ReadWitness(bytes.NewReader(mulAddWtns(1, 29, 7, 3, 5)), ccs)
+	assert.Error(err)
+
+	// the first value must be one
+	_, err = snarkjs.ReadWitness(bytes.NewReader(mulAddWtns(2, 29, 7, 3, 5, 15)), ccs)
+	assert.Error(err)
+
+	// not a .wtns file
+	_, err = snarkjs.ReadWitness(bytes.NewReader(mulAddR1CS()), ccs)
+	assert.Error(err)
+}
+
+func TestReadR1CSBounds(t *testing.T) {
+	assert := require.New(t)
+
+	// the header section is written last, and ends with the number of wires,
+	// the counts of the inputs and outputs, the number of labels on 8 bytes and
+	// the number of constraints
+	nbWiresOffset, nbConstraintsOffset := 4*4+8+4, 4
+
+	b := mulAddR1CS()
+	binary.LittleEndian.PutUint32(b[len(b)-nbConstraintsOffset:], 1<<31)
+	_, err := snarkjs.ReadR1CS(bytes.NewReader(b))
+	assert.Error(err, "more constraints than the section holds")
+
+	b = mulAddR1CS()
+	binary.LittleEndian.PutUint32(b[len(b)-nbWiresOffset:], 1<<31)
+	_, err = snarkjs.ReadR1CS(bytes.NewReader(b))
+	assert.Error(err, "more wires than labels")
+
+	b = mulAddR1CS()
+	binary.LittleEndian.PutUint32(b[len(b)-nbConstraintsOffset:], 2)
+	_, err = snarkjs.ReadR1CS(bytes.NewReader(b))
+	assert.Error(err, "fewer constraints than the section holds")
+}
+
+// TestFixtures checks the interoperability with the files of circom and snarkjs
+// generated by testdata/mul_add/generate.sh. The fixtures are not committed,
+// the CI generates them with the pinned versions of circom and snarkjs before
+// running the test.
+func TestFixtures(t *testing.T) {
+	assert := require.New(t)
+	dir := filepath.Join("testdata", "mul_add")
+	if _, err := os.Stat(filepath.Join(dir, "proof.json")); os.IsNotExist(err) {
+		t.Skip("the fixtures are not generated, see testdata/mul_add/generate.sh")
+	}
+	open := func(name string) *os.File {
+		f, err := os.Open(filepath.Join(dir, name))
+		assert.NoError(err)
+		t.Cleanup(func() { f.Close() })
+		return f
+	}
+
+	// a proof of snarkjs is verified by gnark
+	vk, err := snarkjs.ReadGroth16VerifyingKey(open("verification_key.json"))
+	assert.NoError(err)
+	proof, err := snarkjs.ReadGroth16Proof(open("proof.json"))
+	assert.NoError(err)
+	publicWitness, err := snarkjs.ReadPublicSignals(open("public.json"))
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(proof, vk, publicWitness))
+
+	// a circuit and a witness of circom are proved by gnark
+	ccs, err := snarkjs.ReadR1CS(open("mul_add.r1cs"))
+	assert.NoError(err)
+	w, err := snarkjs.ReadWitness(open("mul_add.wtns"), ccs)
+	assert.NoError(err)
+	assert.NoError(ccs.IsSolved(w))
+	pk, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+	proof, err = groth16.Prove(ccs, pk, w)
+	assert.NoError(err)
+	publicWitness, err = w.Public()
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(proof, vk, publicWitness))
+
+	// the public signals of the witness are the ones of snarkjs
+	var publicJSON bytes.Buffer
+	assert.NoError(snarkjs.WritePublicSignals(&publicJSON, w))
+	var expected, public []string
+	b, err := os.ReadFile(filepath.Join(dir, "public.json"))
+	assert.NoError(err)
+	assert.NoError(json.Unmarshal(b, &expected))
+	assert.NoError(json.Unmarshal(publicJSON.Bytes(), &public))
+	assert.Equal(expected, public)
+
+	// the proof of gnark is verified by snarkjs, if installed
+	if _, err := exec.LookPath("snarkjs"); err != nil {
+		t.Log("snarkjs not found, skipping the verification by snarkjs")
+		return
+	}
+	tmp := t.TempDir()
+	create := func(name string) *os.File {
+		f, err := os.Create(filepath.Join(tmp, name))
+		assert.NoError(err)
+		t.Cleanup(func() { f.Close() })
+		return f
+	}
+	assert.NoError(snarkjs.WriteGroth16VerifyingKey(create("verification_key.json"), vk))
+	assert.NoError(snarkjs.WriteGroth16Proof(create("proof.json"), proof))
+	assert.NoError(snarkjs.WritePublicSignals(create("public.json"), w))
+	cmd := exec.Command("snarkjs", "groth16", "verify", "verification_key.json", "public.json", "proof.json")
+	cmd.Dir = tmp
+	out, err := cmd.CombinedOutput()
+	assert.NoError(err, string(out))
+}
@@ -0,0 +1,25 @@
+#!/bin/sh
+# Generates the fixtures of TestFixtures with circom and snarkjs, pinned to:
+#
+#   cargo install --locked --git https://github.com/iden3/circom.git --tag v2.1.9 circom
+#   npm install -g snarkjs@0.7.4
+#
+# Run from this directory. It writes mul_add.r1cs and mul_add.wtns (circom),
+# and verification_key.json, proof.json and public.json (snarkjs Groth16).
+set -eu
+
+build=$(mktemp -d)
+trap 'rm -rf "$build"' EXIT
+
+circom mul_add.circom --r1cs --wasm -o "$build"
+cp "$build/mul_add.r1cs" .
+node "$build/mul_add_js/generate_witness.js" "$build/mul_add_js/mul_add.wasm" input.json mul_add.wtns
+
+snarkjs powersoftau new bn128 4 "$build/pot_0.ptau"
+snarkjs powersoftau contribute "$build/pot_0.ptau" "$build/pot_1.ptau" --name=fixture -e=gnark
+snarkjs powersoftau prepare phase2 "$build/pot_1.ptau" "$build/pot.ptau"
+snarkjs groth16 setup mul_add.r1cs "$build/pot.ptau" "$build/mul_add_0.zkey"
+snarkjs zkey contribute "$build/mul_add_0.zkey" "$build/mul_add.zkey" --name=fixture -e=gnark
+snarkjs zkey export verificationkey "$build/mul_add.zkey" verification_key.json
+snarkjs groth16 prove "$build/mul_add.zkey" mul_add.wtns proof.json public.json
+snarkjs groth16 verify verification_key.json public.json proof.json
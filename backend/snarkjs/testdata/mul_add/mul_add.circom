@@ -0,0 +1,14 @@
+pragma circom 2.1.9;
+
+template MulAdd() {
+    signal input in;
+    signal input a;
+    signal input b;
+    signal output out;
+    signal t;
+    t <== a * b;
+    out <== t + 2 * in;
+    out - t === 2 * in;
+}
+
+component main {public [in]} = MulAdd();
@@ -0,0 +1,73 @@
+package snarkjs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+)
+
+// sections of the .wtns files
+const (
+	wtnsHeader = 1
+	wtnsValues = 2
+)
+
+// ReadWitness reads a witness computed by circom or snarkjs (.wtns) for the
+// constraint system ccs, read by ReadR1CS. The witness holds the values of all
+// the wires of the circuit but the constant one.
+func ReadWitness(r io.Reader, ccs constraint.ConstraintSystem) (witness.Witness, error) {
+	if ccs.Field().Cmp(fr.Modulus()) != 0 {
+		return nil, errors.New("snarkjs: the constraint system is not on the scalar field of BN254")
+	}
+	sections, err := readBinFile(r, "wtns", 2)
+	if err != nil {
+		return nil, err
+	}
+	headerSection, ok := sections[wtnsHeader]
+	if !ok {
+		return nil, errors.New("snarkjs: missing wtns header")
+	}
+	valuesSection, ok := sections[wtnsValues]
+	if !ok {
+		return nil, errors.New("snarkjs: missing wtns values")
+	}
+
+	rh := bytes.NewReader(headerSection)
+	if err := readField(rh); err != nil {
+		return nil, err
+	}
+	var nbValues uint32
+	if err := binary.Read(rh, binary.LittleEndian, &nbValues); err != nil {
+		return nil, err
+	}
+	nbPublic := ccs.GetNbPublicVariables() - 1
+	nbSecret := ccs.GetNbSecretVariables()
+	if int(nbValues) != 1+nbPublic+nbSecret {
+		return nil, fmt.Errorf("snarkjs: %d values in the witness, expected %d", nbValues, 1+nbPublic+nbSecret)
+	}
+	if len(valuesSection) != int(nbValues)*fr.Bytes {
+		return nil, errors.New("snarkjs: invalid wtns values")
+	}
+
+	rv := bytes.NewReader(valuesSection)
+	one, err := readFr(rv)
+	if err != nil {
+		return nil, err
+	}
+	if !one.IsOne() {
+		return nil, errors.New("snarkjs: the first value of the witness is not one")
+	}
+	values := make([]fr.Element, nbValues-1)
+	for i := range values {
+		if values[i], err = readFr(rv); err != nil {
+			return nil, err
+		}
+	}
+	return newWitness(nbPublic, nbSecret, values)
+}
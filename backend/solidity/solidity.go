@@ -0,0 +1,171 @@
+// Package solidity defines the options of the Solidity verifier exporters of
+// the backends (see the ExportSolidity methods of the verifying keys).
+package solidity
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// HashToField identifies the hash function the verifier contract uses to map
+// the BSB22 commitments to field elements. It must match the hash function
+// used by the prover (see [backend.WithProverHashToFieldFunction]).
+//
+// [backend.WithProverHashToFieldFunction]: https://pkg.go.dev/github.com/consensys/gnark/backend#WithProverHashToFieldFunction
+type HashToField uint8
+
+const (
+	// HashToFieldDefault is the default of the backend: HashToFieldSHA256 for
+	// Groth16 and HashToFieldRFC9380 for PLONK.
+	HashToFieldDefault HashToField = iota
+	// HashToFieldSHA256 reduces the SHA2-256 hash of the data modulo the
+	// scalar field. The prover must use sha256.New().
+	HashToFieldSHA256
+	// HashToFieldKeccak256 reduces the Keccak-256 hash of the data modulo the
+	// scalar field. The prover must use sha3.NewLegacyKeccak256().
+	HashToFieldKeccak256
+	// HashToFieldRFC9380 is the hash_to_field construction of RFC 9380 with
+	// expand_message_xmd and SHA2-256, which is the default of the prover.
+	HashToFieldRFC9380
+)
+
+// String returns the name of the hash function.
+func (h HashToField) String() string {
+	switch h {
+	case HashToFieldDefault:
+		return "default"
+	case HashToFieldSHA256:
+		return "sha256"
+	case HashToFieldKeccak256:
+		return "keccak256"
+	case HashToFieldRFC9380:
+		return "rfc9380"
+	default:
+		return fmt.Sprintf("HashToField(%d)", h)
+	}
+}
+
+// ExportOption defines option for altering the behavior of the Solidity
+// exporters. See the descriptions of functions returning instances of this
+// type for implemented options.
+type ExportOption func(*ExportConfig) error
+
+// ExportConfig is the configuration of the Solidity exporters with the options
+// applied. The zero value of a field means the default of the exporter.
+type ExportConfig struct {
+	// PragmaVersion is the version constraint of the pragma solidity directive.
+	PragmaVersion string
+	// ContractName is the name of the verifier contract.
+	ContractName string
+	// Imports are the paths of the files imported by the contract.
+	Imports []string
+	// Interfaces are the interfaces and contracts the verifier inherits from.
+	Interfaces []string
+	// HashToField is the hash function used for the commitments.
+	HashToField HashToField
+	// BoolVerifier adds a Verify function which returns false instead of
+	// reverting when the proof is invalid.
+	BoolVerifier bool
+}
+
+// NewExportConfig returns the configuration with the options applied.
+func NewExportConfig(opts ...ExportOption) (ExportConfig, error) {
+	var cfg ExportConfig
+	for _, option := range opts {
+		if err := option(&cfg); err != nil {
+			return ExportConfig{}, err
+		}
+	}
+	return cfg, nil
+}
+
+var (
+	identifier    = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+	invalidPragma = regexp.MustCompile(`[;\n]`)
+	invalidImport = regexp.MustCompile(`["\n]`)
+)
+
+// WithPragmaVersion sets the version constraint of the pragma solidity
+// directive of the contract, for example "0.8.24" or "^0.8.20". The exporters
+// default to the lowest version they support.
+func WithPragmaVersion(version string) ExportOption {
+	return func(cfg *ExportConfig) error {
+		if version == "" || invalidPragma.MatchString(version) {
+			return fmt.Errorf("invalid pragma version %q", version)
+		}
+		cfg.PragmaVersion = version
+		return nil
+	}
+}
+
+// WithContractName sets the name of the verifier contract.
+func WithContractName(name string) ExportOption {
+	return func(cfg *ExportConfig) error {
+		if !identifier.MatchString(name) {
+			return fmt.Errorf("invalid contract name %q", name)
+		}
+		cfg.ContractName = name
+		return nil
+	}
+}
+
+// WithInterfaces makes the verifier contract inherit from the given interfaces
+// or contracts, which must be declared in the files given with WithImports.
+// Functions implementing an interface don't need the override specifier since
+// Solidity 0.8.8, which must then be set with WithPragmaVersion.
+func WithInterfaces(interfaces ...string) ExportOption {
+	return func(cfg *ExportConfig) error {
+		for _, name := range interfaces {
+			if !identifier.MatchString(name) {
+				return fmt.Errorf("invalid interface name %q", name)
+			}
+		}
+		cfg.Interfaces = append(cfg.Interfaces, interfaces...)
+		return nil
+	}
+}
+
+// WithImports adds import directives for the given paths to the contract.
+func WithImports(paths ...string) ExportOption {
+	return func(cfg *ExportConfig) error {
+		for _, path := range paths {
+			if path == "" || invalidImport.MatchString(path) {
+				return fmt.Errorf("invalid import path %q", path)
+			}
+		}
+		cfg.Imports = append(cfg.Imports, paths...)
+		return nil
+	}
+}
+
+// WithHashToField sets the hash function the contract uses to map the
+// commitments to field elements.
+func WithHashToField(h HashToField) ExportOption {
+	return func(cfg *ExportConfig) error {
+		if h > HashToFieldRFC9380 {
+			return errors.New("unknown hash to field function")
+		}
+		cfg.HashToField = h
+		return nil
+	}
+}
+
+// WithBoolVerifier adds a function
+//
+//	function Verify(bytes calldata proof, uint256[] calldata input) public view returns (bool)
+//
+// to the contract, taking the proof encoded with MarshalSolidity. It returns
+// false instead of reverting when the proof or the public inputs are invalid.
+// It calls the reverting verifier of the contract through an external call
+// to catch its errors. For PLONK, the reverting verifier, named Verify by
+// default, is then named verifyProof.
+//
+// For Groth16 on BN254, it also adds a function VerifyCompressed with the same
+// signature, taking the proof encoded with MarshalSolidityCompressed.
+func WithBoolVerifier() ExportOption {
+	return func(cfg *ExportConfig) error {
+		cfg.BoolVerifier = true
+		return nil
+	}
+}
@@ -0,0 +1,225 @@
+package solidity_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/solidity"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+	"github.com/consensys/gnark/test/unsafekzg"
+	"github.com/stretchr/testify/require"
+)
+
+type commitmentCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *commitmentCircuit) Define(api frontend.API) error {
+	cmt, err := api.(frontend.Committer).Commit(c.X)
+	if err != nil {
+		return err
+	}
+	api.AssertIsDifferent(cmt, c.Y)
+	return nil
+}
+
+type mulCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *mulCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(c.X, c.X), c.Y)
+	return nil
+}
+
+func TestExportConfig(t *testing.T) {
+	assert := require.New(t)
+
+	cfg, err := solidity.NewExportConfig(
+		solidity.WithPragmaVersion("0.8.24"),
+		solidity.WithContractName("MyVerifier"),
+		solidity.WithImports("./IVerifier.sol"),
+		solidity.WithInterfaces("IVerifier"),
+		solidity.WithHashToField(solidity.HashToFieldKeccak256),
+		solidity.WithBoolVerifier(),
+	)
+	assert.NoError(err)
+	assert.Equal(solidity.ExportConfig{
+		PragmaVersion: "0.8.24",
+		ContractName:  "MyVerifier",
+		Imports:       []string{"./IVerifier.sol"},
+		Interfaces:    []string{"IVerifier"},
+		HashToField:   solidity.HashToFieldKeccak256,
+		BoolVerifier:  true,
+	}, cfg)
+
+	for _, opt := range []solidity.ExportOption{
+		solidity.WithPragmaVersion("0.8.0; contract X {}"),
+		solidity.WithContractName("My Verifier"),
+		solidity.WithImports(`a.sol"; import "b.sol`),
+		solidity.WithInterfaces("1Verifier"),
+		solidity.WithHashToField(solidity.HashToFieldRFC9380 + 1),
+	} {
+		_, err = solidity.NewExportConfig(opt)
+		assert.Error(err)
+	}
+}
+
+func TestExportGroth16(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &commitmentCircuit{})
+	assert.NoError(err)
+	_, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+
+	var buf bytes.Buffer
+	assert.NoError(vk.ExportSolidity(&buf,
+		solidity.WithPragmaVersion("0.8.24"),
+		solidity.WithContractName("MyVerifier"),
+		solidity.WithImports("./IVerifier.sol"),
+		solidity.WithInterfaces("IVerifier"),
+		solidity.WithHashToField(solidity.HashToFieldKeccak256),
+		solidity.WithBoolVerifier(),
+	))
+	contract := buf.String()
+	assert.Contains(contract, "pragma solidity 0.8.24;")
+	assert.Contains(contract, `import "./IVerifier.sol";`)
+	assert.Contains(contract, "contract MyVerifier is IVerifier {")
+	assert.Contains(contract, "keccak256(")
+	assert.NotContains(contract, "sha256(")
+	assert.Contains(contract, "function Verify(bytes calldata proof, uint256[] calldata input) public view returns (bool)")
+	assert.Contains(contract, "function VerifyCompressed(bytes calldata proof, uint256[] calldata input) public view returns (bool)")
+	assert.Contains(contract, "if (proof.length != 192 || input.length != 1) {")
+
+	buf.Reset()
+	assert.NoError(vk.ExportSolidity(&buf))
+	assert.Contains(buf.String(), "pragma solidity ^0.8.0;")
+	assert.Contains(buf.String(), "contract Verifier {")
+	assert.NotContains(buf.String(), "function Verify(")
+
+	assert.Error(vk.ExportSolidity(&buf, solidity.WithHashToField(solidity.HashToFieldRFC9380)))
+}
+
+func TestExportPlonk(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &commitmentCircuit{})
+	assert.NoError(err)
+	srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+	assert.NoError(err)
+	_, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+	assert.NoError(err)
+
+	var buf bytes.Buffer
+	assert.NoError(vk.ExportSolidity(&buf,
+		solidity.WithContractName("MyPlonkVerifier"),
+		solidity.WithBoolVerifier(),
+	))
+	contract := buf.String()
+	assert.Contains(contract, "pragma solidity ^0.8.19;")
+	assert.Contains(contract, "contract MyPlonkVerifier {")
+	assert.Contains(contract, "function verifyProof(")
+	assert.Contains(contract, "function Verify(bytes calldata proof, uint256[] calldata public_inputs)\n  public view returns(bool) {")
+
+	assert.Error(vk.ExportSolidity(&buf, solidity.WithHashToField(solidity.HashToFieldKeccak256)))
+}
+
+func TestExportBLS12381(t *testing.T) {
+	assert := require.New(t)
+
+	var assignment commitmentCircuit
+	assignment.X, assignment.Y = 3, 5
+	w, err := frontend.NewWitness(&assignment, ecc.BLS12_381.ScalarField())
+	assert.NoError(err)
+
+	// groth16
+	ccs, err := frontend.Compile(ecc.BLS12_381.ScalarField(), r1cs.NewBuilder, &commitmentCircuit{})
+	assert.NoError(err)
+	pk, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+
+	var buf bytes.Buffer
+	assert.NoError(vk.ExportSolidity(&buf, solidity.WithBoolVerifier()))
+	contract := buf.String()
+	assert.Contains(contract, "contract Verifier {")
+	assert.Contains(contract, "PRECOMPILE_G1MSM = 0x0c;")
+	assert.Contains(contract, "PRECOMPILE_PAIRING = 0x0f;")
+	assert.Contains(contract, "function Verify(bytes calldata proof, uint256[] calldata input) public view returns (bool)")
+
+	proof, err := groth16.Prove(ccs, pk, w, backend.WithProverHashToFieldFunction(sha256.New()))
+	assert.NoError(err)
+	// A, B, C and the commitment with its proof of knowledge
+	assert.Len(proof.(interface{ MarshalSolidity() []byte }).MarshalSolidity(), 0x80+0x100+0x80+0x80+0x80)
+
+	// plonk
+	ccs, err = frontend.Compile(ecc.BLS12_381.ScalarField(), scs.NewBuilder, &commitmentCircuit{})
+	assert.NoError(err)
+	srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+	assert.NoError(err)
+	ppk, pvk, err := plonk.Setup(ccs, srs, srsLagrange)
+	assert.NoError(err)
+
+	buf.Reset()
+	assert.NoError(pvk.ExportSolidity(&buf, solidity.WithBoolVerifier()))
+	contract = buf.String()
+	assert.Contains(contract, "contract PlonkVerifier {")
+	assert.Contains(contract, "BLS12_G1MSM = 0x0c;")
+	assert.Contains(contract, "BLS12_PAIRING_CHECK = 0x0f;")
+	assert.Contains(contract, "VK_COSET_SHIFT = 7;")
+
+	pproof, err := plonk.Prove(ccs, ppk, w)
+	assert.NoError(err)
+	// 0x540 bytes and 0xa0 per commitment
+	assert.Len(pproof.(interface{ MarshalSolidity() []byte }).MarshalSolidity(), 0x540+0xa0)
+}
+
+// TestVerifyBLS12381 runs the BLS12-381 verifiers, with and without
+// commitment, on the EVM with the precompiles of EIP-2537, when the build tags
+// "solccheck" and "prover_checks" are set.
+func TestVerifyBLS12381(t *testing.T) {
+	if !test.SolcCheck {
+		t.Skip("requires the build tag solccheck and solc in the PATH")
+	}
+	assert := test.NewAssert(t)
+	assert.CheckCircuit(&mulCircuit{},
+		test.WithValidAssignment(&mulCircuit{X: 3, Y: 9}),
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16, backend.PLONK),
+	)
+	assert.CheckCircuit(&commitmentCircuit{},
+		test.WithValidAssignment(&commitmentCircuit{X: 3, Y: 5}),
+		test.WithCurves(ecc.BLS12_381),
+		test.WithBackends(backend.GROTH16, backend.PLONK),
+	)
+}
+
+// TestVerifyCompressed runs the BN254 Groth16 verifier on the EVM with a
+// compressed proof, when the build tags "solccheck" and "prover_checks" are
+// set.
+func TestVerifyCompressed(t *testing.T) {
+	if !test.SolcCheck {
+		t.Skip("requires the build tag solccheck, solc and gnark-solidity-checker in the PATH")
+	}
+	assert := test.NewAssert(t)
+	assert.CheckCircuit(&mulCircuit{},
+		test.WithValidAssignment(&mulCircuit{X: 3, Y: 9}),
+		test.WithCurves(ecc.BN254),
+		test.WithBackends(backend.GROTH16),
+	)
+	assert.CheckCircuit(&commitmentCircuit{},
+		test.WithValidAssignment(&commitmentCircuit{X: 3, Y: 5}),
+		test.WithCurves(ecc.BN254),
+		test.WithBackends(backend.GROTH16),
+	)
+}
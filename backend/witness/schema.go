@@ -0,0 +1,298 @@
+package witness
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"math/big"
+	"reflect"
+
+	"github.com/consensys/gnark/frontend/schema"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// ErrNoSchema is returned by ReadValue when the witness doesn't embed the schema of its circuit.
+var ErrNoSchema = errors.New("witness has no schema")
+
+// schemaFlag is set in the nbSecret field of the binary encoding when a schema section follows
+// the header.
+const schemaFlag = 1 << 31
+
+// maxSchemaSize bounds the size of the encoded schema, and maxNbValues the number of values it
+// describes, as the schema is instantiated to name the values.
+const (
+	maxSchemaSize = 1 << 24
+	maxNbValues   = 1 << 26
+)
+
+// ReadPublic reads the public part of a witness encoded by WriteTo, with its embedded schema if
+// any, without decoding the secret part. r is left after the public part.
+func ReadPublic(r io.Reader, field *big.Int) (Witness, error) {
+	v, err := newVector(field, 0)
+	if err != nil {
+		return nil, err
+	}
+	nbPublic, nbSecret, s, _, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if v, err = readElements(r, v, int(nbPublic)+int(nbSecret), 0, int(nbPublic)); err != nil {
+		return nil, err
+	}
+	return &witness{
+		vector:   v,
+		nbPublic: nbPublic,
+		schema:   s,
+	}, nil
+}
+
+// ReadValue reads the value of the leaf name of a witness encoded by WriteTo, without decoding
+// the other values. The witness must embed the schema of its circuit, and name is the full name
+// of the leaf in this schema, for instance "A_0_X" for the field X of the first element of the
+// array A. r is left after the value.
+func ReadValue(r io.Reader, field *big.Int, name string) (*big.Int, error) {
+	v, err := newVector(field, 0)
+	if err != nil {
+		return nil, err
+	}
+	nbPublic, nbSecret, s, _, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, ErrNoSchema
+	}
+	names, err := leafNames(s)
+	if err != nil {
+		return nil, err
+	}
+	index := -1
+	for i := range names {
+		if names[i] == name {
+			index = i
+			break
+		}
+	}
+	if index == -1 || index >= int(nbPublic)+int(nbSecret) {
+		return nil, fmt.Errorf("no value %s in the witness", name)
+	}
+	if v, err = readElements(r, v, int(nbPublic)+int(nbSecret), index, 1); err != nil {
+		return nil, err
+	}
+	return get(v, 0), nil
+}
+
+// readHeader reads the number of public and secret values and the schema section, if any.
+func readHeader(r io.Reader) (nbPublic, nbSecret uint32, s *schema.Schema, n int64, err error) {
+	var buf [8]byte
+	read, err := io.ReadFull(r, buf[:])
+	n = int64(read)
+	if err != nil {
+		return
+	}
+	nbPublic = binary.BigEndian.Uint32(buf[:4])
+	nbSecret = binary.BigEndian.Uint32(buf[4:])
+	if nbSecret&schemaFlag == 0 {
+		return
+	}
+	nbSecret &^= schemaFlag
+
+	var m int64
+	s, m, err = readSchema(r)
+	n += m
+	if err != nil {
+		return
+	}
+	err = checkSchema(s, nbPublic, nbSecret)
+	return
+}
+
+// readElements reads count elements of the vector of nbElements elements encoded in r, from
+// the element at index start, in a vector of the type of v.
+func readElements(r io.Reader, v any, nbElements, start, count int) (any, error) {
+	var buf [4]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, err
+	}
+	if l := binary.BigEndian.Uint32(buf[:]); l != uint32(nbElements) {
+		return nil, fmt.Errorf("vector of %d elements, expected %d", l, nbElements)
+	}
+
+	size := int64(elementSize(v))
+	if start != 0 {
+		offset := int64(start) * size
+		if seeker, ok := r.(io.Seeker); ok {
+			if _, err := seeker.Seek(offset, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		} else if _, err := io.CopyN(io.Discard, r, offset); err != nil {
+			return nil, err
+		}
+	}
+
+	// the elements are decoded as a vector of count elements
+	binary.BigEndian.PutUint32(buf[:], uint32(count))
+	v, _, err := readVector(v, io.MultiReader(bytes.NewReader(buf[:]), io.LimitReader(r, int64(count)*size)))
+	return v, err
+}
+
+func writeSchema(w io.Writer, s *schema.Schema) (int64, error) {
+	enc, err := cbor.CoreDetEncOptions().EncMode()
+	if err != nil {
+		return 0, err
+	}
+	data, err := enc.Marshal(s)
+	if err != nil {
+		return 0, err
+	}
+	if err := binary.Write(w, binary.BigEndian, uint32(len(data))); err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n) + 4, err
+}
+
+func readSchema(r io.Reader) (*schema.Schema, int64, error) {
+	var buf [4]byte
+	if read, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, int64(read), err
+	}
+	l := binary.BigEndian.Uint32(buf[:])
+	if l > maxSchemaSize {
+		return nil, 4, fmt.Errorf("witness schema of %d bytes, the maximum is %d", l, maxSchemaSize)
+	}
+
+	// the data is read before it is allocated, so that a corrupted length doesn't allocate
+	data, err := io.ReadAll(io.LimitReader(r, int64(l)))
+	n := int64(len(data)) + 4
+	if err != nil {
+		return nil, n, err
+	}
+	if len(data) != int(l) {
+		return nil, n, io.ErrUnexpectedEOF
+	}
+
+	dec, err := cbor.DecOptions{MaxNestedLevels: 256}.DecMode()
+	if err != nil {
+		return nil, n, err
+	}
+	s := new(schema.Schema)
+	if err := dec.Unmarshal(data, s); err != nil {
+		return nil, n, fmt.Errorf("decode witness schema: %w", err)
+	}
+	return s, n, nil
+}
+
+// validateSchema returns an error if s can't be instantiated, or describes more than
+// maxNbValues values.
+func validateSchema(s *schema.Schema) error {
+	if s.NbPublic < 0 || s.NbSecret < 0 || s.NbPublic > maxNbValues-s.NbSecret {
+		return fmt.Errorf("invalid witness schema: %d public, %d secret values", s.NbPublic, s.NbSecret)
+	}
+	if _, err := nbLeaves(s.Fields); err != nil {
+		return fmt.Errorf("invalid witness schema: %w", err)
+	}
+	return nil
+}
+
+// nbLeaves returns the number of leaves of fields, or an error if they can't be instantiated as
+// the fields of a struct or have more than maxNbValues leaves.
+func nbLeaves(fields []schema.Field) (int, error) {
+	names := make(map[string]struct{}, len(fields))
+	total := 0
+	for _, f := range fields {
+		if !token.IsIdentifier(f.Name) || !token.IsExported(f.Name) {
+			return 0, fmt.Errorf("invalid field name %q", f.Name)
+		}
+		if _, ok := names[f.Name]; ok {
+			return 0, fmt.Errorf("duplicate field %s", f.Name)
+		}
+		names[f.Name] = struct{}{}
+
+		var n int
+		var err error
+		switch f.Type {
+		case schema.Leaf:
+			n = 1
+		case schema.Struct:
+			n, err = nbLeaves(f.SubFields)
+		case schema.Array:
+			n, err = nbArrayLeaves(f.ArraySize, f.SubFields)
+		default:
+			err = fmt.Errorf("invalid type of field %s", f.Name)
+		}
+		if err != nil {
+			return 0, err
+		}
+		if total += n; total > maxNbValues {
+			return 0, errors.New("too many values")
+		}
+	}
+	return total, nil
+}
+
+// nbArrayLeaves returns the number of leaves of an array of size elements, described by the
+// first of fields as in schema.Schema.Instantiate.
+func nbArrayLeaves(size int, fields []schema.Field) (int, error) {
+	if size < 0 || size > maxNbValues {
+		return 0, fmt.Errorf("invalid array size %d", size)
+	}
+	n := 1
+	if len(fields) != 0 {
+		var err error
+		switch fields[0].Type {
+		case schema.Struct:
+			n, err = nbLeaves(fields[0].SubFields)
+		case schema.Array:
+			n, err = nbArrayLeaves(fields[0].ArraySize, fields[0].SubFields)
+		default:
+			err = errors.New("invalid array type")
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if size != 0 && n > maxNbValues/size {
+		return 0, errors.New("too many values")
+	}
+	return n * size, nil
+}
+
+// checkSchema returns an error if s is not the schema of a witness with nbPublic public values
+// and nbSecret secret values, or of its public part.
+func checkSchema(s *schema.Schema, nbPublic, nbSecret uint32) error {
+	if err := validateSchema(s); err != nil {
+		return err
+	}
+	if s.NbPublic != int(nbPublic) || (nbSecret != 0 && nbSecret != uint32(s.NbSecret)) {
+		return errors.New("schema is inconsistent with Witness")
+	}
+	return nil
+}
+
+// leafNames returns the full names of the leaves of s, in the order of the witness.
+func leafNames(s *schema.Schema) ([]string, error) {
+	public := make([]string, 0, s.NbPublic)
+	secret := make([]string, 0, s.NbSecret)
+
+	// the leaves are pointers, as in ToJSON, so that the walk finds them
+	typ := reflect.TypeOf((*int)(nil))
+	instance := s.Instantiate(typ, false)
+	if _, err := schema.Walk(instance, typ, func(f schema.LeafInfo, _ reflect.Value) error {
+		if f.Visibility == schema.Public {
+			public = append(public, f.FullName())
+		} else if f.Visibility == schema.Secret {
+			secret = append(secret, f.FullName())
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if len(public) != s.NbPublic || len(secret) != s.NbSecret {
+		return nil, errors.New("schema is inconsistent with its leaves")
+	}
+	return append(public, secret...), nil
+}
@@ -2,6 +2,7 @@ package witness
 
 import (
 	"errors"
+	"io"
 	"math/big"
 	"reflect"
 
@@ -247,3 +248,83 @@ func resize(v any, n int) any {
 		panic("invalid input")
 	}
 }
+
+// readVector decodes a vector of the type of v from r.
+func readVector(v any, r io.Reader) (any, int64, error) {
+	switch t := v.(type) {
+	case fr_bn254.Vector:
+		n, err := t.ReadFrom(r)
+		return t, n, err
+	case fr_bls12377.Vector:
+		n, err := t.ReadFrom(r)
+		return t, n, err
+	case fr_bls12381.Vector:
+		n, err := t.ReadFrom(r)
+		return t, n, err
+	case fr_bw6761.Vector:
+		n, err := t.ReadFrom(r)
+		return t, n, err
+	case fr_bls24317.Vector:
+		n, err := t.ReadFrom(r)
+		return t, n, err
+	case fr_bls24315.Vector:
+		n, err := t.ReadFrom(r)
+		return t, n, err
+	case fr_bw6633.Vector:
+		n, err := t.ReadFrom(r)
+		return t, n, err
+	case tinyfield.Vector:
+		n, err := t.ReadFrom(r)
+		return t, n, err
+	default:
+		panic("invalid input")
+	}
+}
+
+// elementSize returns the size of the binary encoding of an element of v.
+func elementSize(v any) int {
+	switch v.(type) {
+	case fr_bn254.Vector:
+		return fr_bn254.Bytes
+	case fr_bls12377.Vector:
+		return fr_bls12377.Bytes
+	case fr_bls12381.Vector:
+		return fr_bls12381.Bytes
+	case fr_bw6761.Vector:
+		return fr_bw6761.Bytes
+	case fr_bls24317.Vector:
+		return fr_bls24317.Bytes
+	case fr_bls24315.Vector:
+		return fr_bls24315.Bytes
+	case fr_bw6633.Vector:
+		return fr_bw6633.Bytes
+	case tinyfield.Vector:
+		return tinyfield.Bytes
+	default:
+		panic("invalid input")
+	}
+}
+
+// get returns the element of v at index, in regular form.
+func get(v any, index int) *big.Int {
+	switch pv := v.(type) {
+	case fr_bn254.Vector:
+		return pv[index].BigInt(new(big.Int))
+	case fr_bls12377.Vector:
+		return pv[index].BigInt(new(big.Int))
+	case fr_bls12381.Vector:
+		return pv[index].BigInt(new(big.Int))
+	case fr_bw6761.Vector:
+		return pv[index].BigInt(new(big.Int))
+	case fr_bls24317.Vector:
+		return pv[index].BigInt(new(big.Int))
+	case fr_bls24315.Vector:
+		return pv[index].BigInt(new(big.Int))
+	case fr_bw6633.Vector:
+		return pv[index].BigInt(new(big.Int))
+	case tinyfield.Vector:
+		return pv[index].BigInt(new(big.Int))
+	default:
+		panic("invalid input")
+	}
+}
@@ -19,6 +19,16 @@
 //	Witness     ->  [uint32(nbPublic) | uint32(nbSecret) | fr.Vector(variables)]
 //	fr.Vector is a *field element* vector encoded a big-endian byte array like so: [uint32(len(vector)) | elements]
 //
+// A witness may embed the schema of its circuit (see Schemer), so that its values can
+// be named without the circuit structure. The most significant bit of nbSecret is then set, and
+// the schema follows the header:
+//
+//	Witness     ->  [uint32(nbPublic) | uint32(nbSecret) | 1<<31 | uint32(len(schema)) | schema | fr.Vector(variables)]
+//	schema is the CBOR encoding of the schema.Schema
+//
+// Since the elements have a fixed size, ReadPublic and ReadValue read the public part or a single
+// named value of an encoded witness without decoding the whole vector.
+//
 // # Ordering
 //
 // First, `publicVariables`, then `secretVariables`. Each subset is ordered from the order of definition in the circuit structure.
@@ -95,11 +105,25 @@ type Witness interface {
 	Fill(nbPublic, nbSecret int, values <-chan any) error
 }
 
+// Schemer is implemented by the witnesses which can embed the schema of their circuit in their
+// binary encoding, as the witnesses returned by New.
+type Schemer interface {
+	// Schema returns the schema embedded in the witness, or nil if it has none.
+	Schema() *schema.Schema
+
+	// SetSchema embeds the provided Schema in the binary encoding of the witness, so that its
+	// values can be named without the circuit structure (see ReadValue). A nil Schema removes it.
+	SetSchema(s *schema.Schema) error
+}
+
 type witness struct {
 	vector             any
 	nbPublic, nbSecret uint32
+	schema             *schema.Schema
 }
 
+var _ Schemer = &witness{}
+
 // New initialize a new empty Witness.
 func New(field *big.Int) (Witness, error) {
 	v, err := newVector(field, 0)
@@ -113,6 +137,10 @@ func New(field *big.Int) (Witness, error) {
 }
 
 func (w *witness) Fill(nbPublic, nbSecret int, values <-chan any) error {
+	// the counts are encoded on 32 bits, the last one flagging the schema
+	if nbPublic < 0 || nbSecret < 0 || uint64(nbPublic) >= schemaFlag || uint64(nbSecret) >= schemaFlag {
+		return fmt.Errorf("invalid number of values: %d public, %d secret", nbPublic, nbSecret)
+	}
 	n := nbPublic + nbSecret
 	w.vector = resize(w.vector, n)
 	w.nbPublic = uint32(nbPublic)
@@ -156,20 +184,48 @@ func (w *witness) Public() (Witness, error) {
 	return &witness{
 		vector:   v,
 		nbPublic: w.nbPublic,
+		schema:   w.schema,
 	}, nil
 }
 
+func (w *witness) Schema() *schema.Schema {
+	return w.schema
+}
+
+func (w *witness) SetSchema(s *schema.Schema) error {
+	if s != nil {
+		if err := checkSchema(s, w.nbPublic, w.nbSecret); err != nil {
+			return err
+		}
+	}
+	w.schema = s
+	return nil
+}
+
 func (w *witness) WriteTo(wr io.Writer) (n int64, err error) {
 	// write number of public, number of secret
 	if err := binary.Write(wr, binary.BigEndian, w.nbPublic); err != nil {
 		return 0, err
 	}
 	n = int64(4)
-	if err := binary.Write(wr, binary.BigEndian, w.nbSecret); err != nil {
+	nbSecret := w.nbSecret
+	if w.schema != nil {
+		nbSecret |= schemaFlag
+	}
+	if err := binary.Write(wr, binary.BigEndian, nbSecret); err != nil {
 		return n, err
 	}
 	n += 4
 
+	// write the schema
+	if w.schema != nil {
+		m, err := writeSchema(wr, w.schema)
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+
 	// write the vector
 	var m int64
 	switch t := w.vector.(type) {
@@ -197,48 +253,13 @@ func (w *witness) WriteTo(wr io.Writer) (n int64, err error) {
 }
 
 func (w *witness) ReadFrom(r io.Reader) (n int64, err error) {
-	var buf [4]byte
-	if read, err := io.ReadFull(r, buf[:]); err != nil {
-		return int64(read), err
-	}
-	w.nbPublic = binary.BigEndian.Uint32(buf[:4])
-	if read, err := io.ReadFull(r, buf[:]); err != nil {
-		return int64(read) + 4, err
+	w.nbPublic, w.nbSecret, w.schema, n, err = readHeader(r)
+	if err != nil {
+		return n, err
 	}
-	w.nbSecret = binary.BigEndian.Uint32(buf[:4])
-
-	n = 8
 
 	var m int64
-	switch t := w.vector.(type) {
-	case fr_bn254.Vector:
-		m, err = t.ReadFrom(r)
-		w.vector = t
-	case fr_bls12377.Vector:
-		m, err = t.ReadFrom(r)
-		w.vector = t
-	case fr_bls12381.Vector:
-		m, err = t.ReadFrom(r)
-		w.vector = t
-	case fr_bw6761.Vector:
-		m, err = t.ReadFrom(r)
-		w.vector = t
-	case fr_bls24317.Vector:
-		m, err = t.ReadFrom(r)
-		w.vector = t
-	case fr_bls24315.Vector:
-		m, err = t.ReadFrom(r)
-		w.vector = t
-	case fr_bw6633.Vector:
-		m, err = t.ReadFrom(r)
-		w.vector = t
-	case tinyfield.Vector:
-		m, err = t.ReadFrom(r)
-		w.vector = t
-	default:
-		panic("invalid input")
-	}
-
+	w.vector, m, err = readVector(w.vector, r)
 	n += m
 	return n, err
 }
@@ -267,8 +288,8 @@ func (w *witness) Vector() any {
 // ToJSON returns the JSON encoding of the witness following the provided Schema. This is a
 // convenience method and should be avoided in most cases.
 func (w *witness) ToJSON(s *schema.Schema) ([]byte, error) {
-	if s.NbPublic != int(w.nbPublic) || (w.nbSecret != 0 && w.nbSecret != uint32(s.NbSecret)) {
-		return nil, errors.New("schema is inconsistent with Witness")
+	if err := checkSchema(s, w.nbPublic, w.nbSecret); err != nil {
+		return nil, err
 	}
 	typ := reflect.PtrTo(leafType(w.vector))
 	instance := s.Instantiate(typ)
@@ -307,6 +328,9 @@ func (w *witness) ToJSON(s *schema.Schema) ([]byte, error) {
 // FromJSON parses a JSON data input and attempt to reconstruct a witness following the provided Schema.
 // This is a convenience method and should be avoided in most cases.
 func (w *witness) FromJSON(s *schema.Schema, data []byte) error {
+	if err := validateSchema(s); err != nil {
+		return err
+	}
 	typ := leafType(w.vector)
 	ptrTyp := reflect.PtrTo(typ)
 
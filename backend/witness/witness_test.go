@@ -2,6 +2,7 @@ package witness_test
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -11,7 +12,9 @@ import (
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/schema"
 	"github.com/consensys/gnark/io"
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/require"
 )
 
@@ -110,6 +113,141 @@ func TestPublic(t *testing.T) {
 	assert.Equal("8000", wt[1].String())
 }
 
+type schemaCircuit struct {
+	X frontend.Variable `gnark:",public"`
+	A [2]struct {
+		Y frontend.Variable `gnark:",public"`
+		Z frontend.Variable
+	}
+	S []frontend.Variable `gnark:"s"`
+}
+
+func (c *schemaCircuit) Define(frontend.API) error {
+	return nil
+}
+
+func TestSchema(t *testing.T) {
+	assert := require.New(t)
+
+	assignment := &schemaCircuit{X: 1, S: []frontend.Variable{6, 7}}
+	assignment.A[0].Y, assignment.A[0].Z = 2, 3
+	assignment.A[1].Y, assignment.A[1].Z = 4, 5
+
+	w, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField(), frontend.WithSchema())
+	assert.NoError(err)
+	assert.NotNil(w.(witness.Schemer).Schema())
+	assert.NoError(io.RoundTripCheck(w, func() interface{} {
+		rw, err := witness.New(ecc.BN254.ScalarField())
+		assert.NoError(err)
+		return rw
+	}))
+	data, err := w.MarshalBinary()
+	assert.NoError(err)
+
+	// the public part is read alone, with the schema
+	pw, err := witness.ReadPublic(bytes.NewReader(data), ecc.BN254.ScalarField())
+	assert.NoError(err)
+	expected, err := w.Public()
+	assert.NoError(err)
+	assert.Equal(expected, pw)
+
+	for name, value := range map[string]int64{"X": 1, "A_0_Y": 2, "A_1_Y": 4, "A_0_Z": 3, "A_1_Z": 5, "s_0": 6, "s_1": 7} {
+		v, err := witness.ReadValue(bytes.NewReader(data), ecc.BN254.ScalarField(), name)
+		assert.NoError(err, name)
+		assert.Equal(value, v.Int64(), name)
+	}
+	_, err = witness.ReadValue(bytes.NewReader(data), ecc.BN254.ScalarField(), "A_2_Y")
+	assert.Error(err)
+
+	// the public witness keeps the schema, without the secret values
+	data, err = pw.MarshalBinary()
+	assert.NoError(err)
+	v, err := witness.ReadValue(bytes.NewReader(data), ecc.BN254.ScalarField(), "A_1_Y")
+	assert.NoError(err)
+	assert.Equal(int64(4), v.Int64())
+	_, err = witness.ReadValue(bytes.NewReader(data), ecc.BN254.ScalarField(), "A_1_Z")
+	assert.Error(err)
+
+	// the layout is unchanged without schema
+	w, err = frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	assert.NoError(err)
+	assert.Nil(w.(witness.Schemer).Schema())
+	data, err = w.MarshalBinary()
+	assert.NoError(err)
+	assert.Equal(uint32(4), binary.BigEndian.Uint32(data[4:8]))
+	_, err = witness.ReadValue(bytes.NewReader(data), ecc.BN254.ScalarField(), "X")
+	assert.ErrorIs(err, witness.ErrNoSchema)
+	pw, err = witness.ReadPublic(bytes.NewReader(data), ecc.BN254.ScalarField())
+	assert.NoError(err)
+	assert.Equal(3, len(pw.Vector().(fr.Vector)))
+
+	// the schema must match the witness
+	s, err := frontend.NewSchema(&circuit{})
+	assert.NoError(err)
+	assert.Error(w.(witness.Schemer).SetSchema(s))
+}
+
+// encodeWithSchema encodes a witness of one public value with the schema s, which may be
+// inconsistent.
+func encodeWithSchema(s *schema.Schema) []byte {
+	data, err := cbor.Marshal(s)
+	if err != nil {
+		panic(err)
+	}
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.BigEndian, uint32(1))
+	_ = binary.Write(&buf, binary.BigEndian, uint32(1<<31))
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(data)))
+	buf.Write(data)
+	vector := fr.Vector{fr.NewElement(42)}
+	_, _ = vector.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+func TestSchemaBounds(t *testing.T) {
+	assert := require.New(t)
+
+	leaf := schema.Field{Name: "X", Type: schema.Leaf, Visibility: schema.Public}
+	valid := &schema.Schema{Fields: []schema.Field{leaf}, NbPublic: 1}
+	v, err := witness.ReadValue(bytes.NewReader(encodeWithSchema(valid)), ecc.BN254.ScalarField(), "X")
+	assert.NoError(err)
+	assert.Equal(int64(42), v.Int64())
+
+	for name, s := range map[string]*schema.Schema{
+		"negative count": {Fields: []schema.Field{leaf}, NbPublic: 1, NbSecret: -1},
+		"huge array":     {Fields: []schema.Field{{Name: "X", Type: schema.Array, Visibility: schema.Public, ArraySize: 1 << 40}}, NbPublic: 1},
+		"nested arrays": {Fields: []schema.Field{{Name: "X", Type: schema.Array, Visibility: schema.Public, ArraySize: 1 << 20, SubFields: []schema.Field{
+			{Type: schema.Array, ArraySize: 1 << 20},
+		}}}, NbPublic: 1},
+		"negative array":   {Fields: []schema.Field{{Name: "X", Type: schema.Array, Visibility: schema.Public, ArraySize: -1}}, NbPublic: 1},
+		"unexported field": {Fields: []schema.Field{{Name: "x", Type: schema.Leaf, Visibility: schema.Public}}, NbPublic: 1},
+		"duplicate field":  {Fields: []schema.Field{leaf, leaf}, NbPublic: 1},
+		"invalid type":     {Fields: []schema.Field{{Name: "X", Type: 3, Visibility: schema.Public}}, NbPublic: 1},
+		"wrong count":      {Fields: []schema.Field{leaf, {Name: "Y", Type: schema.Leaf, Visibility: schema.Public}}, NbPublic: 1},
+	} {
+		data := encodeWithSchema(s)
+		_, err := witness.ReadValue(bytes.NewReader(data), ecc.BN254.ScalarField(), "X")
+		assert.Error(err, name)
+		w, err := witness.New(ecc.BN254.ScalarField())
+		assert.NoError(err)
+		assert.Error(w.FromJSON(s, []byte(`{"X":"42"}`)), name)
+	}
+
+	// the size of the schema is bounded before it is read
+	data := encodeWithSchema(valid)
+	binary.BigEndian.PutUint32(data[8:], 1<<30)
+	_, err = witness.ReadPublic(bytes.NewReader(data), ecc.BN254.ScalarField())
+	assert.Error(err)
+
+	// the counts of values are bounded
+	w, err := witness.New(ecc.BN254.ScalarField())
+	assert.NoError(err)
+	values := make(chan any)
+	close(values)
+	assert.Error(w.Fill(-1, 0, values))
+	assert.Error(w.Fill(0, 1<<31, values))
+}
+
 func roundTripMarshal(assert *require.Assertions, assignment circuit, publicOnly bool) {
 	var opts []frontend.WitnessOption
 	if publicOnly {
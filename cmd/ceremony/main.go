@@ -0,0 +1,284 @@
+// Command ceremony coordinates and contributes to MPC setup ceremonies.
+//
+// The coordinator creates a ceremony directory with init, hands the latest
+// state to the next participant with latest, and records their contribution
+// with submit. Participants run contribute on the state they received. Once
+// all the participants have contributed, the coordinator closes the ceremony
+// with beacon, using a public random value unknown before the last
+// contribution (e.g. a future block hash). Anyone can re-verify the directory
+// with verify.
+//
+//	ceremony init -dir ph1 -phase groth16-phase1 -curve bn254 -power 20
+//	ceremony latest -dir ph1 -out state.bin
+//	ceremony contribute -phase groth16-phase1 -curve bn254 -in state.bin -out contribution.bin
+//	ceremony submit -dir ph1 -in contribution.bin -name alice
+//	ceremony beacon -dir ph1 -value 0x…
+//	ceremony verify -dir ph1
+//
+// A Groth16 phase 2 is initialized from the final state of a phase 1 and the
+// compiled circuit; the evaluations needed to extract the keys are written to
+// evaluations.bin in the ceremony directory. Both inputs are needed again to
+// verify it, as its initial state is computed from them:
+//
+//	ceremony init -dir ph2 -phase groth16-phase2 -curve bn254 -phase1 final.bin -r1cs circuit.r1cs
+//	ceremony verify -dir ph2 -phase1 final.bin -r1cs circuit.r1cs
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/ceremony"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+)
+
+const usage = `usage: ceremony <command> [flags]
+
+commands:
+  init        create a ceremony directory
+  latest      write the state to contribute to
+  contribute  contribute to a state (participant)
+  submit      verify and record a contribution
+  beacon      seal the ceremony with a random beacon
+  verify      re-verify a ceremony directory
+
+run ceremony <command> -h for the flags of a command`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	commands := map[string]func([]string) error{
+		"init":       initCmd,
+		"latest":     latestCmd,
+		"contribute": contributeCmd,
+		"submit":     submitCmd,
+		"beacon":     beaconCmd,
+		"verify":     verifyCmd,
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err := cmd(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func initCmd(args []string) error {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	dir := fs.String("dir", "", "ceremony directory")
+	phaseName := fs.String("phase", ceremony.Groth16Phase1, "phase of the ceremony: groth16-phase1, groth16-phase2 or plonk-phase1")
+	curveName := fs.String("curve", "bn254", "curve of the ceremony")
+	power := fs.Int("power", 0, "groth16-phase1: log2 of the maximum number of constraints")
+	size := fs.Uint64("size", 0, "plonk-phase1: maximum number of constraints")
+	phase1File := fs.String("phase1", "", "groth16-phase2: final state of the phase 1")
+	r1csFile := fs.String("r1cs", "", "groth16-phase2: compiled circuit")
+	fs.Parse(args)
+	if *dir == "" {
+		return errors.New("missing -dir")
+	}
+	phase, err := newPhase(*phaseName, *curveName)
+	if err != nil {
+		return err
+	}
+
+	var initial ceremony.Contribution
+	var evals io.WriterTo
+	switch phase.Name {
+	case ceremony.Groth16Phase1:
+		initial, err = ceremony.NewGroth16Phase1(phase.Curve, *power)
+	case ceremony.PlonkPhase1:
+		initial, err = ceremony.NewPlonkPhase1(phase.Curve, *size)
+	case ceremony.Groth16Phase2:
+		ccs, phase1, err := readPhase2Inputs(phase.Curve, *r1csFile, *phase1File)
+		if err != nil {
+			return err
+		}
+		if initial, evals, err = ceremony.NewGroth16Phase2(ccs, phase1); err != nil {
+			return err
+		}
+		if _, err := ceremony.InitGroth16Phase2(*dir, ccs, phase1, initial); err != nil {
+			return err
+		}
+		return writeFile(filepath.Join(*dir, "evaluations.bin"), evals)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = ceremony.Init(*dir, phase, initial)
+	return err
+}
+
+func latestCmd(args []string) error {
+	fs := flag.NewFlagSet("latest", flag.ExitOnError)
+	dir := fs.String("dir", "", "ceremony directory")
+	out := fs.String("out", "", "output file")
+	fs.Parse(args)
+	c, err := ceremony.Open(*dir)
+	if err != nil {
+		return err
+	}
+	return writeFile(*out, writerToFunc(c.WriteLatest))
+}
+
+func contributeCmd(args []string) error {
+	fs := flag.NewFlagSet("contribute", flag.ExitOnError)
+	phaseName := fs.String("phase", ceremony.Groth16Phase1, "phase of the ceremony")
+	curveName := fs.String("curve", "bn254", "curve of the ceremony")
+	in := fs.String("in", "", "state to contribute to")
+	out := fs.String("out", "", "output file")
+	fs.Parse(args)
+	phase, err := newPhase(*phaseName, *curveName)
+	if err != nil {
+		return err
+	}
+	state := phase.NewContribution()
+	if err := readFile(*in, state); err != nil {
+		return err
+	}
+	state.Contribute()
+	return writeFile(*out, state)
+}
+
+func submitCmd(args []string) error {
+	fs := flag.NewFlagSet("submit", flag.ExitOnError)
+	dir := fs.String("dir", "", "ceremony directory")
+	in := fs.String("in", "", "contribution file")
+	name := fs.String("name", "", "name of the contributor")
+	fs.Parse(args)
+	c, err := ceremony.Open(*dir)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	entry, err := c.Contribute(f, *name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("contribution %d recorded, hash %s\n", entry.Index, entry.Hash)
+	return nil
+}
+
+func beaconCmd(args []string) error {
+	fs := flag.NewFlagSet("beacon", flag.ExitOnError)
+	dir := fs.String("dir", "", "ceremony directory")
+	value := fs.String("value", "", "hex encoded random beacon")
+	fs.Parse(args)
+	beacon, err := hex.DecodeString(strings.TrimPrefix(*value, "0x"))
+	if err != nil {
+		return err
+	}
+	c, err := ceremony.Open(*dir)
+	if err != nil {
+		return err
+	}
+	entry, err := c.Seal(beacon)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("ceremony sealed, final state %s, hash %s\n", entry.File, entry.Hash)
+	return nil
+}
+
+func verifyCmd(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	dir := fs.String("dir", "", "ceremony directory")
+	phase1File := fs.String("phase1", "", "groth16-phase2: final state of the phase 1")
+	r1csFile := fs.String("r1cs", "", "groth16-phase2: compiled circuit")
+	fs.Parse(args)
+	c, err := ceremony.Open(*dir)
+	if err != nil {
+		return err
+	}
+	if c.Phase().Name == ceremony.Groth16Phase2 {
+		ccs, phase1, err := readPhase2Inputs(c.Phase().Curve, *r1csFile, *phase1File)
+		if err != nil {
+			return err
+		}
+		err = c.VerifyGroth16Phase2(ccs, phase1)
+	} else {
+		err = c.Verify()
+	}
+	if err != nil {
+		return err
+	}
+	transcript := c.Transcript()
+	fmt.Printf("%d contributions verified, sealed: %t, chain %s\n", len(transcript)-1, c.Sealed(), transcript[len(transcript)-1].Chain)
+	return nil
+}
+
+// readPhase2Inputs reads the circuit and the final state of the phase 1 of a
+// Groth16 phase 2.
+func readPhase2Inputs(curve ecc.ID, r1csFile, phase1File string) (constraint.ConstraintSystem, ceremony.Contribution, error) {
+	phase1Phase, err := ceremony.NewPhase(ceremony.Groth16Phase1, curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	phase1 := phase1Phase.NewContribution()
+	if err := readFile(phase1File, phase1); err != nil {
+		return nil, nil, err
+	}
+	ccs := groth16.NewCS(curve)
+	if err := readFile(r1csFile, ccs); err != nil {
+		return nil, nil, err
+	}
+	return ccs, phase1, nil
+}
+
+func newPhase(name, curve string) (ceremony.Phase, error) {
+	id, err := ecc.IDFromString(curve)
+	if err != nil {
+		return ceremony.Phase{}, err
+	}
+	return ceremony.NewPhase(name, id)
+}
+
+type writerToFunc func(io.Writer) (int64, error)
+
+func (f writerToFunc) WriteTo(w io.Writer) (int64, error) {
+	return f(w)
+}
+
+func readFile(name string, v io.ReaderFrom) error {
+	if name == "" {
+		return errors.New("missing input file")
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = v.ReadFrom(f)
+	return err
+}
+
+func writeFile(name string, v io.WriterTo) error {
+	if name == "" {
+		return errors.New("missing output file")
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := v.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
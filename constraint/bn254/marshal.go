@@ -17,10 +17,14 @@
 package cs
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
 
+	"github.com/consensys/gnark/constraint"
+	fcs "github.com/consensys/gnark/frontend/cs"
+
 	"github.com/blang/semver/v4"
 )
 
@@ -61,27 +65,32 @@ func (cs *system) WriteTo(w io.Writer) (int64, error) {
 	return int64(n+m) + 4*8, err
 }
 
-// ReadFrom attempts to decode R1CS from io.Reader using cbor
+// ReadFrom attempts to decode R1CS from io.Reader using cbor.
+//
+// Systems serialized by gnark v0.8 and v0.9, which had no header, are migrated
+// to the current layout (see constraint.LegacySystem).
 func (cs *system) ReadFrom(r io.Reader) (int64, error) {
-	var totalLen uint64
-	if err := binary.Read(r, binary.LittleEndian, &totalLen); err != nil {
-		return 0, err
+	var header [4 * 8]byte
+	if n, err := io.ReadFull(r, header[:]); err != nil {
+		return int64(n), err
 	}
+	totalLen := binary.LittleEndian.Uint64(header[0:])
+	major := binary.LittleEndian.Uint64(header[8:])
+	minor := binary.LittleEndian.Uint64(header[16:])
+	patch := binary.LittleEndian.Uint64(header[24:])
 
-	var major, minor, patch uint64
-	if err := binary.Read(r, binary.LittleEndian, &major); err != nil {
-		return 0, err
-	}
-	if err := binary.Read(r, binary.LittleEndian, &minor); err != nil {
-		return 0, err
-	}
-	if err := binary.Read(r, binary.LittleEndian, &patch); err != nil {
-		return 0, err
-	}
 	// TODO @gbotrel validate version, duplicate logic with core.go CheckSerializationHeader
-	if major != 0 || minor < 10 {
+	if major == 0 && minor < 10 {
 		return 0, fmt.Errorf("unsupported gnark version %d.%d.%d", major, minor, patch)
 	}
+	if major != 0 {
+		// the legacy encoding has no header: it is a CBOR map, of major type 5,
+		// whose first key is a text string, of major type 3
+		if header[0]>>5 != 5 || header[1]>>5 != 3 {
+			return 0, fmt.Errorf("unsupported gnark version %d.%d.%d", major, minor, patch)
+		}
+		return cs.readLegacyFrom(io.MultiReader(bytes.NewReader(header[:]), r))
+	}
 
 	data := make([]byte, totalLen)
 	if _, err := io.ReadFull(r, data); err != nil {
@@ -99,3 +108,18 @@ func (cs *system) ReadFrom(r io.Reader) (int64, error) {
 
 	return int64(totalLen) + 4*8, nil
 }
+
+// readLegacyFrom decodes a system serialized by gnark v0.8 or v0.9 and
+// migrates it to the current layout.
+func (cs *system) readLegacyFrom(r io.Reader) (int64, error) {
+	var legacy constraint.LegacySystem
+	n, err := legacy.ReadFrom(r)
+	if err != nil {
+		return n, err
+	}
+	*cs = *newSystem(0, legacy.Type())
+	if err := legacy.Migrate(cs, &cs.System, fcs.Bsb22CommitmentComputePlaceholder); err != nil {
+		return n, fmt.Errorf("migrate constraint system of gnark %s: %w", legacy.Version(), err)
+	}
+	return n, nil
+}
@@ -17,6 +17,7 @@
 package cs
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/consensys/gnark-crypto/ecc"
@@ -49,6 +50,7 @@ type solver struct {
 	// used to out api.Println
 	logger  zerolog.Logger
 	nbTasks int
+	ctx     context.Context
 
 	a, b, c fr.Vector // R1CS solver will compute the a,b,c matrices
 
@@ -104,6 +106,7 @@ func newSolver(cs *system, witness fr.Vector, opts ...csolver.Option) (*solver,
 		mHintsFunctions: hintFunctions,
 		logger:          opt.Logger,
 		nbTasks:         opt.NbTasks,
+		ctx:             opt.Context,
 		q:               cs.Field(),
 	}
 
@@ -203,6 +206,11 @@ func (s *solver) accumulateInto(t constraint.Term, r *fr.Element) {
 
 // solveWithHint executes a hint and assign the result to its defined outputs.
 func (s *solver) solveWithHint(h *constraint.HintMapping) error {
+	// hints may be expensive, do not call them when the solver is cancelled
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
 	// ensure hint function was provided
 	f, ok := s.mHintsFunctions[h.HintID]
 	if !ok {
@@ -470,6 +478,10 @@ func (solver *solver) run() error {
 	// for each level, we push the tasks
 	for _, level := range solver.Levels {
 
+		if err := solver.ctx.Err(); err != nil {
+			return err
+		}
+
 		// max CPU to use
 		maxCPU := float64(len(level)) / minWorkPerCPU
 
@@ -0,0 +1,519 @@
+package constraint
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/blang/semver/v4"
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/debug"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// LegacySystem is a constraint system serialized by gnark v0.8 or v0.9.
+//
+// These releases serialized the curve-typed system as a whole with CBOR, without
+// the header and the binary sections of the current layout (see ToBytes). In
+// v0.9 the system was made of instructions and blueprints, as now; in v0.8 it
+// was a list of constraints, with the hints attached to the wires they solve.
+// ReadFrom decodes both layouts, and Migrate rebuilds the system in the current
+// layout.
+type LegacySystem struct {
+	s       legacySystem
+	version semver.Version
+}
+
+// legacySystem holds the fields of the systems serialized by gnark v0.8 and
+// v0.9, flattened as they were by CBOR. The fields which changed type between
+// the two releases are decoded later, depending on the version.
+type legacySystem struct {
+	GnarkVersion string
+	ScalarField  string
+
+	// v0.9
+	Type          SystemType
+	Instructions  []PackedInstruction
+	Blueprints    []Blueprint
+	CallData      []uint32
+	NbConstraints int
+	Levels        [][]uint32
+	GkrInfo       GkrInfo
+
+	// v0.8
+	Constraints cbor.RawMessage
+	MHints      map[int]*legacyHint
+
+	NbInternalVariables int
+	Public, Secret      []string
+	Logs                []LogEntry
+	DebugInfo           []LogEntry
+	SymbolTable         debug.SymbolTable
+	MDebug              map[int]int
+	MHintsDependencies  map[solver.HintID]string
+	CommitmentInfo      cbor.RawMessage
+
+	// Coefficients are the words of the coefficients of the curve-typed system,
+	// in Montgomery form.
+	Coefficients [][]uint64
+}
+
+// legacyHint is a hint of gnark v0.8, which solves the wires Wires.
+type legacyHint struct {
+	ID     solver.HintID
+	Inputs []LinearExpression
+	Wires  []int
+}
+
+// legacySparseR1C is a constraint L + R + M[0]⋅M[1] + O + K == 0 of gnark v0.8,
+// where K is a coefficient ID.
+type legacySparseR1C struct {
+	L, R, O    Term
+	M          [2]Term
+	K          int
+	Commitment CommitmentConstraint
+}
+
+// legacyCommitment is the single commitment of a gnark v0.8 system.
+type legacyCommitment struct {
+	Committed          []int
+	NbPrivateCommitted int
+	HintID             solver.HintID
+	CommitmentIndex    int
+}
+
+// ReadFrom decodes a constraint system serialized by gnark v0.8 or v0.9. The
+// CBOR decoder buffers its input, so r may be read past the end of the system.
+func (l *LegacySystem) ReadFrom(r io.Reader) (int64, error) {
+	dm, err := cbor.DecOptions{
+		MaxArrayElements: 2147483647,
+		MaxMapPairs:      2147483647,
+	}.DecModeWithTags(getTagSet())
+	if err != nil {
+		return 0, err
+	}
+	decoder := dm.NewDecoder(r)
+	if err := decoder.Decode(&l.s); err != nil {
+		return int64(decoder.NumBytesRead()), fmt.Errorf("decode legacy constraint system: %w", err)
+	}
+	n := int64(decoder.NumBytesRead())
+
+	if l.version, err = semver.Parse(l.s.GnarkVersion); err != nil {
+		return n, fmt.Errorf("when parsing gnark version: %w", err)
+	}
+	if !isLegacyVersion(l.version) {
+		return n, fmt.Errorf("unsupported gnark version %s", l.version)
+	}
+	if l.isV08() {
+		if l.s.Type, err = l.s.legacyType(); err != nil {
+			return n, err
+		}
+	}
+	if l.s.Type != SystemR1CS && l.s.Type != SystemSparseR1CS {
+		return n, fmt.Errorf("unsupported constraint system type %d", l.s.Type)
+	}
+	return n, nil
+}
+
+// Version returns the version of gnark which serialized the system.
+func (l *LegacySystem) Version() semver.Version {
+	return l.version
+}
+
+// Type returns the type of the system.
+func (l *LegacySystem) Type() SystemType {
+	return l.s.Type
+}
+
+// isLegacyVersion reports whether v is the version of a release serialized
+// with CBOR. The v0.9 releases were stamped with the pre-release 0.10.0-alpha.
+func isLegacyVersion(v semver.Version) bool {
+	if v.Major != 0 {
+		return false
+	}
+	return v.Minor == 8 || v.Minor == 9 || (v.Minor == 10 && v.Patch == 0 && len(v.Pre) != 0)
+}
+
+func (l *LegacySystem) isV08() bool {
+	return l.version.Minor == 8
+}
+
+// legacyType returns the type of a v0.8 system, which is only known from the
+// shape of its constraints.
+func (s *legacySystem) legacyType() (SystemType, error) {
+	if len(s.Constraints) == 0 {
+		return SystemR1CS, nil
+	}
+	var constraints []map[string]cbor.RawMessage
+	if err := cbor.Unmarshal(s.Constraints, &constraints); err != nil {
+		return SystemUnknown, fmt.Errorf("decode legacy constraints: %w", err)
+	}
+	if len(constraints) != 0 {
+		if _, ok := constraints[0]["M"]; ok {
+			return SystemSparseR1CS, nil
+		}
+	}
+	return SystemR1CS, nil
+}
+
+// Migrate rebuilds the legacy system in cs, a new system of type l.Type() on
+// the same curve. system is the System embedded in cs.
+//
+// A v0.9 system is used as is, except for its commitment hints, which are
+// replaced by commitmentHint, the placeholder which the provers override. The
+// constraints and the hints of a v0.8 system are added again with the generic
+// blueprints, and its coefficient table is rebuilt; v0.8 commitments are
+// rejected, as the commitment scheme changed in v0.9.
+func (l *LegacySystem) Migrate(cs ConstraintSystem, system *System, commitmentHint solver.Hint) error {
+	if system.Type != l.s.Type {
+		return fmt.Errorf("migrating a system of type %d into a system of type %d", l.s.Type, system.Type)
+	}
+	if l.s.ScalarField != system.ScalarField {
+		return fmt.Errorf("constraint system on the scalar field %s, expected %s", l.s.ScalarField, system.ScalarField)
+	}
+
+	// the coefficient IDs are remapped, as gnark v0.8 had fewer predefined
+	// coefficients
+	nbWords := (system.FieldBitLen() + 63) / 64
+	cIDs := make([]uint32, len(l.s.Coefficients))
+	for i, words := range l.s.Coefficients {
+		if len(words) != nbWords {
+			return fmt.Errorf("coefficient %d has %d words, expected %d", i, len(words), nbWords)
+		}
+		var c Element
+		copy(c[:], words)
+		cIDs[i] = cs.AddCoeff(c)
+	}
+
+	if l.isV08() {
+		m := legacyMigration{cs: cs, system: system, cIDs: cIDs}
+		return m.run(&l.s)
+	}
+
+	for i := range cIDs {
+		if cIDs[i] != uint32(i) {
+			return errors.New("unsupported coefficient table")
+		}
+	}
+
+	var commitments Commitments
+	if len(l.s.CommitmentInfo) != 0 {
+		dm, err := cbor.DecOptions{}.DecModeWithTags(getTagSet())
+		if err != nil {
+			return err
+		}
+		if err := dm.Unmarshal(l.s.CommitmentInfo, &commitments); err != nil {
+			return fmt.Errorf("decode legacy commitments: %w", err)
+		}
+	}
+	switch v := commitments.(type) {
+	case nil:
+		commitments = NewCommitments(l.s.Type)
+	case *Groth16Commitments:
+		commitments = *v
+	case *PlonkCommitments:
+		commitments = *v
+	}
+
+	system.Instructions = l.s.Instructions
+	system.Blueprints = l.s.Blueprints
+	system.CallData = l.s.CallData
+	system.NbConstraints = l.s.NbConstraints
+	system.Levels = l.s.Levels
+	system.NbInternalVariables = l.s.NbInternalVariables
+	system.Public = l.s.Public
+	system.Secret = l.s.Secret
+	system.Logs = l.s.Logs
+	system.DebugInfo = l.s.DebugInfo
+	system.SymbolTable = l.s.SymbolTable
+	system.MDebug = l.s.MDebug
+	system.MHintsDependencies = l.s.MHintsDependencies
+	system.CommitmentInfo = commitments
+	system.GkrInfo = l.s.GkrInfo
+	system.lbWireLevel = nil
+	if system.MDebug == nil {
+		system.MDebug = map[int]int{}
+	}
+	if system.MHintsDependencies == nil {
+		system.MHintsDependencies = map[solver.HintID]string{}
+	}
+	return migrateCommitmentHints(cs, system, len(commitments.CommitmentIndexes()), commitmentHint)
+}
+
+// migrateCommitmentHints replaces the commitment hints of a gnark v0.9 system.
+// These had an ID per commitment, derived from the name "bsb22 commitment #i",
+// while the provers now override a single hint which takes the index of the
+// commitment as its first input.
+func migrateCommitmentHints(cs ConstraintSystem, system *System, nbCommitments int, commitmentHint solver.Hint) error {
+	if nbCommitments == 0 {
+		return nil
+	}
+	legacyIDs := make(map[solver.HintID]int, nbCommitments)
+	for i := 0; i < nbCommitments; i++ {
+		hf := fnv.New32a()
+		hf.Write([]byte("bsb22 commitment #" + strconv.Itoa(i))) // #nosec G104 -- does not err
+		legacyIDs[solver.HintID(hf.Sum32())] = i
+	}
+	hintID := solver.GetHintID(commitmentHint)
+
+	// the call data is rewritten, as the hints get one more input
+	callData := make([]uint32, 0, len(system.CallData)+4*nbCommitments)
+	var h HintMapping
+	nbMigrated := 0
+	for i, pi := range system.Instructions {
+		inst := pi.Unpack(system)
+		system.Instructions[i].StartCallData = uint64(len(callData))
+		b, ok := system.Blueprints[pi.BlueprintID].(BlueprintHint)
+		if !ok {
+			callData = append(callData, inst.Calldata...)
+			continue
+		}
+		b.DecompressHint(&h, inst)
+		index, ok := legacyIDs[h.HintID]
+		if !ok {
+			callData = append(callData, inst.Calldata...)
+			continue
+		}
+		t := cs.MakeTerm(cs.FromInterface(index), 0)
+		t.MarkConstant()
+		h.HintID = hintID
+		h.Inputs = append([]LinearExpression{{t}}, h.Inputs...)
+		b.CompressHint(h, &callData)
+		nbMigrated++
+	}
+	if nbMigrated != nbCommitments {
+		return fmt.Errorf("found %d commitment hints, expected %d", nbMigrated, nbCommitments)
+	}
+	system.CallData = callData
+
+	for id := range legacyIDs {
+		delete(system.MHintsDependencies, id)
+	}
+	system.MHintsDependencies[hintID] = solver.GetHintName(commitmentHint)
+	return nil
+}
+
+// legacyMigration rebuilds a gnark v0.8 system. The first error is sticky.
+type legacyMigration struct {
+	cs     ConstraintSystem
+	system *System
+	cIDs   []uint32
+	err    error
+
+	// hints maps the wires solved by a hint to the hint, emitted records the
+	// hints already added to the system
+	hints   map[int]*HintMapping
+	emitted map[*HintMapping]bool
+}
+
+func (m *legacyMigration) run(s *legacySystem) error {
+	if len(s.CommitmentInfo) != 0 {
+		var c legacyCommitment
+		if err := cbor.Unmarshal(s.CommitmentInfo, &c); err != nil {
+			return fmt.Errorf("decode legacy commitment: %w", err)
+		}
+		if len(c.Committed) != 0 {
+			return errors.New("commitments of gnark v0.8 can't be migrated")
+		}
+	}
+
+	for _, name := range s.Public {
+		m.cs.AddPublicVariable(name)
+	}
+	for _, name := range s.Secret {
+		m.cs.AddSecretVariable(name)
+	}
+	for i := 0; i < s.NbInternalVariables; i++ {
+		m.cs.AddInternalVariable()
+	}
+	nbWires := uint32(len(s.Public) + len(s.Secret) + s.NbInternalVariables)
+	for id, name := range s.MHintsDependencies {
+		m.system.MHintsDependencies[id] = name
+	}
+
+	// the same hint is decoded once per wire it solves
+	m.hints = make(map[int]*HintMapping)
+	m.emitted = make(map[*HintMapping]bool)
+	var hints []*HintMapping
+	for _, h := range s.MHints {
+		if len(h.Wires) == 0 {
+			return errors.New("legacy hint without outputs")
+		}
+		if m.hints[h.Wires[0]] != nil {
+			continue
+		}
+		hm := &HintMapping{HintID: h.ID, Inputs: make([]LinearExpression, len(h.Inputs))}
+		hm.OutputRange.Start = uint32(h.Wires[0])
+		hm.OutputRange.End = hm.OutputRange.Start + uint32(len(h.Wires))
+		if hm.OutputRange.End > nbWires || hm.OutputRange.Start < uint32(len(s.Public)+len(s.Secret)) {
+			return fmt.Errorf("legacy hint solves invalid wires %v", h.Wires)
+		}
+		for i, w := range h.Wires {
+			if w != h.Wires[0]+i {
+				return fmt.Errorf("legacy hint solves non-contiguous wires %v", h.Wires)
+			}
+			m.hints[w] = hm
+		}
+		for i := range h.Inputs {
+			hm.Inputs[i] = m.linearExpression(h.Inputs[i])
+		}
+		hints = append(hints, hm)
+	}
+
+	switch s.Type {
+	case SystemR1CS:
+		var constraints []R1C
+		if len(s.Constraints) != 0 {
+			if err := cbor.Unmarshal(s.Constraints, &constraints); err != nil {
+				return fmt.Errorf("decode legacy constraints: %w", err)
+			}
+		}
+		r1cs, ok := m.cs.(R1CS)
+		if !ok {
+			return errors.New("migrating a R1CS into another system")
+		}
+		bID := m.cs.AddBlueprint(&BlueprintGenericR1C{})
+		for _, c := range constraints {
+			c.L, c.R, c.O = m.linearExpression(c.L), m.linearExpression(c.R), m.linearExpression(c.O)
+			m.emitHints(c.L, c.R, c.O)
+			if m.err != nil {
+				return m.err
+			}
+			r1cs.AddR1C(c, bID)
+		}
+	case SystemSparseR1CS:
+		var constraints []legacySparseR1C
+		if err := cbor.Unmarshal(s.Constraints, &constraints); err != nil {
+			return fmt.Errorf("decode legacy constraints: %w", err)
+		}
+		scs, ok := m.cs.(SparseR1CS)
+		if !ok {
+			return errors.New("migrating a SparseR1CS into another system")
+		}
+		bID := m.cs.AddBlueprint(&BlueprintGenericSparseR1C{})
+		for i := range constraints {
+			c := m.sparseR1C(&constraints[i])
+			m.emitHints(LinearExpression{{CID: c.QL, VID: c.XA}, {CID: c.QR, VID: c.XB}, {CID: c.QO, VID: c.XC}})
+			if m.err != nil {
+				return fmt.Errorf("legacy constraint %d: %w", i, m.err)
+			}
+			scs.AddSparseR1C(c, bID)
+		}
+	}
+
+	// the hints whose outputs aren't constrained
+	sort.Slice(hints, func(i, j int) bool { return hints[i].OutputRange.Start < hints[j].OutputRange.Start })
+	for _, hm := range hints {
+		m.emitHint(hm)
+	}
+
+	for _, l := range s.Logs {
+		m.cs.AddLog(m.logEntry(l))
+	}
+	for i := range s.DebugInfo {
+		m.system.DebugInfo = append(m.system.DebugInfo, m.logEntry(s.DebugInfo[i]))
+	}
+	for cID, dID := range s.MDebug {
+		m.system.MDebug[cID] = dID
+	}
+	m.system.SymbolTable = s.SymbolTable
+	return m.err
+}
+
+// emitHints adds the hints solving the wires of the expressions, and the hints
+// they depend on, if they were not added yet.
+func (m *legacyMigration) emitHints(l ...LinearExpression) {
+	for _, e := range l {
+		for _, t := range e {
+			if t.IsConstant() {
+				continue
+			}
+			if hm := m.hints[t.WireID()]; hm != nil {
+				m.emitHint(hm)
+			}
+		}
+	}
+}
+
+func (m *legacyMigration) emitHint(hm *HintMapping) {
+	if m.emitted[hm] || m.err != nil {
+		return
+	}
+	m.emitted[hm] = true
+	m.emitHints(hm.Inputs...)
+
+	calldata := getBuffer()
+	m.system.Blueprints[m.system.genericHint].(BlueprintHint).CompressHint(*hm, calldata)
+	m.cs.AddInstruction(m.system.genericHint, *calldata)
+	putBuffer(calldata)
+}
+
+// sparseR1C converts the v0.8 constraint, whose multiplication term holds the
+// wires of L and R.
+func (m *legacyMigration) sparseR1C(c *legacySparseR1C) SparseR1C {
+	l, r, o := m.term(c.L), m.term(c.R), m.term(c.O)
+	m0, m1 := m.term(c.M[0]), m.term(c.M[1])
+	res := SparseR1C{
+		XA: l.VID, XB: r.VID, XC: o.VID,
+		QL: l.CID, QR: r.CID, QO: o.CID,
+		QC:         m.coeffID(c.K),
+		Commitment: c.Commitment,
+	}
+	if m0.CID == CoeffIdZero || m1.CID == CoeffIdZero {
+		return res
+	}
+	if l.CID == CoeffIdZero {
+		res.XA = m0.VID
+	}
+	if r.CID == CoeffIdZero {
+		res.XB = m1.VID
+	}
+	if m0.VID != res.XA || m1.VID != res.XB {
+		if m0.VID != res.XB || m1.VID != res.XA {
+			m.setErr(errors.New("multiplication of wires other than L and R"))
+			return res
+		}
+	}
+	res.QM = m.cs.AddCoeff(m.cs.Mul(m.cs.GetCoefficient(int(m0.CID)), m.cs.GetCoefficient(int(m1.CID))))
+	return res
+}
+
+func (m *legacyMigration) logEntry(l LogEntry) LogEntry {
+	res := l
+	res.ToResolve = make([]LinearExpression, len(l.ToResolve))
+	for i := range l.ToResolve {
+		res.ToResolve[i] = m.linearExpression(l.ToResolve[i])
+	}
+	return res
+}
+
+func (m *legacyMigration) linearExpression(l LinearExpression) LinearExpression {
+	res := make(LinearExpression, len(l))
+	for i := range l {
+		res[i] = m.term(l[i])
+	}
+	return res
+}
+
+func (m *legacyMigration) term(t Term) Term {
+	t.CID = m.coeffID(int(t.CID))
+	return t
+}
+
+func (m *legacyMigration) coeffID(cID int) uint32 {
+	if cID < 0 || cID >= len(m.cIDs) || cID > math.MaxUint32 {
+		m.setErr(fmt.Errorf("invalid coefficient ID %d", cID))
+		return CoeffIdZero
+	}
+	return m.cIDs[cID]
+}
+
+func (m *legacyMigration) setErr(err error) {
+	if m.err == nil {
+		m.err = err
+	}
+}
@@ -1,6 +1,7 @@
 package frontend
 
 import (
+	"errors"
 	"math/big"
 	"reflect"
 
@@ -57,6 +58,20 @@ func NewWitness(assignment Circuit, field *big.Int, opts ...WitnessOption) (witn
 		return nil, err
 	}
 
+	if opt.withSchema {
+		s, err := schema.New(assignment, tVariable)
+		if err != nil {
+			return nil, err
+		}
+		schemer, ok := w.(witness.Schemer)
+		if !ok {
+			return nil, errors.New("the witness can't embed a schema")
+		}
+		if err := schemer.SetSchema(s); err != nil {
+			return nil, err
+		}
+	}
+
 	return w, nil
 }
 
@@ -87,6 +102,7 @@ type WitnessOption func(*witnessConfig) error
 
 type witnessConfig struct {
 	publicOnly bool
+	withSchema bool
 }
 
 // PublicOnly enables to instantiate a witness with the public part only of the assignment
@@ -96,3 +112,12 @@ func PublicOnly() WitnessOption {
 		return nil
 	}
 }
+
+// WithSchema embeds the schema of the assignment in the witness, so that its binary encoding can
+// be inspected without the circuit structure (see witness.ReadValue).
+func WithSchema() WitnessOption {
+	return func(opt *witnessConfig) error {
+		opt.withSchema = true
+		return nil
+	}
+}
@@ -0,0 +1,197 @@
+// Command evmcheck runs a gnark Solidity verifier on the EVM of go-ethereum,
+// with the precompiles of the Prague hard fork enabled (EIP-2537 for
+// BLS12-381).
+//
+// It compiles the contract with solc, which must be reachable in the PATH,
+// calls Verify(bytes, uint256[]) with the given proof and public inputs and
+// fails unless the proof is accepted. It then checks that the contract
+// rejects a tampered proof and a tampered public input. If a compressed proof
+// is given, VerifyCompressed is checked the same way.
+//
+// It is a separate module so that go-ethereum, and the version of
+// gnark-crypto it requires, stay out of the gnark module. The tests of the
+// gnark module run it when the build tag "solccheck" is set:
+//
+//	cd internal/evmcheck
+//	go run . --solidity verifier.sol --proof <hex> --public-inputs <hex>
+//
+// The public inputs are the concatenation of their 32 bytes big-endian
+// encodings.
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"os/exec"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	fSolidity     = flag.String("solidity", "", "path of the Solidity verifier")
+	fProof        = flag.String("proof", "", "proof, hex encoded with MarshalSolidity")
+	fPublicInputs = flag.String("public-inputs", "", "public inputs, hex encoded on 32 bytes each")
+	fCompressed   = flag.String("compressed-proof", "", "optional proof, hex encoded with MarshalSolidityCompressed")
+	fSolc         = flag.String("solc", "solc", "solc binary")
+)
+
+func main() {
+	flag.Parse()
+	if *fSolidity == "" {
+		log.Fatal("missing --solidity")
+	}
+	proof, err := hex.DecodeString(*fProof)
+	if err != nil {
+		log.Fatalf("decoding the proof: %v", err)
+	}
+	publicInputs, err := decodeInputs(*fPublicInputs)
+	if err != nil {
+		log.Fatalf("decoding the public inputs: %v", err)
+	}
+
+	contract, err := compile(*fSolc, *fSolidity)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err = contract.check("Verify", proof, publicInputs); err != nil {
+		log.Fatal(err)
+	}
+	if *fCompressed != "" {
+		compressed, err := hex.DecodeString(*fCompressed)
+		if err != nil {
+			log.Fatalf("decoding the compressed proof: %v", err)
+		}
+		if err = contract.check("VerifyCompressed", compressed, publicInputs); err != nil {
+			log.Fatal(err)
+		}
+	}
+	fmt.Println("ok")
+}
+
+func decodeInputs(s string) ([]*big.Int, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b)%32 != 0 {
+		return nil, errors.New("public inputs must be encoded on 32 bytes each")
+	}
+	inputs := make([]*big.Int, len(b)/32)
+	for i := range inputs {
+		inputs[i] = new(big.Int).SetBytes(b[32*i : 32*(i+1)])
+	}
+	return inputs, nil
+}
+
+type contract struct {
+	abi  abi.ABI
+	code []byte
+}
+
+// compile compiles the Solidity file and returns the contract exposing Verify.
+func compile(solc, path string) (*contract, error) {
+	out, err := exec.Command(solc, "--optimize", "--combined-json", "abi,bin-runtime", path).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("compiling %s: %w\n%s", path, err, exitErr.Stderr)
+		}
+		return nil, fmt.Errorf("compiling %s: %w", path, err)
+	}
+	var combined struct {
+		Contracts map[string]struct {
+			ABI        json.RawMessage `json:"abi"`
+			BinRuntime string          `json:"bin-runtime"`
+		} `json:"contracts"`
+	}
+	if err = json.Unmarshal(out, &combined); err != nil {
+		return nil, fmt.Errorf("reading solc output: %w", err)
+	}
+	for name, c := range combined.Contracts {
+		// solc < 0.8.10 encodes the ABI as a JSON string
+		rawABI := c.ABI
+		var s string
+		if json.Unmarshal(c.ABI, &s) == nil {
+			rawABI = json.RawMessage(s)
+		}
+		parsed, err := abi.JSON(strings.NewReader(string(rawABI)))
+		if err != nil {
+			return nil, fmt.Errorf("reading the ABI of %s: %w", name, err)
+		}
+		if _, ok := parsed.Methods["Verify"]; !ok {
+			continue
+		}
+		code, err := hex.DecodeString(c.BinRuntime)
+		if err != nil {
+			return nil, fmt.Errorf("reading the code of %s: %w", name, err)
+		}
+		return &contract{abi: parsed, code: code}, nil
+	}
+	return nil, fmt.Errorf("no contract of %s exposes Verify", path)
+}
+
+// check calls method, which must accept the proof and reject a tampered proof
+// and a tampered public input, either by returning false or by reverting.
+func (c *contract) check(method string, proof []byte, publicInputs []*big.Int) error {
+	ok, err := c.verify(method, proof, publicInputs)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if !ok {
+		return fmt.Errorf("%s rejected a valid proof", method)
+	}
+	tampered := bytes.Clone(proof)
+	tampered[len(tampered)-1] ^= 1
+	if ok, _ = c.verify(method, tampered, publicInputs); ok {
+		return fmt.Errorf("%s accepted a tampered proof", method)
+	}
+	if len(publicInputs) > 0 {
+		tamperedInputs := append([]*big.Int{}, publicInputs...)
+		tamperedInputs[0] = new(big.Int).Add(publicInputs[0], big.NewInt(1))
+		if ok, _ = c.verify(method, proof, tamperedInputs); ok {
+			return fmt.Errorf("%s accepted a tampered public input", method)
+		}
+	}
+	return nil
+}
+
+// newConfig returns the configuration of a chain where all the hard forks up
+// to Prague are active.
+func newConfig() *runtime.Config {
+	return &runtime.Config{ChainConfig: params.MergedTestChainConfig}
+}
+
+// verify calls method on a fresh state of the chain returned by newConfig.
+// It returns an error if the call reverts.
+func (c *contract) verify(method string, proof []byte, publicInputs []*big.Int) (bool, error) {
+	input, err := c.abi.Pack(method, proof, publicInputs)
+	if err != nil {
+		return false, err
+	}
+	ret, _, err := runtime.Execute(c.code, input, newConfig())
+	if err != nil {
+		return false, err
+	}
+	res, err := c.abi.Unpack(method, ret)
+	if err != nil {
+		return false, err
+	}
+	if len(res) != 1 {
+		return false, fmt.Errorf("%s must return a single bool", method)
+	}
+	ok, isBool := res[0].(bool)
+	if !isBool {
+		return false, fmt.Errorf("%s must return a single bool", method)
+	}
+	return ok, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+)
+
+// TestPraguePrecompiles checks that the EIP-2537 precompiles are active on the
+// chain the verifiers run on.
+func TestPraguePrecompiles(t *testing.T) {
+	// returns success * returndatasize of the G1ADD precompile (0x0b) applied
+	// to two points at infinity
+	code := []byte{
+		0x60, 0x80, // PUSH1 0x80 (retSize)
+		0x60, 0x00, // PUSH1 0 (retOffset)
+		0x61, 0x01, 0x00, // PUSH2 0x100 (argsSize)
+		0x60, 0x00, // PUSH1 0 (argsOffset)
+		0x60, 0x0b, // PUSH1 0x0b (address)
+		0x5a,       // GAS
+		0xfa,       // STATICCALL
+		0x3d,       // RETURNDATASIZE
+		0x02,       // MUL
+		0x60, 0x00, // PUSH1 0
+		0x52,       // MSTORE
+		0x60, 0x20, // PUSH1 0x20
+		0x60, 0x00, // PUSH1 0
+		0xf3, // RETURN
+	}
+	ret, _, err := runtime.Execute(code, nil, newConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := new(big.Int).SetBytes(ret); got.Cmp(big.NewInt(0x80)) != 0 {
+		t.Fatalf("G1ADD returned %d bytes, expected 128", got)
+	}
+}
+
+func TestDecodeInputs(t *testing.T) {
+	inputs, err := decodeInputs("00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 2 || inputs[0].Int64() != 1 || inputs[1].Int64() != 2 {
+		t.Fatalf("unexpected inputs %v", inputs)
+	}
+	if _, err = decodeInputs("0001"); err == nil {
+		t.Fatal("expected an error on a truncated input")
+	}
+}
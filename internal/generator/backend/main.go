@@ -160,7 +160,10 @@ func main() {
 				{File: filepath.Join(groth16Dir, "prove.go"), Templates: []string{"groth16/groth16.prove.go.tmpl", importCurve}},
 				{File: filepath.Join(groth16Dir, "setup.go"), Templates: []string{"groth16/groth16.setup.go.tmpl", importCurve}},
 				{File: filepath.Join(groth16Dir, "marshal.go"), Templates: []string{"groth16/groth16.marshal.go.tmpl", importCurve}},
+				{File: filepath.Join(groth16Dir, "marshal_json.go"), Templates: []string{"groth16/groth16.marshal_json.go.tmpl", importCurve}},
+				{File: filepath.Join(groth16Dir, "migrate.go"), Templates: []string{"groth16/groth16.migrate.go.tmpl", importCurve}},
 				{File: filepath.Join(groth16Dir, "marshal_test.go"), Templates: []string{"groth16/tests/groth16.marshal.go.tmpl", importCurve}},
+				{File: filepath.Join(groth16Dir, "prove_test.go"), Templates: []string{"groth16/tests/groth16.prove.go.tmpl", importCurve}},
 			}
 			if err := bgen.Generate(d, "groth16", "./template/zkpschemes/", entries...); err != nil {
 				panic(err) // TODO handle
@@ -184,23 +187,71 @@ func main() {
 				{File: filepath.Join(groth16MpcSetupDir, "setup_test.go"), Templates: []string{"groth16/mpcsetup/setup_test.go.tmpl", importCurve}},
 				{File: filepath.Join(groth16MpcSetupDir, "utils.go"), Templates: []string{"groth16/mpcsetup/utils.go.tmpl", importCurve}},
 			}
+			if d.Curve == "BN254" || d.Curve == "BLS12-381" {
+				entries = append(entries,
+					bavard.Entry{File: filepath.Join(groth16MpcSetupDir, "ptau.go"), Templates: []string{"groth16/mpcsetup/ptau.go.tmpl", importCurve}},
+					bavard.Entry{File: filepath.Join(groth16MpcSetupDir, "ptau_test.go"), Templates: []string{"groth16/mpcsetup/ptau_test.go.tmpl", importCurve}},
+				)
+			}
+			if d.Curve == "BN254" {
+				entries = append(entries,
+					bavard.Entry{File: filepath.Join(groth16MpcSetupDir, "ppot.go"), Templates: []string{"groth16/mpcsetup/ppot.go.tmpl", importCurve}},
+					bavard.Entry{File: filepath.Join(groth16MpcSetupDir, "ppot_test.go"), Templates: []string{"groth16/mpcsetup/ppot_test.go.tmpl", importCurve}},
+				)
+			}
 
 			if err := bgen.Generate(d, "mpcsetup", "./template/zkpschemes/", entries...); err != nil {
 				panic(err) // TODO handle
 			}
 
+			// groth16 aggregation
+			if d.Curve == "BN254" || d.Curve == "BLS12-381" {
+				groth16AggregationDir := filepath.Join(groth16Dir, "aggregation")
+				entries = []bavard.Entry{
+					{File: filepath.Join(groth16AggregationDir, "aggregate.go"), Templates: []string{"groth16/aggregation/aggregate.go.tmpl", importCurve}},
+					{File: filepath.Join(groth16AggregationDir, "aggregate_test.go"), Templates: []string{"groth16/aggregation/aggregate_test.go.tmpl", importCurve}},
+					{File: filepath.Join(groth16AggregationDir, "marshal.go"), Templates: []string{"groth16/aggregation/marshal.go.tmpl", importCurve}},
+					{File: filepath.Join(groth16AggregationDir, "srs.go"), Templates: []string{"groth16/aggregation/srs.go.tmpl", importCurve}},
+					{File: filepath.Join(groth16AggregationDir, "transcript.go"), Templates: []string{"groth16/aggregation/transcript.go.tmpl", importCurve}},
+					{File: filepath.Join(groth16AggregationDir, "verify.go"), Templates: []string{"groth16/aggregation/verify.go.tmpl", importCurve}},
+				}
+				if err := bgen.Generate(d, "aggregation", "./template/zkpschemes/", entries...); err != nil {
+					panic(err)
+				}
+			}
+
 			// plonk
 			entries = []bavard.Entry{
 				{File: filepath.Join(plonkDir, "verify.go"), Templates: []string{"plonk/plonk.verify.go.tmpl", importCurve}},
 				{File: filepath.Join(plonkDir, "prove.go"), Templates: []string{"plonk/plonk.prove.go.tmpl", importCurve}},
 				{File: filepath.Join(plonkDir, "setup.go"), Templates: []string{"plonk/plonk.setup.go.tmpl", importCurve}},
 				{File: filepath.Join(plonkDir, "marshal.go"), Templates: []string{"plonk/plonk.marshal.go.tmpl", importCurve}},
+				{File: filepath.Join(plonkDir, "marshal_json.go"), Templates: []string{"plonk/plonk.marshal_json.go.tmpl", importCurve}},
 				{File: filepath.Join(plonkDir, "marshal_test.go"), Templates: []string{"plonk/tests/marshal.go.tmpl", importCurve}},
 			}
 			if err := bgen.Generate(d, "plonk", "./template/zkpschemes/", entries...); err != nil {
 				panic(err)
 			}
 
+			// plonk mpcsetup
+			plonkMpcSetupDir := filepath.Join(plonkDir, "mpcsetup")
+			entries = []bavard.Entry{
+				{File: filepath.Join(plonkMpcSetupDir, "marshal.go"), Templates: []string{"plonk/mpcsetup/marshal.go.tmpl", importCurve}},
+				{File: filepath.Join(plonkMpcSetupDir, "phase1.go"), Templates: []string{"plonk/mpcsetup/phase1.go.tmpl", importCurve}},
+				{File: filepath.Join(plonkMpcSetupDir, "setup_test.go"), Templates: []string{"plonk/mpcsetup/setup_test.go.tmpl", importCurve}},
+				{File: filepath.Join(plonkMpcSetupDir, "srs.go"), Templates: []string{"plonk/mpcsetup/srs.go.tmpl", importCurve}},
+				{File: filepath.Join(plonkMpcSetupDir, "utils.go"), Templates: []string{"plonk/mpcsetup/utils.go.tmpl", importCurve}},
+			}
+			if d.Curve == "BLS12-381" {
+				entries = append(entries,
+					bavard.Entry{File: filepath.Join(plonkMpcSetupDir, "ethereum.go"), Templates: []string{"plonk/mpcsetup/ethereum.go.tmpl", importCurve}},
+					bavard.Entry{File: filepath.Join(plonkMpcSetupDir, "ethereum_test.go"), Templates: []string{"plonk/mpcsetup/ethereum_test.go.tmpl", importCurve}},
+				)
+			}
+			if err := bgen.Generate(d, "mpcsetup", "./template/zkpschemes/", entries...); err != nil {
+				panic(err)
+			}
+
 		}(d)
 
 	}
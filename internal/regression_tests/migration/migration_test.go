@@ -0,0 +1,240 @@
+// Package migration checks that the constraint systems and the Groth16 keys
+// serialized by the previous releases of gnark are migrated to the current
+// layout.
+//
+// The fixtures in testdata are written by gnark v0.8.1 and v0.9.1 themselves,
+// with the programs of testdata/generate, each pinned to its release in its
+// own module:
+//
+//	cd testdata/generate/v0.9.1 && go run .
+package migration
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/consensys/gnark/test/unsafekzg"
+)
+
+var (
+	v08 = semver.MustParse("0.8.1")
+	v09 = semver.MustParse("0.9.1")
+)
+
+func init() {
+	solver.RegisterHint(DivHint)
+	// the hint IDs are derived from the names of the functions, and the
+	// fixtures are generated by main packages
+	h := fnv.New32a()
+	h.Write([]byte("main.DivHint"))
+	solver.RegisterNamedHint(DivHint, solver.HintID(h.Sum32()))
+}
+
+// DivHint returns inputs[0] / inputs[1] and inputs[0] * inputs[1].
+func DivHint(mod *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	outputs[0].ModInverse(inputs[1], mod)
+	outputs[0].Mul(outputs[0], inputs[0]).Mod(outputs[0], mod)
+	outputs[1].Mul(inputs[0], inputs[1]).Mod(outputs[1], mod)
+	return nil
+}
+
+type Circuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+
+	// withCommitment is false for the circuits of gnark v0.8, whose proving
+	// keys don't serialize the commitment key
+	withCommitment bool
+}
+
+func (c *Circuit) Define(api frontend.API) error {
+	res, err := api.Compiler().NewHint(DivHint, 2, c.X, c.Y)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(api.Mul(res[0], c.Y), c.X)
+	api.AssertIsEqual(res[1], api.Mul(c.X, c.Y))
+	api.AssertIsEqual(api.Add(res[0], api.Mul(3, c.X), -2), c.Z)
+	api.AssertIsDifferent(c.Y, 0)
+	if c.withCommitment {
+		commitment, err := api.Compiler().(frontend.Committer).Commit(c.X, res[0])
+		if err != nil {
+			return err
+		}
+		api.AssertIsDifferent(commitment, c.Z)
+	}
+	return nil
+}
+
+func assignment() *Circuit {
+	// 12 / 3 + 3 * 12 - 2
+	return &Circuit{X: 12, Y: 3, Z: 38}
+}
+
+func fixture(version semver.Version, ext string) string {
+	return filepath.Join("testdata", fmt.Sprintf("v%s.%s", version, ext))
+}
+
+func TestGroth16(t *testing.T) {
+	for _, version := range []semver.Version{v08, v09} {
+		t.Run(version.String(), func(t *testing.T) {
+			assert := test.NewAssert(t)
+
+			f, err := os.Open(fixture(version, "r1cs"))
+			assert.NoError(err)
+			defer f.Close()
+			ccs := groth16.NewCS(ecc.BN254)
+			_, err = ccs.ReadFrom(f)
+			assert.NoError(err)
+
+			f, err = os.Open(fixture(version, "groth16.pk"))
+			assert.NoError(err)
+			defer f.Close()
+			pk, err := groth16.ReadLegacyProvingKey(f, ecc.BN254, version)
+			assert.NoError(err)
+
+			f, err = os.Open(fixture(version, "groth16.vk"))
+			assert.NoError(err)
+			defer f.Close()
+			vk, err := groth16.ReadLegacyVerifyingKey(f, ecc.BN254, version)
+			assert.NoError(err)
+
+			w, err := frontend.NewWitness(assignment(), ecc.BN254.ScalarField())
+			assert.NoError(err)
+			pw, err := w.Public()
+			assert.NoError(err)
+			proof, err := groth16.Prove(ccs, pk, w)
+			assert.NoError(err)
+			assert.NoError(groth16.Verify(proof, vk, pw))
+
+			// the migrated system is written in the current layout
+			assert.NoError(roundTrip(ccs, groth16.NewCS(ecc.BN254)))
+		})
+	}
+}
+
+func TestPlonk(t *testing.T) {
+	for _, version := range []semver.Version{v08, v09} {
+		t.Run(version.String(), func(t *testing.T) {
+			assert := test.NewAssert(t)
+
+			f, err := os.Open(fixture(version, "scs"))
+			assert.NoError(err)
+			defer f.Close()
+			ccs := plonk.NewCS(ecc.BN254)
+			_, err = ccs.ReadFrom(f)
+			assert.NoError(err)
+
+			// PlonK keys are derived from the system and the SRS only, so they
+			// are set up again rather than migrated
+			srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+			assert.NoError(err)
+			pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+			assert.NoError(err)
+
+			w, err := frontend.NewWitness(assignment(), ecc.BN254.ScalarField())
+			assert.NoError(err)
+			pw, err := w.Public()
+			assert.NoError(err)
+			proof, err := plonk.Prove(ccs, pk, w)
+			assert.NoError(err)
+			assert.NoError(plonk.Verify(proof, vk, pw))
+
+			assert.NoError(roundTrip(ccs, plonk.NewCS(ecc.BN254)))
+		})
+	}
+}
+
+func TestUnsatisfied(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, ext := range []string{"r1cs", "scs"} {
+		data, err := os.ReadFile(fixture(v08, ext))
+		assert.NoError(err)
+		ccs := groth16.NewCS(ecc.BN254)
+		if ext == "scs" {
+			ccs = plonk.NewCS(ecc.BN254)
+		}
+		_, err = ccs.ReadFrom(bytes.NewReader(data))
+		assert.NoError(err)
+
+		w, err := frontend.NewWitness(&Circuit{X: 12, Y: 3, Z: 39}, ecc.BN254.ScalarField())
+		assert.NoError(err)
+		_, err = ccs.Solve(w)
+		assert.Error(err, ext)
+	}
+}
+
+func TestUnsupportedVersion(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	// the v0.9 releases were stamped with 0.10.0-alpha, which is replaced by a
+	// version of the same length to keep the CBOR encoding valid
+	data, err := os.ReadFile(fixture(v09, "r1cs"))
+	assert.NoError(err)
+	data = bytes.Replace(data, []byte("0.10.0-alpha"), []byte("0.7.1-alpha0"), 1)
+	_, err = groth16.NewCS(ecc.BN254).ReadFrom(bytes.NewReader(data))
+	assert.ErrorContains(err, "unsupported gnark version 0.7.1-alpha0")
+
+	f, err := os.Open(fixture(v09, "groth16.pk"))
+	assert.NoError(err)
+	defer f.Close()
+	_, err = groth16.ReadLegacyProvingKey(f, ecc.BN254, semver.MustParse("0.7.1"))
+	assert.ErrorContains(err, "unsupported gnark version 0.7.1")
+}
+
+func TestHeader(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	// a header of the current layout with the version of a legacy release
+	var header [4 * 8]byte
+	binary.LittleEndian.PutUint64(header[16:], 9)
+	_, err := groth16.NewCS(ecc.BN254).ReadFrom(bytes.NewReader(header[:]))
+	assert.ErrorContains(err, "unsupported gnark version 0.9.0")
+
+	// neither a header nor a CBOR map
+	for i := range header {
+		header[i] = 0xff
+	}
+	_, err = groth16.NewCS(ecc.BN254).ReadFrom(bytes.NewReader(header[:]))
+	assert.ErrorContains(err, "unsupported gnark version")
+
+	// a truncated header
+	_, err = groth16.NewCS(ecc.BN254).ReadFrom(bytes.NewReader(header[:8]))
+	assert.Error(err)
+
+	// the keys of the v0.9 releases are read with the version they were
+	// stamped with
+	f, err := os.Open(fixture(v09, "groth16.pk"))
+	assert.NoError(err)
+	defer f.Close()
+	_, err = groth16.ReadLegacyProvingKey(f, ecc.BN254, semver.MustParse("0.10.0-alpha"))
+	assert.NoError(err)
+}
+
+func roundTrip(ccs, into constraint.ConstraintSystem) error {
+	var buf bytes.Buffer
+	if _, err := ccs.WriteTo(&buf); err != nil {
+		return err
+	}
+	if _, err := into.ReadFrom(&buf); err != nil {
+		return err
+	}
+	if ccs.GetNbConstraints() != into.GetNbConstraints() {
+		return fmt.Errorf("round trip changed the number of constraints")
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Command generate writes the fixtures of the migration tests with gnark
+// v0.9.1. Run it from this directory:
+//
+//	go run .
+//
+// It writes v0.9.1.r1cs, v0.9.1.scs, v0.9.1.groth16.pk and v0.9.1.groth16.vk
+// to the testdata directory. The circuit must match the Circuit of the tests,
+// which register DivHint under the name main.DivHint.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+)
+
+const version = "v0.9.1"
+
+var fDir = flag.String("dir", filepath.Join("..", ".."), "output directory")
+
+// DivHint returns inputs[0] / inputs[1] and inputs[0] * inputs[1].
+func DivHint(mod *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	outputs[0].ModInverse(inputs[1], mod)
+	outputs[0].Mul(outputs[0], inputs[0]).Mod(outputs[0], mod)
+	outputs[1].Mul(inputs[0], inputs[1]).Mod(outputs[1], mod)
+	return nil
+}
+
+type Circuit struct {
+	X, Y frontend.Variable
+	Z    frontend.Variable `gnark:",public"`
+}
+
+func (c *Circuit) Define(api frontend.API) error {
+	res, err := api.Compiler().NewHint(DivHint, 2, c.X, c.Y)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(api.Mul(res[0], c.Y), c.X)
+	api.AssertIsEqual(res[1], api.Mul(c.X, c.Y))
+	api.AssertIsEqual(api.Add(res[0], api.Mul(3, c.X), -2), c.Z)
+	api.AssertIsDifferent(c.Y, 0)
+	commitment, err := api.Compiler().(frontend.Committer).Commit(c.X, res[0])
+	if err != nil {
+		return err
+	}
+	api.AssertIsDifferent(commitment, c.Z)
+	return nil
+}
+
+func main() {
+	flag.Parse()
+	solver.RegisterHint(DivHint)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &Circuit{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	write("r1cs", ccs)
+	write("groth16.pk", pk)
+	write("groth16.vk", vk)
+
+	ccs, err = frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &Circuit{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	write("scs", ccs)
+}
+
+func write(ext string, v io.WriterTo) {
+	f, err := os.Create(filepath.Join(*fDir, fmt.Sprintf("%s.%s", version, ext)))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	if _, err = v.WriteTo(f); err != nil {
+		log.Fatal(err)
+	}
+}
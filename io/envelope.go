@@ -0,0 +1,230 @@
+package io
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+)
+
+// EnvelopeVersion is the version of the envelope format written by
+// WriteEnvelope.
+const EnvelopeVersion = 1
+
+// envelopeMagic starts every envelope. As in PNG, the non-ASCII first byte and
+// the CRLF catch files mangled by a text-mode transfer.
+var envelopeMagic = [8]byte{0x89, 'g', 'n', 'a', 'r', 'k', '\r', '\n'}
+
+// envelopeHeaderSize is the size of the magic and the encoded EnvelopeHeader.
+const envelopeHeaderSize = len(envelopeMagic) + 7*2
+
+// ErrNotEnveloped is returned by ReadEnvelopeHeader when the data doesn't start
+// with the magic bytes of an envelope, for instance when it was written by
+// WriteTo directly.
+var ErrNotEnveloped = errors.New("not a gnark envelope")
+
+// Kind is the type of the object held by an envelope.
+type Kind uint16
+
+const (
+	KindUnknown Kind = iota
+	KindConstraintSystem
+	KindProvingKey
+	KindVerifyingKey
+	KindProof
+)
+
+// String returns a human-readable name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case KindConstraintSystem:
+		return "constraint system"
+	case KindProvingKey:
+		return "proving key"
+	case KindVerifyingKey:
+		return "verifying key"
+	case KindProof:
+		return "proof"
+	default:
+		return "unknown"
+	}
+}
+
+// EnvelopeHeader identifies the object held by an envelope.
+//
+// An envelope wraps the binary encoding of an object (as written by its
+// WriteTo method) so that it can be decoded without knowing its type in
+// advance:
+//
+//	magic        [8]byte  0x89 "gnark" "\r\n"
+//	version      uint16   EnvelopeVersion
+//	gnark        3×uint16 major, minor and patch versions of the writer
+//	curve        uint16   ecc.ID
+//	backend      uint16   backend.ID
+//	kind         uint16   Kind
+//	payload      []byte   WriteTo
+//	payloadLen   uint64   length of the payload
+//	payloadHash  [32]byte SHA-256 of the payload
+//
+// The integers are little-endian. The length and the hash of the payload
+// follow it, so that the envelope is written in a single pass.
+type EnvelopeHeader struct {
+	Version      uint16
+	GnarkVersion [3]uint16
+	Curve        ecc.ID
+	Backend      backend.ID
+	Kind         Kind
+}
+
+// NewEnvelopeHeader returns the header of the current version and gnark
+// release for an object of the curve, the backend and the kind.
+func NewEnvelopeHeader(curve ecc.ID, b backend.ID, kind Kind) EnvelopeHeader {
+	return EnvelopeHeader{
+		Version:      EnvelopeVersion,
+		GnarkVersion: [3]uint16{uint16(gnark.Version.Major), uint16(gnark.Version.Minor), uint16(gnark.Version.Patch)},
+		Curve:        curve,
+		Backend:      b,
+		Kind:         kind,
+	}
+}
+
+// Check returns an error if h is not the header of an object of the backend
+// and the kind.
+func (h *EnvelopeHeader) Check(b backend.ID, kind Kind) error {
+	if h.Backend != b {
+		return fmt.Errorf("envelope of backend %s, expected %s", h.Backend, b)
+	}
+	if h.Kind != kind {
+		return fmt.Errorf("envelope holds a %s, expected a %s", h.Kind, kind)
+	}
+	return nil
+}
+
+// WriteEnvelope writes object to w with its WriteTo method, in an envelope
+// with the header h. It returns the number of bytes written.
+func WriteEnvelope(w io.Writer, h EnvelopeHeader, object io.WriterTo) (int64, error) {
+	var header [envelopeHeaderSize]byte
+	copy(header[:], envelopeMagic[:])
+	b := header[len(envelopeMagic):]
+	for i, v := range []uint16{h.Version, h.GnarkVersion[0], h.GnarkVersion[1], h.GnarkVersion[2], uint16(h.Curve), uint16(h.Backend), uint16(h.Kind)} {
+		binary.LittleEndian.PutUint16(b[2*i:], v)
+	}
+	n, err := w.Write(header[:])
+	written := int64(n)
+	if err != nil {
+		return written, err
+	}
+
+	payload := countingWriter{w: w, hash: sha256.New()}
+	_, err = object.WriteTo(&payload)
+	written += payload.n
+	if err != nil {
+		return written, err
+	}
+
+	var trailer [8 + sha256.Size]byte
+	binary.LittleEndian.PutUint64(trailer[:], uint64(payload.n))
+	payload.hash.Sum(trailer[:8])
+	n, err = w.Write(trailer[:])
+	return written + int64(n), err
+}
+
+// ReadEnvelopeHeader reads the header of an envelope written by WriteEnvelope.
+// The payload is then read with ReadEnvelopePayload. It returns
+// ErrNotEnveloped if r doesn't start with an envelope.
+func ReadEnvelopeHeader(r io.Reader) (EnvelopeHeader, error) {
+	var header [envelopeHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:len(envelopeMagic)]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = ErrNotEnveloped
+		}
+		return EnvelopeHeader{}, err
+	}
+	if !bytes.Equal(header[:len(envelopeMagic)], envelopeMagic[:]) {
+		return EnvelopeHeader{}, ErrNotEnveloped
+	}
+	if _, err := io.ReadFull(r, header[len(envelopeMagic):]); err != nil {
+		return EnvelopeHeader{}, err
+	}
+
+	var v [7]uint16
+	b := header[len(envelopeMagic):]
+	for i := range v {
+		v[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	h := EnvelopeHeader{
+		Version:      v[0],
+		GnarkVersion: [3]uint16{v[1], v[2], v[3]},
+		Curve:        ecc.ID(v[4]),
+		Backend:      backend.ID(v[5]),
+		Kind:         Kind(v[6]),
+	}
+	if h.Version == 0 || h.Version > EnvelopeVersion {
+		return h, fmt.Errorf("unsupported envelope version %d", h.Version)
+	}
+	return h, nil
+}
+
+// ReadEnvelopePayload decodes the payload of an envelope in object with its
+// ReadFrom method, after its header was read with ReadEnvelopeHeader. It
+// checks the length and the hash of the payload, and returns the number of
+// bytes read. The hash is only known at the end of the payload, so the object
+// is decoded (with the checks of ReadFrom) before the hash is checked; it must
+// be discarded if an error is returned.
+func ReadEnvelopePayload(r io.Reader, object io.ReaderFrom) (int64, error) {
+	// the bytes are counted here rather than trusting the count returned by
+	// ReadFrom, so that the length and the hash cover exactly what was consumed
+	payload := countingReader{r: r, hash: sha256.New()}
+	if _, err := object.ReadFrom(&payload); err != nil {
+		return payload.n, err
+	}
+
+	var trailer [8 + sha256.Size]byte
+	n, err := io.ReadFull(r, trailer[:])
+	read := payload.n + int64(n)
+	if err != nil {
+		return read, fmt.Errorf("read envelope trailer: %w", err)
+	}
+	if payloadLen := binary.LittleEndian.Uint64(trailer[:]); payloadLen != uint64(payload.n) {
+		return read, fmt.Errorf("envelope payload of %d bytes, decoded %d", payloadLen, payload.n)
+	}
+	if !bytes.Equal(payload.hash.Sum(nil), trailer[8:]) {
+		return read, errors.New("envelope payload hash mismatch")
+	}
+	return read, nil
+}
+
+// countingWriter hashes and counts the bytes written to w.
+type countingWriter struct {
+	w    io.Writer
+	hash hash.Hash
+	n    int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.hash.Write(p[:n])
+	c.n += int64(n)
+	return n, err
+}
+
+// countingReader hashes and counts the bytes read from r.
+type countingReader struct {
+	r    io.Reader
+	hash hash.Hash
+	n    int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.hash.Write(p[:n])
+	c.n += int64(n)
+	return n, err
+}
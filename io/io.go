@@ -19,6 +19,7 @@ package io
 
 import (
 	"io"
+	"os"
 )
 
 // WriterRawTo is the interface that wraps the WriteRawTo method.
@@ -47,3 +48,20 @@ type BinaryDumper interface {
 	WriteDump(w io.Writer) error
 	ReadDump(r io.Reader) error
 }
+
+// Mapper is the interface that wraps the WriteMappable and Map methods.
+// WriteMappable writes the object to w in a binary format whose large tables
+// can be memory-mapped. Map reads the object from a file written by
+// WriteMappable; instead of being loaded in memory, the tables are paged in
+// from the file on demand. The returned Closer releases the mapping, after
+// which the object must not be used. Like WriteDump, the format is platform
+// dependent and the data is not checked.
+//
+// The mapping is read-only and shared with the file: writing to the mapped
+// tables faults (SIGSEGV), as does reading them after the file is truncated
+// (SIGBUS). The mapped object must be used as is, and the file must not be
+// modified while it is mapped.
+type Mapper interface {
+	WriteMappable(w io.Writer) error
+	Map(f *os.File) (io.Closer, error)
+}
@@ -0,0 +1,93 @@
+package io
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// JSONVersion is the version of the JSON encoding of the proofs and verifying
+// keys. It changes only if the encoding of an existing object changes.
+const JSONVersion = 1
+
+// JSONHeader identifies the curve and the backend of a proof or a verifying
+// key encoded in JSON, with the version of the encoding. It is embedded in the
+// JSON object, next to the fields of the proof or the key:
+//
+//	{"version": 1, "curve": "bn254", "backend": "groth16", "ar": "0x...", ...}
+//
+// The points are compressed as in gnark-crypto (WriteTo), and the points and
+// the field elements are written as hexadecimal strings (see HexBytes).
+type JSONHeader struct {
+	Version uint32 `json:"version"`
+	Curve   string `json:"curve"`
+	Backend string `json:"backend"`
+}
+
+// NewJSONHeader returns the header of the current version for an object of
+// the curve and the backend.
+func NewJSONHeader(curve ecc.ID, backend string) JSONHeader {
+	return JSONHeader{
+		Version: JSONVersion,
+		Curve:   curve.String(),
+		Backend: backend,
+	}
+}
+
+// Check returns an error if h is not the header of an object of the curve and
+// the backend, in a supported version.
+func (h *JSONHeader) Check(curve ecc.ID, backend string) error {
+	if h.Version == 0 || h.Version > JSONVersion {
+		return fmt.Errorf("unsupported JSON encoding version %d", h.Version)
+	}
+	if h.Curve != curve.String() {
+		return fmt.Errorf("JSON object on curve %q, expected %s", h.Curve, curve)
+	}
+	if h.Backend != backend {
+		return fmt.Errorf("JSON object of backend %q, expected %s", h.Backend, backend)
+	}
+	return nil
+}
+
+// ReadJSONHeader decodes the header of a proof or a verifying key encoded in
+// JSON, so that the object can be decoded in the concrete type of its curve.
+func ReadJSONHeader(data []byte) (curve ecc.ID, backend string, err error) {
+	var h JSONHeader
+	if err = json.Unmarshal(data, &h); err != nil {
+		return ecc.UNKNOWN, "", err
+	}
+	if h.Version == 0 || h.Version > JSONVersion {
+		return ecc.UNKNOWN, "", fmt.Errorf("unsupported JSON encoding version %d", h.Version)
+	}
+	if curve, err = ecc.IDFromString(h.Curve); err != nil {
+		return ecc.UNKNOWN, "", err
+	}
+	return curve, h.Backend, nil
+}
+
+// HexBytes are bytes encoded in JSON as a hexadecimal string prefixed by 0x.
+type HexBytes []byte
+
+// MarshalText implements encoding.TextMarshaler.
+func (h HexBytes) MarshalText() ([]byte, error) {
+	res := make([]byte, 2+hex.EncodedLen(len(h)))
+	copy(res, "0x")
+	hex.Encode(res[2:], h)
+	return res, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. The prefix 0x is
+// optional.
+func (h *HexBytes) UnmarshalText(text []byte) error {
+	s := strings.TrimPrefix(string(text), "0x")
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return errors.New("invalid hexadecimal string")
+	}
+	*h = b
+	return nil
+}
@@ -2,8 +2,10 @@ package io
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"io"
+	"os"
 	"reflect"
 )
 
@@ -11,6 +13,8 @@ import (
 // It writes the object to a buffer, then reads it back and checks that the reconstructed object is equal to the original.
 // It supports both io.ReaderFrom and UnsafeReaderFrom interfaces (to object)
 // It also supports both io.WriterTo and WriterRawTo interfaces (from object)
+// If the object implements json.Marshaler and json.Unmarshaler, the JSON
+// encoding is checked too.
 func RoundTripCheck(from any, to func() any) error {
 	var buf bytes.Buffer
 
@@ -71,6 +75,22 @@ func RoundTripCheck(from any, to func() any) error {
 		}
 	}
 
+	// if from implements json.Marshaler
+	if m, ok := from.(json.Marshaler); ok {
+		data, err := m.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		if r, ok := to().(json.Unmarshaler); ok {
+			if err := r.UnmarshalJSON(data); err != nil {
+				return err
+			}
+			if !reflect.DeepEqual(from, r) {
+				return errors.New("reconstructed object don't match original (UnmarshalJSON)")
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -90,3 +110,29 @@ func DumpRoundTripCheck(from any, to func() any) error {
 	}
 	return nil
 }
+
+// MapRoundTripCheck is a helper to check that the object is unchanged when
+// written with WriteMappable to a temporary file and mapped back with Map.
+func MapRoundTripCheck(from any, to func() any) error {
+	f, err := os.CreateTemp("", "gnark-map-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := from.(Mapper).WriteMappable(f); err != nil {
+		return err
+	}
+
+	r := to().(Mapper)
+	closer, err := r.Map(f)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	if !reflect.DeepEqual(from, r) {
+		return errors.New("reconstructed object don't match original (Map)")
+	}
+	return nil
+}
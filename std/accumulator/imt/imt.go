@@ -0,0 +1,213 @@
+// Package imt provides ZKP-circuit functions for indexed Merkle trees.
+//
+// An indexed Merkle tree is an append-only Merkle tree where the leaves form a
+// sorted linked list. Every leaf stores a value, and the index and value of the
+// leaf with the next larger value. The leaf with the largest value has next
+// value 0. The tree is initialised with the zero leaf (0, 0, 0) at index 0.
+// This kind of tree is used for example as a nullifier set in privacy
+// applications.
+//
+// The non-membership of a value x is proven by showing the low leaf, i.e. the
+// leaf whose value is less than x and whose next value is larger than x (or
+// zero). Inserting x updates the low leaf to point to x and appends a new leaf
+// pointing to the previous next leaf of the low leaf.
+//
+// Empty leaves are 0 and non-empty leaves are H(1, value, nextIndex,
+// nextValue). Internal nodes are H(left, right), so that the leading 1
+// separates the leaves from the internal nodes. The native counterpart [Tree]
+// generates the witnesses for the circuit.
+package imt
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash"
+	"github.com/consensys/gnark/std/math/cmp"
+	"github.com/consensys/gnark/std/rangecheck"
+)
+
+// Leaf is a leaf of the indexed Merkle tree.
+type Leaf struct {
+	// Value is the value stored in the leaf.
+	Value frontend.Variable
+	// NextIndex is the index of the leaf storing NextValue.
+	NextIndex frontend.Variable
+	// NextValue is the next larger value in the tree, or 0 if Value is the
+	// largest.
+	NextValue frontend.Variable
+}
+
+// Proof is a Merkle proof of a leaf in the indexed Merkle tree.
+type Proof struct {
+	// Siblings are the siblings of the nodes on the path from the leaf to the
+	// root. Siblings[0] is the sibling of the leaf.
+	Siblings []frontend.Variable
+}
+
+// PlaceholderProof returns a placeholder proof for circuit compilation for a
+// tree of given depth.
+func PlaceholderProof(depth int) Proof {
+	return Proof{Siblings: make([]frontend.Variable, depth)}
+}
+
+// Insertion is the witness for inserting a single value into the tree.
+type Insertion struct {
+	// Value is the inserted value.
+	Value frontend.Variable
+	// LowLeaf is the leaf with the largest value less than Value.
+	LowLeaf Leaf
+	// LowIndex is the index of LowLeaf.
+	LowIndex frontend.Variable
+	// LowProof is the proof of LowLeaf in the tree before the insertion.
+	LowProof Proof
+	// NewProof is the proof of the empty leaf at the insertion position in
+	// the tree after updating LowLeaf.
+	NewProof Proof
+	// PrevNode is the node of the leaf before the insertion position, which
+	// is not empty.
+	PrevNode frontend.Variable
+	// PrevProof is the proof of PrevNode in the tree after updating LowLeaf.
+	PrevProof Proof
+}
+
+// PlaceholderInsertion returns a placeholder insertion for circuit compilation
+// for a tree of given depth.
+func PlaceholderInsertion(depth int) Insertion {
+	return Insertion{LowProof: PlaceholderProof(depth), NewProof: PlaceholderProof(depth), PrevProof: PlaceholderProof(depth)}
+}
+
+// IndexedMerkleTree verifies indexed Merkle tree operations in-circuit.
+type IndexedMerkleTree struct {
+	api        frontend.API
+	h          hash.FieldHasher
+	depth      int
+	valueBits  int
+	rchecker   frontend.Rangechecker
+	comparator *cmp.BoundedComparator
+}
+
+// New returns a new [IndexedMerkleTree] for trees of given depth, using the
+// hash function h. All the values in the tree must be less than
+// 2^valueBits, where valueBits must be less than the bit length of the
+// native field modulus minus one.
+func New(api frontend.API, h hash.FieldHasher, depth, valueBits int) *IndexedMerkleTree {
+	return &IndexedMerkleTree{
+		api:        api,
+		h:          h,
+		depth:      depth,
+		valueBits:  valueBits,
+		rchecker:   rangecheck.New(api),
+		comparator: cmp.NewBoundedComparator(api, new(big.Int).Lsh(big.NewInt(1), uint(valueBits)), false),
+	}
+}
+
+// leafTag is the first input of the hash of a non-empty leaf.
+const leafTag = 1
+
+// leafHash returns the node corresponding to a non-empty leaf.
+func (t *IndexedMerkleTree) leafHash(leaf Leaf) frontend.Variable {
+	t.h.Reset()
+	t.h.Write(leafTag, leaf.Value, leaf.NextIndex, leaf.NextValue)
+	return t.h.Sum()
+}
+
+// computeRoot computes the root from the leaf node at index.
+func (t *IndexedMerkleTree) computeRoot(index, leaf frontend.Variable, proof Proof) (frontend.Variable, error) {
+	if len(proof.Siblings) != t.depth {
+		return nil, fmt.Errorf("proof has %d siblings, expected %d for the tree depth", len(proof.Siblings), t.depth)
+	}
+	path := t.api.ToBinary(index, t.depth)
+	sum := leaf
+	for i := range proof.Siblings {
+		left := t.api.Select(path[i], proof.Siblings[i], sum)
+		right := t.api.Select(path[i], sum, proof.Siblings[i])
+		t.h.Reset()
+		t.h.Write(left, right)
+		sum = t.h.Sum()
+	}
+	return sum, nil
+}
+
+// assertIsLowLeaf asserts that lowLeaf.Value < value < lowLeaf.NextValue,
+// where NextValue 0 is considered as infinity.
+func (t *IndexedMerkleTree) assertIsLowLeaf(value frontend.Variable, lowLeaf Leaf) {
+	t.rchecker.Check(value, t.valueBits)
+	t.rchecker.Check(lowLeaf.Value, t.valueBits)
+	t.rchecker.Check(lowLeaf.NextValue, t.valueBits)
+	t.comparator.AssertIsLess(lowLeaf.Value, value)
+	isLast := t.api.IsZero(lowLeaf.NextValue)
+	isLessNext := t.comparator.IsLess(value, lowLeaf.NextValue)
+	t.api.AssertIsEqual(t.api.Or(isLast, isLessNext), 1)
+}
+
+// VerifyMembership asserts that leaf is stored at index in the tree with the
+// given root. It returns an error if the proof does not match the depth of the
+// tree.
+func (t *IndexedMerkleTree) VerifyMembership(root, index frontend.Variable, leaf Leaf, proof Proof) error {
+	computed, err := t.computeRoot(index, t.leafHash(leaf), proof)
+	if err != nil {
+		return err
+	}
+	t.api.AssertIsEqual(computed, root)
+	return nil
+}
+
+// VerifyNonMembership asserts that value is not stored in the tree with the
+// given root by checking the low leaf lowLeaf stored at lowIndex. It returns
+// an error if the proof does not match the depth of the tree.
+func (t *IndexedMerkleTree) VerifyNonMembership(root, value frontend.Variable, lowLeaf Leaf, lowIndex frontend.Variable, lowProof Proof) error {
+	t.assertIsLowLeaf(value, lowLeaf)
+	return t.VerifyMembership(root, lowIndex, lowLeaf, lowProof)
+}
+
+// Insert asserts that ins.Value is not in the tree with the given root and
+// returns the root of the tree after inserting the value at position newIndex.
+// It returns an error if the proofs do not match the depth of the tree.
+//
+// newIndex must be the number of leaves in the tree. It is asserted that the
+// leaf at newIndex is empty and that the leaf before it is not: as the tree
+// starts with the zero leaf and every insertion is checked this way, the
+// leaves are filled in order and no position can be skipped.
+func (t *IndexedMerkleTree) Insert(root, newIndex frontend.Variable, ins Insertion) (frontend.Variable, error) {
+	if err := t.VerifyNonMembership(root, ins.Value, ins.LowLeaf, ins.LowIndex, ins.LowProof); err != nil {
+		return nil, fmt.Errorf("low leaf: %w", err)
+	}
+	// update the low leaf to point to the new leaf.
+	updatedLow := Leaf{Value: ins.LowLeaf.Value, NextIndex: newIndex, NextValue: ins.Value}
+	root, err := t.computeRoot(ins.LowIndex, t.leafHash(updatedLow), ins.LowProof)
+	if err != nil {
+		return nil, fmt.Errorf("low leaf: %w", err)
+	}
+	// the previous leaf is not empty. Non-empty leaves are hashes, which are
+	// non-zero except with negligible probability.
+	t.api.AssertIsDifferent(ins.PrevNode, 0)
+	prevRoot, err := t.computeRoot(t.api.Sub(newIndex, 1), ins.PrevNode, ins.PrevProof)
+	if err != nil {
+		return nil, fmt.Errorf("previous leaf: %w", err)
+	}
+	t.api.AssertIsEqual(prevRoot, root)
+	// the new leaf takes over the pointers of the low leaf.
+	emptyRoot, err := t.computeRoot(newIndex, 0, ins.NewProof)
+	if err != nil {
+		return nil, fmt.Errorf("new leaf: %w", err)
+	}
+	t.api.AssertIsEqual(emptyRoot, root)
+	newLeaf := Leaf{Value: ins.Value, NextIndex: ins.LowLeaf.NextIndex, NextValue: ins.LowLeaf.NextValue}
+	return t.computeRoot(newIndex, t.leafHash(newLeaf), ins.NewProof)
+}
+
+// BatchInsert inserts the values at the consecutive positions starting from
+// startIndex and returns the root of the tree after all the insertions. Every
+// insertion is checked as by [IndexedMerkleTree.Insert] against the tree after
+// the previous insertions, in the order in which [Tree.Insert] generates them.
+func (t *IndexedMerkleTree) BatchInsert(root, startIndex frontend.Variable, ins []Insertion) (frontend.Variable, error) {
+	for i := range ins {
+		var err error
+		if root, err = t.Insert(root, t.api.Add(startIndex, i), ins[i]); err != nil {
+			return nil, fmt.Errorf("insertion %d: %w", i, err)
+		}
+	}
+	return root, nil
+}
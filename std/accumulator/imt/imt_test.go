@@ -0,0 +1,228 @@
+package imt
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/hash"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/test"
+)
+
+const (
+	testDepth     = 6
+	testValueBits = 64
+)
+
+type nonMembershipCircuit struct {
+	Root     frontend.Variable
+	Value    frontend.Variable
+	LowLeaf  Leaf
+	LowIndex frontend.Variable
+	LowProof Proof
+}
+
+func (c *nonMembershipCircuit) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	t := New(api, &h, testDepth, testValueBits)
+	return t.VerifyNonMembership(c.Root, c.Value, c.LowLeaf, c.LowIndex, c.LowProof)
+}
+
+func TestNonMembership(t *testing.T) {
+	assert := test.NewAssert(t)
+	tree, err := NewTree(hash.MIMC_BN254.New(), testDepth)
+	assert.NoError(err)
+	for _, v := range []int64{50, 10, 30, 70} {
+		_, err := tree.Insert(big.NewInt(v))
+		assert.NoError(err)
+	}
+	circuit := &nonMembershipCircuit{LowProof: PlaceholderProof(testDepth)}
+	for _, v := range []int64{5, 20, 60, 1000} {
+		lowIndex, err := tree.LowLeaf(big.NewInt(v))
+		assert.NoError(err)
+		lowLeaf, err := tree.Leaf(lowIndex)
+		assert.NoError(err)
+		assignment := &nonMembershipCircuit{
+			Root:     tree.Root(),
+			Value:    v,
+			LowLeaf:  ValueOfLeaf(lowLeaf),
+			LowIndex: lowIndex,
+			LowProof: ValueOfProof(tree.Prove(lowIndex)),
+		}
+		assert.NoError(test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()))
+	}
+	// 30 is in the tree, the low leaf of 20 (storing 10) does not prove
+	// non-membership of 30.
+	lowIndex, err := tree.LowLeaf(big.NewInt(20))
+	assert.NoError(err)
+	lowLeaf, err := tree.Leaf(lowIndex)
+	assert.NoError(err)
+	assignment := &nonMembershipCircuit{
+		Root:     tree.Root(),
+		Value:    30,
+		LowLeaf:  ValueOfLeaf(lowLeaf),
+		LowIndex: lowIndex,
+		LowProof: ValueOfProof(tree.Prove(lowIndex)),
+	}
+	assert.Error(test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()))
+}
+
+type membershipCircuit struct {
+	Root  frontend.Variable
+	Index frontend.Variable
+	Leaf  Leaf
+	Proof Proof
+}
+
+func (c *membershipCircuit) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	t := New(api, &h, testDepth, testValueBits)
+	return t.VerifyMembership(c.Root, c.Index, c.Leaf, c.Proof)
+}
+
+func TestMembership(t *testing.T) {
+	assert := test.NewAssert(t)
+	tree, err := NewTree(hash.MIMC_BN254.New(), testDepth)
+	assert.NoError(err)
+	for _, v := range []int64{50, 10, 30} {
+		_, err := tree.Insert(big.NewInt(v))
+		assert.NoError(err)
+	}
+	index, ok := tree.Find(big.NewInt(30))
+	assert.True(ok)
+	leaf, err := tree.Leaf(index)
+	assert.NoError(err)
+	assert.Equal(int64(50), leaf.NextValue.Int64())
+	circuit := &membershipCircuit{Proof: PlaceholderProof(testDepth)}
+	assignment := &membershipCircuit{Root: tree.Root(), Index: index, Leaf: ValueOfLeaf(leaf), Proof: ValueOfProof(tree.Prove(index))}
+	assert.NoError(test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()))
+
+	// the proof must match the depth of the tree.
+	_, err = frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &membershipCircuit{Proof: PlaceholderProof(testDepth - 1)})
+	assert.Error(err)
+}
+
+type batchInsertCircuit struct {
+	OldRoot    frontend.Variable `gnark:",public"`
+	NewRoot    frontend.Variable `gnark:",public"`
+	StartIndex frontend.Variable
+	Insertions []Insertion
+}
+
+func (c *batchInsertCircuit) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	t := New(api, &h, testDepth, testValueBits)
+	root, err := t.BatchInsert(c.OldRoot, c.StartIndex, c.Insertions)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(root, c.NewRoot)
+	return nil
+}
+
+func TestBatchInsert(t *testing.T) {
+	assert := test.NewAssert(t)
+	tree, err := NewTree(hash.MIMC_BN254.New(), testDepth)
+	assert.NoError(err)
+	for _, v := range []int64{50, 10} {
+		_, err := tree.Insert(big.NewInt(v))
+		assert.NoError(err)
+	}
+	values := []int64{30, 40, 100, 1}
+	circuit := &batchInsertCircuit{Insertions: make([]Insertion, len(values))}
+	assignment := &batchInsertCircuit{OldRoot: tree.Root(), StartIndex: tree.Size(), Insertions: make([]Insertion, len(values))}
+	for i, v := range values {
+		circuit.Insertions[i] = PlaceholderInsertion(testDepth)
+		ins, err := tree.Insert(big.NewInt(v))
+		assert.NoError(err)
+		assignment.Insertions[i] = ValueOfInsertion(ins)
+	}
+	assignment.NewRoot = tree.Root()
+	assert.CheckCircuit(circuit, test.WithValidAssignment(assignment), test.WithCurves(ecc.BN254))
+
+	// inserting an existing value fails natively.
+	_, err = tree.Insert(big.NewInt(40))
+	assert.Error(err)
+}
+
+type insertCircuit struct {
+	OldRoot   frontend.Variable
+	NewRoot   frontend.Variable
+	NewIndex  frontend.Variable
+	Insertion Insertion
+}
+
+func (c *insertCircuit) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	t := New(api, &h, testDepth, testValueBits)
+	root, err := t.Insert(c.OldRoot, c.NewIndex, c.Insertion)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(root, c.NewRoot)
+	return nil
+}
+
+func TestInsertSkipsPosition(t *testing.T) {
+	assert := test.NewAssert(t)
+	tree, err := NewTree(hash.MIMC_BN254.New(), testDepth)
+	assert.NoError(err)
+	_, err = tree.Insert(big.NewInt(50))
+	assert.NoError(err)
+	oldRoot := tree.Root()
+
+	// insert 30 at the empty position 3 instead of 2, leaving a hole at
+	// position 2.
+	const newIndex = 3
+	lowIndex, err := tree.LowLeaf(big.NewInt(30))
+	assert.NoError(err)
+	low := tree.copyLeaf(tree.leaves[lowIndex])
+	ins := NativeInsertion{
+		Value:       big.NewInt(30),
+		NewIndex:    newIndex,
+		LowLeaf:     tree.copyLeaf(low),
+		LowIndex:    lowIndex,
+		LowSiblings: tree.Prove(lowIndex),
+	}
+	newLeaf := NativeLeaf{Value: big.NewInt(30), NextIndex: low.NextIndex, NextValue: low.NextValue}
+	low.NextIndex, low.NextValue = newIndex, big.NewInt(30)
+	lowNode, err := tree.leafHash(low)
+	assert.NoError(err)
+	assert.NoError(tree.setLeaf(lowIndex, lowNode))
+	ins.NewSiblings = tree.Prove(newIndex)
+	ins.PrevNode = new(big.Int).Set(tree.node(0, newIndex-1))
+	ins.PrevSiblings = tree.Prove(newIndex - 1)
+	newNode, err := tree.leafHash(newLeaf)
+	assert.NoError(err)
+	assert.NoError(tree.setLeaf(newIndex, newNode))
+
+	circuit := &insertCircuit{Insertion: PlaceholderInsertion(testDepth)}
+	assignment := &insertCircuit{OldRoot: oldRoot, NewRoot: tree.Root(), NewIndex: newIndex, Insertion: ValueOfInsertion(ins)}
+	assert.Error(test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()))
+}
+
+func TestTreeLargeValue(t *testing.T) {
+	assert := test.NewAssert(t)
+	tree, err := NewTree(hash.MIMC_BN254.New(), testDepth)
+	assert.NoError(err)
+	root := tree.Root()
+	_, err = tree.Insert(new(big.Int).Lsh(big.NewInt(1), 256))
+	assert.Error(err)
+	assert.Equal(uint64(1), tree.Size())
+	assert.Equal(root, tree.Root())
+}
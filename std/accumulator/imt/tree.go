@@ -0,0 +1,280 @@
+package imt
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"sort"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// NativeLeaf is a leaf of the native indexed Merkle tree.
+type NativeLeaf struct {
+	Value     *big.Int
+	NextIndex uint64
+	NextValue *big.Int
+}
+
+// NativeInsertion is the native witness of an insertion. It is returned by
+// [Tree.Insert] and converted to the circuit witness using
+// [ValueOfInsertion].
+type NativeInsertion struct {
+	Value        *big.Int
+	NewIndex     uint64
+	LowLeaf      NativeLeaf
+	LowIndex     uint64
+	LowSiblings  []*big.Int
+	NewSiblings  []*big.Int
+	PrevNode     *big.Int
+	PrevSiblings []*big.Int
+}
+
+// Tree is a native indexed Merkle tree for generating the witnesses for the
+// circuit functions in this package. A leaf is hashed as the tag 1, its value,
+// next index and next value, each padded to [hash.Hash.BlockSize] bytes
+// big-endian, and a node as its two padded children. MiMC from gnark-crypto reads its input this
+// way. Values must fit into a block.
+type Tree struct {
+	h      hash.Hash
+	depth  int
+	leaves []NativeLeaf
+	// sorted stores the indices of the leaves sorted by value.
+	sorted []uint64
+	// nodes[i] stores the non-default nodes at level i. Level 0 are the
+	// leaves.
+	nodes []map[uint64]*big.Int
+	// defaults[i] is the root of an empty subtree of depth i.
+	defaults []*big.Int
+}
+
+// NewTree returns a new indexed Merkle tree of given depth using the hash
+// function h. The tree contains the zero leaf at index 0.
+func NewTree(h hash.Hash, depth int) (*Tree, error) {
+	t := &Tree{
+		h:        h,
+		depth:    depth,
+		nodes:    make([]map[uint64]*big.Int, depth+1),
+		defaults: make([]*big.Int, depth+1),
+	}
+	for i := range t.nodes {
+		t.nodes[i] = make(map[uint64]*big.Int)
+	}
+	t.defaults[0] = new(big.Int)
+	for i := 1; i <= depth; i++ {
+		d, err := t.hash(t.defaults[i-1], t.defaults[i-1])
+		if err != nil {
+			return nil, err
+		}
+		t.defaults[i] = d
+	}
+	zero := NativeLeaf{Value: new(big.Int), NextValue: new(big.Int)}
+	leaf, err := t.leafHash(zero)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.setLeaf(0, leaf); err != nil {
+		return nil, err
+	}
+	t.leaves = append(t.leaves, zero)
+	t.sorted = append(t.sorted, 0)
+	return t, nil
+}
+
+// Root returns the current root of the tree.
+func (t *Tree) Root() *big.Int {
+	return new(big.Int).Set(t.node(t.depth, 0))
+}
+
+// Size returns the number of non-empty leaves in the tree, which is also the
+// index of the next inserted leaf.
+func (t *Tree) Size() uint64 {
+	return uint64(len(t.leaves))
+}
+
+// Leaf returns the leaf at index.
+func (t *Tree) Leaf(index uint64) (NativeLeaf, error) {
+	if index >= uint64(len(t.leaves)) {
+		return NativeLeaf{}, fmt.Errorf("leaf %d is empty", index)
+	}
+	return t.copyLeaf(t.leaves[index]), nil
+}
+
+// Prove returns the siblings of the path from the leaf at index to the root.
+func (t *Tree) Prove(index uint64) []*big.Int {
+	siblings := make([]*big.Int, t.depth)
+	idx := index
+	for i := 0; i < t.depth; i++ {
+		siblings[i] = new(big.Int).Set(t.node(i, idx^1))
+		idx >>= 1
+	}
+	return siblings
+}
+
+// Find returns the index of the leaf storing value and whether it exists.
+func (t *Tree) Find(value *big.Int) (uint64, bool) {
+	pos := t.search(value)
+	if pos < len(t.sorted) && t.leaves[t.sorted[pos]].Value.Cmp(value) == 0 {
+		return t.sorted[pos], true
+	}
+	return 0, false
+}
+
+// LowLeaf returns the index of the low leaf of value, i.e. the leaf with the
+// largest value less than value. It returns an error if value is in the tree
+// or if it is zero.
+func (t *Tree) LowLeaf(value *big.Int) (uint64, error) {
+	if value.Sign() <= 0 {
+		return 0, errors.New("value must be positive")
+	}
+	if _, ok := t.Find(value); ok {
+		return 0, errors.New("value already in the tree")
+	}
+	// the zero leaf is always at the first position, so pos > 0.
+	pos := t.search(value)
+	return t.sorted[pos-1], nil
+}
+
+// Insert inserts value into the tree and returns the witness for the
+// insertion.
+func (t *Tree) Insert(value *big.Int) (NativeInsertion, error) {
+	lowIndex, err := t.LowLeaf(value)
+	if err != nil {
+		return NativeInsertion{}, err
+	}
+	newIndex := uint64(len(t.leaves))
+	if t.depth < 64 && newIndex >= 1<<t.depth {
+		return NativeInsertion{}, errors.New("tree is full")
+	}
+	ret := NativeInsertion{
+		Value:       new(big.Int).Set(value),
+		NewIndex:    newIndex,
+		LowLeaf:     t.copyLeaf(t.leaves[lowIndex]),
+		LowIndex:    lowIndex,
+		LowSiblings: t.Prove(lowIndex),
+	}
+	low := &t.leaves[lowIndex]
+	newLeaf := NativeLeaf{Value: new(big.Int).Set(value), NextIndex: low.NextIndex, NextValue: low.NextValue}
+	updatedLow := NativeLeaf{Value: low.Value, NextIndex: newIndex, NextValue: new(big.Int).Set(value)}
+
+	// the leaves are hashed before modifying the tree, so that a value which
+	// can't be hashed leaves it unchanged.
+	lowNode, err := t.leafHash(updatedLow)
+	if err != nil {
+		return NativeInsertion{}, err
+	}
+	newNode, err := t.leafHash(newLeaf)
+	if err != nil {
+		return NativeInsertion{}, err
+	}
+
+	*low = updatedLow
+	if err := t.setLeaf(lowIndex, lowNode); err != nil {
+		return NativeInsertion{}, err
+	}
+	ret.NewSiblings = t.Prove(newIndex)
+	ret.PrevNode = new(big.Int).Set(t.node(0, newIndex-1))
+	ret.PrevSiblings = t.Prove(newIndex - 1)
+
+	t.leaves = append(t.leaves, newLeaf)
+	pos := t.search(value)
+	t.sorted = append(t.sorted, 0)
+	copy(t.sorted[pos+1:], t.sorted[pos:])
+	t.sorted[pos] = newIndex
+	if err := t.setLeaf(newIndex, newNode); err != nil {
+		return NativeInsertion{}, err
+	}
+	return ret, nil
+}
+
+// search returns the position in sorted of the first leaf with value not less
+// than value.
+func (t *Tree) search(value *big.Int) int {
+	return sort.Search(len(t.sorted), func(i int) bool {
+		return t.leaves[t.sorted[i]].Value.Cmp(value) >= 0
+	})
+}
+
+func (t *Tree) copyLeaf(l NativeLeaf) NativeLeaf {
+	return NativeLeaf{Value: new(big.Int).Set(l.Value), NextIndex: l.NextIndex, NextValue: new(big.Int).Set(l.NextValue)}
+}
+
+func (t *Tree) setLeaf(index uint64, leaf *big.Int) error {
+	idx := index
+	cur := leaf
+	for i := 0; i <= t.depth; i++ {
+		t.nodes[i][idx] = cur
+		if i == t.depth {
+			break
+		}
+		sibling := t.node(i, idx^1)
+		var err error
+		if idx&1 == 0 {
+			cur, err = t.hash(cur, sibling)
+		} else {
+			cur, err = t.hash(sibling, cur)
+		}
+		if err != nil {
+			return err
+		}
+		idx >>= 1
+	}
+	return nil
+}
+
+func (t *Tree) node(level int, idx uint64) *big.Int {
+	if n, ok := t.nodes[level][idx]; ok {
+		return n
+	}
+	return t.defaults[level]
+}
+
+func (t *Tree) leafHash(l NativeLeaf) (*big.Int, error) {
+	return t.hash(big.NewInt(leafTag), l.Value, new(big.Int).SetUint64(l.NextIndex), l.NextValue)
+}
+
+func (t *Tree) hash(in ...*big.Int) (*big.Int, error) {
+	size := t.h.BlockSize()
+	buf := make([]byte, len(in)*size)
+	for i := range in {
+		if in[i].Sign() < 0 || in[i].BitLen() > 8*size {
+			return nil, fmt.Errorf("value %s does not fit into a hash block of %d bytes", in[i], size)
+		}
+		in[i].FillBytes(buf[i*size : (i+1)*size])
+	}
+	t.h.Reset()
+	if _, err := t.h.Write(buf); err != nil {
+		return nil, fmt.Errorf("hash write: %w", err)
+	}
+	return new(big.Int).SetBytes(t.h.Sum(nil)), nil
+}
+
+// ValueOfLeaf returns the witness assignment of the leaf.
+func ValueOfLeaf(l NativeLeaf) Leaf {
+	return Leaf{Value: l.Value, NextIndex: l.NextIndex, NextValue: l.NextValue}
+}
+
+// ValueOfProof returns the witness assignment of the proof given by the
+// siblings returned by [Tree.Prove].
+func ValueOfProof(siblings []*big.Int) Proof {
+	ret := Proof{Siblings: make([]frontend.Variable, len(siblings))}
+	for i := range siblings {
+		ret.Siblings[i] = siblings[i]
+	}
+	return ret
+}
+
+// ValueOfInsertion returns the witness assignment of the insertion returned by
+// [Tree.Insert].
+func ValueOfInsertion(ins NativeInsertion) Insertion {
+	return Insertion{
+		Value:     ins.Value,
+		LowLeaf:   ValueOfLeaf(ins.LowLeaf),
+		LowIndex:  ins.LowIndex,
+		LowProof:  ValueOfProof(ins.LowSiblings),
+		NewProof:  ValueOfProof(ins.NewSiblings),
+		PrevNode:  ins.PrevNode,
+		PrevProof: ValueOfProof(ins.PrevSiblings),
+	}
+}
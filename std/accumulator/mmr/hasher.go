@@ -0,0 +1,115 @@
+package mmr
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash"
+	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/uints"
+)
+
+// Hasher abstracts the hash function used for computing the nodes of the MMR.
+// The type parameter T is the type of a node. Use [NewFieldHasher] for
+// SNARK-friendly hash functions and [NewBinaryHasher] for hash functions
+// operating on bytes.
+type Hasher[T any] interface {
+	// Node returns the parent node of left and right.
+	Node(left, right T) T
+	// Encode returns the node encoding the integer v. The integer must be
+	// less than 2^64.
+	Encode(v frontend.Variable) T
+	// Select returns a if b is 1 and c otherwise.
+	Select(b frontend.Variable, a, c T) T
+	// AssertIsEqual asserts that the nodes a and b are equal.
+	AssertIsEqual(a, b T)
+}
+
+type fieldHasher struct {
+	api frontend.API
+	h   hash.FieldHasher
+}
+
+// NewFieldHasher returns a [Hasher] where the nodes are native field elements
+// and the parent of left and right is h(left, right).
+func NewFieldHasher(api frontend.API, h hash.FieldHasher) Hasher[frontend.Variable] {
+	return &fieldHasher{api: api, h: h}
+}
+
+func (f *fieldHasher) Node(left, right frontend.Variable) frontend.Variable {
+	f.h.Reset()
+	f.h.Write(left, right)
+	return f.h.Sum()
+}
+
+func (f *fieldHasher) Encode(v frontend.Variable) frontend.Variable {
+	return v
+}
+
+func (f *fieldHasher) Select(b frontend.Variable, a, c frontend.Variable) frontend.Variable {
+	return f.api.Select(b, a, c)
+}
+
+func (f *fieldHasher) AssertIsEqual(a, b frontend.Variable) {
+	f.api.AssertIsEqual(a, b)
+}
+
+type binaryHasher struct {
+	api       frontend.API
+	newHasher func() (hash.BinaryHasher, error)
+	size      int
+}
+
+// NewBinaryHasher returns a [Hasher] where the nodes are byte slices and the
+// parent of left and right is the digest of their concatenation. As
+// [hash.BinaryHasher] cannot be reset, newHasher is called for every node to
+// obtain a fresh hasher. Integers are encoded big-endian over the digest size.
+func NewBinaryHasher(api frontend.API, newHasher func() (hash.BinaryHasher, error)) (Hasher[[]uints.U8], error) {
+	h, err := newHasher()
+	if err != nil {
+		return nil, fmt.Errorf("new hasher: %w", err)
+	}
+	if h.Size() < 8 {
+		return nil, fmt.Errorf("digest size %d too small", h.Size())
+	}
+	return &binaryHasher{api: api, newHasher: newHasher, size: h.Size()}, nil
+}
+
+func (b *binaryHasher) Node(left, right []uints.U8) []uints.U8 {
+	h, err := b.newHasher()
+	if err != nil {
+		panic(fmt.Sprintf("new hasher: %v", err))
+	}
+	h.Write(left)
+	h.Write(right)
+	return h.Sum()
+}
+
+func (b *binaryHasher) Encode(v frontend.Variable) []uints.U8 {
+	ret := make([]uints.U8, b.size)
+	for i := 0; i < b.size-8; i++ {
+		ret[i] = uints.NewU8(0)
+	}
+	vBits := bits.ToBinary(b.api, v, bits.WithNbDigits(64))
+	for i := 0; i < 8; i++ {
+		ret[b.size-1-i] = uints.U8{Val: bits.FromBinary(b.api, vBits[8*i:8*i+8])}
+	}
+	return ret
+}
+
+func (b *binaryHasher) Select(sel frontend.Variable, x, y []uints.U8) []uints.U8 {
+	ret := make([]uints.U8, len(x))
+	for i := range ret {
+		ret[i] = uints.U8{Val: b.api.Select(sel, x[i].Val, y[i].Val)}
+	}
+	return ret
+}
+
+func (b *binaryHasher) AssertIsEqual(x, y []uints.U8) {
+	if len(x) != len(y) {
+		panic("nodes of different length")
+	}
+	for i := range x {
+		b.api.AssertIsEqual(x[i].Val, y[i].Val)
+	}
+}
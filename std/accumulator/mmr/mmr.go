@@ -0,0 +1,147 @@
+// Package mmr provides ZKP-circuit functions for Merkle Mountain Ranges.
+//
+// A Merkle Mountain Range (MMR) is an append-only accumulator. An MMR with n
+// leaves consists of perfect binary Merkle trees (peaks), one for every bit set
+// in the binary decomposition of n, ordered from the highest to the lowest.
+// MMRs are commonly used as history accumulators, for example for block
+// headers.
+//
+// The root of the MMR is computed by bagging the peaks from right to left and
+// hashing the result together with the number of leaves:
+//
+//	root = H(n, H(p_k, H(p_{k-1}, ... H(p_1, p_0))))
+//
+// where p_k is the highest and p_0 the lowest peak. The root of the empty MMR
+// is H(0, 0).
+//
+// In-circuit, the number of leaves is a witness value bounded by 2^(maxHeight+1)
+// and the peaks are given indexed by their height. The peaks at heights not set
+// in the number of leaves are ignored.
+//
+// The gadget is generic over the node type to support both SNARK-friendly hash
+// functions (see [NewFieldHasher]) and hash functions operating on bytes (see
+// [NewBinaryHasher]). The native counterpart [Native] generates the
+// witnesses.
+package mmr
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/cmp"
+)
+
+// MMR verifies Merkle Mountain Range proofs in-circuit.
+type MMR[T any] struct {
+	api       frontend.API
+	h         Hasher[T]
+	maxHeight int
+}
+
+// New returns a new [MMR] for ranges with peaks of height up to maxHeight,
+// i.e. with less than 2^(maxHeight+1) leaves.
+func New[T any](api frontend.API, h Hasher[T], maxHeight int) (*MMR[T], error) {
+	if maxHeight < 0 || maxHeight > 62 {
+		return nil, fmt.Errorf("max height %d not in range [0, 62]", maxHeight)
+	}
+	return &MMR[T]{api: api, h: h, maxHeight: maxHeight}, nil
+}
+
+// sizeBits returns the binary decomposition of the number of leaves.
+func (m *MMR[T]) sizeBits(size frontend.Variable) []frontend.Variable {
+	return m.api.ToBinary(size, m.maxHeight+1)
+}
+
+func (m *MMR[T]) checkPeaks(peaks []T) {
+	if len(peaks) != m.maxHeight+1 {
+		panic(fmt.Sprintf("expected %d peaks, got %d", m.maxHeight+1, len(peaks)))
+	}
+}
+
+// bag computes the root from the peaks given the binary decomposition of the
+// number of leaves.
+func (m *MMR[T]) bag(size frontend.Variable, sizeBits []frontend.Variable, peaks []T) T {
+	acc := m.h.Encode(0)
+	var isEmpty frontend.Variable = 1
+	for i := range sizeBits {
+		bagged := m.h.Select(isEmpty, peaks[i], m.h.Node(peaks[i], acc))
+		acc = m.h.Select(sizeBits[i], bagged, acc)
+		isEmpty = m.api.Mul(isEmpty, m.api.Sub(1, sizeBits[i]))
+	}
+	return m.h.Node(m.h.Encode(size), acc)
+}
+
+// Root returns the root of the MMR with size leaves and given peaks. The peaks
+// are indexed by height and must have length maxHeight+1.
+func (m *MMR[T]) Root(size frontend.Variable, peaks []T) T {
+	m.checkPeaks(peaks)
+	return m.bag(size, m.sizeBits(size), peaks)
+}
+
+// VerifyInclusion asserts that leaf is the leaf at index in the MMR with the
+// given root, size leaves and peaks. The siblings are the Merkle path from the
+// leaf to its peak and must have length maxHeight. Only the first h siblings
+// are used, where h is the height of the peak containing the leaf.
+func (m *MMR[T]) VerifyInclusion(root T, size frontend.Variable, peaks []T, index frontend.Variable, leaf T, siblings []T) {
+	api := m.api
+	m.checkPeaks(peaks)
+	if len(siblings) != m.maxHeight {
+		panic(fmt.Sprintf("expected %d siblings, got %d", m.maxHeight, len(siblings)))
+	}
+	sizeBits := m.sizeBits(size)
+	indexBits := api.ToBinary(index, m.maxHeight+1)
+	comparator := cmp.NewBoundedComparator(api, new(big.Int).Lsh(big.NewInt(1), uint(m.maxHeight+1)), false)
+	comparator.AssertIsLess(index, size)
+
+	// as index < size, the highest bit where index and size differ is set in
+	// size and its position is the height of the peak containing the leaf.
+	isTop := make([]frontend.Variable, m.maxHeight+1)
+	var above frontend.Variable = 1
+	for i := m.maxHeight; i >= 0; i-- {
+		differ := api.Xor(sizeBits[i], indexBits[i])
+		isTop[i] = api.Mul(above, differ)
+		above = api.Sub(above, isTop[i])
+	}
+
+	// compute the Merkle path from the leaf and select the node at the height
+	// of the peak.
+	node := leaf
+	computed := leaf
+	expected := peaks[0]
+	for i := 1; i <= m.maxHeight; i++ {
+		left := m.h.Select(indexBits[i-1], siblings[i-1], node)
+		right := m.h.Select(indexBits[i-1], node, siblings[i-1])
+		node = m.h.Node(left, right)
+		computed = m.h.Select(isTop[i], node, computed)
+		expected = m.h.Select(isTop[i], peaks[i], expected)
+	}
+	m.h.AssertIsEqual(computed, expected)
+	m.h.AssertIsEqual(m.bag(size, sizeBits, peaks), root)
+}
+
+// Append asserts that the MMR with size leaves and given peaks has the given
+// root, and returns the root of the MMR after appending leaf. It additionally
+// returns the peaks of the new MMR.
+func (m *MMR[T]) Append(root T, size frontend.Variable, peaks []T, leaf T) (newRoot T, newPeaks []T) {
+	api := m.api
+	m.checkPeaks(peaks)
+	sizeBits := m.sizeBits(size)
+	m.h.AssertIsEqual(m.bag(size, sizeBits, peaks), root)
+
+	// appending a leaf merges the lowest peaks while the corresponding bits of
+	// size are set. The merged peak is placed at the lowest unset bit.
+	newPeaks = make([]T, len(peaks))
+	carry := leaf
+	var carrying frontend.Variable = 1
+	for i := range peaks {
+		placed := api.Mul(carrying, api.Sub(1, sizeBits[i]))
+		newPeaks[i] = m.h.Select(placed, carry, peaks[i])
+		carry = m.h.Node(peaks[i], carry)
+		carrying = api.Mul(carrying, sizeBits[i])
+	}
+	// size+1 must still fit.
+	api.AssertIsEqual(carrying, 0)
+	newSize := api.Add(size, 1)
+	return m.bag(newSize, m.sizeBits(newSize), newPeaks), newPeaks
+}
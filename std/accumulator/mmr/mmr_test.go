@@ -0,0 +1,200 @@
+package mmr
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	cryptohash "github.com/consensys/gnark-crypto/hash"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/hash/sha3"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/test"
+	nativesha3 "golang.org/x/crypto/sha3"
+)
+
+const testMaxHeight = 4
+
+func randomFieldLeaf(t *testing.T) []byte {
+	var e fr.Element
+	if _, err := e.SetRandom(); err != nil {
+		t.Fatal(err)
+	}
+	b := e.Bytes()
+	return b[:]
+}
+
+func toVariables(nodes [][]byte) []frontend.Variable {
+	ret := make([]frontend.Variable, len(nodes))
+	for i := range nodes {
+		ret[i] = new(big.Int).SetBytes(nodes[i])
+	}
+	return ret
+}
+
+type fieldInclusionCircuit struct {
+	Root     frontend.Variable
+	Size     frontend.Variable
+	Peaks    [testMaxHeight + 1]frontend.Variable
+	Index    frontend.Variable
+	Leaf     frontend.Variable
+	Siblings [testMaxHeight]frontend.Variable
+}
+
+func (c *fieldInclusionCircuit) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	m, err := New(api, NewFieldHasher(api, &h), testMaxHeight)
+	if err != nil {
+		return err
+	}
+	m.VerifyInclusion(c.Root, c.Size, c.Peaks[:], c.Index, c.Leaf, c.Siblings[:])
+	return nil
+}
+
+func TestFieldInclusion(t *testing.T) {
+	assert := test.NewAssert(t)
+	native := NewNative(cryptohash.MIMC_BN254.New())
+	var leaves [][]byte
+	for i := 0; i < 13; i++ {
+		leaves = append(leaves, randomFieldLeaf(t))
+		assert.NoError(native.Append(leaves[i]))
+	}
+	peaks, err := native.Peaks(testMaxHeight)
+	assert.NoError(err)
+	for _, index := range []uint64{0, 7, 8, 11, 12} {
+		siblings, err := native.Prove(index, testMaxHeight)
+		assert.NoError(err)
+		assignment := &fieldInclusionCircuit{
+			Root:  new(big.Int).SetBytes(native.Root()),
+			Size:  native.Size(),
+			Index: index,
+			Leaf:  new(big.Int).SetBytes(leaves[index]),
+		}
+		copy(assignment.Peaks[:], toVariables(peaks))
+		copy(assignment.Siblings[:], toVariables(siblings))
+		assert.NoError(test.IsSolved(&fieldInclusionCircuit{}, assignment, ecc.BN254.ScalarField()))
+
+		// wrong leaf
+		assignment.Leaf = new(big.Int).SetBytes(leaves[(index+1)%13])
+		assert.Error(test.IsSolved(&fieldInclusionCircuit{}, assignment, ecc.BN254.ScalarField()))
+	}
+}
+
+type fieldAppendCircuit struct {
+	OldRoot frontend.Variable `gnark:",public"`
+	NewRoot frontend.Variable `gnark:",public"`
+	Size    frontend.Variable
+	Peaks   [testMaxHeight + 1]frontend.Variable
+	Leaves  [3]frontend.Variable
+}
+
+func (c *fieldAppendCircuit) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	m, err := New(api, NewFieldHasher(api, &h), testMaxHeight)
+	if err != nil {
+		return err
+	}
+	root, peaks, size := c.OldRoot, c.Peaks[:], c.Size
+	for i := range c.Leaves {
+		root, peaks = m.Append(root, size, peaks, c.Leaves[i])
+		size = api.Add(size, 1)
+	}
+	api.AssertIsEqual(root, c.NewRoot)
+	api.AssertIsEqual(m.Root(size, peaks), c.NewRoot)
+	return nil
+}
+
+func TestFieldAppend(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, initial := range []int{0, 5, 7} {
+		native := NewNative(cryptohash.MIMC_BN254.New())
+		for i := 0; i < initial; i++ {
+			assert.NoError(native.Append(randomFieldLeaf(t)))
+		}
+		peaks, err := native.Peaks(testMaxHeight)
+		assert.NoError(err)
+		assignment := &fieldAppendCircuit{
+			OldRoot: new(big.Int).SetBytes(native.Root()),
+			Size:    native.Size(),
+		}
+		copy(assignment.Peaks[:], toVariables(peaks))
+		for i := range assignment.Leaves {
+			leaf := randomFieldLeaf(t)
+			assert.NoError(native.Append(leaf))
+			assignment.Leaves[i] = new(big.Int).SetBytes(leaf)
+		}
+		assignment.NewRoot = new(big.Int).SetBytes(native.Root())
+		assert.CheckCircuit(&fieldAppendCircuit{}, test.WithValidAssignment(assignment), test.WithCurves(ecc.BN254))
+	}
+}
+
+type binaryInclusionCircuit struct {
+	Root     [32]uints.U8
+	Size     frontend.Variable
+	Peaks    [testMaxHeight + 1][32]uints.U8
+	Index    frontend.Variable
+	Leaf     [32]uints.U8
+	Siblings [testMaxHeight][32]uints.U8
+}
+
+func (c *binaryInclusionCircuit) Define(api frontend.API) error {
+	h, err := NewBinaryHasher(api, func() (hash.BinaryHasher, error) { return sha3.NewLegacyKeccak256(api) })
+	if err != nil {
+		return err
+	}
+	m, err := New(api, h, testMaxHeight)
+	if err != nil {
+		return err
+	}
+	peaks := make([][]uints.U8, len(c.Peaks))
+	for i := range peaks {
+		peaks[i] = c.Peaks[i][:]
+	}
+	siblings := make([][]uints.U8, len(c.Siblings))
+	for i := range siblings {
+		siblings[i] = c.Siblings[i][:]
+	}
+	m.VerifyInclusion(c.Root[:], c.Size, peaks, c.Index, c.Leaf[:], siblings)
+	return nil
+}
+
+func TestBinaryInclusion(t *testing.T) {
+	assert := test.NewAssert(t)
+	native := NewNative(nativesha3.NewLegacyKeccak256())
+	var leaves [][]byte
+	for i := 0; i < 6; i++ {
+		leaf := make([]byte, 32)
+		_, err := rand.Read(leaf)
+		assert.NoError(err)
+		leaves = append(leaves, leaf)
+		assert.NoError(native.Append(leaf))
+	}
+	peaks, err := native.Peaks(testMaxHeight)
+	assert.NoError(err)
+	index := uint64(2)
+	siblings, err := native.Prove(index, testMaxHeight)
+	assert.NoError(err)
+	assignment := &binaryInclusionCircuit{
+		Size:  native.Size(),
+		Index: index,
+	}
+	copy(assignment.Root[:], uints.NewU8Array(native.Root()))
+	copy(assignment.Leaf[:], uints.NewU8Array(leaves[index]))
+	for i := range peaks {
+		copy(assignment.Peaks[i][:], uints.NewU8Array(peaks[i]))
+	}
+	for i := range siblings {
+		copy(assignment.Siblings[i][:], uints.NewU8Array(siblings[i]))
+	}
+	assert.NoError(test.IsSolved(&binaryInclusionCircuit{}, assignment, ecc.BN254.ScalarField()))
+}
@@ -0,0 +1,125 @@
+package mmr
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"math/bits"
+)
+
+// Native is a native Merkle Mountain Range for generating the witnesses for
+// the circuit functions in this package. The nodes are byte slices of
+// [hash.Hash.Size] bytes and the parent of two nodes is the hash of their
+// concatenation. Integers are encoded big-endian over the node size.
+//
+// To match the in-circuit [NewFieldHasher], use a hash function which
+// interprets its input as a sequence of field elements of node size, as for
+// example MiMC from gnark-crypto.
+type Native struct {
+	h hash.Hash
+	// levels[i] stores the roots of all perfect subtrees of height i, in order.
+	levels [][][]byte
+	size   uint64
+}
+
+// NewNative returns a new empty native MMR using the hash function h.
+func NewNative(h hash.Hash) *Native {
+	return &Native{h: h}
+}
+
+// Size returns the number of leaves.
+func (m *Native) Size() uint64 {
+	return m.size
+}
+
+// Append appends the leaf. The leaf must have the node size.
+func (m *Native) Append(leaf []byte) error {
+	if len(leaf) != m.h.Size() {
+		return fmt.Errorf("leaf has length %d, expected %d", len(leaf), m.h.Size())
+	}
+	node := append([]byte{}, leaf...)
+	for i := 0; ; i++ {
+		if len(m.levels) == i {
+			m.levels = append(m.levels, nil)
+		}
+		m.levels[i] = append(m.levels[i], node)
+		if len(m.levels[i])%2 == 1 {
+			break
+		}
+		node = m.node(m.levels[i][len(m.levels[i])-2], node)
+	}
+	m.size++
+	return nil
+}
+
+// Peaks returns the peaks indexed by height up to maxHeight. The peaks at
+// heights not set in the number of leaves are zero.
+func (m *Native) Peaks(maxHeight int) ([][]byte, error) {
+	if bits.Len64(m.size) > maxHeight+1 {
+		return nil, fmt.Errorf("size %d does not fit max height %d", m.size, maxHeight)
+	}
+	peaks := make([][]byte, maxHeight+1)
+	for i := range peaks {
+		if m.size>>i&1 == 1 {
+			peaks[i] = append([]byte{}, m.levels[i][m.size>>i-1]...)
+		} else {
+			peaks[i] = make([]byte, m.h.Size())
+		}
+	}
+	return peaks, nil
+}
+
+// Root returns the root of the MMR.
+func (m *Native) Root() []byte {
+	acc := m.encode(0)
+	isEmpty := true
+	for i := 0; i < bits.Len64(m.size); i++ {
+		if m.size>>i&1 == 0 {
+			continue
+		}
+		peak := m.levels[i][m.size>>i-1]
+		if isEmpty {
+			acc = peak
+		} else {
+			acc = m.node(peak, acc)
+		}
+		isEmpty = false
+	}
+	return m.node(m.encode(m.size), acc)
+}
+
+// Prove returns the Merkle path from the leaf at index to its peak, padded
+// with zero nodes to maxHeight siblings.
+func (m *Native) Prove(index uint64, maxHeight int) ([][]byte, error) {
+	if index >= m.size {
+		return nil, errors.New("index out of range")
+	}
+	height := bits.Len64(index^m.size) - 1
+	if height > maxHeight {
+		return nil, fmt.Errorf("peak height %d exceeds max height %d", height, maxHeight)
+	}
+	siblings := make([][]byte, maxHeight)
+	for i := range siblings {
+		if i < height {
+			siblings[i] = append([]byte{}, m.levels[i][(index>>i)^1]...)
+		} else {
+			siblings[i] = make([]byte, m.h.Size())
+		}
+	}
+	return siblings, nil
+}
+
+func (m *Native) node(left, right []byte) []byte {
+	m.h.Reset()
+	m.h.Write(left)
+	m.h.Write(right)
+	return m.h.Sum(nil)
+}
+
+func (m *Native) encode(v uint64) []byte {
+	ret := make([]byte, m.h.Size())
+	for i := 0; i < 8; i++ {
+		ret[len(ret)-1-i] = byte(v >> (8 * i))
+	}
+	return ret
+}
@@ -0,0 +1,164 @@
+package mpt
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/test"
+)
+
+// ethProof is the result of the eth_getProof RPC method, with the state root
+// of the block the proof was requested at. The fixtures in
+// testdata/eth_getProof are built with the trie of go-ethereum by
+// testdata/generate. A fixture can also be captured from a node of mainnet or
+// of a devnet with:
+//
+//	curl -s -X POST -H 'Content-Type: application/json' $RPC \
+//	  --data '{"jsonrpc":"2.0","id":1,"method":"eth_getProof","params":["<address>",["<slot>"],"<block>"]}' \
+//	  | jq '.result' > proof.json
+//	curl -s -X POST -H 'Content-Type: application/json' $RPC \
+//	  --data '{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["<block>",false]}' \
+//	  | jq -r '.result.stateRoot'
+//
+// and by adding the state root to proof.json as "stateRoot", before saving it
+// under testdata/eth_getProof.
+type ethProof struct {
+	StateRoot    string   `json:"stateRoot"`
+	Address      string   `json:"address"`
+	AccountProof []string `json:"accountProof"`
+	Nonce        string   `json:"nonce"`
+	Balance      string   `json:"balance"`
+	StorageHash  string   `json:"storageHash"`
+	CodeHash     string   `json:"codeHash"`
+	StorageProof []struct {
+		Key   string   `json:"key"`
+		Value string   `json:"value"`
+		Proof []string `json:"proof"`
+	} `json:"storageProof"`
+}
+
+type storageProofCircuit struct {
+	StorageRoot [32]uints.U8
+	Slot        [32]uints.U8
+	Proof       Proof
+	Value       [33]uints.U8
+	ValueLen    frontend.Variable
+}
+
+func (c *storageProofCircuit) Define(api frontend.API) error {
+	v, err := New(api)
+	if err != nil {
+		return err
+	}
+	return v.VerifyStorageProof(c.StorageRoot, c.Slot, c.Proof, c.Value[:], c.ValueLen)
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	s = strings.TrimPrefix(s, "0x")
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// decodeQuantity returns the minimal big-endian encoding of a hex quantity,
+// as stored in RLP.
+func decodeQuantity(t *testing.T, s string) []byte {
+	v, ok := new(big.Int).SetString(strings.TrimPrefix(s, "0x"), 16)
+	if !ok {
+		t.Fatalf("invalid quantity %s", s)
+	}
+	return v.Bytes()
+}
+
+func decodeNodes(t *testing.T, nodes []string) [][]byte {
+	res := make([][]byte, len(nodes))
+	for i := range nodes {
+		res[i] = decodeHex(t, nodes[i])
+	}
+	return res
+}
+
+func padLeft(b []byte, n int) []byte {
+	return append(make([]byte, n-len(b)), b...)
+}
+
+// TestEthGetProof verifies the account and storage proofs returned by the
+// eth_getProof RPC method of an Ethereum node. The proofs are read from
+// testdata/eth_getProof, see ethProof for capturing them.
+func TestEthGetProof(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "eth_getProof", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no eth_getProof fixture in testdata/eth_getProof")
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			assert := test.NewAssert(t)
+			data, err := os.ReadFile(file)
+			assert.NoError(err)
+			var p ethProof
+			assert.NoError(json.Unmarshal(data, &p))
+
+			account := rlpList(
+				rlpString(decodeQuantity(t, p.Nonce)),
+				rlpString(decodeQuantity(t, p.Balance)),
+				rlpString(decodeHex(t, p.StorageHash)),
+				rlpString(decodeHex(t, p.CodeHash)),
+			)
+			// the leaf may be embedded in the last node of the proof.
+			maxDepth := len(p.AccountProof) + 1
+			proof, err := ValueOfProof(keccak(decodeHex(t, p.Address)), decodeNodes(t, p.AccountProof), maxDepth)
+			assert.NoError(err)
+			accountAssignment := &accountProofCircuit{
+				Proof:      proof,
+				AccountLen: len(account),
+			}
+			copy(accountAssignment.StateRoot[:], uints.NewU8Array(decodeHex(t, p.StateRoot)))
+			copy(accountAssignment.Address[:], uints.NewU8Array(decodeHex(t, p.Address)))
+			copy(accountAssignment.Account[:], uints.NewU8Array(append(account, make([]byte, len(accountAssignment.Account)-len(account))...)))
+			err = test.IsSolved(&accountProofCircuit{Proof: PlaceholderProof(maxDepth)}, accountAssignment, ecc.BN254.ScalarField())
+			assert.NoError(err)
+
+			for _, sp := range p.StorageProof {
+				value := decodeQuantity(t, sp.Value)
+				if len(value) == 0 {
+					// the slot is empty and the proof is an exclusion proof
+					continue
+				}
+				value = rlpString(value)
+				maxDepth := len(sp.Proof) + 1
+				slot := padLeft(decodeHex(t, sp.Key), 32)
+				proof, err := ValueOfProof(keccak(slot), decodeNodes(t, sp.Proof), maxDepth)
+				assert.NoError(err)
+				storageAssignment := &storageProofCircuit{
+					Proof:    proof,
+					ValueLen: len(value),
+				}
+				copy(storageAssignment.StorageRoot[:], uints.NewU8Array(decodeHex(t, p.StorageHash)))
+				copy(storageAssignment.Slot[:], uints.NewU8Array(slot))
+				copy(storageAssignment.Value[:], uints.NewU8Array(append(value, make([]byte, len(storageAssignment.Value)-len(value))...)))
+				err = test.IsSolved(&storageProofCircuit{Proof: PlaceholderProof(maxDepth)}, storageAssignment, ecc.BN254.ScalarField())
+				assert.NoError(err)
+				// wrong value
+				storageAssignment.Value[len(value)-1] = uints.NewU8(value[len(value)-1] ^ 1)
+				err = test.IsSolved(&storageProofCircuit{Proof: PlaceholderProof(maxDepth)}, storageAssignment, ecc.BN254.ScalarField())
+				assert.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package mpt
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark/constraint/solver"
+)
+
+func init() {
+	solver.RegisterHint(GetHints()...)
+}
+
+// GetHints returns all hint functions used in the package.
+func GetHints() []solver.Hint {
+	return []solver.Hint{isBranchHint}
+}
+
+// isBranchHint returns 1 if the RLP-encoded node given as inputs[1:] with
+// length inputs[0] is a list of 17 items (a branch node), and 0 otherwise. The
+// output is not trusted in-circuit, we only use it to decide which parsing
+// should be enforced.
+func isBranchHint(_ *big.Int, inputs, outputs []*big.Int) error {
+	if len(inputs) < 1 || len(outputs) != 1 {
+		return errors.New("expecting node length and bytes as input and single output")
+	}
+	if !inputs[0].IsUint64() || inputs[0].Uint64() > uint64(len(inputs)-1) {
+		outputs[0].SetUint64(0)
+		return nil
+	}
+	node := make([]byte, inputs[0].Uint64())
+	for i := range node {
+		node[i] = byte(inputs[i+1].Uint64())
+	}
+	items, err := decodeList(node)
+	if err == nil && len(items) == 17 {
+		outputs[0].SetUint64(1)
+	} else {
+		outputs[0].SetUint64(0)
+	}
+	return nil
+}
+
+// decodeList decodes the top-level RLP list in b and returns the encodings of
+// its items.
+func decodeList(b []byte) ([][]byte, error) {
+	payload, rest, isList, err := decodeItem(b)
+	if err != nil {
+		return nil, err
+	}
+	if !isList {
+		return nil, errors.New("not a list")
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing bytes after list")
+	}
+	var items [][]byte
+	for len(payload) > 0 {
+		_, r, _, err := decodeItem(payload)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, payload[:len(payload)-len(r)])
+		payload = r
+	}
+	return items, nil
+}
+
+// decodeItem decodes the first RLP item in b and returns its payload, the
+// remaining bytes and whether the item is a list.
+func decodeItem(b []byte) (payload, rest []byte, isList bool, err error) {
+	if len(b) == 0 {
+		return nil, nil, false, errors.New("empty input")
+	}
+	var hdr, length int
+	switch p := int(b[0]); {
+	case p < 0x80:
+		return b[:1], b[1:], false, nil
+	case p <= 0xb7:
+		hdr, length = 1, p-0x80
+	case p < 0xc0:
+		hdr, length, err = decodeLongLength(b, p-0xb7)
+	case p <= 0xf7:
+		hdr, length, isList = 1, p-0xc0, true
+	default:
+		hdr, length, err = decodeLongLength(b, p-0xf7)
+		isList = true
+	}
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if hdr+length > len(b) {
+		return nil, nil, false, errors.New("item exceeds input")
+	}
+	return b[hdr : hdr+length], b[hdr+length:], isList, nil
+}
+
+func decodeLongLength(b []byte, lenOfLen int) (hdr, length int, err error) {
+	if lenOfLen > 3 || 1+lenOfLen > len(b) {
+		return 0, 0, errors.New("invalid length prefix")
+	}
+	for i := 0; i < lenOfLen; i++ {
+		length = length<<8 | int(b[1+i])
+	}
+	return 1 + lenOfLen, length, nil
+}
@@ -0,0 +1,441 @@
+// Package mpt provides ZKP-circuit functions to verify Ethereum
+// Merkle-Patricia trie inclusion proofs.
+//
+// A proof is the list of RLP-encoded trie nodes on the path from the root to
+// the leaf, as returned by the eth_getProof RPC method. Every node is hashed
+// using Keccak-256 and compared against the reference stored in its parent (or
+// the root for the first node). Branch, extension and leaf nodes are parsed
+// in-circuit and the key nibbles are consumed along the path.
+//
+// The circuit is defined for a fixed maximum proof depth, but the actual number
+// of nodes in the proof is a witness value. Every node is stored in a fixed
+// size buffer of [MaxNodeLen] bytes.
+//
+// Nodes whose encoding is shorter than 32 bytes are embedded into their parent
+// instead of being referenced by their hash. In the storage tries this happens
+// for leaves with small values deep in the trie. Such a node is not part of the
+// proof returned by eth_getProof, [ValueOfProof] extracts it from its parent
+// and the circuit checks it against the bytes of the parent instead of its
+// hash. Only leaves embedded in a branch node are supported: an embedded
+// branch or extension node would require keys sharing almost all of their
+// nibbles, which does not happen with Keccak-256 hashed keys.
+package mpt
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/sha3"
+	"github.com/consensys/gnark/std/lookup/logderivlookup"
+	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/bitslice"
+	"github.com/consensys/gnark/std/math/cmp"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/std/rangecheck"
+	"github.com/consensys/gnark/std/selector"
+)
+
+const (
+	// MaxNodeLen is the maximum length of a RLP-encoded trie node. It
+	// corresponds to a branch node with 16 hashed children and an empty value.
+	MaxNodeLen = 532
+	// KeyLen is the length of the trie keys in bytes.
+	KeyLen = 32
+
+	nbKeyNibbles = 2 * KeyLen
+	// maxPathLen is the maximum length of the hex-prefix encoded path in a leaf
+	// or extension node.
+	maxPathLen = KeyLen + 1
+	// maxEmbeddedLen is the maximum length of the encoding of a node embedded
+	// in its parent.
+	maxEmbeddedLen = 31
+)
+
+// Node is a RLP-encoded trie node stored in a fixed size buffer.
+type Node struct {
+	// Data is the encoding of the node, padded with zeros.
+	Data [MaxNodeLen]uints.U8
+	// Length is the length of the encoding of the node.
+	Length frontend.Variable
+}
+
+// Proof is a Merkle-Patricia trie inclusion proof.
+type Proof struct {
+	// Nodes are the nodes on the path from the root to the leaf. The nodes
+	// after Depth are ignored.
+	Nodes []Node
+	// Depth is the number of nodes in the proof.
+	Depth frontend.Variable
+}
+
+// PlaceholderProof returns a placeholder proof for circuit compilation
+// supporting proofs with up to maxDepth nodes.
+func PlaceholderProof(maxDepth int) Proof {
+	return Proof{
+		Nodes: make([]Node, maxDepth),
+	}
+}
+
+// ValueOfProof returns the witness assignment of the proof for path given as
+// the list of RLP-encoded nodes from the root to the leaf, as returned by
+// eth_getProof. For the secure tries the path is the Keccak-256 hash of the
+// key. If the leaf is embedded in the last node, it is appended to the proof.
+// The assignment is padded to maxDepth nodes.
+func ValueOfProof(path []byte, nodes [][]byte, maxDepth int) (Proof, error) {
+	if len(path) != KeyLen {
+		return Proof{}, fmt.Errorf("path has length %d, expected %d", len(path), KeyLen)
+	}
+	if len(nodes) > 0 {
+		leaf, err := embeddedLeaf(path, nodes)
+		if err != nil {
+			return Proof{}, err
+		}
+		if leaf != nil {
+			nodes = append(nodes[:len(nodes):len(nodes)], leaf)
+		}
+	}
+	if len(nodes) == 0 || len(nodes) > maxDepth {
+		return Proof{}, fmt.Errorf("proof has %d nodes, expected between 1 and %d", len(nodes), maxDepth)
+	}
+	ret := Proof{
+		Nodes: make([]Node, maxDepth),
+		Depth: len(nodes),
+	}
+	for i := range ret.Nodes {
+		var node []byte
+		if i < len(nodes) {
+			node = nodes[i]
+		}
+		if len(node) > MaxNodeLen {
+			return Proof{}, fmt.Errorf("node %d has length %d exceeding %d", i, len(node), MaxNodeLen)
+		}
+		for j := range ret.Nodes[i].Data {
+			if j < len(node) {
+				ret.Nodes[i].Data[j] = uints.NewU8(node[j])
+			} else {
+				ret.Nodes[i].Data[j] = uints.NewU8(0)
+			}
+		}
+		ret.Nodes[i].Length = len(node)
+	}
+	return ret, nil
+}
+
+// embeddedLeaf returns the encoding of the child at path of the last node of
+// the proof if it is embedded in that node, and nil otherwise.
+func embeddedLeaf(path []byte, nodes [][]byte) ([]byte, error) {
+	pos := 0
+	for i, node := range nodes {
+		items, err := decodeList(node)
+		if err != nil {
+			return nil, fmt.Errorf("node %d: %w", i, err)
+		}
+		switch len(items) {
+		case 17:
+			if pos >= nbKeyNibbles {
+				return nil, fmt.Errorf("node %d: path exhausted", i)
+			}
+			child := items[nibbleAt(path, pos)]
+			pos++
+			if i == len(nodes)-1 && child[0] >= 0xc0 {
+				return child, nil
+			}
+		case 2:
+			hp, _, _, err := decodeItem(items[0])
+			if err != nil || len(hp) == 0 {
+				return nil, fmt.Errorf("node %d: invalid path", i)
+			}
+			// the hex-prefix flag nibble is followed by a padding nibble if
+			// the path has even length.
+			pos += 2*len(hp) - 2 + int(hp[0]>>4&1)
+		default:
+			return nil, fmt.Errorf("node %d: list of %d items", i, len(items))
+		}
+	}
+	return nil, nil
+}
+
+// nibbleAt returns the i-th nibble of path.
+func nibbleAt(path []byte, i int) byte {
+	if i%2 == 0 {
+		return path[i/2] >> 4
+	}
+	return path[i/2] & 0x0f
+}
+
+// Verifier verifies Merkle-Patricia trie proofs in-circuit.
+type Verifier struct {
+	api      frontend.API
+	rchecker frontend.Rangechecker
+	// byteCmp compares values which are at most a byte long.
+	byteCmp *cmp.BoundedComparator
+}
+
+// New returns a new [Verifier].
+func New(api frontend.API) (*Verifier, error) {
+	return &Verifier{
+		api:      api,
+		rchecker: rangecheck.New(api),
+		byteCmp:  cmp.NewBoundedComparator(api, big.NewInt(512), false),
+	}, nil
+}
+
+// VerifyAccountProof asserts that account is the value stored for address in
+// the state trie with root stateRoot. The account is the RLP encoding of the
+// list [nonce, balance, storageRoot, codeHash] and only its first accountLen
+// bytes are used.
+func (v *Verifier) VerifyAccountProof(stateRoot [32]uints.U8, address [20]uints.U8, proof Proof, account []uints.U8, accountLen frontend.Variable) error {
+	path, err := v.hashKey(address[:])
+	if err != nil {
+		return fmt.Errorf("hash address: %w", err)
+	}
+	return v.VerifyProof(stateRoot, path, proof, account, accountLen)
+}
+
+// VerifyStorageProof asserts that value is the value stored for slot in the
+// storage trie with root storageRoot. The value is the RLP encoding of the
+// slot value and only its first valueLen bytes are used.
+func (v *Verifier) VerifyStorageProof(storageRoot [32]uints.U8, slot [32]uints.U8, proof Proof, value []uints.U8, valueLen frontend.Variable) error {
+	path, err := v.hashKey(slot[:])
+	if err != nil {
+		return fmt.Errorf("hash slot: %w", err)
+	}
+	return v.VerifyProof(storageRoot, path, proof, value, valueLen)
+}
+
+// VerifyProof asserts that the leaf at path in the trie with the given root
+// stores value. Only the first valueLen bytes of value are used. The path is
+// given as-is, for the secure tries used in Ethereum it is the Keccak-256 hash
+// of the key.
+func (v *Verifier) VerifyProof(root [32]uints.U8, path [KeyLen]uints.U8, proof Proof, value []uints.U8, valueLen frontend.Variable) error {
+	api := v.api
+	maxDepth := len(proof.Nodes)
+	if maxDepth == 0 {
+		return fmt.Errorf("empty proof")
+	}
+	valueCmp := cmp.NewBoundedComparator(api, big.NewInt(int64(len(value)+256)), false)
+	valueCmp.AssertIsLessEq(valueLen, len(value))
+	depthCmp := cmp.NewBoundedComparator(api, big.NewInt(int64(maxDepth+1)), false)
+	depthCmp.AssertIsLessEq(1, proof.Depth)
+	depthCmp.AssertIsLessEq(proof.Depth, maxDepth)
+
+	// the key nibbles are accessed at variable positions. We pad the table so
+	// that lookups when parsing a node speculatively stay in range.
+	keyNibbles := logderivlookup.New(api)
+	for i := range path {
+		v.rchecker.Check(path[i].Val, 8)
+		lo, hi := bitslice.Partition(api, path[i].Val, 4, bitslice.WithNbDigits(8))
+		keyNibbles.Insert(hi)
+		keyNibbles.Insert(lo)
+	}
+	for i := 0; i < 2*maxPathLen; i++ {
+		keyNibbles.Insert(0)
+	}
+
+	expected := make([]frontend.Variable, 32)
+	for i := range expected {
+		expected[i] = root[i].Val
+	}
+	var keyPos frontend.Variable = 0
+	// isEmbedded is 1 if the current node is embedded in its parent, which is
+	// then referenced by its encoding of embeddedLen bytes instead of its hash.
+	var isEmbedded, embeddedLen frontend.Variable = 0, 0
+	for i := range proof.Nodes {
+		node := &proof.Nodes[i]
+		active := depthCmp.IsLess(i, proof.Depth)
+		isLast := api.IsZero(api.Sub(proof.Depth, i+1))
+
+		// check that the node hashes to the reference in its parent, or that
+		// it is the encoding embedded in its parent.
+		h, err := sha3.NewLegacyKeccak256(api)
+		if err != nil {
+			return fmt.Errorf("new keccak: %w", err)
+		}
+		h.Write(node.Data[:])
+		digest := h.FixedLengthSum(node.Length)
+		isHashedActive := api.Mul(active, api.Sub(1, isEmbedded))
+		for j := range digest {
+			v.assertEqualIf(isHashedActive, digest[j].Val, expected[j])
+		}
+		isEmbeddedActive := api.Mul(active, isEmbedded)
+		v.assertEqualIf(isEmbeddedActive, node.Length, embeddedLen)
+		for j := 0; j < maxEmbeddedLen; j++ {
+			inNode := v.byteCmp.IsLess(j, node.Length)
+			v.assertEqualIf(api.Mul(isEmbeddedActive, inNode), node.Data[j].Val, expected[j])
+		}
+
+		// the table is padded so that reading the value or the reference to
+		// the next node stays in range.
+		tbl := logderivlookup.New(api)
+		for j := range node.Data {
+			v.rchecker.Check(node.Data[j].Val, 8)
+			tbl.Insert(node.Data[j].Val)
+		}
+		for j := 0; j < len(value)+32; j++ {
+			tbl.Insert(0)
+		}
+		at := func(idx frontend.Variable) frontend.Variable {
+			return tbl.Lookup(idx)[0]
+		}
+
+		// the node is a list. We support lists with length encoded in at
+		// most two bytes.
+		b0 := at(0)
+		isF8 := api.IsZero(api.Sub(b0, 0xf8))
+		isF9 := api.IsZero(api.Sub(b0, 0xf9))
+		isList := api.Sub(1, v.byteCmp.IsLess(b0, 0xc0))
+		isList = api.Mul(isList, v.byteCmp.IsLess(b0, 0xfa))
+		v.assertEqualIf(active, isList, 1)
+		hdrLen := api.Add(1, isF8, api.Mul(2, isF9))
+		payloadLen := api.Select(isF8, at(1), api.Select(isF9, api.Add(api.Mul(at(1), 256), at(2)), api.Sub(b0, 0xc0)))
+		v.assertEqualIf(active, api.Add(hdrLen, payloadLen), node.Length)
+
+		hint := make([]frontend.Variable, len(node.Data)+1)
+		hint[0] = node.Length
+		for j := range node.Data {
+			hint[j+1] = node.Data[j].Val
+		}
+		res, err := api.Compiler().NewHint(isBranchHint, 1, hint...)
+		if err != nil {
+			return fmt.Errorf("is branch hint: %w", err)
+		}
+		isBranch := res[0]
+		api.AssertIsBoolean(isBranch)
+		isBranchActive := api.Mul(active, isBranch)
+		isShortActive := api.Sub(active, isBranchActive)
+
+		// parse as a branch node. Every child is either empty (0x80), a
+		// hash (0xa0 followed by 32 bytes) or an embedded node (a list shorter
+		// than 32 bytes). The value is always empty.
+		offsets := make([]frontend.Variable, 17)
+		offsets[0] = hdrLen
+		for k := 0; k < 16; k++ {
+			p := at(offsets[k])
+			isEmpty := api.IsZero(api.Sub(p, 0x80))
+			isHash := api.IsZero(api.Sub(p, 0xa0))
+			isList := v.isEmbeddedList(p)
+			v.assertEqualIf(isBranchActive, api.Add(isEmpty, isHash, isList), 1)
+			offsets[k+1] = api.Add(offsets[k], 1, api.Mul(isHash, 32), api.Mul(isList, api.Sub(p, 0xc0)))
+		}
+		v.assertEqualIf(isBranchActive, at(offsets[16]), 0x80)
+		v.assertEqualIf(isBranchActive, api.Add(offsets[16], 1), node.Length)
+		// branch nodes are never terminal as all keys have the same length.
+		v.assertEqualIf(isBranchActive, isLast, 0)
+		nibble := keyNibbles.Lookup(keyPos)[0]
+		childOffset := selector.Mux(api, nibble, offsets[:16]...)
+		child := at(childOffset)
+		childIsHash := api.IsZero(api.Sub(child, 0xa0))
+		childIsList := v.isEmbeddedList(child)
+		v.assertEqualIf(isBranchActive, api.Add(childIsHash, childIsList), 1)
+
+		// parse as a leaf or extension node. The first item is the hex-prefix
+		// encoded path and the second item is the value or the reference to
+		// the child.
+		pb := at(hdrLen)
+		pathIsSingle := v.byteCmp.IsLess(pb, 0x80)
+		v.assertEqualIf(isShortActive, v.byteCmp.IsLess(pb, 0x81+maxPathLen), 1)
+		v.assertEqualIf(isShortActive, api.IsZero(api.Sub(pb, 0x80)), 0)
+		pathOffset := api.Sub(api.Add(hdrLen, 1), pathIsSingle)
+		pathLen := api.Select(pathIsSingle, 1, api.Sub(pb, 0x80))
+
+		// the first byte of the path contains the flags. The high nibble
+		// encodes if the path has odd length and if the node is a leaf.
+		pathBytes := make([]frontend.Variable, maxPathLen)
+		for m := range pathBytes {
+			pathBytes[m] = at(api.Add(pathOffset, m))
+		}
+		flagLo, flagHi := bitslice.Partition(api, pathBytes[0], 4, bitslice.WithNbDigits(8))
+		flags := bits.ToBinary(api, flagHi, bits.WithNbDigits(4))
+		isOdd, isLeaf := flags[0], flags[1]
+		v.assertEqualIf(isShortActive, api.Add(flags[2], flags[3]), 0)
+		v.assertEqualIf(isShortActive, api.Mul(api.Sub(1, isOdd), flagLo), 0)
+		v.assertEqualIf(isShortActive, isLeaf, isLast)
+
+		// the nibbles of the path start at position pathStart in the nibble
+		// stream of the encoded path and end at position 2*pathLen.
+		pathStart := api.Sub(2, isOdd)
+		nbPathNibbles := api.Sub(api.Mul(2, pathLen), pathStart)
+		for t := 1; t < 2*maxPathLen; t++ {
+			var nib frontend.Variable
+			if t == 1 {
+				nib = flagLo
+			} else {
+				lo, hi := bitslice.Partition(api, pathBytes[t/2], 4, bitslice.WithNbDigits(8))
+				if t%2 == 0 {
+					nib = hi
+				} else {
+					nib = lo
+				}
+			}
+			var inPath frontend.Variable = 1
+			if t == 1 {
+				inPath = isOdd
+			}
+			inPath = api.Mul(inPath, v.byteCmp.IsLess(t, api.Mul(2, pathLen)))
+			keyIdx := api.Select(inPath, api.Sub(api.Add(keyPos, t), pathStart), 0)
+			keyNib := keyNibbles.Lookup(keyIdx)[0]
+			v.assertEqualIf(api.Mul(isShortActive, inPath), nib, keyNib)
+		}
+
+		// the second item is either a single byte, a short string or a
+		// string with one byte length.
+		valuePos := api.Add(pathOffset, pathLen)
+		vb := at(valuePos)
+		valueIsSingle := v.byteCmp.IsLess(vb, 0x80)
+		valueIsB8 := api.IsZero(api.Sub(vb, 0xb8))
+		v.assertEqualIf(isShortActive, v.byteCmp.IsLess(vb, 0xb9), 1)
+		valueOffset := api.Add(valuePos, api.Select(valueIsSingle, 0, api.Add(1, valueIsB8)))
+		itemLen := api.Select(valueIsSingle, 1, api.Select(valueIsB8, at(api.Add(valuePos, 1)), api.Sub(vb, 0x80)))
+		v.assertEqualIf(isShortActive, api.Add(valueOffset, itemLen), node.Length)
+
+		// extension nodes reference the child by hash, as a branch node is
+		// never short enough to be embedded.
+		isExtActive := api.Mul(isShortActive, api.Sub(1, isLeaf))
+		v.assertEqualIf(isExtActive, vb, 0xa0)
+
+		// the leaf must consume the whole key and store the value.
+		isLeafActive := api.Mul(isShortActive, isLeaf)
+		v.assertEqualIf(isLeafActive, api.Add(keyPos, nbPathNibbles), nbKeyNibbles)
+		v.assertEqualIf(isLeafActive, itemLen, valueLen)
+		for j := range value {
+			inValue := valueCmp.IsLess(j, valueLen)
+			v.assertEqualIf(api.Mul(isLeafActive, inValue), at(api.Add(valueOffset, j)), value[j].Val)
+		}
+
+		// the reference to the next node. An embedded node is referenced by
+		// its encoding, including the list header.
+		isEmbedded = api.Mul(isBranch, childIsList)
+		embeddedLen = api.Sub(child, 0xbf)
+		refOffset := api.Select(isBranch, api.Sub(api.Add(childOffset, 1), isEmbedded), api.Add(valuePos, 1))
+		for j := range expected {
+			expected[j] = at(api.Add(refOffset, j))
+		}
+		keyPos = api.Select(isBranch, api.Add(keyPos, 1), api.Add(keyPos, nbPathNibbles))
+	}
+	return nil
+}
+
+// hashKey returns the Keccak-256 hash of the key, which is used as the path in
+// the secure tries.
+func (v *Verifier) hashKey(key []uints.U8) ([KeyLen]uints.U8, error) {
+	var ret [KeyLen]uints.U8
+	h, err := sha3.NewLegacyKeccak256(v.api)
+	if err != nil {
+		return ret, err
+	}
+	h.Write(key)
+	copy(ret[:], h.Sum())
+	return ret, nil
+}
+
+// isEmbeddedList returns 1 if b is the header of a list shorter than 32 bytes,
+// i.e. of an embedded node, and 0 otherwise. The input must be a byte.
+func (v *Verifier) isEmbeddedList(b frontend.Variable) frontend.Variable {
+	return v.api.Mul(v.byteCmp.IsLess(0xbf, b), v.byteCmp.IsLess(b, 0xc0+maxEmbeddedLen))
+}
+
+// assertEqualIf asserts a == b when cond is 1.
+func (v *Verifier) assertEqualIf(cond, a, b frontend.Variable) {
+	v.api.AssertIsEqual(v.api.Mul(cond, v.api.Sub(a, b)), 0)
+}
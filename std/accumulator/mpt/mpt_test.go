@@ -0,0 +1,354 @@
+package mpt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/test"
+	"golang.org/x/crypto/sha3"
+)
+
+// testTrie is a minimal native Merkle-Patricia trie used for generating test
+// vectors. It only supports insertion of keys of the same length.
+type testTrie struct {
+	root trieNode
+}
+
+type trieNode interface{}
+
+type leafNode struct {
+	path  []byte
+	value []byte
+}
+
+type extensionNode struct {
+	path  []byte
+	child trieNode
+}
+
+type branchNode struct {
+	children [16]trieNode
+}
+
+func keccak(b []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(b)
+	return h.Sum(nil)
+}
+
+func toNibbles(key []byte) []byte {
+	ret := make([]byte, 2*len(key))
+	for i := range key {
+		ret[2*i] = key[i] >> 4
+		ret[2*i+1] = key[i] & 0x0f
+	}
+	return ret
+}
+
+func commonPrefix(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+func (t *testTrie) insert(key, value []byte) {
+	t.root = insertNode(t.root, toNibbles(key), value)
+}
+
+func insertNode(n trieNode, path, value []byte) trieNode {
+	switch n := n.(type) {
+	case nil:
+		return &leafNode{path: path, value: value}
+	case *leafNode:
+		c := commonPrefix(n.path, path)
+		if c == len(path) {
+			return &leafNode{path: path, value: value}
+		}
+		br := &branchNode{}
+		br.children[n.path[c]] = &leafNode{path: n.path[c+1:], value: n.value}
+		br.children[path[c]] = &leafNode{path: path[c+1:], value: value}
+		if c == 0 {
+			return br
+		}
+		return &extensionNode{path: path[:c], child: br}
+	case *extensionNode:
+		c := commonPrefix(n.path, path)
+		if c == len(n.path) {
+			return &extensionNode{path: n.path, child: insertNode(n.child, path[c:], value)}
+		}
+		br := &branchNode{}
+		if c+1 == len(n.path) {
+			br.children[n.path[c]] = n.child
+		} else {
+			br.children[n.path[c]] = &extensionNode{path: n.path[c+1:], child: n.child}
+		}
+		br.children[path[c]] = &leafNode{path: path[c+1:], value: value}
+		if c == 0 {
+			return br
+		}
+		return &extensionNode{path: path[:c], child: br}
+	case *branchNode:
+		n.children[path[0]] = insertNode(n.children[path[0]], path[1:], value)
+		return n
+	}
+	panic("unknown node")
+}
+
+func rlpString(b []byte) []byte {
+	if len(b) == 1 && b[0] < 0x80 {
+		return b
+	}
+	return append(rlpHeader(0x80, len(b)), b...)
+}
+
+func rlpList(items ...[]byte) []byte {
+	payload := bytes.Join(items, nil)
+	return append(rlpHeader(0xc0, len(payload)), payload...)
+}
+
+func rlpHeader(offset byte, length int) []byte {
+	if length <= 55 {
+		return []byte{offset + byte(length)}
+	}
+	lb := big.NewInt(int64(length)).Bytes()
+	return append([]byte{offset + 55 + byte(len(lb))}, lb...)
+}
+
+func hexPrefix(path []byte, isLeaf bool) []byte {
+	var flag byte
+	if isLeaf {
+		flag = 2
+	}
+	var ret []byte
+	if len(path)%2 == 1 {
+		ret = append(ret, (flag+1)<<4|path[0])
+		path = path[1:]
+	} else {
+		ret = append(ret, flag<<4)
+	}
+	for i := 0; i < len(path); i += 2 {
+		ret = append(ret, path[i]<<4|path[i+1])
+	}
+	return ret
+}
+
+func encodeNode(n trieNode) []byte {
+	switch n := n.(type) {
+	case *leafNode:
+		return rlpList(rlpString(hexPrefix(n.path, true)), rlpString(n.value))
+	case *extensionNode:
+		return rlpList(rlpString(hexPrefix(n.path, false)), reference(n.child))
+	case *branchNode:
+		items := make([][]byte, 17)
+		for i := range n.children {
+			if n.children[i] == nil {
+				items[i] = rlpString(nil)
+			} else {
+				items[i] = reference(n.children[i])
+			}
+		}
+		items[16] = rlpString(nil)
+		return rlpList(items...)
+	}
+	panic("unknown node")
+}
+
+// reference returns the reference to a child node in its parent: its encoding
+// if it is shorter than 32 bytes and its hash otherwise.
+func reference(n trieNode) []byte {
+	enc := encodeNode(n)
+	if len(enc) < 32 {
+		return enc
+	}
+	return rlpString(keccak(enc))
+}
+
+func (t *testTrie) rootHash() []byte {
+	return keccak(encodeNode(t.root))
+}
+
+func (t *testTrie) prove(key []byte) [][]byte {
+	var proof [][]byte
+	path := toNibbles(key)
+	n := t.root
+	for n != nil {
+		// as in eth_getProof, embedded nodes are not part of the proof.
+		if enc := encodeNode(n); len(proof) == 0 || len(enc) >= 32 {
+			proof = append(proof, enc)
+		}
+		switch nn := n.(type) {
+		case *leafNode:
+			n = nil
+		case *extensionNode:
+			path = path[len(nn.path):]
+			n = nn.child
+		case *branchNode:
+			n = nn.children[path[0]]
+			path = path[1:]
+		}
+	}
+	return proof
+}
+
+type proofCircuit struct {
+	Root     [32]uints.U8
+	Path     [KeyLen]uints.U8
+	Proof    Proof
+	Value    [64]uints.U8
+	ValueLen frontend.Variable
+}
+
+func (c *proofCircuit) Define(api frontend.API) error {
+	v, err := New(api)
+	if err != nil {
+		return err
+	}
+	return v.VerifyProof(c.Root, c.Path, c.Proof, c.Value[:], c.ValueLen)
+}
+
+func proofAssignment(t *testing.T, tr *testTrie, key, value []byte, maxDepth int) *proofCircuit {
+	proof, err := ValueOfProof(key, tr.prove(key), maxDepth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignment := &proofCircuit{
+		Proof:    proof,
+		ValueLen: len(value),
+	}
+	copy(assignment.Root[:], uints.NewU8Array(tr.rootHash()))
+	copy(assignment.Path[:], uints.NewU8Array(key))
+	copy(assignment.Value[:], uints.NewU8Array(append(value, make([]byte, 64-len(value))...)))
+	return assignment
+}
+
+func TestVerifyProof(t *testing.T) {
+	assert := test.NewAssert(t)
+	const maxDepth = 4
+	// keys sharing a long prefix lead to an extension node at the root.
+	var tr testTrie
+	keys := make([][]byte, 4)
+	values := make([][]byte, 4)
+	for i := range keys {
+		keys[i] = bytes.Repeat([]byte{0xab}, KeyLen)
+		keys[i][KeyLen-1] = byte(i)
+		values[i] = rlpString(bytes.Repeat([]byte{byte(i + 1)}, 40+i))
+		tr.insert(keys[i], values[i])
+	}
+	if _, ok := tr.root.(*extensionNode); !ok {
+		t.Fatal("expected extension node at root")
+	}
+	for i := range keys {
+		assignment := proofAssignment(t, &tr, keys[i], values[i], maxDepth)
+		err := test.IsSolved(&proofCircuit{Proof: PlaceholderProof(maxDepth)}, assignment, ecc.BN254.ScalarField())
+		assert.NoError(err)
+	}
+
+	// wrong value
+	assignment := proofAssignment(t, &tr, keys[0], values[1], maxDepth)
+	err := test.IsSolved(&proofCircuit{Proof: PlaceholderProof(maxDepth)}, assignment, ecc.BN254.ScalarField())
+	assert.Error(err)
+	// wrong path
+	assignment = proofAssignment(t, &tr, keys[0], values[0], maxDepth)
+	assignment.Path[KeyLen-1] = uints.NewU8(1)
+	err = test.IsSolved(&proofCircuit{Proof: PlaceholderProof(maxDepth)}, assignment, ecc.BN254.ScalarField())
+	assert.Error(err)
+}
+
+func TestVerifyProofEmbedded(t *testing.T) {
+	assert := test.NewAssert(t)
+	const maxDepth = 3
+	// keys differing in the middle lead to leaves short enough to be embedded
+	// in the branch node when their values are small. The last key has a long
+	// value and is referenced by its hash.
+	var tr testTrie
+	keys := make([][]byte, 4)
+	values := make([][]byte, 4)
+	for i := range keys {
+		keys[i] = bytes.Repeat([]byte{0xab}, KeyLen)
+		keys[i][20] = byte(i)
+		values[i] = rlpString([]byte{byte(i + 1)})
+	}
+	values[3] = rlpString(bytes.Repeat([]byte{4}, 40))
+	for i := range keys {
+		tr.insert(keys[i], values[i])
+	}
+	for i := range keys {
+		// the extension and branch nodes, and the leaf if it is not embedded.
+		if nodes, expected := tr.prove(keys[i]), 2+i/3; len(nodes) != expected {
+			t.Fatalf("expected proof with %d nodes, got %d", expected, len(nodes))
+		}
+		assignment := proofAssignment(t, &tr, keys[i], values[i], maxDepth)
+		err := test.IsSolved(&proofCircuit{Proof: PlaceholderProof(maxDepth)}, assignment, ecc.BN254.ScalarField())
+		assert.NoError(err)
+	}
+
+	// wrong value
+	assignment := proofAssignment(t, &tr, keys[0], values[1], maxDepth)
+	err := test.IsSolved(&proofCircuit{Proof: PlaceholderProof(maxDepth)}, assignment, ecc.BN254.ScalarField())
+	assert.Error(err)
+	// embedded leaf which does not match its parent
+	assignment = proofAssignment(t, &tr, keys[0], values[0], maxDepth)
+	leaf := assignment.Proof.Nodes[2]
+	assignment.Proof.Nodes[2].Data[leaf.Length.(int)-1] = uints.NewU8(2)
+	assignment.Value[0] = uints.NewU8(2)
+	err = test.IsSolved(&proofCircuit{Proof: PlaceholderProof(maxDepth)}, assignment, ecc.BN254.ScalarField())
+	assert.Error(err)
+}
+
+type accountProofCircuit struct {
+	StateRoot  [32]uints.U8
+	Address    [20]uints.U8
+	Proof      Proof
+	Account    [110]uints.U8
+	AccountLen frontend.Variable
+}
+
+func (c *accountProofCircuit) Define(api frontend.API) error {
+	v, err := New(api)
+	if err != nil {
+		return err
+	}
+	return v.VerifyAccountProof(c.StateRoot, c.Address, c.Proof, c.Account[:], c.AccountLen)
+}
+
+func TestVerifyAccountProof(t *testing.T) {
+	assert := test.NewAssert(t)
+	const maxDepth = 4
+	var tr testTrie
+	addresses := make([][]byte, 8)
+	accounts := make([][]byte, len(addresses))
+	for i := range addresses {
+		addresses[i] = make([]byte, 20)
+		_, err := rand.Read(addresses[i])
+		assert.NoError(err)
+		storageRoot := make([]byte, 32)
+		codeHash := make([]byte, 32)
+		_, err = rand.Read(storageRoot)
+		assert.NoError(err)
+		_, err = rand.Read(codeHash)
+		assert.NoError(err)
+		balance := big.NewInt(int64(1000000007 * (i + 1))).Bytes()
+		accounts[i] = rlpList(rlpString([]byte{byte(i + 1)}), rlpString(balance), rlpString(storageRoot), rlpString(codeHash))
+		tr.insert(keccak(addresses[i]), accounts[i])
+	}
+	for _, i := range []int{0, 7} {
+		proof, err := ValueOfProof(keccak(addresses[i]), tr.prove(keccak(addresses[i])), maxDepth)
+		assert.NoError(err)
+		assignment := &accountProofCircuit{
+			Proof:      proof,
+			AccountLen: len(accounts[i]),
+		}
+		copy(assignment.StateRoot[:], uints.NewU8Array(tr.rootHash()))
+		copy(assignment.Address[:], uints.NewU8Array(addresses[i]))
+		copy(assignment.Account[:], uints.NewU8Array(append(accounts[i], make([]byte, len(assignment.Account)-len(accounts[i]))...)))
+		err = test.IsSolved(&accountProofCircuit{Proof: PlaceholderProof(maxDepth)}, assignment, ecc.BN254.ScalarField())
+		assert.NoError(err)
+	}
+}
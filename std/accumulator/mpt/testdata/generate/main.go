@@ -0,0 +1,249 @@
+// Command generate writes the eth_getProof fixtures of the mpt tests. The
+// tries are built and the proofs computed with the trie package of
+// go-ethereum, which is also used by the eth_getProof method of geth. Run it
+// from this directory:
+//
+//	go run .
+//
+// The storage trie of the contract contains two slots whose hashes share their
+// first eight nibbles, so that their leaves are embedded in a branch node below
+// an extension node. In the state trie, the contract and another account share
+// a prefix so that an extension node is on the path of the contract.
+//
+// It is a separate module so that go-ethereum stays out of the gnark module.
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/holiman/uint256"
+)
+
+var fDir = flag.String("dir", filepath.Join("..", "eth_getProof"), "output directory")
+
+// storageResult and accountResult follow the result of eth_getProof, with the
+// state root of the block added as stateRoot.
+type storageResult struct {
+	Key   string          `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+type accountResult struct {
+	StateRoot    common.Hash     `json:"stateRoot"`
+	Address      common.Address  `json:"address"`
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []storageResult `json:"storageProof"`
+}
+
+// proofList collects the nodes of a proof in the order they are written.
+type proofList []hexutil.Bytes
+
+func (l *proofList) Put(_, value []byte) error {
+	*l = append(*l, common.CopyBytes(value))
+	return nil
+}
+
+func (l *proofList) Delete([]byte) error {
+	panic("not supported")
+}
+
+func newTrie() *trie.Trie {
+	return trie.NewEmpty(triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil))
+}
+
+func prove(tr *trie.Trie, key []byte) []hexutil.Bytes {
+	var proof proofList
+	if err := tr.Prove(crypto.Keccak256(key), &proof); err != nil {
+		log.Fatal(err)
+	}
+	return proof
+}
+
+func slotKey(i uint64) common.Hash {
+	return common.BigToHash(new(big.Int).SetUint64(i))
+}
+
+func address(i uint64) common.Address {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], i)
+	return common.BytesToAddress(crypto.Keccak256([]byte("gnark mpt fixture"), b[:]))
+}
+
+// collidingSlots returns two slots whose hashes share their first n bytes.
+func collidingSlots(n int) (uint64, uint64) {
+	seen := make(map[string]uint64)
+	for i := uint64(0); ; i++ {
+		prefix := string(crypto.Keccak256(slotKey(i).Bytes())[:n])
+		if j, ok := seen[prefix]; ok {
+			return j, i
+		}
+		seen[prefix] = i
+	}
+}
+
+// firstNibble returns the first nibble of the hash of b.
+func firstNibble(b []byte) byte {
+	return crypto.Keccak256(b)[0] >> 4
+}
+
+func main() {
+	flag.Parse()
+
+	// storage trie. The leaves of the colliding slots store a single byte and
+	// are shorter than 32 bytes. The other slots start with other nibbles.
+	a, b := collidingSlots(4)
+	values := map[common.Hash]common.Hash{
+		slotKey(a): common.BigToHash(big.NewInt(0x2a)),
+		slotKey(b): common.BigToHash(big.NewInt(0x07)),
+	}
+	slots := []common.Hash{slotKey(a), slotKey(b)}
+	used := map[byte]bool{firstNibble(slotKey(a).Bytes()): true}
+	others := []common.Hash{crypto.Keccak256Hash([]byte("gnark")), common.BigToHash(big.NewInt(1000000))}
+	for i := uint64(0); len(others) > 0; i++ {
+		k := slotKey(i)
+		if nb := firstNibble(k.Bytes()); !used[nb] {
+			used[nb] = true
+			values[k] = others[0]
+			slots = append(slots, k)
+			others = others[1:]
+		}
+	}
+	storage := newTrie()
+	for _, k := range slots {
+		v := values[k]
+		enc, err := rlp.EncodeToBytes(common.TrimLeftZeroes(v[:]))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := storage.Update(crypto.Keccak256(k[:]), enc); err != nil {
+			log.Fatal(err)
+		}
+	}
+	storageRoot := storage.Hash()
+	// the proof of an absent slot is an exclusion proof.
+	for i := uint64(0); ; i++ {
+		if _, ok := values[slotKey(i)]; !ok {
+			slots = append(slots, slotKey(i))
+			break
+		}
+	}
+	var storageProof []storageResult
+	for _, k := range slots {
+		v := values[k]
+		storageProof = append(storageProof, storageResult{
+			Key:   hexutil.EncodeBig(k.Big()),
+			Value: (*hexutil.Big)(v.Big()),
+			Proof: prove(storage, k[:]),
+		})
+	}
+	for _, sp := range storageProof[:2] {
+		last := sp.Proof[len(sp.Proof)-1]
+		if n, err := rlp.CountValues(mustContent(last)); err != nil || n != 17 {
+			log.Fatalf("slot %s: leaf is not embedded in a branch node", sp.Key)
+		}
+	}
+
+	// state trie. The contract and the first other account share the first
+	// nibbles of their hashes.
+	type account struct {
+		address common.Address
+		state   types.StateAccount
+	}
+	code := []byte{0x60, 0x2a, 0x60, 0x00, 0x55, 0x00}
+	contract := account{address(0), types.StateAccount{
+		Nonce:    1,
+		Balance:  uint256.NewInt(1e18),
+		Root:     storageRoot,
+		CodeHash: crypto.Keccak256(code),
+	}}
+	accounts := []account{contract}
+	contractHash := crypto.Keccak256(contract.address[:])
+	for i := uint64(1); ; i++ {
+		if h := crypto.Keccak256(address(i).Bytes()); bytes.Equal(h[:1], contractHash[:1]) {
+			accounts = append(accounts, account{address(i), types.StateAccount{
+				Nonce:    7,
+				Balance:  uint256.NewInt(123456789),
+				Root:     types.EmptyRootHash,
+				CodeHash: types.EmptyCodeHash.Bytes(),
+			}})
+			break
+		}
+	}
+	used = map[byte]bool{contractHash[0] >> 4: true}
+	for i := uint64(1); len(accounts) < 5; i++ {
+		addr := address(i)
+		if nb := firstNibble(addr.Bytes()); !used[nb] {
+			used[nb] = true
+			accounts = append(accounts, account{addr, types.StateAccount{
+				Balance:  uint256.NewInt(i * 1000000007),
+				Root:     types.EmptyRootHash,
+				CodeHash: types.EmptyCodeHash.Bytes(),
+			}})
+		}
+	}
+	state := newTrie()
+	for _, acc := range accounts {
+		enc, err := rlp.EncodeToBytes(&acc.state)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := state.Update(crypto.Keccak256(acc.address[:]), enc); err != nil {
+			log.Fatal(err)
+		}
+	}
+	stateRoot := state.Hash()
+
+	write := func(name string, acc account, storageProof []storageResult) {
+		res := accountResult{
+			StateRoot:    stateRoot,
+			Address:      acc.address,
+			AccountProof: prove(state, acc.address[:]),
+			Balance:      (*hexutil.Big)(acc.state.Balance.ToBig()),
+			CodeHash:     common.BytesToHash(acc.state.CodeHash),
+			Nonce:        hexutil.Uint64(acc.state.Nonce),
+			StorageHash:  acc.state.Root,
+			StorageProof: storageProof,
+		}
+		data, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		path := filepath.Join(*fDir, name)
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("wrote", path)
+	}
+	write("contract.json", contract, storageProof)
+	write("eoa.json", accounts[len(accounts)-1], []storageResult{})
+}
+
+// mustContent returns the payload of the RLP list enc.
+func mustContent(enc []byte) []byte {
+	content, _, err := rlp.SplitList(enc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return content
+}
@@ -0,0 +1,289 @@
+// Package smt provides ZKP-circuit functions for sparse Merkle trees.
+//
+// A sparse Merkle tree of depth d has 2^d leaves and the position of a leaf is
+// given by the d least significant bits of its key. An empty leaf is 0 and a
+// non-empty leaf is H(1, key, value). Internal nodes are H(left, right), so
+// that the leading 1 separates the leaves from the internal nodes. As every
+// key has a unique position in the tree, the tree allows to prove both
+// membership and non-membership of a key.
+//
+// Additionally to the membership checks, the package allows to prove insertion
+// of a new leaf and update of an existing leaf, where the circuit computes the
+// new root of the tree from the old root and the proof. [BatchUpdate] applies
+// a batch of operations with a single [MultiProof], which shares the siblings
+// and the nodes common to the paths of the batch. The native counterpart
+// [Tree] generates the proofs for the witness.
+package smt
+
+import (
+	"math/bits"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash"
+)
+
+// Proof is a sparse Merkle tree proof for a single leaf.
+type Proof struct {
+	// Siblings are the siblings of the nodes on the path from the leaf to the
+	// root. Siblings[0] is the sibling of the leaf.
+	Siblings []frontend.Variable
+}
+
+// PlaceholderProof returns a placeholder proof for circuit compilation for a
+// tree of given depth.
+func PlaceholderProof(depth int) Proof {
+	return Proof{Siblings: make([]frontend.Variable, depth)}
+}
+
+// Operation is a single insertion or update in a batch of operations.
+type Operation struct {
+	// Key is the key of the modified leaf.
+	Key frontend.Variable
+	// OldValue is the value of the leaf before the operation. It is ignored
+	// for insertions.
+	OldValue frontend.Variable
+	// NewValue is the value of the leaf after the operation.
+	NewValue frontend.Variable
+	// IsInsert is 1 if the leaf is empty before the operation and 0
+	// otherwise.
+	IsInsert frontend.Variable
+}
+
+// MultiProof is a sparse Merkle tree proof for a batch of leaves with strictly
+// increasing keys. The siblings which are on the path of another leaf of the
+// batch are not part of the proof and a sibling shared by several leaves is
+// given only once.
+type MultiProof struct {
+	// Siblings[i][j] is the sibling at level i of the path of the j-th leaf.
+	// It is ignored when the sibling is on the path of another leaf or when
+	// the node of the j-th leaf at level i is also the node of the (j-1)-th
+	// leaf.
+	Siblings [][]frontend.Variable
+	// Nodes are the nodes of the tree at level len(Siblings). The nodes on
+	// the path of a leaf of the batch are ignored.
+	Nodes []frontend.Variable
+}
+
+// PlaceholderMultiProof returns a placeholder proof for circuit compilation
+// for a batch of nbLeaves leaves in a tree of given depth.
+func PlaceholderMultiProof(depth, nbLeaves int) MultiProof {
+	top := batchTopLevel(depth, nbLeaves)
+	ret := MultiProof{
+		Siblings: make([][]frontend.Variable, top),
+		Nodes:    make([]frontend.Variable, 1<<(depth-top)),
+	}
+	for i := range ret.Siblings {
+		ret.Siblings[i] = make([]frontend.Variable, nbLeaves)
+	}
+	return ret
+}
+
+// batchTopLevel returns the lowest level of a tree of given depth which has
+// at most nbLeaves nodes. From this level on, a batch of nbLeaves leaves
+// hashes the complete tree instead of the paths of the leaves.
+func batchTopLevel(depth, nbLeaves int) int {
+	if nbLeaves < 1 {
+		return depth
+	}
+	return max(0, depth-bits.Len(uint(nbLeaves))+1)
+}
+
+// leafTag is the first input of the hash of a non-empty leaf.
+const leafTag = 1
+
+// leafHash returns the node corresponding to a non-empty leaf.
+func leafHash(h hash.FieldHasher, key, value frontend.Variable) frontend.Variable {
+	h.Reset()
+	h.Write(leafTag, key, value)
+	return h.Sum()
+}
+
+// nodeHash returns the internal node with given children.
+func nodeHash(h hash.FieldHasher, left, right frontend.Variable) frontend.Variable {
+	h.Reset()
+	h.Write(left, right)
+	return h.Sum()
+}
+
+// computeRoot computes the root from the leaf node and the path given by the
+// binary decomposition of the key.
+func (p *Proof) computeRoot(api frontend.API, h hash.FieldHasher, path []frontend.Variable, leaf frontend.Variable) frontend.Variable {
+	sum := leaf
+	for i := range p.Siblings {
+		left := api.Select(path[i], p.Siblings[i], sum)
+		right := api.Select(path[i], sum, p.Siblings[i])
+		sum = nodeHash(h, left, right)
+	}
+	return sum
+}
+
+// path returns the binary decomposition of the key. It also asserts that the
+// key fits into the tree.
+func (p *Proof) path(api frontend.API, key frontend.Variable) []frontend.Variable {
+	return api.ToBinary(key, len(p.Siblings))
+}
+
+// VerifyMembership asserts that the leaf at key stores value in the tree with
+// the given root.
+func (p *Proof) VerifyMembership(api frontend.API, h hash.FieldHasher, root, key, value frontend.Variable) {
+	path := p.path(api, key)
+	leaf := leafHash(h, key, value)
+	api.AssertIsEqual(p.computeRoot(api, h, path, leaf), root)
+}
+
+// VerifyNonMembership asserts that the leaf at key is empty in the tree with
+// the given root.
+func (p *Proof) VerifyNonMembership(api frontend.API, h hash.FieldHasher, root, key frontend.Variable) {
+	path := p.path(api, key)
+	api.AssertIsEqual(p.computeRoot(api, h, path, 0), root)
+}
+
+// Insert asserts that the leaf at key is empty in the tree with the given root
+// and returns the root of the tree where the leaf stores value.
+func (p *Proof) Insert(api frontend.API, h hash.FieldHasher, root, key, value frontend.Variable) frontend.Variable {
+	path := p.path(api, key)
+	api.AssertIsEqual(p.computeRoot(api, h, path, 0), root)
+	return p.computeRoot(api, h, path, leafHash(h, key, value))
+}
+
+// Update asserts that the leaf at key stores oldValue in the tree with the
+// given root and returns the root of the tree where the leaf stores newValue.
+func (p *Proof) Update(api frontend.API, h hash.FieldHasher, root, key, oldValue, newValue frontend.Variable) frontend.Variable {
+	path := p.path(api, key)
+	api.AssertIsEqual(p.computeRoot(api, h, path, leafHash(h, key, oldValue)), root)
+	return p.computeRoot(api, h, path, leafHash(h, key, newValue))
+}
+
+// BatchUpdate applies the operations to the tree with the given root and
+// returns the root of the tree after all the operations. The keys of the
+// operations must be strictly increasing and the proof is given against the
+// tree before the operations, as returned by [Tree.SetBatch].
+//
+// The old and the new roots are computed once for the whole batch. Below the
+// level len(proof.Siblings), every leaf hashes the nodes on its own path,
+// where the siblings on the path of the other leaves are taken from these
+// leaves. As the shape of the circuit does not depend on the keys, leaves
+// sharing a node still hash it separately. From that level on, the tree has
+// at most len(ops) nodes per level and every node is hashed once.
+func BatchUpdate(api frontend.API, h hash.FieldHasher, root frontend.Variable, ops []Operation, proof MultiProof) frontend.Variable {
+	if len(ops) == 0 {
+		return root
+	}
+	top := len(proof.Siblings)
+	depth := top + bits.Len(uint(len(proof.Nodes))) - 1
+	paths := make([][]frontend.Variable, len(ops))
+	oldNodes := make([]frontend.Variable, len(ops))
+	newNodes := make([]frontend.Variable, len(ops))
+	for j := range ops {
+		op := &ops[j]
+		api.AssertIsBoolean(op.IsInsert)
+		paths[j] = api.ToBinary(op.Key, depth)
+		if j > 0 {
+			// the keys are strictly increasing, so that the leaves of the
+			// batch are distinct and the leaves in a subtree are consecutive.
+			api.ToBinary(api.Sub(op.Key, ops[j-1].Key, 1), depth)
+		}
+		oldNodes[j] = api.Select(op.IsInsert, 0, leafHash(h, op.Key, op.OldValue))
+		newNodes[j] = leafHash(h, op.Key, op.NewValue)
+	}
+
+	// same[i][j] is 1 if the j-th and the (j+1)-th leaves have the same
+	// node at level i, and 0 otherwise.
+	same := make([][]frontend.Variable, depth+1)
+	same[depth] = make([]frontend.Variable, len(ops)-1)
+	for j := range same[depth] {
+		same[depth][j] = 1
+	}
+	for i := depth - 1; i >= 0; i-- {
+		same[i] = make([]frontend.Variable, len(ops)-1)
+		for j := range same[i] {
+			same[i][j] = api.Sub(same[i+1][j], api.Mul(same[i+1][j], api.Xor(paths[j][i], paths[j+1][i])))
+		}
+	}
+
+	for i := 0; i < top; i++ {
+		oldSiblings := batchSiblings(api, same, i, oldNodes, proof.Siblings[i])
+		newSiblings := batchSiblings(api, same, i, newNodes, proof.Siblings[i])
+		for j := range ops {
+			oldNodes[j] = nodeHash(h, api.Select(paths[j][i], oldSiblings[j], oldNodes[j]), api.Select(paths[j][i], oldNodes[j], oldSiblings[j]))
+			newNodes[j] = nodeHash(h, api.Select(paths[j][i], newSiblings[j], newNodes[j]), api.Select(paths[j][i], newNodes[j], newSiblings[j]))
+		}
+	}
+
+	// place the nodes of the leaves into the nodes at the top level. Only the
+	// first leaf of every node is placed, the nodes of the next leaves are
+	// equal to it.
+	oldTop := make([]frontend.Variable, len(proof.Nodes))
+	newTop := make([]frontend.Variable, len(proof.Nodes))
+	covered := make([]frontend.Variable, len(proof.Nodes))
+	for s := range proof.Nodes {
+		oldTop[s], newTop[s], covered[s] = 0, 0, 0
+	}
+	for j := range ops {
+		var first frontend.Variable = 1
+		if j > 0 {
+			first = api.Sub(1, same[top][j-1])
+		}
+		ind := decode(api, first, paths[j][top:])
+		for s := range ind {
+			covered[s] = api.Add(covered[s], ind[s])
+			oldTop[s] = api.Add(oldTop[s], api.Mul(ind[s], oldNodes[j]))
+			newTop[s] = api.Add(newTop[s], api.Mul(ind[s], newNodes[j]))
+		}
+	}
+	for s := range proof.Nodes {
+		node := api.Sub(proof.Nodes[s], api.Mul(covered[s], proof.Nodes[s]))
+		oldTop[s] = api.Add(oldTop[s], node)
+		newTop[s] = api.Add(newTop[s], node)
+	}
+	for len(oldTop) > 1 {
+		for s := 0; s < len(oldTop)/2; s++ {
+			oldTop[s] = nodeHash(h, oldTop[2*s], oldTop[2*s+1])
+			newTop[s] = nodeHash(h, newTop[2*s], newTop[2*s+1])
+		}
+		oldTop, newTop = oldTop[:len(oldTop)/2], newTop[:len(newTop)/2]
+	}
+	api.AssertIsEqual(oldTop[0], root)
+	return newTop[0]
+}
+
+// batchSiblings returns the siblings at level i of the paths of the leaves of
+// a batch with the given nodes at level i. A sibling is the node of the
+// neighbouring leaf if the neighbour is in the sibling subtree, the sibling
+// of the previous or the next leaf if they have the same node and the sibling
+// in the proof otherwise.
+func batchSiblings(api frontend.API, same [][]frontend.Variable, i int, nodes, siblings []frontend.Variable) []frontend.Variable {
+	n := len(nodes)
+	// the sibling of the first leaf of a node, propagated forwards to the
+	// leaves with the same node. It is only wrong if the sibling subtree is
+	// on the right and contains the leaf after the last leaf of the node.
+	fwd := make([]frontend.Variable, n)
+	fwd[0] = siblings[0]
+	for j := 1; j < n; j++ {
+		isSibling := api.Sub(same[i+1][j-1], same[i][j-1])
+		fwd[j] = api.Select(same[i][j-1], fwd[j-1], api.Select(isSibling, nodes[j-1], siblings[j]))
+	}
+	// fix the sibling at the last leaf of a node and propagate it backwards.
+	ret := make([]frontend.Variable, n)
+	ret[n-1] = fwd[n-1]
+	for j := n - 2; j >= 0; j-- {
+		isSibling := api.Sub(same[i+1][j], same[i][j])
+		ret[j] = api.Select(same[i][j], ret[j+1], api.Select(isSibling, nodes[j+1], fwd[j]))
+	}
+	return ret
+}
+
+// decode returns the vector of length 2^len(path) which is x at the position
+// given by the little-endian bits of path and 0 elsewhere.
+func decode(api frontend.API, x frontend.Variable, path []frontend.Variable) []frontend.Variable {
+	ret := []frontend.Variable{x}
+	for k := len(path) - 1; k >= 0; k-- {
+		next := make([]frontend.Variable, 2*len(ret))
+		for t := range ret {
+			next[2*t+1] = api.Mul(ret[t], path[k])
+			next[2*t] = api.Sub(ret[t], next[2*t+1])
+		}
+		ret = next
+	}
+	return ret
+}
@@ -0,0 +1,250 @@
+package smt
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/hash"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/test"
+)
+
+const testDepth = 8
+
+type membershipCircuit struct {
+	Root  frontend.Variable
+	Key   frontend.Variable
+	Value frontend.Variable
+	Proof Proof
+
+	member bool
+}
+
+func (c *membershipCircuit) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	if c.member {
+		c.Proof.VerifyMembership(api, &h, c.Root, c.Key, c.Value)
+	} else {
+		c.Proof.VerifyNonMembership(api, &h, c.Root, c.Key)
+	}
+	return nil
+}
+
+func TestMembership(t *testing.T) {
+	assert := test.NewAssert(t)
+	tree, err := NewTree(hash.MIMC_BN254.New(), testDepth)
+	assert.NoError(err)
+	for i := int64(0); i < 10; i++ {
+		_, err := tree.Set(big.NewInt(i*7+3), big.NewInt(i*i+1))
+		assert.NoError(err)
+	}
+	// member
+	siblings, err := tree.Prove(big.NewInt(17))
+	assert.NoError(err)
+	circuit := &membershipCircuit{Proof: PlaceholderProof(testDepth), member: true}
+	assignment := &membershipCircuit{Root: tree.Root(), Key: 17, Value: 5, Proof: ValueOfProof(siblings)}
+	assert.CheckCircuit(circuit, test.WithValidAssignment(assignment), test.WithCurves(ecc.BN254))
+	assert.Error(test.IsSolved(circuit, &membershipCircuit{Root: tree.Root(), Key: 17, Value: 6, Proof: ValueOfProof(siblings)}, ecc.BN254.ScalarField()))
+
+	// non-member
+	siblings, err = tree.Prove(big.NewInt(18))
+	assert.NoError(err)
+	circuit = &membershipCircuit{Proof: PlaceholderProof(testDepth), member: false}
+	assignment = &membershipCircuit{Root: tree.Root(), Key: 18, Value: 0, Proof: ValueOfProof(siblings)}
+	assert.NoError(test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()))
+	siblings, err = tree.Prove(big.NewInt(17))
+	assert.NoError(err)
+	assert.Error(test.IsSolved(circuit, &membershipCircuit{Root: tree.Root(), Key: 17, Value: 0, Proof: ValueOfProof(siblings)}, ecc.BN254.ScalarField()))
+}
+
+type updateCircuit struct {
+	OldRoot  frontend.Variable
+	NewRoot  frontend.Variable
+	Key      frontend.Variable
+	OldValue frontend.Variable
+	NewValue frontend.Variable
+	Proof    Proof
+
+	insert bool
+}
+
+func (c *updateCircuit) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	var root frontend.Variable
+	if c.insert {
+		root = c.Proof.Insert(api, &h, c.OldRoot, c.Key, c.NewValue)
+	} else {
+		root = c.Proof.Update(api, &h, c.OldRoot, c.Key, c.OldValue, c.NewValue)
+	}
+	api.AssertIsEqual(root, c.NewRoot)
+	return nil
+}
+
+func TestInsertUpdate(t *testing.T) {
+	assert := test.NewAssert(t)
+	tree, err := NewTree(hash.MIMC_BN254.New(), testDepth)
+	assert.NoError(err)
+	for i := int64(0); i < 10; i++ {
+		_, err := tree.Set(big.NewInt(i*5+1), big.NewInt(i+100))
+		assert.NoError(err)
+	}
+	// insert
+	oldRoot := tree.Root()
+	siblings, err := tree.Set(big.NewInt(200), big.NewInt(42))
+	assert.NoError(err)
+	circuit := &updateCircuit{Proof: PlaceholderProof(testDepth), insert: true}
+	assignment := &updateCircuit{OldRoot: oldRoot, NewRoot: tree.Root(), Key: 200, OldValue: 0, NewValue: 42, Proof: ValueOfProof(siblings)}
+	assert.NoError(test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()))
+
+	// insert of existing key must fail
+	oldRoot = tree.Root()
+	siblings, err = tree.Set(big.NewInt(200), big.NewInt(43))
+	assert.NoError(err)
+	assignment = &updateCircuit{OldRoot: oldRoot, NewRoot: tree.Root(), Key: 200, OldValue: 0, NewValue: 43, Proof: ValueOfProof(siblings)}
+	assert.Error(test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()))
+
+	// update
+	circuit = &updateCircuit{Proof: PlaceholderProof(testDepth), insert: false}
+	assignment = &updateCircuit{OldRoot: oldRoot, NewRoot: tree.Root(), Key: 200, OldValue: 42, NewValue: 43, Proof: ValueOfProof(siblings)}
+	assert.CheckCircuit(circuit, test.WithValidAssignment(assignment), test.WithCurves(ecc.BN254))
+}
+
+type batchCircuit struct {
+	OldRoot frontend.Variable
+	NewRoot frontend.Variable
+	Ops     []Operation
+	Proof   MultiProof
+}
+
+func (c *batchCircuit) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	root := BatchUpdate(api, &h, c.OldRoot, c.Ops, c.Proof)
+	api.AssertIsEqual(root, c.NewRoot)
+	return nil
+}
+
+// sequentialCircuit applies the operations of batchCircuit one by one with
+// [Proof.Update].
+type sequentialCircuit struct {
+	OldRoot frontend.Variable
+	NewRoot frontend.Variable
+	Ops     []Operation
+	Proofs  []Proof
+}
+
+func (c *sequentialCircuit) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	root := c.OldRoot
+	for i := range c.Ops {
+		root = c.Proofs[i].Update(api, &h, root, c.Ops[i].Key, c.Ops[i].OldValue, c.Ops[i].NewValue)
+	}
+	api.AssertIsEqual(root, c.NewRoot)
+	return nil
+}
+
+func TestBatchUpdate(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, keys := range [][]int64{
+		{1, 2, 3, 100},
+		{0, 1, 2, 3, 4},
+		{7, 200, 201, 202, 255},
+		{42},
+	} {
+		tree, err := NewTree(hash.MIMC_BN254.New(), testDepth)
+		assert.NoError(err)
+		for i := int64(0); i < 4; i++ {
+			_, err := tree.Set(big.NewInt(i), big.NewInt(i+1))
+			assert.NoError(err)
+		}
+		oldRoot := tree.Root()
+		bKeys := make([]*big.Int, len(keys))
+		bValues := make([]*big.Int, len(keys))
+		circuit := &batchCircuit{Ops: make([]Operation, len(keys)), Proof: PlaceholderMultiProof(testDepth, len(keys))}
+		assignment := &batchCircuit{OldRoot: oldRoot, Ops: make([]Operation, len(keys))}
+		for i := range keys {
+			bKeys[i], bValues[i] = big.NewInt(keys[i]), big.NewInt(keys[i]+10)
+			oldValue, ok := tree.Get(bKeys[i])
+			isInsert := 0
+			if !ok {
+				oldValue, isInsert = new(big.Int), 1
+			}
+			assignment.Ops[i] = Operation{Key: keys[i], OldValue: oldValue, NewValue: bValues[i], IsInsert: isInsert}
+		}
+		proof, err := tree.SetBatch(bKeys, bValues)
+		assert.NoError(err)
+		assignment.Proof = ValueOfMultiProof(proof)
+		assignment.NewRoot = tree.Root()
+		assert.CheckCircuit(circuit, test.WithValidAssignment(assignment), test.WithCurves(ecc.BN254))
+
+		if len(keys) > 1 {
+			// the keys must be strictly increasing
+			assignment.Ops[0], assignment.Ops[1] = assignment.Ops[1], assignment.Ops[0]
+			assert.Error(test.IsSolved(circuit, assignment, ecc.BN254.ScalarField()))
+		}
+	}
+	tree, err := NewTree(hash.MIMC_BN254.New(), testDepth)
+	assert.NoError(err)
+	_, err = tree.SetBatch([]*big.Int{big.NewInt(2), big.NewInt(1)}, []*big.Int{big.NewInt(1), big.NewInt(1)})
+	assert.Error(err)
+	_, err = tree.SetBatch([]*big.Int{big.NewInt(2), big.NewInt(2)}, []*big.Int{big.NewInt(1), big.NewInt(1)})
+	assert.Error(err)
+}
+
+func TestBatchUpdateCost(t *testing.T) {
+	assert := test.NewAssert(t)
+	for _, n := range []int{4, 8, 16} {
+		batch := &batchCircuit{Ops: make([]Operation, n), Proof: PlaceholderMultiProof(testDepth, n)}
+		sequential := &sequentialCircuit{Ops: make([]Operation, n), Proofs: make([]Proof, n)}
+		for i := range sequential.Proofs {
+			sequential.Proofs[i] = PlaceholderProof(testDepth)
+		}
+		batchCs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, batch)
+		assert.NoError(err)
+		sequentialCs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, sequential)
+		assert.NoError(err)
+		t.Logf("%d operations: %d constraints in batch, %d sequentially", n, batchCs.GetNbConstraints(), sequentialCs.GetNbConstraints())
+		assert.Less(batchCs.GetNbConstraints(), sequentialCs.GetNbConstraints())
+	}
+}
+
+func TestTreeDelete(t *testing.T) {
+	assert := test.NewAssert(t)
+	tree, err := NewTree(hash.MIMC_BN254.New(), testDepth)
+	assert.NoError(err)
+	emptyRoot := tree.Root()
+	_, err = tree.Set(big.NewInt(3), big.NewInt(4))
+	assert.NoError(err)
+	assert.NotEqual(emptyRoot, tree.Root())
+	_, err = tree.Delete(big.NewInt(3))
+	assert.NoError(err)
+	assert.Equal(emptyRoot, tree.Root())
+	_, err = tree.Prove(big.NewInt(1 << testDepth))
+	assert.Error(err)
+}
+
+func TestTreeLargeValue(t *testing.T) {
+	assert := test.NewAssert(t)
+	tree, err := NewTree(hash.MIMC_BN254.New(), testDepth)
+	assert.NoError(err)
+	root := tree.Root()
+	_, err = tree.Set(big.NewInt(3), new(big.Int).Lsh(big.NewInt(1), 256))
+	assert.Error(err)
+	_, ok := tree.Get(big.NewInt(3))
+	assert.False(ok)
+	assert.Equal(root, tree.Root())
+}
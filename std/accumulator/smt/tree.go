@@ -0,0 +1,296 @@
+package smt
+
+import (
+	"fmt"
+	"hash"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// Tree is a native sparse Merkle tree for generating the witnesses for the
+// circuit functions in this package. Keys, values and nodes are written to
+// the hash function as big-endian integers of [hash.Hash.BlockSize] bytes, so
+// that for example MiMC from gnark-crypto computes the same nodes as its
+// in-circuit counterpart. Values must fit into a block.
+type Tree struct {
+	h     hash.Hash
+	depth int
+	// nodes[i] stores the non-default nodes at level i, indexed by the key
+	// prefix. Level 0 are the leaves.
+	nodes []map[string]*big.Int
+	// values stores the values of non-empty leaves.
+	values map[string]*big.Int
+	// defaults[i] is the root of an empty subtree of depth i.
+	defaults []*big.Int
+}
+
+// NewTree returns a new empty sparse Merkle tree of given depth using the hash
+// function h.
+func NewTree(h hash.Hash, depth int) (*Tree, error) {
+	t := &Tree{
+		h:        h,
+		depth:    depth,
+		nodes:    make([]map[string]*big.Int, depth+1),
+		values:   make(map[string]*big.Int),
+		defaults: make([]*big.Int, depth+1),
+	}
+	for i := range t.nodes {
+		t.nodes[i] = make(map[string]*big.Int)
+	}
+	t.defaults[0] = new(big.Int)
+	for i := 1; i <= depth; i++ {
+		d, err := t.hash(t.defaults[i-1], t.defaults[i-1])
+		if err != nil {
+			return nil, err
+		}
+		t.defaults[i] = d
+	}
+	return t, nil
+}
+
+// Depth returns the depth of the tree.
+func (t *Tree) Depth() int {
+	return t.depth
+}
+
+// Root returns the current root of the tree.
+func (t *Tree) Root() *big.Int {
+	return new(big.Int).Set(t.node(t.depth, new(big.Int)))
+}
+
+// Get returns the value stored at key and whether the leaf is non-empty.
+func (t *Tree) Get(key *big.Int) (*big.Int, bool) {
+	v, ok := t.values[key.String()]
+	if !ok {
+		return nil, false
+	}
+	return new(big.Int).Set(v), true
+}
+
+// Prove returns the siblings of the path from the leaf at key to the root.
+func (t *Tree) Prove(key *big.Int) ([]*big.Int, error) {
+	if err := t.checkKey(key); err != nil {
+		return nil, err
+	}
+	siblings := make([]*big.Int, t.depth)
+	idx := new(big.Int).Set(key)
+	for i := 0; i < t.depth; i++ {
+		sibling := new(big.Int).Xor(idx, big.NewInt(1))
+		siblings[i] = new(big.Int).Set(t.node(i, sibling))
+		idx.Rsh(idx, 1)
+	}
+	return siblings, nil
+}
+
+// Set sets the value of the leaf at key and returns the siblings of the path
+// from the leaf to the root before the modification. The siblings are the
+// proof for [Proof.Insert] or [Proof.Update].
+func (t *Tree) Set(key, value *big.Int) ([]*big.Int, error) {
+	siblings, err := t.Prove(key)
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := t.hash(big.NewInt(leafTag), key, value)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.setLeaf(key, leaf); err != nil {
+		return nil, err
+	}
+	t.values[key.String()] = new(big.Int).Set(value)
+	return siblings, nil
+}
+
+// Delete empties the leaf at key and returns the siblings of the path from the
+// leaf to the root before the modification.
+func (t *Tree) Delete(key *big.Int) ([]*big.Int, error) {
+	siblings, err := t.Prove(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.setLeaf(key, new(big.Int)); err != nil {
+		return nil, err
+	}
+	delete(t.values, key.String())
+	return siblings, nil
+}
+
+// BatchProof is a native proof for a batch of leaves, returned by
+// [Tree.ProveBatch] and [Tree.SetBatch]. Its witness assignment is given by
+// [ValueOfMultiProof].
+type BatchProof struct {
+	// Siblings[i][j] is the sibling at level i of the path of the j-th leaf,
+	// or 0 if the sibling is on the path of another leaf or if the (j-1)-th
+	// leaf has the same node at level i.
+	Siblings [][]*big.Int
+	// Nodes are the nodes of the tree at level len(Siblings), or 0 for the
+	// nodes on the path of a leaf.
+	Nodes []*big.Int
+}
+
+// ProveBatch returns the proof of the leaves at the given keys, which must be
+// strictly increasing.
+func (t *Tree) ProveBatch(keys []*big.Int) (*BatchProof, error) {
+	for i := range keys {
+		if err := t.checkKey(keys[i]); err != nil {
+			return nil, err
+		}
+		if i > 0 && keys[i].Cmp(keys[i-1]) <= 0 {
+			return nil, fmt.Errorf("keys are not strictly increasing at index %d", i)
+		}
+	}
+	top := batchTopLevel(t.depth, len(keys))
+	p := &BatchProof{
+		Siblings: make([][]*big.Int, top),
+		Nodes:    make([]*big.Int, 1<<(t.depth-top)),
+	}
+	idx := make([]*big.Int, len(keys))
+	for j := range keys {
+		idx[j] = new(big.Int).Set(keys[j])
+	}
+	onPath := make(map[string]bool, len(keys))
+	for i := 0; i < top; i++ {
+		clear(onPath)
+		for j := range idx {
+			onPath[idx[j].String()] = true
+		}
+		p.Siblings[i] = make([]*big.Int, len(keys))
+		for j := range idx {
+			sibling := new(big.Int).Xor(idx[j], big.NewInt(1))
+			if onPath[sibling.String()] || (j > 0 && idx[j].Cmp(idx[j-1]) == 0) {
+				p.Siblings[i][j] = new(big.Int)
+			} else {
+				p.Siblings[i][j] = new(big.Int).Set(t.node(i, sibling))
+			}
+		}
+		for j := range idx {
+			idx[j].Rsh(idx[j], 1)
+		}
+	}
+	clear(onPath)
+	for j := range idx {
+		onPath[idx[j].String()] = true
+	}
+	for s := range p.Nodes {
+		n := big.NewInt(int64(s))
+		if onPath[n.String()] {
+			p.Nodes[s] = new(big.Int)
+		} else {
+			p.Nodes[s] = new(big.Int).Set(t.node(top, n))
+		}
+	}
+	return p, nil
+}
+
+// SetBatch sets the values of the leaves at the given keys, which must be
+// strictly increasing, and returns the proof of the leaves before the
+// modification. The proof is the proof for [BatchUpdate].
+func (t *Tree) SetBatch(keys, values []*big.Int) (*BatchProof, error) {
+	if len(keys) != len(values) {
+		return nil, fmt.Errorf("got %d keys and %d values", len(keys), len(values))
+	}
+	p, err := t.ProveBatch(keys)
+	if err != nil {
+		return nil, err
+	}
+	leaves := make([]*big.Int, len(keys))
+	for i := range keys {
+		if leaves[i], err = t.hash(big.NewInt(leafTag), keys[i], values[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i := range keys {
+		if err := t.setLeaf(keys[i], leaves[i]); err != nil {
+			return nil, err
+		}
+		t.values[keys[i].String()] = new(big.Int).Set(values[i])
+	}
+	return p, nil
+}
+
+func (t *Tree) setLeaf(key, leaf *big.Int) error {
+	idx := new(big.Int).Set(key)
+	cur := leaf
+	for i := 0; i <= t.depth; i++ {
+		if cur.Cmp(t.defaults[i]) == 0 {
+			delete(t.nodes[i], idx.String())
+		} else {
+			t.nodes[i][idx.String()] = cur
+		}
+		if i == t.depth {
+			break
+		}
+		sibling := t.node(i, new(big.Int).Xor(idx, big.NewInt(1)))
+		var err error
+		if idx.Bit(0) == 0 {
+			cur, err = t.hash(cur, sibling)
+		} else {
+			cur, err = t.hash(sibling, cur)
+		}
+		if err != nil {
+			return err
+		}
+		idx.Rsh(idx, 1)
+	}
+	return nil
+}
+
+func (t *Tree) node(level int, idx *big.Int) *big.Int {
+	if n, ok := t.nodes[level][idx.String()]; ok {
+		return n
+	}
+	return t.defaults[level]
+}
+
+func (t *Tree) checkKey(key *big.Int) error {
+	if key.Sign() < 0 || key.BitLen() > t.depth {
+		return fmt.Errorf("key does not fit into tree of depth %d", t.depth)
+	}
+	return nil
+}
+
+func (t *Tree) hash(in ...*big.Int) (*big.Int, error) {
+	size := t.h.BlockSize()
+	buf := make([]byte, len(in)*size)
+	for i := range in {
+		if in[i].Sign() < 0 || in[i].BitLen() > 8*size {
+			return nil, fmt.Errorf("value %s does not fit into a hash block of %d bytes", in[i], size)
+		}
+		in[i].FillBytes(buf[i*size : (i+1)*size])
+	}
+	t.h.Reset()
+	if _, err := t.h.Write(buf); err != nil {
+		return nil, fmt.Errorf("hash write: %w", err)
+	}
+	return new(big.Int).SetBytes(t.h.Sum(nil)), nil
+}
+
+// ValueOfProof returns the witness assignment of the proof given by the
+// siblings returned by [Tree.Prove], [Tree.Set] or [Tree.Delete].
+func ValueOfProof(siblings []*big.Int) Proof {
+	ret := Proof{Siblings: make([]frontend.Variable, len(siblings))}
+	for i := range siblings {
+		ret.Siblings[i] = siblings[i]
+	}
+	return ret
+}
+
+// ValueOfMultiProof returns the witness assignment of the proof returned by
+// [Tree.ProveBatch] or [Tree.SetBatch].
+func ValueOfMultiProof(p *BatchProof) MultiProof {
+	ret := MultiProof{
+		Siblings: make([][]frontend.Variable, len(p.Siblings)),
+		Nodes:    make([]frontend.Variable, len(p.Nodes)),
+	}
+	for i := range p.Siblings {
+		ret.Siblings[i] = make([]frontend.Variable, len(p.Siblings[i]))
+		for j := range p.Siblings[i] {
+			ret.Siblings[i][j] = p.Siblings[i][j]
+		}
+	}
+	for i := range p.Nodes {
+		ret.Nodes[i] = p.Nodes[i]
+	}
+	return ret
+}
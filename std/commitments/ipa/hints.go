@@ -0,0 +1,41 @@
+package ipa
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/std/math/emulated"
+)
+
+func init() {
+	solver.RegisterHint(GetHints()...)
+}
+
+// GetHints returns all hint functions used in the package.
+func GetHints() []solver.Hint {
+	return []solver.Hint{sqrtHint, reduceHint}
+}
+
+// sqrtHint returns a square root of the input in the native field. It returns
+// an error if the input is not a square.
+func sqrtHint(mod *big.Int, inputs, outputs []*big.Int) error {
+	if len(inputs) != 1 || len(outputs) != 1 {
+		return errors.New("expecting single input and output")
+	}
+	if outputs[0].ModSqrt(inputs[0], mod) == nil {
+		return errors.New("input is not a square")
+	}
+	return nil
+}
+
+// reduceHint returns the canonical representative of the emulated input.
+func reduceHint(nativeMod *big.Int, nativeInputs, nativeOutputs []*big.Int) error {
+	return emulated.UnwrapHint(nativeInputs, nativeOutputs, func(mod *big.Int, inputs, outputs []*big.Int) error {
+		if len(inputs) != 1 || len(outputs) != 1 {
+			return errors.New("expecting single input and output")
+		}
+		outputs[0].Mod(inputs[0], mod)
+		return nil
+	})
+}
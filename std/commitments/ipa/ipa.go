@@ -0,0 +1,352 @@
+// Package ipa implements in-circuit verification of inner product argument
+// (IPA) openings of Pedersen vector commitments over the Bandersnatch curve and
+// their multiproof aggregation, as used in Ethereum verkle trees.
+//
+// The commitments are to polynomials in evaluation form over the domain
+// {0, 1, ..., n-1}, where n is the number of generators in the verifying key
+// and must be a power of two (256 for verkle trees). The points are elements of
+// the Banderwagon group, i.e. Bandersnatch points modulo the two-torsion point,
+// which are serialized by their x-coordinate. The proofs use a SHA256-based
+// Fiat-Shamir transcript and the scalars are elements of the Bandersnatch
+// scalar field, which is emulated.
+//
+// As the curve is defined over the scalar field of BLS12-381, the circuits
+// must be defined over the BLS12-381 scalar field. The helpers for building the
+// queries of verkle tree proofs are provided by [Verkle].
+package ipa
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/bandersnatch"
+	tedwards "github.com/consensys/gnark-crypto/ecc/twistededwards"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/twistededwards"
+	fbits "github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/math/emulated/emparams"
+	"github.com/consensys/gnark/std/math/uints"
+)
+
+// Fr is the scalar field of the Bandersnatch curve.
+type Fr = emparams.BandersnatchFr
+
+// VerifyingKey is the public parameters of the commitment scheme. It is
+// expected to be fixed in the circuit, see [ValueOfVerifyingKey].
+type VerifyingKey struct {
+	// G are the generators of the vector commitments.
+	G []twistededwards.Point
+	// Q is the generator used for binding the inner product.
+	Q twistededwards.Point
+}
+
+// Proof is an IPA opening proof.
+type Proof struct {
+	// L and R are the cross commitments of every folding round.
+	L, R []twistededwards.Point
+	// A is the folded vector.
+	A emulated.Element[Fr]
+}
+
+// MultiProof is a proof of several openings aggregated into a single IPA
+// opening.
+type MultiProof struct {
+	// D is the commitment to the quotient polynomial.
+	D   twistededwards.Point
+	IPA Proof
+}
+
+// Query is a claimed opening of a commitment to a polynomial in evaluation
+// form at the domain point Z with value Y.
+type Query struct {
+	Commitment twistededwards.Point
+	Z          emulated.Element[Fr]
+	Y          emulated.Element[Fr]
+}
+
+// PlaceholderProof returns a placeholder proof for circuit compilation for the
+// domain size n.
+func PlaceholderProof(n int) Proof {
+	k := bits.Len(uint(n)) - 1
+	return Proof{L: make([]twistededwards.Point, k), R: make([]twistededwards.Point, k)}
+}
+
+// PlaceholderMultiProof returns a placeholder multiproof for circuit
+// compilation for the domain size n.
+func PlaceholderMultiProof(n int) MultiProof {
+	return MultiProof{IPA: PlaceholderProof(n)}
+}
+
+// Verifier verifies IPA proofs in-circuit.
+type Verifier struct {
+	api   frontend.API
+	f     *emulated.Field[Fr]
+	curve twistededwards.Curve
+	vk    VerifyingKey
+	// halfP is (p-1)/2 where p is the native field modulus.
+	halfP *big.Int
+	// label is the initial state of the transcripts.
+	label string
+}
+
+// NewVerifier returns a new [Verifier] using the verifying key vk. The
+// generators of vk are not checked to be in the Banderwagon group.
+func NewVerifier(api frontend.API, vk VerifyingKey, opts ...VerifierOption) (*Verifier, error) {
+	cfg, err := newCfg(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("apply options: %w", err)
+	}
+	n := len(vk.G)
+	if n < 2 || n&(n-1) != 0 {
+		return nil, fmt.Errorf("number of generators %d is not a power of two", n)
+	}
+	curve, err := twistededwards.NewEdCurve(api, tedwards.BLS12_381_BANDERSNATCH)
+	if err != nil {
+		return nil, fmt.Errorf("new curve: %w", err)
+	}
+	f, err := emulated.NewField[Fr](api)
+	if err != nil {
+		return nil, fmt.Errorf("new field: %w", err)
+	}
+	halfP := new(big.Int).Sub(api.Compiler().Field(), big.NewInt(1))
+	halfP.Rsh(halfP, 1)
+	return &Verifier{api: api, f: f, curve: curve, vk: vk, halfP: halfP, label: cfg.transcriptLabel}, nil
+}
+
+// AssertIsOnGroup asserts that p is on the curve and is a representative of
+// a Banderwagon element. It should be called on all points given as witness.
+func (v *Verifier) AssertIsOnGroup(p twistededwards.Point) {
+	v.curve.AssertIsOnCurve(p)
+	// p is in the Banderwagon group iff 1-a*x^2 is a square.
+	a := v.curve.Params().A
+	u := v.api.Sub(1, v.api.Mul(a, p.X, p.X))
+	s, err := v.api.Compiler().NewHint(sqrtHint, 1, u)
+	if err != nil {
+		panic(fmt.Sprintf("sqrt hint: %v", err))
+	}
+	v.api.AssertIsEqual(v.api.Mul(s[0], s[0]), u)
+}
+
+// AssertIsEqual asserts that p and q represent the same Banderwagon element.
+func (v *Verifier) AssertIsEqual(p, q twistededwards.Point) {
+	v.api.AssertIsEqual(v.api.Mul(p.X, q.Y), v.api.Mul(q.X, p.Y))
+}
+
+// CheckIPAProof asserts that proof is a valid opening of the commitment at
+// point z to the value y. The checks of the points in proof are included.
+func (v *Verifier) CheckIPAProof(commitment twistededwards.Point, z, y *emulated.Element[Fr], proof Proof) {
+	for i := range proof.L {
+		v.AssertIsOnGroup(proof.L[i])
+		v.AssertIsOnGroup(proof.R[i])
+	}
+	v.checkIPAProof(newTranscript(v), commitment, z, y, proof)
+}
+
+func (v *Verifier) checkIPAProof(t *transcript, commitment twistededwards.Point, z, y *emulated.Element[Fr], proof Proof) {
+	f := v.f
+	n := len(v.vk.G)
+	k := bits.Len(uint(n)) - 1
+	if len(proof.L) != k || len(proof.R) != k {
+		panic(fmt.Sprintf("expected %d rounds, got %d", k, len(proof.L)))
+	}
+	t.domainSep("ipa")
+	b := v.barycentricCoefficients(z)
+	t.appendPoint(commitment, "C")
+	t.appendScalar(z, "input point")
+	t.appendScalar(y, "output point")
+	w := t.challengeScalar("w")
+	q := v.scalarMul(v.vk.Q, w)
+	commitment = v.curve.Add(commitment, v.scalarMul(q, y))
+
+	xs := make([]*emulated.Element[Fr], k)
+	for i := range xs {
+		t.appendPoint(proof.L[i], "L")
+		t.appendPoint(proof.R[i], "R")
+		xs[i] = t.challengeScalar("x")
+	}
+	// folding G and b with x_i^-1 in round i gives the sum of s_j*G_j and
+	// s_j*b_j, where s_j is the product of x_i^-1 for all rounds i where j is
+	// in the right half. The first round corresponds to the most significant
+	// bit of j.
+	s := []*emulated.Element[Fr]{f.One()}
+	for i := range xs {
+		commitment = v.curve.Add(commitment, v.scalarMul(proof.L[i], xs[i]))
+		xInv := f.Inverse(xs[i])
+		commitment = v.curve.Add(commitment, v.scalarMul(proof.R[i], xInv))
+		next := make([]*emulated.Element[Fr], 2*len(s))
+		for j := range s {
+			next[2*j] = s[j]
+			next[2*j+1] = f.Mul(s[j], xInv)
+		}
+		s = next
+	}
+	g0 := v.vk.G[0]
+	b0 := b[0]
+	for j := 1; j < n; j++ {
+		g0 = v.curve.Add(g0, v.scalarMul(v.vk.G[j], s[j]))
+		b0 = f.Add(b0, f.Mul(s[j], b[j]))
+	}
+	got := v.curve.Add(v.scalarMul(g0, &proof.A), v.scalarMul(q, f.Mul(&proof.A, b0)))
+	v.AssertIsEqual(got, commitment)
+}
+
+// CheckMultiProof asserts that proof is a valid proof of all the queries. The
+// queries must be given in the order used by the prover. The checks of the
+// points in the queries and in proof are included.
+func (v *Verifier) CheckMultiProof(queries []Query, proof MultiProof) {
+	f := v.f
+	if len(queries) == 0 {
+		panic("no queries")
+	}
+	for i := range queries {
+		v.AssertIsOnGroup(queries[i].Commitment)
+	}
+	v.AssertIsOnGroup(proof.D)
+	for i := range proof.IPA.L {
+		v.AssertIsOnGroup(proof.IPA.L[i])
+		v.AssertIsOnGroup(proof.IPA.R[i])
+	}
+
+	t := newTranscript(v)
+	t.domainSep("multiproof")
+	for i := range queries {
+		t.appendPoint(queries[i].Commitment, "C")
+		t.appendScalar(&queries[i].Z, "z")
+		t.appendScalar(&queries[i].Y, "y")
+	}
+	r := t.challengeScalar("r")
+	t.appendPoint(proof.D, "D")
+	tt := t.challengeScalar("t")
+
+	// E = sum r^i/(t-z_i) C_i and g2(t) = sum r^i/(t-z_i) y_i.
+	var e twistededwards.Point
+	var g2t *emulated.Element[Fr]
+	ri := f.One()
+	for i := range queries {
+		helper := f.Div(ri, f.Sub(tt, &queries[i].Z))
+		ce := v.scalarMul(queries[i].Commitment, helper)
+		if i == 0 {
+			e, g2t = ce, f.Mul(helper, &queries[i].Y)
+		} else {
+			e = v.curve.Add(e, ce)
+			g2t = f.Add(g2t, f.Mul(helper, &queries[i].Y))
+		}
+		ri = f.Mul(ri, r)
+	}
+	t.appendPoint(e, "E")
+	v.checkIPAProof(t, v.curve.Add(e, v.curve.Neg(proof.D)), tt, g2t, proof.IPA)
+}
+
+// barycentricCoefficients returns the values of the Lagrange basis polynomials
+// over the domain at z, i.e. b_i = prod_{j!=i} (z-j)/(i-j).
+func (v *Verifier) barycentricCoefficients(z *emulated.Element[Fr]) []*emulated.Element[Fr] {
+	f := v.f
+	n := len(v.vk.G)
+	modulus := Fr{}.Modulus()
+	diffs := make([]*emulated.Element[Fr], n)
+	for j := range diffs {
+		diffs[j] = f.Sub(z, f.NewElement(j))
+	}
+	prefix := make([]*emulated.Element[Fr], n)
+	suffix := make([]*emulated.Element[Fr], n)
+	prefix[0], suffix[n-1] = f.One(), f.One()
+	for j := 1; j < n; j++ {
+		prefix[j] = f.Mul(prefix[j-1], diffs[j-1])
+		suffix[n-1-j] = f.Mul(suffix[n-j], diffs[n-j])
+	}
+	ret := make([]*emulated.Element[Fr], n)
+	for i := range ret {
+		denom := big.NewInt(1)
+		for j := 0; j < n; j++ {
+			if j != i {
+				denom.Mul(denom, big.NewInt(int64(i-j)))
+				denom.Mod(denom, modulus)
+			}
+		}
+		denom.ModInverse(denom, modulus)
+		ret[i] = f.Mul(f.Mul(prefix[i], suffix[i]), f.NewElement(denom))
+	}
+	return ret
+}
+
+// canonicalBits returns the little-endian bits of the canonical representation
+// of s.
+func (v *Verifier) canonicalBits(s *emulated.Element[Fr]) []frontend.Variable {
+	// Reduce does not ensure that the result is less than the modulus, so we
+	// obtain the canonical representative from a hint.
+	r, err := v.f.NewHint(reduceHint, 1, s)
+	if err != nil {
+		panic(fmt.Sprintf("reduce hint: %v", err))
+	}
+	v.f.AssertIsEqual(r[0], s)
+	v.f.AssertIsInRange(r[0])
+	return v.f.ToBits(r[0])
+}
+
+// scalarMul returns [s]p.
+func (v *Verifier) scalarMul(p twistededwards.Point, s *emulated.Element[Fr]) twistededwards.Point {
+	sBits := v.canonicalBits(s)
+	scalar := fbits.FromBinary(v.api, sBits[:Fr{}.Modulus().BitLen()])
+	return v.curve.ScalarMul(p, scalar)
+}
+
+// pointBytes returns the Banderwagon serialization of p, which is the
+// big-endian encoding of x if y is lexicographically largest and of -x
+// otherwise.
+func (v *Verifier) pointBytes(p twistededwards.Point) []uints.U8 {
+	api := v.api
+	isLargest := api.IsZero(api.Sub(api.Cmp(p.Y, v.halfP), 1))
+	x := api.Select(isLargest, p.X, api.Neg(p.X))
+	return bitsToBytes(api, fbits.ToBinary(api, x), true)
+}
+
+// ValueOfVerifyingKey returns the verifying key for the generators g and q.
+// The returned points are constants when used in-circuit.
+func ValueOfVerifyingKey(g []bandersnatch.PointAffine, q bandersnatch.PointAffine) VerifyingKey {
+	ret := VerifyingKey{G: make([]twistededwards.Point, len(g)), Q: valueOfPoint(q)}
+	for i := range g {
+		ret.G[i] = valueOfPoint(g[i])
+	}
+	return ret
+}
+
+// ValueOfProof returns the witness assignment of the IPA proof given by the
+// cross commitments l, r and the folded scalar a.
+func ValueOfProof(l, r []bandersnatch.PointAffine, a *big.Int) Proof {
+	ret := Proof{
+		L: make([]twistededwards.Point, len(l)),
+		R: make([]twistededwards.Point, len(r)),
+		A: emulated.ValueOf[Fr](a),
+	}
+	for i := range l {
+		ret.L[i] = valueOfPoint(l[i])
+	}
+	for i := range r {
+		ret.R[i] = valueOfPoint(r[i])
+	}
+	return ret
+}
+
+// ValueOfMultiProof returns the witness assignment of the multiproof given by
+// the quotient commitment d and the IPA proof.
+func ValueOfMultiProof(d bandersnatch.PointAffine, l, r []bandersnatch.PointAffine, a *big.Int) MultiProof {
+	return MultiProof{D: valueOfPoint(d), IPA: ValueOfProof(l, r, a)}
+}
+
+// ValueOfQuery returns the witness assignment of the query.
+func ValueOfQuery(commitment bandersnatch.PointAffine, z, y *big.Int) Query {
+	return Query{
+		Commitment: valueOfPoint(commitment),
+		Z:          emulated.ValueOf[Fr](z),
+		Y:          emulated.ValueOf[Fr](y),
+	}
+}
+
+func valueOfPoint(p bandersnatch.PointAffine) twistededwards.Point {
+	var x, y big.Int
+	p.X.BigInt(&x)
+	p.Y.BigInt(&y)
+	return twistededwards.Point{X: &x, Y: &y}
+}
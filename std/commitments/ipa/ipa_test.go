@@ -0,0 +1,559 @@
+package ipa
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/bandersnatch"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/twistededwards"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/test"
+)
+
+const testDomainSize = 8
+
+var order = func() *big.Int {
+	o := bandersnatch.GetEdwardsCurve().Order
+	return &o
+}()
+
+// nativeTranscript mirrors the in-circuit transcript.
+type nativeTranscript struct {
+	h hash.Hash
+}
+
+func newNativeTranscript() *nativeTranscript {
+	return &nativeTranscript{h: sha256.New()}
+}
+
+func (t *nativeTranscript) domainSep(label string) {
+	t.h.Write([]byte(label))
+}
+
+func (t *nativeTranscript) appendScalar(s *big.Int, label string) {
+	var buf [32]byte
+	s.FillBytes(buf[:])
+	reverse(buf[:])
+	t.domainSep(label)
+	t.h.Write(buf[:])
+}
+
+func (t *nativeTranscript) appendPoint(p *bandersnatch.PointAffine, label string) {
+	buf := pointBytes(p)
+	t.domainSep(label)
+	t.h.Write(buf[:])
+}
+
+func (t *nativeTranscript) challengeScalar(label string) *big.Int {
+	t.domainSep(label)
+	digest := t.h.Sum(nil)
+	reverse(digest)
+	ret := new(big.Int).SetBytes(digest)
+	ret.Mod(ret, order)
+	t.h.Reset()
+	t.appendScalar(ret, label)
+	return ret
+}
+
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+// mul computes [k]p using the complete addition formulas, as the points may not
+// be in the prime order subgroup.
+func mul(p *bandersnatch.PointAffine, k *big.Int) bandersnatch.PointAffine {
+	var res bandersnatch.PointAffine
+	res.X.SetZero()
+	res.Y.SetOne()
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		res.Add(&res, &res)
+		if k.Bit(i) == 1 {
+			res.Add(&res, p)
+		}
+	}
+	return res
+}
+
+func commit(g []bandersnatch.PointAffine, a []*big.Int) bandersnatch.PointAffine {
+	var res bandersnatch.PointAffine
+	res.X.SetZero()
+	res.Y.SetOne()
+	for i := range a {
+		t := mul(&g[i], a[i])
+		res.Add(&res, &t)
+	}
+	return res
+}
+
+func inner(a, b []*big.Int) *big.Int {
+	ret := new(big.Int)
+	for i := range a {
+		ret.Add(ret, new(big.Int).Mul(a[i], b[i]))
+	}
+	return ret.Mod(ret, order)
+}
+
+func fold(l, r []*big.Int, x *big.Int) []*big.Int {
+	ret := make([]*big.Int, len(l))
+	for i := range l {
+		ret[i] = new(big.Int).Mul(r[i], x)
+		ret[i].Add(ret[i], l[i]).Mod(ret[i], order)
+	}
+	return ret
+}
+
+func barycentric(n int, z *big.Int) []*big.Int {
+	ret := make([]*big.Int, n)
+	for i := range ret {
+		num, den := big.NewInt(1), big.NewInt(1)
+		for j := 0; j < n; j++ {
+			if j == i {
+				continue
+			}
+			num.Mul(num, new(big.Int).Sub(z, big.NewInt(int64(j)))).Mod(num, order)
+			den.Mul(den, big.NewInt(int64(i-j))).Mod(den, order)
+		}
+		den.ModInverse(den, order)
+		ret[i] = num.Mul(num, den).Mod(num, order)
+	}
+	return ret
+}
+
+type nativeProof struct {
+	l, r []bandersnatch.PointAffine
+	a    *big.Int
+}
+
+// proveIPA returns the opening proof of the vector a at z.
+func proveIPA(t *nativeTranscript, g []bandersnatch.PointAffine, q bandersnatch.PointAffine, commitment bandersnatch.PointAffine, a []*big.Int, z *big.Int) nativeProof {
+	b := barycentric(len(a), z)
+	t.domainSep("ipa")
+	t.appendPoint(&commitment, "C")
+	t.appendScalar(z, "input point")
+	t.appendScalar(inner(a, b), "output point")
+	w := t.challengeScalar("w")
+	q = mul(&q, w)
+	var proof nativeProof
+	for len(a) > 1 {
+		m := len(a) / 2
+		cl := commit(g[:m], a[m:])
+		cl2 := mul(&q, inner(a[m:], b[:m]))
+		cl.Add(&cl, &cl2)
+		cr := commit(g[m:], a[:m])
+		cr2 := mul(&q, inner(a[:m], b[m:]))
+		cr.Add(&cr, &cr2)
+		t.appendPoint(&cl, "L")
+		t.appendPoint(&cr, "R")
+		proof.l = append(proof.l, cl)
+		proof.r = append(proof.r, cr)
+		x := t.challengeScalar("x")
+		xInv := new(big.Int).ModInverse(x, order)
+		a = fold(a[:m], a[m:], x)
+		b = fold(b[:m], b[m:], xInv)
+		gn := make([]bandersnatch.PointAffine, m)
+		for i := range gn {
+			gn[i] = mul(&g[m+i], xInv)
+			gn[i].Add(&gn[i], &g[i])
+		}
+		g = gn
+	}
+	proof.a = a[0]
+	return proof
+}
+
+type nativeQuery struct {
+	commitment bandersnatch.PointAffine
+	f          []*big.Int
+	z          int
+}
+
+// quotient returns the evaluations of (f(X)-f(z))/(X-z) over the domain.
+func quotient(f []*big.Int, z int) []*big.Int {
+	n := len(f)
+	// weight returns the barycentric weight 1/prod_{j!=i}(i-j).
+	weight := func(i int) *big.Int {
+		d := big.NewInt(1)
+		for j := 0; j < n; j++ {
+			if j != i {
+				d.Mul(d, big.NewInt(int64(i-j))).Mod(d, order)
+			}
+		}
+		return d.ModInverse(d, order)
+	}
+	ret := make([]*big.Int, n)
+	ret[z] = new(big.Int)
+	wzInv := new(big.Int).ModInverse(weight(z), order)
+	for i := range ret {
+		if i == z {
+			continue
+		}
+		diff := new(big.Int).Mod(big.NewInt(int64(i-z)), order)
+		ret[i] = new(big.Int).Sub(f[i], f[z])
+		ret[i].Mul(ret[i], diff.ModInverse(diff, order)).Mod(ret[i], order)
+		// the value at z is -sum_{i!=z} q_i w_i/w_z.
+		t := new(big.Int).Mul(ret[i], weight(i))
+		t.Mul(t, wzInv)
+		ret[z].Sub(ret[z], t)
+	}
+	ret[z].Mod(ret[z], order)
+	return ret
+}
+
+func proveMulti(g []bandersnatch.PointAffine, q bandersnatch.PointAffine, queries []nativeQuery) (bandersnatch.PointAffine, nativeProof) {
+	n := len(g)
+	t := newNativeTranscript()
+	t.domainSep("multiproof")
+	for i := range queries {
+		t.appendPoint(&queries[i].commitment, "C")
+		t.appendScalar(big.NewInt(int64(queries[i].z)), "z")
+		t.appendScalar(queries[i].f[queries[i].z], "y")
+	}
+	r := t.challengeScalar("r")
+	gx := make([]*big.Int, n)
+	for i := range gx {
+		gx[i] = new(big.Int)
+	}
+	ri := big.NewInt(1)
+	for i := range queries {
+		qt := quotient(queries[i].f, queries[i].z)
+		for j := range gx {
+			gx[j].Add(gx[j], new(big.Int).Mul(ri, qt[j])).Mod(gx[j], order)
+		}
+		ri = new(big.Int).Mul(ri, r)
+		ri.Mod(ri, order)
+	}
+	d := commit(g, gx)
+	t.appendPoint(&d, "D")
+	tt := t.challengeScalar("t")
+	hx := make([]*big.Int, n)
+	for i := range hx {
+		hx[i] = new(big.Int)
+	}
+	ri = big.NewInt(1)
+	for i := range queries {
+		helper := new(big.Int).Sub(tt, big.NewInt(int64(queries[i].z)))
+		helper.ModInverse(helper.Mod(helper, order), order)
+		helper.Mul(helper, ri)
+		for j := range hx {
+			hx[j].Add(hx[j], new(big.Int).Mul(helper, queries[i].f[j])).Mod(hx[j], order)
+		}
+		ri = new(big.Int).Mul(ri, r)
+		ri.Mod(ri, order)
+	}
+	e := commit(g, hx)
+	t.appendPoint(&e, "E")
+	var negD bandersnatch.PointAffine
+	negD.Neg(&d)
+	var ed bandersnatch.PointAffine
+	ed.Add(&e, &negD)
+	diff := make([]*big.Int, n)
+	for i := range diff {
+		diff[i] = new(big.Int).Sub(hx[i], gx[i])
+		diff[i].Mod(diff[i], order)
+	}
+	return d, proveIPA(t, g, q, ed, diff, tt)
+}
+
+func randomScalar(t *testing.T) *big.Int {
+	r, err := rand.Int(rand.Reader, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func randomVector(t *testing.T, n int) []*big.Int {
+	ret := make([]*big.Int, n)
+	for i := range ret {
+		ret[i] = randomScalar(t)
+	}
+	return ret
+}
+
+func testKey() ([]bandersnatch.PointAffine, bandersnatch.PointAffine) {
+	return GenerateRandomPoints(testDomainSize), bandersnatch.GetEdwardsCurve().Base
+}
+
+func TestGenerateRandomPoints(t *testing.T) {
+	assert := test.NewAssert(t)
+	g := GenerateRandomPoints(16)
+	assert.Len(g, 16)
+	for i := range g {
+		assert.True(g[i].IsOnCurve())
+		assert.True(g[i].Y.LexicographicallyLargest())
+		for j := 0; j < i; j++ {
+			assert.False(g[i].Equal(&g[j]))
+		}
+	}
+}
+
+// goIPAGenerators are the first generators of go-ipa, as serialized by
+// banderwagon.Element.Bytes.
+var goIPAGenerators = []string{
+	"01587ad1336675eb912550ec2a28eb8923b824b490dd2ba82e48f14590a298a0",
+	"6c6e607df0723edfff382fa914bfc38136f3300ab2e06fb97007b559fd323b82",
+	"326be3bebfd97ed9d0d4ca1b8bc47e036a24b129f1488110b71c2cae1463db8f",
+}
+
+func TestGenerateRandomPointsGoIPA(t *testing.T) {
+	assert := test.NewAssert(t)
+	g := GenerateRandomPoints(len(goIPAGenerators))
+	for i := range g {
+		b := pointBytes(&g[i])
+		assert.Equal(goIPAGenerators[i], hex.EncodeToString(b[:]))
+	}
+}
+
+// pointFromBytes returns a representative of the Banderwagon element with
+// serialization b.
+func pointFromBytes(b [32]byte) (bandersnatch.PointAffine, error) {
+	var x fr.Element
+	if err := x.SetBytesCanonical(b[:]); err != nil {
+		return bandersnatch.PointAffine{}, err
+	}
+	p, ok := pointFromX(x)
+	if !ok {
+		return bandersnatch.PointAffine{}, errors.New("not a Banderwagon element")
+	}
+	return p, nil
+}
+
+func decodePoint(t *testing.T, b []byte) bandersnatch.PointAffine {
+	p, err := pointFromBytes([32]byte(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+type ipaCircuit struct {
+	VK         VerifyingKey `gnark:"-"`
+	Commitment twistededwards.Point
+	Z, Y       emulated.Element[Fr]
+	Proof      Proof
+}
+
+func (c *ipaCircuit) Define(api frontend.API) error {
+	v, err := NewVerifier(api, c.VK)
+	if err != nil {
+		return err
+	}
+	v.CheckIPAProof(c.Commitment, &c.Z, &c.Y, c.Proof)
+	return nil
+}
+
+func TestIPA(t *testing.T) {
+	assert := test.NewAssert(t)
+	g, q := testKey()
+	vk := ValueOfVerifyingKey(g, q)
+	a := randomVector(t, testDomainSize)
+	z := randomScalar(t)
+	y := inner(a, barycentric(testDomainSize, z))
+	commitment := commit(g, a)
+	proof := proveIPA(newNativeTranscript(), g, q, commitment, a, z)
+
+	circuit := ipaCircuit{VK: vk, Proof: PlaceholderProof(testDomainSize)}
+	assignment := ipaCircuit{
+		Commitment: valueOfPoint(commitment),
+		Z:          emulated.ValueOf[Fr](z),
+		Y:          emulated.ValueOf[Fr](y),
+		Proof:      ValueOfProof(proof.l, proof.r, proof.a),
+	}
+	assert.NoError(test.IsSolved(&circuit, &assignment, ecc.BLS12_381.ScalarField()))
+
+	assignment.Y = emulated.ValueOf[Fr](new(big.Int).Add(y, big.NewInt(1)))
+	assert.Error(test.IsSolved(&circuit, &assignment, ecc.BLS12_381.ScalarField()))
+}
+
+type multiProofCircuit struct {
+	VK      VerifyingKey `gnark:"-"`
+	Queries []Query
+	Proof   MultiProof
+}
+
+func (c *multiProofCircuit) Define(api frontend.API) error {
+	v, err := NewVerifier(api, c.VK)
+	if err != nil {
+		return err
+	}
+	v.CheckMultiProof(c.Queries, c.Proof)
+	return nil
+}
+
+func TestMultiProof(t *testing.T) {
+	assert := test.NewAssert(t)
+	g, q := testKey()
+	vk := ValueOfVerifyingKey(g, q)
+	f0, f1 := randomVector(t, testDomainSize), randomVector(t, testDomainSize)
+	c0, c1 := commit(g, f0), commit(g, f1)
+	queries := []nativeQuery{
+		{commitment: c0, f: f0, z: 0},
+		{commitment: c1, f: f1, z: 5},
+		{commitment: c0, f: f0, z: 7},
+	}
+	d, proof := proveMulti(g, q, queries)
+
+	circuit := multiProofCircuit{VK: vk, Queries: make([]Query, len(queries)), Proof: PlaceholderMultiProof(testDomainSize)}
+	assignment := multiProofCircuit{Queries: make([]Query, len(queries)), Proof: ValueOfMultiProof(d, proof.l, proof.r, proof.a)}
+	for i := range queries {
+		assignment.Queries[i] = ValueOfQuery(queries[i].commitment, big.NewInt(int64(queries[i].z)), queries[i].f[queries[i].z])
+	}
+	assert.NoError(test.IsSolved(&circuit, &assignment, ecc.BLS12_381.ScalarField()))
+
+	assignment.Queries[1] = ValueOfQuery(c1, big.NewInt(5), f1[4])
+	assert.Error(test.IsSolved(&circuit, &assignment, ecc.BLS12_381.ScalarField()))
+}
+
+type leafQueriesCircuit struct {
+	Ext, C1, C2 twistededwards.Point
+	Stem        [StemLen]uints.U8
+	Suffix      uints.U8
+	Value       [ValueLen]uints.U8
+	Present     frontend.Variable
+
+	ExpectedZ, ExpectedY [5]emulated.Element[Fr]
+	ExpectedC            [5]twistededwards.Point
+}
+
+func (c *leafQueriesCircuit) Define(api frontend.API) error {
+	v, err := NewVerkle(api)
+	if err != nil {
+		return err
+	}
+	f, err := emulated.NewField[Fr](api)
+	if err != nil {
+		return err
+	}
+	queries := v.LeafQueries(c.Ext, c.C1, c.C2, c.Stem, c.Suffix, c.Value, c.Present)
+	for i := range queries {
+		f.AssertIsEqual(&queries[i].Z, &c.ExpectedZ[i])
+		f.AssertIsEqual(&queries[i].Y, &c.ExpectedY[i])
+		api.AssertIsEqual(queries[i].Commitment.X, c.ExpectedC[i].X)
+		api.AssertIsEqual(queries[i].Commitment.Y, c.ExpectedC[i].Y)
+	}
+	return nil
+}
+
+func TestLeafQueries(t *testing.T) {
+	assert := test.NewAssert(t)
+	g := GenerateRandomPoints(3)
+	ext, c1, c2 := g[0], g[1], g[2]
+	var stem [StemLen]byte
+	var value [ValueLen]byte
+	_, _ = rand.Read(stem[:])
+	_, _ = rand.Read(value[:])
+	leBytes := func(b []byte) *big.Int {
+		c := append([]byte{}, b...)
+		reverse(c)
+		return new(big.Int).SetBytes(c)
+	}
+	for _, suffix := range []byte{0, 77, 128, 255} {
+		for _, present := range []bool{false, true} {
+			v := value
+			if !present {
+				v = [ValueLen]byte{}
+			}
+			c := c1
+			if suffix >= 128 {
+				c = c2
+			}
+			lo := leBytes(v[:16])
+			if present {
+				lo.Add(lo, new(big.Int).Lsh(big.NewInt(1), 128))
+			}
+			z := 2 * int64(suffix%128)
+			expectedZ := []int64{0, 1, 2 + int64(suffix/128), z, z + 1}
+			expectedY := []*big.Int{big.NewInt(1), leBytes(stem[:]), MapToScalarField(c), lo, leBytes(v[16:])}
+			expectedC := []bandersnatch.PointAffine{ext, ext, ext, c, c}
+
+			assignment := leafQueriesCircuit{
+				Ext: valueOfPoint(ext), C1: valueOfPoint(c1), C2: valueOfPoint(c2),
+				Suffix: uints.NewU8(suffix),
+			}
+			copy(assignment.Stem[:], uints.NewU8Array(stem[:]))
+			copy(assignment.Value[:], uints.NewU8Array(v[:]))
+			assignment.Present = 0
+			if present {
+				assignment.Present = 1
+			}
+			for i := range expectedZ {
+				assignment.ExpectedZ[i] = emulated.ValueOf[Fr](expectedZ[i])
+				assignment.ExpectedY[i] = emulated.ValueOf[Fr](expectedY[i])
+				assignment.ExpectedC[i] = valueOfPoint(expectedC[i])
+			}
+			assert.NoError(test.IsSolved(&leafQueriesCircuit{}, &assignment, ecc.BLS12_381.ScalarField()))
+		}
+	}
+}
+
+// goIPAMultiProof is a multiproof created by go-ipa with the verkle transcript
+// label for the openings of C0 at 0 and 255 and of C1 at 5, where C0 and C1
+// are the commitments to the vectors (i+1) and (i*i+7) for i in 0..255, as
+// serialized by MultiProof.Write.
+var goIPAMultiProof = struct {
+	c0, c1, proof string
+}{
+	c0:    "294b47ca2d37d5ee18f0c8e2908b8912b18571ac01a7198880c058d4381a8cbd",
+	c1:    "0b5b6bcf06a753f350aa7c27abd03bfdc438af424d2cdc732ce224611c768064",
+	proof: "6c3fee4e34c53df0fa215fac3130e51abe260e268bae7d43be8bdf15aff483e15e036c15708644c50d093e059a13fe869674b04d82780c91e79f5f8fde60529973ace9e2810fc9d4988cb90635d43d57d86990c2274ca7c87004642d3b7c476c5dbe8f5b63daa32619b17266e08a8dc44c3e298d7abeb8a62c61258745498e5251a4d13b9bf39c26c7d8bde3c0a799227360941a50abf9d2b838e2c6ea6d87c84bfaa330224883173e6cb46c0d64acd5bf96e68a435ff29f67d320a67417a9e02a7140b37cefebef364b135bc287156ee3f53dcd0c5d5c80852fc426dd35a7d430591bac20c14bc83485bda86dff9254dc98bbc6e62ac8a969b64d2010d84d5e5651c1701f5ffe5076949b6a2e9c1b9a04700755d46b295d8b814474d567ef0415ecf715c888c8b092c8aa5f6bffc338e72ccb26555cad11e115b95d1ce8aebb70117d162f9c9a4062075b462dfb53d32e466d1001fac7d2b686cc0105ab6fbb5bb53bf5e689fe2837a8997855fec937ac84eae85eca23b7adb4f0919da9d37f1baa4cf5c2c35e886245521542d425cee92b0e75524a7f99319f33c932abf7d94d95e6d4ba3cac3b3b334557f381d7947f60daa3068c624c3545c1749e7bea9900ec4622f3493a2987048196c1b41d532a279b28332e2920fa19a612421e18cc03d5bad6d0c10521bf04f973a79f5579bde60778bc98fcd002cf5ccda141d9843040cc4248e40fc31eb5abb5226c822056b76f297d25ad8af23b6c81efd7377277db45e1a4f260934ce95d0d15c0089fb3313c81f662ed8150a66399aac63614",
+}
+
+type labelledMultiProofCircuit struct {
+	VK      VerifyingKey `gnark:"-"`
+	Queries []Query
+	Proof   MultiProof
+}
+
+func (c *labelledMultiProofCircuit) Define(api frontend.API) error {
+	v, err := NewVerifier(api, c.VK, WithTranscriptLabel(VerkleTranscriptLabel))
+	if err != nil {
+		return err
+	}
+	v.CheckMultiProof(c.Queries, c.Proof)
+	return nil
+}
+
+func TestMultiProofGoIPA(t *testing.T) {
+	assert := test.NewAssert(t)
+	const n = 256
+	proof, err := hex.DecodeString(goIPAMultiProof.proof)
+	assert.NoError(err)
+	assert.Len(proof, 32*(1+2*8+1))
+	points := make([]bandersnatch.PointAffine, 1+2*8)
+	for i := range points {
+		points[i] = decodePoint(t, proof[32*i:32*(i+1)])
+	}
+	a := proof[len(proof)-32:]
+	reverse(a)
+	c0, err := hex.DecodeString(goIPAMultiProof.c0)
+	assert.NoError(err)
+	c1, err := hex.DecodeString(goIPAMultiProof.c1)
+	assert.NoError(err)
+	pc0, pc1 := decodePoint(t, c0), decodePoint(t, c1)
+
+	vk := ValueOfVerifyingKey(GenerateRandomPoints(n), bandersnatch.GetEdwardsCurve().Base)
+	circuit := labelledMultiProofCircuit{VK: vk, Queries: make([]Query, 3), Proof: PlaceholderMultiProof(n)}
+	assignment := labelledMultiProofCircuit{
+		Queries: []Query{
+			ValueOfQuery(pc0, big.NewInt(0), big.NewInt(1)),
+			ValueOfQuery(pc1, big.NewInt(5), big.NewInt(5*5+7)),
+			ValueOfQuery(pc0, big.NewInt(255), big.NewInt(256)),
+		},
+		Proof: ValueOfMultiProof(points[0], points[1:9], points[9:17], new(big.Int).SetBytes(a)),
+	}
+	assert.NoError(test.IsSolved(&circuit, &assignment, ecc.BLS12_381.ScalarField()))
+
+	assignment.Queries[1] = ValueOfQuery(pc1, big.NewInt(5), big.NewInt(5*5+8))
+	assert.Error(test.IsSolved(&circuit, &assignment, ecc.BLS12_381.ScalarField()))
+}
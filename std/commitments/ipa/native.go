@@ -0,0 +1,80 @@
+package ipa
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/bandersnatch"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+// generatorSeed is the seed for deriving the generators of verkle trees.
+const generatorSeed = "eth_verkle_oct_2021"
+
+// GenerateRandomPoints returns n generators of the Banderwagon group derived
+// from the verkle tree seed. The generators are obtained by hashing the seed
+// with an incrementing counter and keeping the hashes which are valid
+// serializations of Banderwagon elements, as in go-ipa.
+func GenerateRandomPoints(n int) []bandersnatch.PointAffine {
+	ret := make([]bandersnatch.PointAffine, 0, n)
+	var counter [8]byte
+	for i := uint64(0); len(ret) < n; i++ {
+		h := sha256.New()
+		h.Write([]byte(generatorSeed))
+		binary.BigEndian.PutUint64(counter[:], i)
+		h.Write(counter[:])
+		var x fr.Element
+		x.SetBytes(h.Sum(nil))
+		if p, ok := pointFromX(x); ok {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
+// pointFromX returns the Bandersnatch point with x-coordinate x and
+// lexicographically largest y-coordinate, if it is a representative of a
+// Banderwagon element.
+func pointFromX(x fr.Element) (bandersnatch.PointAffine, bool) {
+	curve := bandersnatch.GetEdwardsCurve()
+	var one, x2, num, den, y fr.Element
+	one.SetOne()
+	x2.Square(&x)
+	// the point is in the Banderwagon group iff 1-a*x^2 is a square.
+	num.Mul(&x2, &curve.A)
+	num.Sub(&one, &num)
+	if num.Legendre() != 1 {
+		return bandersnatch.PointAffine{}, false
+	}
+	den.Mul(&x2, &curve.D)
+	den.Sub(&one, &den)
+	y.Div(&num, &den)
+	if y.Sqrt(&y) == nil {
+		return bandersnatch.PointAffine{}, false
+	}
+	if !y.LexicographicallyLargest() {
+		y.Neg(&y)
+	}
+	return bandersnatch.NewPointAffine(x, y), true
+}
+
+// pointBytes returns the Banderwagon serialization of p.
+func pointBytes(p *bandersnatch.PointAffine) [32]byte {
+	x := p.X
+	if !p.Y.LexicographicallyLargest() {
+		x.Neg(&x)
+	}
+	return x.Bytes()
+}
+
+// MapToScalarField returns the scalar x/y mod r, where r is the order of the
+// Bandersnatch curve. It is used for committing to commitments in verkle
+// trees.
+func MapToScalarField(p bandersnatch.PointAffine) *big.Int {
+	var q fr.Element
+	q.Div(&p.X, &p.Y)
+	ret := q.BigInt(new(big.Int))
+	order := bandersnatch.GetEdwardsCurve().Order
+	return ret.Mod(ret, &order)
+}
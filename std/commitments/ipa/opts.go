@@ -0,0 +1,30 @@
+package ipa
+
+import "fmt"
+
+type verifierCfg struct {
+	transcriptLabel string
+}
+
+func newCfg(opts ...VerifierOption) (*verifierCfg, error) {
+	cfg := new(verifierCfg)
+	for i := range opts {
+		if err := opts[i](cfg); err != nil {
+			return nil, fmt.Errorf("option %d: %w", i, err)
+		}
+	}
+	return cfg, nil
+}
+
+// VerifierOption allows to modify the behaviour of the IPA verifier.
+type VerifierOption func(cfg *verifierCfg) error
+
+// WithTranscriptLabel returns a VerifierOption that initialises the
+// Fiat-Shamir transcript with label, as the transcripts of go-ipa are. Proofs
+// of Ethereum verkle trees use [VerkleTranscriptLabel].
+func WithTranscriptLabel(label string) VerifierOption {
+	return func(cfg *verifierCfg) error {
+		cfg.transcriptLabel = label
+		return nil
+	}
+}
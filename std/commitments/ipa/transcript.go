@@ -0,0 +1,87 @@
+package ipa
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/twistededwards"
+	"github.com/consensys/gnark/std/hash/sha2"
+	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/math/uints"
+)
+
+// transcript is the in-circuit counterpart of the SHA256-based Fiat-Shamir
+// transcript used for verkle proofs. The state starts with the label of the
+// verifier, messages are appended as label||message and the challenges are
+// derived by hashing the whole state. After deriving a challenge the state is
+// reset and the challenge is appended to it.
+type transcript struct {
+	v     *Verifier
+	state []uints.U8
+}
+
+func newTranscript(v *Verifier) *transcript {
+	t := &transcript{v: v}
+	t.domainSep(v.label)
+	return t
+}
+
+func (t *transcript) domainSep(label string) {
+	for i := range label {
+		t.state = append(t.state, uints.NewU8(label[i]))
+	}
+}
+
+func (t *transcript) appendMessage(msg []uints.U8, label string) {
+	t.domainSep(label)
+	t.state = append(t.state, msg...)
+}
+
+// appendScalar appends the canonical little-endian encoding of s.
+func (t *transcript) appendScalar(s *emulated.Element[Fr], label string) {
+	t.appendMessage(bitsToBytes(t.v.api, t.v.canonicalBits(s), false), label)
+}
+
+// appendPoint appends the Banderwagon serialization of p.
+func (t *transcript) appendPoint(p twistededwards.Point, label string) {
+	t.appendMessage(t.v.pointBytes(p), label)
+}
+
+// challengeScalar derives a challenge by interpreting the SHA256 digest of the
+// state as a little-endian integer reduced modulo the scalar field order.
+func (t *transcript) challengeScalar(label string) *emulated.Element[Fr] {
+	api := t.v.api
+	t.domainSep(label)
+	h, err := sha2.New(api)
+	if err != nil {
+		panic(err)
+	}
+	h.Write(t.state)
+	digest := h.Sum()
+	dBits := make([]frontend.Variable, 0, 8*len(digest))
+	for i := range digest {
+		dBits = append(dBits, bits.ToBinary(api, digest[i].Val, bits.WithNbDigits(8))...)
+	}
+	challenge := t.v.f.Reduce(t.v.f.FromBits(dBits...))
+	t.state = nil
+	t.appendScalar(challenge, label)
+	return challenge
+}
+
+// bitsToBytes packs the little-endian bits into bytes. The bytes are
+// little-endian if bigEndian is false.
+func bitsToBytes(api frontend.API, bs []frontend.Variable, bigEndian bool) []uints.U8 {
+	ret := make([]uints.U8, (len(bs)+7)/8)
+	for i := range ret {
+		end := 8 * (i + 1)
+		if end > len(bs) {
+			end = len(bs)
+		}
+		b := uints.U8{Val: bits.FromBinary(api, bs[8*i:end])}
+		if bigEndian {
+			ret[len(ret)-1-i] = b
+		} else {
+			ret[i] = b
+		}
+	}
+	return ret
+}
@@ -0,0 +1,109 @@
+package ipa
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/twistededwards"
+	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/math/uints"
+)
+
+const (
+	// StemLen is the length of the stem of a verkle tree key.
+	StemLen = 31
+	// ValueLen is the length of a value stored in a verkle tree.
+	ValueLen = 32
+	// VerkleTranscriptLabel is the initial label of the transcripts of verkle
+	// tree proofs, see [WithTranscriptLabel].
+	VerkleTranscriptLabel = "vt"
+)
+
+// Verkle builds the queries of Ethereum verkle tree proofs, which are then
+// checked with [Verifier.CheckMultiProof] of a verifier created with the
+// [WithTranscriptLabel] option for [VerkleTranscriptLabel].
+//
+// An internal node commits to the children C_i at positions i as sum
+// map(C_i)*G_i, where map is [Verkle.MapToScalarField]. A leaf node with
+// extension commitment C commits to the marker 1, the stem and the suffix
+// commitments C1 and C2 at positions 0, 1, 2 and 3. The suffix commitment C1
+// (C2) commits to the values with suffixes 0..127 (128..255), where the value
+// with suffix s is split into its lower 16 bytes with the leaf marker 2^128 at
+// position 2*(s%128) and its upper 16 bytes at position 2*(s%128)+1, both
+// little-endian.
+type Verkle struct {
+	api frontend.API
+	f   *emulated.Field[Fr]
+}
+
+// NewVerkle returns a new [Verkle].
+func NewVerkle(api frontend.API) (*Verkle, error) {
+	f, err := emulated.NewField[Fr](api)
+	if err != nil {
+		return nil, fmt.Errorf("new field: %w", err)
+	}
+	return &Verkle{api: api, f: f}, nil
+}
+
+// MapToScalarField returns x/y reduced modulo the scalar field order, where
+// (x, y) are the coordinates of p.
+func (v *Verkle) MapToScalarField(p twistededwards.Point) *emulated.Element[Fr] {
+	q := v.api.Div(p.X, p.Y)
+	return v.f.Reduce(v.f.FromBits(bits.ToBinary(v.api, q)...))
+}
+
+// InternalQuery returns the query for the child commitment at position index
+// of the internal node with commitment parent.
+func (v *Verkle) InternalQuery(parent twistededwards.Point, index uints.U8, child twistededwards.Point) Query {
+	return Query{
+		Commitment: parent,
+		Z:          *v.fromBytes([]uints.U8{index}),
+		Y:          *v.MapToScalarField(child),
+	}
+}
+
+// LeafQueries returns the queries for the value with given suffix of the leaf
+// node with extension commitment ext, suffix commitments c1 and c2 and stem.
+// If present is 0, then the value must be zero and the queries prove that the
+// value is not set.
+//
+// The returned queries are the openings of ext at 0, 1 and 2 or 3 and the
+// openings of the suffix commitment at 2*(suffix%128) and 2*(suffix%128)+1.
+func (v *Verkle) LeafQueries(ext, c1, c2 twistededwards.Point, stem [StemLen]uints.U8, suffix uints.U8, value [ValueLen]uints.U8, present frontend.Variable) []Query {
+	api, f := v.api, v.f
+	api.AssertIsBoolean(present)
+	suffixBits := bits.ToBinary(api, suffix.Val, bits.WithNbDigits(8))
+	isHigh := suffixBits[7]
+	c := twistededwards.Point{
+		X: api.Select(isHigh, c2.X, c1.X),
+		Y: api.Select(isHigh, c2.Y, c1.Y),
+	}
+	zLo := append([]frontend.Variable{0}, suffixBits[:7]...)
+	zHi := append([]frontend.Variable{1}, suffixBits[:7]...)
+
+	marker := new(big.Int).Lsh(big.NewInt(1), 128)
+	lo := v.fromBytes(value[:ValueLen/2])
+	lo = f.Select(present, f.Add(lo, f.NewElement(marker)), lo)
+	hi := v.fromBytes(value[ValueLen/2:])
+	for i := range value {
+		api.AssertIsEqual(api.Mul(api.Sub(1, present), value[i].Val), 0)
+	}
+	return []Query{
+		{Commitment: ext, Z: *f.Zero(), Y: *f.One()},
+		{Commitment: ext, Z: *f.NewElement(1), Y: *v.fromBytes(stem[:])},
+		{Commitment: ext, Z: *f.FromBits(isHigh, 1), Y: *v.MapToScalarField(c)},
+		{Commitment: c, Z: *f.FromBits(zLo...), Y: *lo},
+		{Commitment: c, Z: *f.FromBits(zHi...), Y: *hi},
+	}
+}
+
+// fromBytes returns the scalar with little-endian encoding b.
+func (v *Verkle) fromBytes(b []uints.U8) *emulated.Element[Fr] {
+	bs := make([]frontend.Variable, 0, 8*len(b))
+	for i := range b {
+		bs = append(bs, bits.ToBinary(v.api, b[i].Val, bits.WithNbDigits(8))...)
+	}
+	return v.f.FromBits(bs...)
+}
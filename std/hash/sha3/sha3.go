@@ -1,11 +1,16 @@
 package sha3
 
 import (
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/cmp"
 	"github.com/consensys/gnark/std/math/uints"
 	"github.com/consensys/gnark/std/permutation/keccakf"
 )
 
 type digest struct {
+	api       frontend.API
 	uapi      *uints.BinaryField[uints.U64]
 	state     [25]uints.U64 // 1600 bits state: 25 x 64
 	in        []uints.U8    // input to be digested
@@ -32,6 +37,65 @@ func (d *digest) Sum() []uints.U8 {
 	return d.squeezeBlocks()
 }
 
+func (d *digest) FixedLengthSum(length frontend.Variable) []uints.U8 {
+	// The padding for the first length bytes always ends in the block which
+	// contains the byte at position length. We pad all the written data with
+	// one more block, place the domain separation byte at position length and
+	// the final bit at the end of that block. We permute over all the blocks
+	// but only keep the state after absorbing the block containing the
+	// padding.
+	nbBlocks := len(d.in)/d.rate + 1
+	data := make([]uints.U8, nbBlocks*d.rate)
+	copy(data, d.in)
+	for i := len(d.in); i < len(data); i++ {
+		data[i] = uints.NewU8(0)
+	}
+
+	comparator := cmp.NewBoundedComparator(d.api, big.NewInt(int64(len(data))), false)
+	comparator.AssertIsLessEq(length, len(d.in))
+
+	for i := range data {
+		isPaddingStartPos := d.api.IsZero(d.api.Sub(i, length))
+		data[i].Val = d.api.Select(isPaddingStartPos, d.dsbyte, data[i].Val)
+
+		isPaddingPos := comparator.IsLess(length, i)
+		data[i].Val = d.api.Select(isPaddingPos, 0, data[i].Val)
+	}
+
+	// isLastBlock[j] is 1 only for the block which contains position length.
+	isLastBlock := make([]frontend.Variable, nbBlocks)
+	var prevInRange frontend.Variable = 0
+	for j := range isLastBlock {
+		inRange := comparator.IsLess(length, (j+1)*d.rate)
+		isLastBlock[j] = d.api.Sub(inRange, prevInRange)
+		prevInRange = inRange
+		// dsbyte never has the highest bit set, so we can add the final bit
+		// instead of xoring it.
+		last := (j+1)*d.rate - 1
+		data[last].Val = d.api.Add(data[last].Val, d.api.Mul(isLastBlock[j], 0x80))
+	}
+
+	blocks := d.composeBlocks(data)
+	resultState := newState()
+	for j, block := range blocks {
+		for i := range block {
+			d.state[i] = d.uapi.Xor(d.state[i], block[i])
+		}
+		d.state = keccakf.Permute(d.uapi, d.state)
+		for i := 0; i < d.outputLen/8; i++ {
+			for k := range resultState[i] {
+				resultState[i][k].Val = d.api.Select(isLastBlock[j], d.state[i][k].Val, resultState[i][k].Val)
+			}
+		}
+	}
+
+	var result []uints.U8
+	for i := 0; i < d.outputLen/8; i++ {
+		result = append(result, d.uapi.UnpackLSB(resultState[i])...)
+	}
+	return result
+}
+
 func (d *digest) padding() []uints.U8 {
 	padded := make([]uints.U8, len(d.in))
 	copy(padded[:], d.in[:])
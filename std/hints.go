@@ -4,6 +4,7 @@ import (
 	"sync"
 
 	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/std/accumulator/mpt"
 	"github.com/consensys/gnark/std/algebra/emulated/fields_bls12381"
 	"github.com/consensys/gnark/std/algebra/emulated/fields_bn254"
 	"github.com/consensys/gnark/std/algebra/emulated/fields_bw6761"
@@ -12,6 +13,7 @@ import (
 	"github.com/consensys/gnark/std/algebra/native/fields_bls24315"
 	"github.com/consensys/gnark/std/algebra/native/sw_bls12377"
 	"github.com/consensys/gnark/std/algebra/native/sw_bls24315"
+	"github.com/consensys/gnark/std/commitments/ipa"
 	"github.com/consensys/gnark/std/evmprecompiles"
 	"github.com/consensys/gnark/std/internal/logderivarg"
 	"github.com/consensys/gnark/std/math/bits"
@@ -42,6 +44,8 @@ func registerHints() {
 	solver.RegisterHint(evmprecompiles.GetHints()...)
 	solver.RegisterHint(logderivarg.GetHints()...)
 	solver.RegisterHint(bitslice.GetHints()...)
+	solver.RegisterHint(mpt.GetHints()...)
+	solver.RegisterHint(ipa.GetHints()...)
 	// emulated fields
 	solver.RegisterHint(fields_bls12381.GetHints()...)
 	solver.RegisterHint(fields_bn254.GetHints()...)
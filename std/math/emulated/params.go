@@ -25,18 +25,19 @@ type FieldParams interface {
 }
 
 type (
-	Goldilocks  = emparams.Goldilocks
-	Secp256k1Fp = emparams.Secp256k1Fp
-	Secp256k1Fr = emparams.Secp256k1Fr
-	BN254Fp     = emparams.BN254Fp
-	BN254Fr     = emparams.BN254Fr
-	BLS12377Fp  = emparams.BLS12377Fp
-	BLS12381Fp  = emparams.BLS12381Fp
-	BLS12381Fr  = emparams.BLS12381Fr
-	P256Fp      = emparams.P256Fp
-	P256Fr      = emparams.P256Fr
-	P384Fp      = emparams.P384Fp
-	P384Fr      = emparams.P384Fr
-	BW6761Fp    = emparams.BW6761Fp
-	BW6761Fr    = emparams.BW6761Fr
+	Goldilocks     = emparams.Goldilocks
+	Secp256k1Fp    = emparams.Secp256k1Fp
+	Secp256k1Fr    = emparams.Secp256k1Fr
+	BN254Fp        = emparams.BN254Fp
+	BN254Fr        = emparams.BN254Fr
+	BLS12377Fp     = emparams.BLS12377Fp
+	BLS12381Fp     = emparams.BLS12381Fp
+	BLS12381Fr     = emparams.BLS12381Fr
+	BandersnatchFr = emparams.BandersnatchFr
+	P256Fp         = emparams.P256Fp
+	P256Fr         = emparams.P256Fr
+	P384Fp         = emparams.P384Fp
+	P384Fr         = emparams.P384Fr
+	BW6761Fp       = emparams.BW6761Fp
+	BW6761Fr       = emparams.BW6761Fr
 )
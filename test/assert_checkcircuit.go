@@ -121,7 +121,7 @@ func (assert *Assert) CheckCircuit(circuit frontend.Circuit, opts ...TestingOpti
 					for _, w := range validWitnesses {
 						w := w
 						assert.Run(func(assert *Assert) {
-							checkSolidity := opt.checkSolidity && curve == ecc.BN254
+							checkSolidity := opt.checkSolidity && (curve == ecc.BN254 || curve == ecc.BLS12_381)
 							proverOpts := opt.proverOpts
 							verifierOpts := opt.verifierOpts
 							if b == backend.GROTH16 {
@@ -141,7 +141,7 @@ func (assert *Assert) CheckCircuit(circuit frontend.Circuit, opts ...TestingOpti
 								// check that the proof can be verified by gnark-solidity-checker
 								if _vk, ok := vk.(verifyingKey); ok {
 									assert.Run(func(assert *Assert) {
-										assert.solidityVerification(b, _vk, proof, w.public)
+										assert.solidityVerification(b, curve, _vk, proof, w.public)
 									}, "solidity")
 								}
 							}
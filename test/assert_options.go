@@ -129,7 +129,10 @@ func NoTestEngine() TestingOption {
 // even when the build tags "solccheck" and "release_checks" are set.
 //
 // When the tags are set; this requires gnark-solidity-checker to be installed, which in turns
-// requires solc and abigen to be reachable in the PATH.
+// requires solc and abigen to be reachable in the PATH. The BLS12-381 verifiers
+// need the precompiles of EIP-2537 (Prague hard fork) and are instead run by
+// internal/evmcheck, a separate module embedding the EVM of go-ethereum, which
+// requires solc only.
 //
 // See https://github.com/ConsenSys/gnark-solidity-checker for more details.
 func NoSolidityChecks() TestingOption {
@@ -6,25 +6,32 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strconv"
 
+	"github.com/consensys/gnark-crypto/ecc"
+	fr_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
 	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/solidity"
 	"github.com/consensys/gnark/backend/witness"
 )
 
 type verifyingKey interface {
 	NbPublicWitness() int
-	ExportSolidity(io.Writer) error
+	ExportSolidity(io.Writer, ...solidity.ExportOption) error
 }
 
 // solidityVerification checks that the exported solidity contract can verify the proof
 // and that the proof is valid.
-// It uses gnark-solidity-checker see test.WithSolidity option.
-func (assert *Assert) solidityVerification(b backend.ID, vk verifyingKey,
+// It uses gnark-solidity-checker see test.WithSolidity option. BLS12-381
+// verifiers need the precompiles of EIP-2537 and are run with
+// internal/evmcheck instead.
+func (assert *Assert) solidityVerification(b backend.ID, curve ecc.ID, vk verifyingKey,
 	proof any,
 	validPublicWitness witness.Witness) {
-	if !SolcCheck || len(validPublicWitness.Vector().(fr_bn254.Vector)) == 0 {
+	nbPublic := nbPublicInputs(validPublicWitness)
+	if !SolcCheck || nbPublic == 0 {
 		return // nothing to check, will make solc fail.
 	}
 	assert.t.Helper()
@@ -38,12 +45,52 @@ func (assert *Assert) solidityVerification(b backend.ID, vk verifyingKey,
 	fSolidity, err := os.Create(filepath.Join(tmpDir, "gnark_verifier.sol"))
 	assert.NoError(err)
 
-	err = vk.ExportSolidity(fSolidity)
+	var exportOpts []solidity.ExportOption
+	if curve == ecc.BLS12_381 {
+		// internal/evmcheck calls Verify(bytes, uint256[])
+		exportOpts = append(exportOpts, solidity.WithBoolVerifier())
+	}
+	err = vk.ExportSolidity(fSolidity, exportOpts...)
 	assert.NoError(err)
 
 	err = fSolidity.Close()
 	assert.NoError(err)
 
+	// proof to hex
+	_proof, ok := proof.(interface{ MarshalSolidity() []byte })
+	if !ok {
+		panic("proof does not implement MarshalSolidity()")
+	}
+
+	proofStr := hex.EncodeToString(_proof.MarshalSolidity())
+
+	// public witness to hex
+	bPublicWitness, err := validPublicWitness.MarshalBinary()
+	assert.NoError(err)
+	// that's quite dirty...
+	// first 4 bytes -> nbPublic
+	// next 4 bytes -> nbSecret
+	// next 4 bytes -> nb elements in the vector (== nbPublic + nbSecret)
+	bPublicWitness = bPublicWitness[12:]
+	publicWitnessStr := hex.EncodeToString(bPublicWitness)
+
+	if curve == ecc.BLS12_381 {
+		assert.evmVerification(filepath.Join(tmpDir, "gnark_verifier.sol"), proofStr, publicWitnessStr)
+		return
+	}
+
+	// the compressed encoding is checked with VerifyCompressed, which is
+	// exported with the boolean verifier only
+	if _proof, ok := proof.(interface{ MarshalSolidityCompressed() ([]byte, error) }); ok {
+		compressed, err := _proof.MarshalSolidityCompressed()
+		assert.NoError(err)
+		fBool, err := os.Create(filepath.Join(tmpDir, "gnark_verifier_bool.sol"))
+		assert.NoError(err)
+		assert.NoError(vk.ExportSolidity(fBool, solidity.WithBoolVerifier()))
+		assert.NoError(fBool.Close())
+		assert.evmVerification(fBool.Name(), proofStr, publicWitnessStr, "--compressed-proof", hex.EncodeToString(compressed))
+	}
+
 	// generate assets
 	// gnark-solidity-checker generate --dir tmpdir --solidity contract_g16.sol
 	cmd := exec.Command("gnark-solidity-checker", "generate", "--dir", tmpDir, "--solidity", "gnark_verifier.sol")
@@ -52,7 +99,7 @@ func (assert *Assert) solidityVerification(b backend.ID, vk verifyingKey,
 	assert.NoError(err, string(out))
 
 	// len(vk.K) - 1 == len(publicWitness) + len(commitments)
-	numOfCommitments := vk.NbPublicWitness() - len(validPublicWitness.Vector().(fr_bn254.Vector))
+	numOfCommitments := vk.NbPublicWitness() - nbPublic
 
 	checkerOpts := []string{"verify"}
 	if b == backend.GROTH16 {
@@ -63,30 +110,12 @@ func (assert *Assert) solidityVerification(b backend.ID, vk verifyingKey,
 		panic("not implemented")
 	}
 
-	// proof to hex
-	_proof, ok := proof.(interface{ MarshalSolidity() []byte })
-	if !ok {
-		panic("proof does not implement MarshalSolidity()")
-	}
-
-	proofStr := hex.EncodeToString(_proof.MarshalSolidity())
-
 	if numOfCommitments > 0 {
 		checkerOpts = append(checkerOpts, "--commitment", strconv.Itoa(numOfCommitments))
 	}
 
-	// public witness to hex
-	bPublicWitness, err := validPublicWitness.MarshalBinary()
-	assert.NoError(err)
-	// that's quite dirty...
-	// first 4 bytes -> nbPublic
-	// next 4 bytes -> nbSecret
-	// next 4 bytes -> nb elements in the vector (== nbPublic + nbSecret)
-	bPublicWitness = bPublicWitness[12:]
-	publicWitnessStr := hex.EncodeToString(bPublicWitness)
-
 	checkerOpts = append(checkerOpts, "--dir", tmpDir)
-	checkerOpts = append(checkerOpts, "--nb-public-inputs", strconv.Itoa(len(validPublicWitness.Vector().(fr_bn254.Vector))))
+	checkerOpts = append(checkerOpts, "--nb-public-inputs", strconv.Itoa(nbPublic))
 	checkerOpts = append(checkerOpts, "--proof", proofStr)
 	checkerOpts = append(checkerOpts, "--public-inputs", publicWitnessStr)
 
@@ -97,3 +126,31 @@ func (assert *Assert) solidityVerification(b backend.ID, vk verifyingKey,
 	out, err = cmd.CombinedOutput()
 	assert.NoError(err, string(out))
 }
+
+// evmVerification runs the contract on the EVM of go-ethereum with the Prague
+// hard fork active, see internal/evmcheck. The proof must be accepted, and
+// tampered proofs and public inputs rejected.
+func (assert *Assert) evmVerification(contract, proof, publicInputs string, args ...string) {
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		panic("could not locate internal/evmcheck")
+	}
+	args = append([]string{"run", ".", "--solidity", contract, "--proof", proof, "--public-inputs", publicInputs}, args...)
+	cmd := exec.Command("go", args...)
+	cmd.Dir = filepath.Join(filepath.Dir(file), "..", "internal", "evmcheck")
+	assert.t.Log("running ", cmd.String())
+	out, err := cmd.CombinedOutput()
+	assert.NoError(err, string(out))
+}
+
+// nbPublicInputs returns the number of elements of the public witness w.
+func nbPublicInputs(w witness.Witness) int {
+	switch v := w.Vector().(type) {
+	case fr_bn254.Vector:
+		return len(v)
+	case fr_bls12381.Vector:
+		return len(v)
+	default:
+		panic("solidity verifier not implemented for this curve")
+	}
+}